@@ -21,6 +21,23 @@ CREATE TABLE IF NOT EXISTS market_data.stocks (
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
 
+-- Named watchlists; symbols of active watchlists are collected in real time
+CREATE TABLE IF NOT EXISTS market_data.watchlists (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS market_data.watchlist_symbols (
+    watchlist_id INTEGER NOT NULL REFERENCES market_data.watchlists(id) ON DELETE CASCADE,
+    symbol VARCHAR(50) NOT NULL REFERENCES market_data.stocks(symbol) ON DELETE CASCADE,
+    added_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (watchlist_id, symbol)
+);
+
 CREATE TABLE IF NOT EXISTS market_data.ohlcv (
     time TIMESTAMP WITH TIME ZONE NOT NULL,
     symbol VARCHAR(50) NOT NULL,
@@ -54,6 +71,55 @@ CREATE TABLE IF NOT EXISTS market_data.ticks (
 SELECT create_hypertable('market_data.ticks', 'time', if_not_exists => TRUE);
 CREATE INDEX IF NOT EXISTS idx_ticks_symbol_time ON market_data.ticks (symbol, time DESC);
 
+-- Market depth snapshots, price levels stored as JSON arrays of {price, quantity, orders}
+CREATE TABLE IF NOT EXISTS market_data.depth_snapshots (
+    time TIMESTAMP WITH TIME ZONE NOT NULL,
+    symbol VARCHAR(50) NOT NULL,
+    bids JSONB NOT NULL,
+    asks JSONB NOT NULL,
+    PRIMARY KEY (time, symbol)
+);
+
+SELECT create_hypertable('market_data.depth_snapshots', 'time', chunk_time_interval => INTERVAL '1 day', if_not_exists => TRUE);
+CREATE INDEX IF NOT EXISTS idx_depth_symbol_time ON market_data.depth_snapshots (symbol, time DESC);
+
+-- Depth is high volume, compress chunks once the trading day is over
+ALTER TABLE market_data.depth_snapshots SET (
+    timescaledb.compress,
+    timescaledb.compress_segmentby = 'symbol',
+    timescaledb.compress_orderby = 'time DESC'
+);
+SELECT add_compression_policy('market_data.depth_snapshots', INTERVAL '1 day', if_not_exists => TRUE);
+
+-- Futures and options contracts
+CREATE TABLE IF NOT EXISTS market_data.instruments (
+    symbol VARCHAR(50) PRIMARY KEY, -- e.g. NIFTY28OCT2524500CE
+    underlying VARCHAR(50) NOT NULL,
+    instrument_type VARCHAR(3) NOT NULL, -- FUT, CE, PE
+    expiry DATE NOT NULL,
+    strike DECIMAL(12,2),
+    lot_size INTEGER NOT NULL,
+    exchange VARCHAR(10) NOT NULL DEFAULT 'NFO',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_instruments_underlying_expiry ON market_data.instruments (underlying, expiry, strike);
+
+-- Open interest history of F&O contracts
+CREATE TABLE IF NOT EXISTS market_data.open_interest (
+    time TIMESTAMP WITH TIME ZONE NOT NULL,
+    symbol VARCHAR(50) NOT NULL,
+    open_interest BIGINT NOT NULL,
+    volume BIGINT DEFAULT 0,
+    price DECIMAL(12,2),
+    iv DECIMAL(8,4),
+    PRIMARY KEY (time, symbol)
+);
+
+SELECT create_hypertable('market_data.open_interest', 'time', if_not_exists => TRUE);
+CREATE INDEX IF NOT EXISTS idx_open_interest_symbol_time ON market_data.open_interest (symbol, time DESC);
+
 -- Trading Tables
 CREATE TABLE IF NOT EXISTS trading.strategies (
     id SERIAL PRIMARY KEY,
@@ -105,7 +171,7 @@ CREATE TABLE IF NOT EXISTS analytics.technical_indicators (
     symbol VARCHAR(50) NOT NULL,
     timeframe VARCHAR(10) NOT NULL,
     indicator_name VARCHAR(50) NOT NULL,
-    value DECIMAL(12,4),
+    value DOUBLE PRECISION,
     metadata JSONB,
     PRIMARY KEY (time, symbol, timeframe, indicator_name)
 );
@@ -145,6 +211,41 @@ CREATE TABLE IF NOT EXISTS analytics.predictions (
 SELECT create_hypertable('analytics.predictions', 'time', if_not_exists => TRUE);
 CREATE INDEX IF NOT EXISTS idx_predictions_symbol ON analytics.predictions (symbol, model_name, time DESC);
 
+-- Saved stock screens, run on their interval while the market is open
+CREATE TABLE IF NOT EXISTS analytics.screens (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL UNIQUE,
+    criteria JSONB NOT NULL,
+    run_interval VARCHAR(20) NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- User-defined alerts; triggered_at also deduplicates triggers seen twice
+CREATE TABLE IF NOT EXISTS analytics.alerts (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL DEFAULT '',
+    symbol VARCHAR(50) NOT NULL,
+    alert_type VARCHAR(20) NOT NULL, -- price_above, price_below, percent_move, condition, volume_spike
+    timeframe VARCHAR(10) NOT NULL DEFAULT '',
+    threshold DECIMAL(18,4) NOT NULL DEFAULT 0,
+    reference DECIMAL(18,4) NOT NULL DEFAULT 0,
+    expression TEXT NOT NULL DEFAULT '',
+    period INTEGER NOT NULL DEFAULT 0,
+    repeat BOOLEAN NOT NULL DEFAULT FALSE,
+    channels JSONB NOT NULL DEFAULT '[]',
+    status VARCHAR(20) NOT NULL DEFAULT 'armed', -- armed, triggered, expired
+    expires_at TIMESTAMP WITH TIME ZONE,
+    triggered_at TIMESTAMP WITH TIME ZONE,
+    trigger_count INTEGER NOT NULL DEFAULT 0,
+    last_value DECIMAL(18,4),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_alerts_status ON analytics.alerts (status, symbol);
+
 -- Create some sample data
 INSERT INTO market_data.stocks (symbol, company_name, sector, exchange) VALUES
 ('RELIANCE', 'Reliance Industries Limited', 'Energy', 'NSE'),
@@ -172,6 +273,12 @@ SELECT add_retention_policy('market_data.ticks', INTERVAL '30 days', if_not_exis
 -- Keep 1-minute OHLCV for 1 year, aggregate to higher timeframes
 SELECT add_retention_policy('market_data.ohlcv', INTERVAL '2 years', if_not_exists => TRUE);
 
+-- Keep open interest history for 1 year
+SELECT add_retention_policy('market_data.open_interest', INTERVAL '1 year', if_not_exists => TRUE);
+
+-- Keep depth snapshots for 14 days
+SELECT add_retention_policy('market_data.depth_snapshots', INTERVAL '14 days', if_not_exists => TRUE);
+
 -- Keep technical indicators for 1 year
 SELECT add_retention_policy('analytics.technical_indicators', INTERVAL '1 year', if_not_exists => TRUE);
 
@@ -0,0 +1,5 @@
+-- Widen technical indicator values for databases created before they were
+-- DOUBLE PRECISION. Cumulative indicators such as OBV and A/D overflow
+-- DECIMAL(12,4) on liquid symbols. Idempotent; apply to an existing database
+-- with: psql -U postgres -d algotrading -f 02-widen-indicator-values.sql
+ALTER TABLE analytics.technical_indicators ALTER COLUMN value TYPE DOUBLE PRECISION;
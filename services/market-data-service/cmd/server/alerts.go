@@ -0,0 +1,271 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "net/http"
+    "strconv"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "github.com/algo-trading/market-data-service/internal/alerts"
+    "github.com/algo-trading/market-data-service/internal/universe"
+)
+
+// notificationWorkers is the number of notifications delivered concurrently
+const notificationWorkers = 4
+
+// newDispatcher registers a notifier for WebSocket, webhooks and each
+// configured adapter
+func newDispatcher(config *Config, service *MarketDataService) *alerts.Dispatcher {
+    timeout := parseDuration("NOTIFY_TIMEOUT", config.NotifyTimeout)
+    dispatcher := alerts.NewDispatcher(config.NotifyQueueSize)
+
+    dispatcher.Register(alerts.WebSocket, alerts.NotifierFunc(func(ctx context.Context, target string, n *alerts.Notification) error {
+        service.wsHub.SendAlert(n)
+        return nil
+    }))
+    dispatcher.Register(alerts.Webhook, alerts.NewWebhookNotifier(timeout, config.WebhookAllowPrivate))
+    if config.SMTPHost != "" {
+        dispatcher.Register(alerts.Email, alerts.NewEmailNotifier(config.SMTPHost, config.SMTPPort, config.SMTPUsername, config.SMTPPassword, config.SMTPFrom, timeout))
+    }
+    if config.TelegramToken != "" {
+        dispatcher.Register(alerts.Telegram, alerts.NewTelegramNotifier(config.TelegramURL, config.TelegramToken, timeout))
+    }
+
+    return dispatcher
+}
+
+// syncAlerts expires alerts past their expiry and reloads the rest into the
+// engine
+func (s *MarketDataService) syncAlerts(now time.Time) {
+    if expired, err := s.db.ExpireAlerts(now); err != nil {
+        log.Printf("Failed to expire alerts: %v", err)
+    } else if expired > 0 {
+        log.Printf("Expired %d alerts", expired)
+    }
+
+    loaded, err := s.db.GetAlerts("", "")
+    if err != nil {
+        log.Printf("Failed to load alerts: %v", err)
+        return
+    }
+    s.alerts.Load(loaded)
+}
+
+// requestAlertSync asks the data collection loop to reload the alerts.
+// Replicas not collecting data, or with a sync already pending, drop it: the
+// signal is local, so changes made through another replica reach the
+// collecting one with its periodic sync, up to 30 seconds later.
+func (s *MarketDataService) requestAlertSync() {
+    select {
+    case s.alertSync <- struct{}{}:
+    default:
+    }
+}
+
+// handleAlertEvents persists the status changes found by the engine and
+// notifies triggers. A trigger another evaluator already recorded is not
+// notified again.
+func (s *MarketDataService) handleAlertEvents(events []alerts.Event) {
+    for _, event := range events {
+        switch event.Status {
+        case alerts.Triggered:
+            triggered, err := s.db.TriggerAlert(event.Alert.ID, event.Time, event.Value)
+            if err != nil {
+                log.Printf("Failed to record trigger of alert %d: %v", event.Alert.ID, err)
+                continue
+            }
+            if triggered {
+                s.notifications.Dispatch(event)
+            }
+
+        case alerts.Armed:
+            if err := s.db.RearmAlert(event.Alert.ID); err != nil {
+                log.Printf("Failed to rearm alert %d: %v", event.Alert.ID, err)
+            }
+        }
+    }
+}
+
+type alertRequest struct {
+    Name       string           `json:"name"`
+    Symbol     string           `json:"symbol"`
+    Type       alerts.Type      `json:"type"`
+    Timeframe  string           `json:"timeframe"`
+    Threshold  float64          `json:"threshold"`
+    Reference  float64          `json:"reference"`
+    Expression string           `json:"expression"`
+    Period     int              `json:"period"`
+    Repeat     bool             `json:"repeat"`
+    Channels   []alerts.Channel `json:"channels"`
+    ExpiresAt  *time.Time       `json:"expires_at"`
+}
+
+func alertID(c *gin.Context) (int, bool) {
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil || id <= 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid alert id"})
+        return 0, false
+    }
+    return id, true
+}
+
+func (s *MarketDataService) getAlerts(c *gin.Context) {
+    var symbol string
+    if c.Query("symbol") != "" {
+        var err error
+        if symbol, err = universe.NormalizeSymbol(c.Query("symbol")); err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+            return
+        }
+    }
+
+    status := alerts.Status(c.Query("status"))
+    switch status {
+    case "", alerts.Armed, alerts.Triggered, alerts.Expired:
+    default:
+        c.JSON(http.StatusBadRequest, gin.H{"error": "status must be armed, triggered or expired"})
+        return
+    }
+
+    result, err := s.db.GetAlerts(status, symbol)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"alerts": result})
+}
+
+func (s *MarketDataService) getAlert(c *gin.Context) {
+    id, ok := alertID(c)
+    if !ok {
+        return
+    }
+
+    alert, err := s.db.GetAlert(id)
+    if err != nil {
+        c.JSON(storageErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"alert": alert})
+}
+
+func (s *MarketDataService) createAlert(c *gin.Context) {
+    var req alertRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    symbol, err := universe.NormalizeSymbol(req.Symbol)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be in the future"})
+        return
+    }
+
+    alert := &alerts.Alert{
+        Name:       req.Name,
+        Symbol:     symbol,
+        Type:       req.Type,
+        Timeframe:  req.Timeframe,
+        Threshold:  req.Threshold,
+        Reference:  req.Reference,
+        Expression: req.Expression,
+        Period:     req.Period,
+        Repeat:     req.Repeat,
+        Channels:   req.Channels,
+        ExpiresAt:  req.ExpiresAt,
+    }
+    if err := alert.Validate(); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    for _, channel := range alert.Channels {
+        if !s.notifications.Supports(channel.Type) {
+            c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s notifications are not configured", channel.Type)})
+            return
+        }
+    }
+
+    // Percent moves are measured from the current price unless given one
+    if alert.Type == alerts.PercentMove && alert.Reference == 0 {
+        quotes, err := s.quotes.GetQuotes(c.Request.Context(), []string{symbol})
+        if err != nil {
+            log.Printf("Failed to get quote for %s: %v", symbol, err)
+        } else if quote, exists := quotes[symbol]; exists {
+            alert.Reference = quote.LastPrice
+        }
+        if alert.Reference <= 0 {
+            c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("reference is required: no current price for %s", symbol)})
+            return
+        }
+    }
+
+    if err := s.db.CreateAlert(alert); err != nil {
+        c.JSON(storageErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+    s.requestAlertSync()
+
+    c.JSON(http.StatusCreated, gin.H{"alert": alert})
+}
+
+// armAlert arms a triggered or expired alert again, optionally with a new
+// expiry
+func (s *MarketDataService) armAlert(c *gin.Context) {
+    id, ok := alertID(c)
+    if !ok {
+        return
+    }
+
+    var req struct {
+        ExpiresAt *time.Time `json:"expires_at"`
+    }
+    if c.Request.ContentLength > 0 {
+        if err := c.ShouldBindJSON(&req); err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+            return
+        }
+    }
+    if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be in the future"})
+        return
+    }
+
+    if err := s.db.ArmAlert(id, req.ExpiresAt); err != nil {
+        c.JSON(storageErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+    s.requestAlertSync()
+
+    alert, err := s.db.GetAlert(id)
+    if err != nil {
+        c.JSON(storageErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"alert": alert})
+}
+
+func (s *MarketDataService) deleteAlert(c *gin.Context) {
+    id, ok := alertID(c)
+    if !ok {
+        return
+    }
+
+    if err := s.db.DeleteAlert(id); err != nil {
+        c.JSON(storageErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+    s.requestAlertSync()
+
+    c.JSON(http.StatusOK, gin.H{"message": "alert deleted"})
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+    "fmt"
+    "math"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "github.com/algo-trading/market-data-service/internal/calendar"
+    "github.com/algo-trading/market-data-service/internal/models"
+    "github.com/algo-trading/market-data-service/pkg/indicators"
+)
+
+// maxResampleBars bounds the lower timeframe bars loaded for resampling
+const maxResampleBars = 50000
+
+// analyticsPoint compares a symbol with another at a bar; values are null
+// during warm-up
+type analyticsPoint struct {
+    Time             time.Time `json:"time"`
+    RelativeStrength *float64  `json:"relative_strength"`
+    Mansfield        *float64  `json:"mansfield"`
+    Correlation      *float64  `json:"correlation"`
+    Beta             *float64  `json:"beta"`
+    Ratio            *float64  `json:"ratio"`
+    HedgeRatio       *float64  `json:"hedge_ratio"`
+    Spread           *float64  `json:"spread"`
+    ZScore           *float64  `json:"zscore"`
+}
+
+// valueAt returns values[i], or nil before first or when not finite
+func valueAt(values []float64, i, first int) *float64 {
+    if i < first || math.IsNaN(values[i]) || math.IsInf(values[i], 0) {
+        return nil
+    }
+    value := values[i]
+    return &value
+}
+
+// analyticsBars loads the latest limit bars of timeframe, resampled from the
+// completed bars of source when given
+func (s *MarketDataService) analyticsBars(symbol, timeframe, source string, limit int) ([]models.OHLCV, error) {
+    if source == "" {
+        return latestBars(s.db, symbol, timeframe, limit)
+    }
+
+    higher, err := calendar.TimeframeDuration(timeframe)
+    if err != nil {
+        return nil, err
+    }
+    lower, err := calendar.TimeframeDuration(source)
+    if err != nil {
+        return nil, err
+    }
+    bars, err := latestBars(s.db, symbol, source, min(limit*int(higher/lower+1), maxResampleBars))
+    if err != nil {
+        return nil, err
+    }
+
+    resampled, err := indicators.Resample(bars, source, timeframe, s.calendar)
+    if err != nil {
+        return nil, err
+    }
+    var completed []models.OHLCV
+    for i, bar := range resampled.Bars {
+        if resampled.Closed[i] >= 0 {
+            completed = append(completed, bar)
+        }
+    }
+    if len(completed) > limit {
+        completed = completed[len(completed)-limit:]
+    }
+    return completed, nil
+}
+
+// getStockAnalytics compares a symbol with another symbol or index: relative
+// strength, rolling correlation and beta of returns, and the pair spread
+func (s *MarketDataService) getStockAnalytics(c *gin.Context) {
+    symbol := strings.ToUpper(c.Param("symbol"))
+    against := strings.ToUpper(c.Query("against"))
+    if against == "" || against == symbol {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "against must name another symbol or index"})
+        return
+    }
+    timeframe := c.DefaultQuery("timeframe", "1d")
+    source := c.Query("source")
+
+    period := 20
+    if value := c.Query("period"); value != "" {
+        parsed, err := strconv.Atoi(value)
+        if err != nil || parsed < 2 || parsed > 500 {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "period must be between 2 and 500"})
+            return
+        }
+        period = parsed
+    }
+    limit := 500
+    if value := c.Query("limit"); value != "" {
+        parsed, err := strconv.Atoi(value)
+        if err != nil || parsed <= 0 || parsed > maxExpressionBars {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 5000"})
+            return
+        }
+        limit = parsed
+    }
+
+    if source != "" {
+        higher, err := calendar.TimeframeDuration(timeframe)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+            return
+        }
+        lower, err := calendar.TimeframeDuration(source)
+        if err != nil || lower >= higher {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "source must be a valid timeframe lower than timeframe"})
+            return
+        }
+    }
+
+    bars, err := s.analyticsBars(symbol, timeframe, source, limit)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    benchmark, err := s.analyticsBars(against, timeframe, source, limit)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    bars, benchmark = indicators.Align(bars, benchmark)
+    if len(bars) <= period {
+        c.JSON(http.StatusUnprocessableEntity, gin.H{"error": fmt.Sprintf("insufficient common bars: need %d, got %d", period+1, len(bars))})
+        return
+    }
+
+    rs, err := indicators.CalculateRelativeStrength(bars, benchmark, period)
+    if err != nil {
+        c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+        return
+    }
+    correlation, err := indicators.CalculateCorrelation(bars, benchmark, period)
+    if err != nil {
+        c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+        return
+    }
+    beta, err := indicators.CalculateBeta(bars, benchmark, period)
+    if err != nil {
+        c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+        return
+    }
+    spread, err := indicators.CalculatePairSpread(bars, benchmark, period)
+    if err != nil {
+        c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+        return
+    }
+
+    points := make([]analyticsPoint, len(bars))
+    for i, bar := range bars {
+        points[i] = analyticsPoint{
+            Time:             bar.Time,
+            RelativeStrength: valueAt(rs.Ratio, i, 0),
+            Mansfield:        valueAt(rs.Mansfield, i, period-1),
+            Correlation:      valueAt(correlation, i, period),
+            Beta:             valueAt(beta, i, period),
+            Ratio:            valueAt(spread.Ratio, i, 0),
+            HedgeRatio:       valueAt(spread.HedgeRatio, i, period-1),
+            Spread:           valueAt(spread.Spread, i, period-1),
+            ZScore:           valueAt(spread.ZScore, i, period-1),
+        }
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "symbol":    symbol,
+        "against":   against,
+        "timeframe": timeframe,
+        "period":    period,
+        "values":    points,
+        "count":     len(points),
+    })
+}
+
+// sessionMinutes is the length of the NSE and BSE regular session
+const sessionMinutes = 375
+
+// riskPoint holds the risk statistics of a symbol at a bar; values are null
+// during warm-up
+type riskPoint struct {
+    Time          time.Time `json:"time"`
+    Volatility    *float64  `json:"volatility"`
+    Parkinson     *float64  `json:"parkinson"`
+    GarmanKlass   *float64  `json:"garman_klass"`
+    YangZhang     *float64  `json:"yang_zhang"`
+    Sharpe        *float64  `json:"sharpe"`
+    Sortino       *float64  `json:"sortino"`
+    Drawdown      *float64  `json:"drawdown"`
+    MaxDrawdown   *float64  `json:"max_drawdown"`
+    HistoricalVaR *float64  `json:"historical_var"`
+    ParametricVaR *float64  `json:"parametric_var"`
+    ZScore        *float64  `json:"zscore"`
+}
+
+// periodsPerYear is the number of bars of a timeframe in a trading year.
+// Multi-day bars span calendar days, five of every seven being trading days.
+func periodsPerYear(timeframe string) (float64, error) {
+    duration, err := calendar.TimeframeDuration(timeframe)
+    if err != nil {
+        return 0, err
+    }
+    if duration >= 24*time.Hour {
+        tradingDays := max(1, duration.Hours()/24*5/7)
+        return indicators.TradingDaysPerYear / tradingDays, nil
+    }
+    return indicators.TradingDaysPerYear * math.Ceil(sessionMinutes/duration.Minutes()), nil
+}
+
+// getStockRisk returns volatility, risk-adjusted return, drawdown and
+// Value-at-Risk series of a symbol
+func (s *MarketDataService) getStockRisk(c *gin.Context) {
+    symbol := strings.ToUpper(c.Param("symbol"))
+    timeframe := c.DefaultQuery("timeframe", "1d")
+    annualise, err := periodsPerYear(timeframe)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    period := 20
+    if value := c.Query("period"); value != "" {
+        parsed, err := strconv.Atoi(value)
+        if err != nil || parsed < 2 || parsed > 500 {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "period must be between 2 and 500"})
+            return
+        }
+        period = parsed
+    }
+    limit := 500
+    if value := c.Query("limit"); value != "" {
+        parsed, err := strconv.Atoi(value)
+        if err != nil || parsed <= 0 || parsed > maxExpressionBars {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 5000"})
+            return
+        }
+        limit = parsed
+    }
+    confidence := 0.95
+    if value := c.Query("confidence"); value != "" {
+        parsed, err := strconv.ParseFloat(value, 64)
+        if err != nil || parsed < 0.5 || parsed > 0.999 {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "confidence must be between 0.5 and 0.999"})
+            return
+        }
+        confidence = parsed
+    }
+    riskFree := 0.0
+    if value := c.Query("risk_free"); value != "" {
+        parsed, err := strconv.ParseFloat(value, 64)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "risk_free must be an annual rate, e.g. 0.065"})
+            return
+        }
+        riskFree = parsed
+    }
+
+    bars, err := latestBars(s.db, symbol, timeframe, limit)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    if len(bars) <= period {
+        c.JSON(http.StatusUnprocessableEntity, gin.H{"error": fmt.Sprintf("insufficient bars: need %d, got %d", period+1, len(bars))})
+        return
+    }
+
+    // With more than period bars none of these can fail
+    volatility, _ := indicators.CalculateHistoricalVolatility(bars, period, indicators.CloseToClose, annualise)
+    parkinson, _ := indicators.CalculateHistoricalVolatility(bars, period, indicators.Parkinson, annualise)
+    garmanKlass, _ := indicators.CalculateHistoricalVolatility(bars, period, indicators.GarmanKlass, annualise)
+    yangZhang, _ := indicators.CalculateHistoricalVolatility(bars, period, indicators.YangZhang, annualise)
+    sharpe, _ := indicators.CalculateSharpe(bars, period, riskFree, annualise)
+    sortino, _ := indicators.CalculateSortino(bars, period, riskFree, annualise)
+    drawdown, _ := indicators.CalculateDrawdown(bars)
+    historicalVaR, _ := indicators.CalculateHistoricalVaR(bars, period, confidence)
+    parametricVaR, _ := indicators.CalculateParametricVaR(bars, period, confidence)
+    zscore, _ := indicators.CalculateZScore(bars, period)
+
+    points := make([]riskPoint, len(bars))
+    for i, bar := range bars {
+        points[i] = riskPoint{
+            Time:          bar.Time,
+            Volatility:    valueAt(volatility, i, period),
+            Parkinson:     valueAt(parkinson, i, period-1),
+            GarmanKlass:   valueAt(garmanKlass, i, period-1),
+            YangZhang:     valueAt(yangZhang, i, period),
+            Sharpe:        valueAt(sharpe, i, period),
+            Sortino:       valueAt(sortino, i, period),
+            Drawdown:      valueAt(drawdown.Drawdown, i, 0),
+            MaxDrawdown:   valueAt(drawdown.MaxDrawdown, i, 0),
+            HistoricalVaR: valueAt(historicalVaR, i, period),
+            ParametricVaR: valueAt(parametricVaR, i, period),
+            ZScore:        valueAt(zscore, i, period-1),
+        }
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "symbol":     symbol,
+        "timeframe":  timeframe,
+        "period":     period,
+        "confidence": confidence,
+        "values":     points,
+        "count":      len(points),
+    })
+}
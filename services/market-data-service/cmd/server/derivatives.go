@@ -0,0 +1,187 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "github.com/algo-trading/market-data-service/internal/calendar"
+    "github.com/algo-trading/market-data-service/internal/derivatives"
+)
+
+// refreshInstruments loads the F&O contracts of the underlying from the
+// provider into the instruments table
+func (s *MarketDataService) refreshInstruments(ctx context.Context, underlying string) error {
+    instruments, err := s.apiManager.GetInstruments(ctx, underlying)
+    if err != nil {
+        return fmt.Errorf("failed to get instruments for %s: %w", underlying, err)
+    }
+
+    if err := s.db.UpsertInstruments(instruments); err != nil {
+        return err
+    }
+
+    log.Printf("Loaded %d instruments for %s", len(instruments), underlying)
+    return nil
+}
+
+// nearestExpiry returns the first option expiry on or after now, refreshing
+// the contracts from the provider once the known expiries have passed
+func (s *MarketDataService) nearestExpiry(ctx context.Context, underlying string, now time.Time) (time.Time, error) {
+    expiries, err := s.db.GetExpiries(underlying, now)
+    if err != nil {
+        return time.Time{}, err
+    }
+
+    if len(expiries) == 0 {
+        if err := s.refreshInstruments(ctx, underlying); err != nil {
+            return time.Time{}, err
+        }
+        if expiries, err = s.db.GetExpiries(underlying, now); err != nil {
+            return time.Time{}, err
+        }
+    }
+
+    if len(expiries) == 0 {
+        return time.Time{}, fmt.Errorf("no option expiries for %s", underlying)
+    }
+    return expiries[0], nil
+}
+
+func (s *MarketDataService) optionChainKey(underlying string, expiry time.Time) string {
+    return s.optionChains.Key(underlying, expiry.Format("2006-01-02"))
+}
+
+// collectOptionChains snapshots the nearest expiry chain of every configured
+// underlying, recording open interest history and streaming the chain
+func (s *MarketDataService) collectOptionChains(ctx context.Context, now time.Time) {
+    for _, underlying := range s.optionUnderlyings {
+        expiry, err := s.nearestExpiry(ctx, underlying, now)
+        if err != nil {
+            log.Printf("Failed to find expiry for %s: %v", underlying, err)
+            continue
+        }
+
+        chain, err := s.apiManager.GetOptionChain(ctx, underlying, expiry)
+        if err != nil {
+            log.Printf("Failed to get option chain for %s: %v", underlying, err)
+            continue
+        }
+        chain.ApplyGreeks(now, s.riskFreeRate)
+
+        if err := s.db.InsertOpenInterest(chain.OpenInterestHistory()); err != nil {
+            log.Printf("Failed to store open interest for %s: %v", underlying, err)
+        }
+        if err := s.optionChains.Set(ctx, s.optionChainKey(underlying, expiry), chain); err != nil {
+            log.Printf("Failed to cache option chain for %s: %v", underlying, err)
+        }
+        s.wsHub.SendOptionChain(chain)
+    }
+}
+
+func (s *MarketDataService) getExpiries(c *gin.Context) {
+    underlying := strings.ToUpper(c.Param("symbol"))
+
+    expiries, err := s.db.GetExpiries(underlying, time.Now())
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    dates := make([]string, len(expiries))
+    for i, expiry := range expiries {
+        dates[i] = expiry.Format("2006-01-02")
+    }
+
+    c.JSON(http.StatusOK, gin.H{"underlying": underlying, "expiries": dates})
+}
+
+func (s *MarketDataService) getOptionChain(c *gin.Context) {
+    ctx := c.Request.Context()
+    underlying := strings.ToUpper(c.Param("symbol"))
+    if _, ok := derivatives.Underlyings[underlying]; !ok {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported underlying: " + underlying})
+        return
+    }
+
+    var expiry time.Time
+    var err error
+    if value := c.Query("expiry"); value != "" {
+        expiry, err = time.ParseInLocation("2006-01-02", value, calendar.IST)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "expiry must be formatted as YYYY-MM-DD"})
+            return
+        }
+    } else {
+        expiry, err = s.nearestExpiry(ctx, underlying, time.Now())
+        if err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+            return
+        }
+    }
+
+    chain, err := s.optionChains.GetOrLoad(ctx, s.optionChainKey(underlying, expiry), func(ctx context.Context) (*derivatives.Chain, error) {
+        chain, err := s.apiManager.GetOptionChain(ctx, underlying, expiry)
+        if err != nil {
+            return nil, err
+        }
+        chain.ApplyGreeks(time.Now(), s.riskFreeRate)
+        return chain, nil
+    })
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"chain": chain, "put_call_ratio": chain.PutCallRatio()})
+}
+
+func (s *MarketDataService) getOpenInterest(c *gin.Context) {
+    symbol := strings.ToUpper(c.Param("symbol"))
+    if _, err := derivatives.ParseSymbol(symbol); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    end := time.Now()
+    start := end.Add(-24 * time.Hour)
+    if value := c.Query("from"); value != "" {
+        parsed, err := time.Parse(time.RFC3339, value)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "from must be an RFC3339 timestamp"})
+            return
+        }
+        start = parsed
+    }
+    if value := c.Query("to"); value != "" {
+        parsed, err := time.Parse(time.RFC3339, value)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "to must be an RFC3339 timestamp"})
+            return
+        }
+        end = parsed
+    }
+
+    limit := 1000
+    if value := c.Query("limit"); value != "" {
+        parsed, err := strconv.Atoi(value)
+        if err != nil || parsed <= 0 {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+            return
+        }
+        limit = parsed
+    }
+
+    points, err := s.db.GetOpenInterest(symbol, start, end, limit)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"symbol": symbol, "open_interest": points, "count": len(points)})
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+    "math"
+    "net/http"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "github.com/algo-trading/market-data-service/pkg/expr"
+    "github.com/algo-trading/market-data-service/pkg/indicators"
+)
+
+// maxExpressionBars bounds the history an expression is evaluated over
+const maxExpressionBars = 5000
+
+type expressionRequest struct {
+    Expression string `json:"expression" binding:"required"`
+    Timeframe  string `json:"timeframe"`
+    Limit      int    `json:"limit"`
+}
+
+// expressionPoint is the value of an expression at a bar; Value is null while
+// the expression is undefined
+type expressionPoint struct {
+    Time  time.Time `json:"time"`
+    Value *float64  `json:"value"`
+}
+
+// getIndicators lists the registered indicators with their parameter schemas
+// and outputs
+func (s *MarketDataService) getIndicators(c *gin.Context) {
+    c.JSON(http.StatusOK, gin.H{"indicators": indicators.Definitions()})
+}
+
+// evaluateExpression evaluates an indicator expression over the latest stored
+// bars of a symbol
+func (s *MarketDataService) evaluateExpression(c *gin.Context) {
+    var req expressionRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    e, err := expr.Parse(req.Expression)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "invalid expression: " + err.Error()})
+        return
+    }
+
+    if req.Timeframe == "" {
+        req.Timeframe = "1d"
+    }
+    if req.Limit == 0 {
+        req.Limit = 500
+    }
+    if req.Limit < 0 || req.Limit > maxExpressionBars {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 5000"})
+        return
+    }
+
+    symbol := strings.ToUpper(c.Param("symbol"))
+    bars, err := latestBars(s.db, symbol, req.Timeframe, req.Limit)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    values, err := e.Evaluate(bars)
+    if err != nil {
+        c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+        return
+    }
+
+    points := make([]expressionPoint, len(bars))
+    for i, bar := range bars {
+        points[i].Time = bar.Time
+        if !math.IsNaN(values[i]) && !math.IsInf(values[i], 0) {
+            value := values[i]
+            points[i].Value = &value
+        }
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "symbol":     symbol,
+        "timeframe":  req.Timeframe,
+        "expression": e.String(),
+        "values":     points,
+        "count":      len(points),
+    })
+}
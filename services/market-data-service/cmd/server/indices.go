@@ -0,0 +1,81 @@
+package main
+
+import (
+    "fmt"
+    "log"
+    "net/http"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "github.com/algo-trading/market-data-service/internal/aggregates"
+    "github.com/algo-trading/market-data-service/internal/storage"
+)
+
+// indexLookback bounds the search for the last stored value of an index
+const indexLookback = 30 * 24 * time.Hour
+
+// loadIndices registers the sector aggregates and custom indices with the
+// engine, resuming each from its last stored close in the first timeframe
+func loadIndices(engine *aggregates.Engine, db *storage.Database, config *Config, timeframes []string) error {
+    stocks, err := db.GetStocks()
+    if err != nil {
+        return err
+    }
+
+    var defs []aggregates.Definition
+    if config.SectorIndices {
+        sectors, err := aggregates.SectorDefinitions(stocks, aggregates.Weighting(config.SectorWeighting))
+        if err != nil {
+            return fmt.Errorf("failed to build sector indices: %w", err)
+        }
+        defs = append(defs, sectors...)
+    }
+
+    custom, err := aggregates.ParseDefinitions(config.CustomIndices, stocks)
+    if err != nil {
+        return fmt.Errorf("failed to parse CUSTOM_INDICES: %w", err)
+    }
+    defs = append(defs, custom...)
+
+    now := time.Now()
+    for _, def := range defs {
+        var baseValue float64
+        if len(timeframes) > 0 {
+            bars, err := db.GetOHLCV(def.Symbol, timeframes[0], now.Add(-indexLookback), now, 1)
+            if err != nil {
+                log.Printf("Failed to load last value of %s: %v", def.Symbol, err)
+            } else if len(bars) > 0 {
+                baseValue = bars[0].Close
+            }
+        }
+
+        if err := engine.Add(def, baseValue); err != nil {
+            return err
+        }
+    }
+
+    log.Printf("Computing %d indices", len(defs))
+    return nil
+}
+
+func (s *MarketDataService) getIndices(c *gin.Context) {
+    if s.indices == nil {
+        c.JSON(http.StatusOK, gin.H{"indices": []gin.H{}, "count": 0})
+        return
+    }
+
+    defs := s.indices.Definitions()
+    indices := make([]gin.H, 0, len(defs))
+    for _, def := range defs {
+        value, _ := s.indices.Value(def.Symbol)
+        indices = append(indices, gin.H{
+            "symbol":       def.Symbol,
+            "name":         def.Name,
+            "weighting":    def.Weighting,
+            "constituents": def.Weights,
+            "value":        value,
+        })
+    }
+
+    c.JSON(http.StatusOK, gin.H{"indices": indices, "count": len(indices)})
+}
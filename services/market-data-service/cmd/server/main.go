@@ -2,11 +2,15 @@ package main
 
 import (
     "context"
+    "fmt"
     "log"
+    "math"
     "net"
     "net/http"
     "os"
     "os/signal"
+    "strconv"
+    "strings"
     "sync"
     "syscall"
     "time"
@@ -14,36 +18,126 @@ import (
     "github.com/gin-gonic/gin"
     "google.golang.org/grpc"
 
+    "github.com/algo-trading/market-data-service/internal/aggregates"
+    "github.com/algo-trading/market-data-service/internal/alerts"
     "github.com/algo-trading/market-data-service/internal/api"
+    "github.com/algo-trading/market-data-service/internal/calendar"
+    "github.com/algo-trading/market-data-service/internal/depth"
+    "github.com/algo-trading/market-data-service/internal/derivatives"
+    "github.com/algo-trading/market-data-service/internal/events"
+    "github.com/algo-trading/market-data-service/internal/ingestion"
+    "github.com/algo-trading/market-data-service/internal/models"
+    "github.com/algo-trading/market-data-service/internal/screener"
     "github.com/algo-trading/market-data-service/internal/storage"
+    "github.com/algo-trading/market-data-service/internal/technicals"
+    "github.com/algo-trading/market-data-service/internal/universe"
     "github.com/algo-trading/market-data-service/internal/websocket"
+    "github.com/algo-trading/market-data-service/pkg/patterns"
 )
 
 type Config struct {
-    HTTPPort    string
-    GRPCPort    string
-    DBHost      string
-    DBPort      string
-    DBName      string
-    DBUser      string
-    DBPassword  string
-    RedisHost   string
-    RedisPort   string
-    KafkaBrokers string
+    HTTPPort              string
+    GRPCPort              string
+    DBHost                string
+    DBPort                string
+    DBName                string
+    DBUser                string
+    DBPassword            string
+    RedisHost             string
+    RedisPort             string
+    CacheTTLs             string
+    TickStreams           bool
+    RedisFanout           bool
+    CollectData           bool
+    TickStreamShards      int
+    TickStreamMaxLen      int64
+    KafkaBrokers          string
+    KafkaTopicPrefix      string
+    Exchange              string
+    Symbols               string
+    Timeframes            string
+    APIRateLimit          int
+    APIRateWindow         string
+    ProviderRateLimit     int
+    ProviderRateWindow    string
+    DepthLevels           int
+    DepthSnapshotInterval string
+    OptionUnderlyings     string
+    OptionChainInterval   string
+    RiskFreeRate          string
+    SectorIndices         bool
+    SectorWeighting       string
+    CustomIndices         string
+    PatternDetection      bool
+    Indicators            bool
+    IndicatorSpecs        string
+    IndicatorHistory      int
+    ScreenerSchedules     bool
+    ScreenerWorkers       int
+    Alerts                bool
+    NotifyTimeout         string
+    NotifyQueueSize       int
+    WebhookAllowPrivate   bool
+    SMTPHost              string
+    SMTPPort              string
+    SMTPUsername          string
+    SMTPPassword          string
+    SMTPFrom              string
+    TelegramToken         string
+    TelegramURL           string
 }
 
 func loadConfig() *Config {
     return &Config{
-        HTTPPort:     getEnv("HTTP_PORT", "8080"),
-        GRPCPort:     getEnv("GRPC_PORT", "8081"),
-        DBHost:       getEnv("DB_HOST", "localhost"),
-        DBPort:       getEnv("DB_PORT", "5432"),
-        DBName:       getEnv("DB_NAME", "algotrading"),
-        DBUser:       getEnv("DB_USER", "postgres"),
-        DBPassword:   getEnv("DB_PASSWORD", "password123"),
-        RedisHost:    getEnv("REDIS_HOST", "localhost"),
-        RedisPort:    getEnv("REDIS_PORT", "6379"),
-        KafkaBrokers: getEnv("KAFKA_BROKERS", "localhost:9092"),
+        HTTPPort:              getEnv("HTTP_PORT", "8080"),
+        GRPCPort:              getEnv("GRPC_PORT", "8081"),
+        DBHost:                getEnv("DB_HOST", "localhost"),
+        DBPort:                getEnv("DB_PORT", "5432"),
+        DBName:                getEnv("DB_NAME", "algotrading"),
+        DBUser:                getEnv("DB_USER", "postgres"),
+        DBPassword:            getEnv("DB_PASSWORD", "password123"),
+        RedisHost:             getEnv("REDIS_HOST", "localhost"),
+        RedisPort:             getEnv("REDIS_PORT", "6379"),
+        CacheTTLs:             getEnv("CACHE_TTLS", ""),
+        TickStreams:           getEnv("TICK_STREAMS_ENABLED", "false") == "true",
+        RedisFanout:           getEnv("WS_REDIS_FANOUT", "true") == "true",
+        CollectData:           getEnv("DATA_COLLECTION_ENABLED", "true") == "true",
+        TickStreamShards:      getEnvInt("TICK_STREAM_SHARDS", 0),
+        TickStreamMaxLen:      int64(getEnvInt("TICK_STREAM_MAXLEN", 100000)),
+        KafkaBrokers:          getEnv("KAFKA_BROKERS", "localhost:9092"),
+        KafkaTopicPrefix:      getEnv("KAFKA_TOPIC_PREFIX", "market"),
+        Exchange:              getEnv("MARKET_EXCHANGE", calendar.NSE),
+        Symbols:               getEnv("SYMBOLS", "RELIANCE,TCS,HDFCBANK,INFY,HINDUNILVR"),
+        Timeframes:            getEnv("BAR_TIMEFRAMES", "1m,5m,15m,1h,1d"),
+        APIRateLimit:          getEnvInt("API_RATE_LIMIT", 600),
+        APIRateWindow:         getEnv("API_RATE_WINDOW", "1m"),
+        ProviderRateLimit:     getEnvInt("PROVIDER_RATE_LIMIT", 10),
+        ProviderRateWindow:    getEnv("PROVIDER_RATE_WINDOW", "1s"),
+        DepthLevels:           getEnvInt("DEPTH_LEVELS", 5),
+        DepthSnapshotInterval: getEnv("DEPTH_SNAPSHOT_INTERVAL", "1s"),
+        OptionUnderlyings:     getEnv("OPTION_UNDERLYINGS", "NIFTY,BANKNIFTY"),
+        OptionChainInterval:   getEnv("OPTION_CHAIN_INTERVAL", "1m"),
+        RiskFreeRate:          getEnv("RISK_FREE_RATE", "0.065"),
+        SectorIndices:         getEnv("SECTOR_INDICES_ENABLED", "true") == "true",
+        SectorWeighting:       getEnv("SECTOR_WEIGHTING", "market_cap"),
+        CustomIndices:         getEnv("CUSTOM_INDICES", ""),
+        PatternDetection:      getEnv("PATTERN_DETECTION_ENABLED", "true") == "true",
+        Indicators:            getEnv("INDICATORS_ENABLED", "true") == "true",
+        IndicatorSpecs:        getEnv("INDICATORS", technicals.DefaultSpecs),
+        IndicatorHistory:      getEnvInt("INDICATOR_HISTORY", technicals.DefaultHistory),
+        ScreenerSchedules:     getEnv("SCREENER_SCHEDULES_ENABLED", "true") == "true",
+        ScreenerWorkers:       getEnvInt("SCREENER_WORKERS", screener.DefaultWorkers),
+        Alerts:                getEnv("ALERTS_ENABLED", "true") == "true",
+        NotifyTimeout:         getEnv("NOTIFY_TIMEOUT", "10s"),
+        NotifyQueueSize:       getEnvInt("NOTIFY_QUEUE_SIZE", 1000),
+        WebhookAllowPrivate:   getEnv("WEBHOOK_ALLOW_PRIVATE", "false") == "true",
+        SMTPHost:              getEnv("SMTP_HOST", ""),
+        SMTPPort:              getEnv("SMTP_PORT", "587"),
+        SMTPUsername:          getEnv("SMTP_USERNAME", ""),
+        SMTPPassword:          getEnv("SMTP_PASSWORD", ""),
+        SMTPFrom:              getEnv("SMTP_FROM", "alerts@algo-trading.local"),
+        TelegramToken:         getEnv("TELEGRAM_BOT_TOKEN", ""),
+        TelegramURL:           getEnv("TELEGRAM_API_URL", alerts.DefaultTelegramURL),
     }
 }
 
@@ -54,6 +148,34 @@ func getEnv(key, defaultValue string) string {
     return defaultValue
 }
 
+func getEnvInt(key string, defaultValue int) int {
+    if value := os.Getenv(key); value != "" {
+        if n, err := strconv.Atoi(value); err == nil {
+            return n
+        }
+        log.Printf("Invalid integer for %s: %q, using %d", key, value, defaultValue)
+    }
+    return defaultValue
+}
+
+func parseDuration(key, value string) time.Duration {
+    d, err := time.ParseDuration(value)
+    if err != nil || d <= 0 {
+        log.Fatalf("Invalid duration for %s: %q", key, value)
+    }
+    return d
+}
+
+func splitList(value string) []string {
+    var items []string
+    for _, item := range strings.Split(value, ",") {
+        if item = strings.TrimSpace(item); item != "" {
+            items = append(items, item)
+        }
+    }
+    return items
+}
+
 func main() {
     log.Println("Starting Market Data Service...")
     
@@ -70,20 +192,153 @@ func main() {
     redisClient := storage.NewRedisClient(config.RedisHost, config.RedisPort)
     defer redisClient.Close()
     
+    // Cache TTL overrides, e.g. CACHE_TTLS="current_price=15s,indicator=10m"
+    for _, entry := range splitList(config.CacheTTLs) {
+        name, value, _ := strings.Cut(entry, "=")
+        ttl, err := time.ParseDuration(value)
+        if err != nil {
+            log.Fatalf("Invalid cache TTL %q: %v", entry, err)
+        }
+        redisClient.SetCacheTTL(name, ttl)
+    }
+    
+    // Durable tick distribution over Redis Streams
+    var tickStream *storage.TickStream
+    if config.TickStreams {
+        tickStream = redisClient.NewTickStream(config.TickStreamShards, config.TickStreamMaxLen)
+    }
+    
+    // Initialize market calendars
+    calendars := make(map[string]*calendar.Calendar)
+    for _, exchange := range []string{calendar.NSE, calendar.BSE} {
+        cal, err := calendar.NewCalendar(exchange)
+        if err != nil {
+            log.Fatalf("Failed to create %s calendar: %v", exchange, err)
+        }
+        calendars[exchange] = cal
+    }
+    
+    marketCalendar, exists := calendars[config.Exchange]
+    if !exists {
+        log.Fatalf("Unsupported exchange: %s", config.Exchange)
+    }
+    
     // Initialize API clients
     apiManager := api.NewAPIManager()
+    apiManager.RegisterProvider("mock", api.NewMockProvider("mock"))
+    if err := apiManager.SetActiveProvider("mock"); err != nil {
+        log.Fatalf("Failed to set active provider: %v", err)
+    }
+    
+    // Rate limits shared by all replicas; a limit of 0 disables them
+    if config.ProviderRateLimit > 0 {
+        window := parseDuration("PROVIDER_RATE_WINDOW", config.ProviderRateWindow)
+        apiManager.SetThrottle(redisClient.NewRateLimiter("provider", config.ProviderRateLimit, window))
+    }
+    
+    var apiLimiter *storage.RateLimiter
+    if config.APIRateLimit > 0 {
+        window := parseDuration("API_RATE_WINDOW", config.APIRateWindow)
+        apiLimiter = redisClient.NewRateLimiter("api", config.APIRateLimit, window)
+    }
+    
+    // Annualised rate used for option Greeks, e.g. 0.065 for 6.5%
+    riskFreeRate, err := strconv.ParseFloat(config.RiskFreeRate, 64)
+    if err != nil {
+        log.Fatalf("Invalid RISK_FREE_RATE %q: %v", config.RiskFreeRate, err)
+    }
+    
+    // Initialize Kafka producer
+    producer := events.NewProducer(splitList(config.KafkaBrokers), events.DefaultTopics(config.KafkaTopicPrefix))
+    defer producer.Close()
+    
+    // Initialize ingestion pipeline
+    timeframes := splitList(config.Timeframes)
+    pipeline := ingestion.NewPipeline(marketCalendar, timeframes)
+    
+    // Sector and custom indices are fed back into the pipeline as synthetic
+    // symbols so that their bars are stored and streamed like any stock
+    var indices *aggregates.Engine
+    if config.SectorIndices || config.CustomIndices != "" {
+        indices = aggregates.NewEngine(pipeline.ProcessTick)
+        if err := loadIndices(indices, db, config, timeframes); err != nil {
+            log.Printf("Failed to load indices: %v", err)
+        }
+    }
+    
+    // Candlestick patterns and chart structures found on completed bars
+    var patternDetector *patterns.Detector
+    if config.PatternDetection {
+        patternDetector = patterns.NewDetector(patterns.DefaultOptions(), patterns.DefaultHistory)
+    }
+    
+    // Configured indicators computed on completed bars
+    var technicalEngine *technicals.Engine
+    if config.Indicators {
+        specs, err := technicals.ParseSpecs(config.IndicatorSpecs)
+        if err != nil {
+            log.Fatalf("Failed to parse INDICATORS: %v", err)
+        }
+        technicalEngine = technicals.NewEngine(specs, config.IndicatorHistory, func(symbol, timeframe string, limit int) ([]models.OHLCV, error) {
+            return latestBars(db, symbol, timeframe, limit)
+        })
+    }
+    
+    // User-defined alerts evaluated on ticks and completed bars
+    var alertEngine *alerts.Engine
+    if config.Alerts {
+        alertEngine = alerts.NewEngine(alerts.DefaultHistory, func(symbol, timeframe string, limit int) ([]models.OHLCV, error) {
+            return latestBars(db, symbol, timeframe, limit)
+        })
+    }
+    
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
     
     // Initialize WebSocket hub
     wsHub := websocket.NewHub()
     go wsHub.Run()
     
+    // Serve clients from data published by any replica
+    if config.RedisFanout {
+        websocket.NewRedisRelay(ctx, wsHub, redisClient)
+    }
+    
     // Create service
     service := &MarketDataService{
-        db:         db,
-        redis:      redisClient,
-        apiManager: apiManager,
-        wsHub:      wsHub,
+        db:                db,
+        redis:             redisClient,
+        apiManager:        apiManager,
+        wsHub:             wsHub,
+        calendar:          marketCalendar,
+        calendars:         calendars,
+        producer:          producer,
+        pipeline:          pipeline,
+        symbols:           splitList(config.Symbols),
+        tickStream:        tickStream,
+        fanout:            config.RedisFanout,
+        stockCache:        storage.NewCache[[]models.Stock](redisClient, storage.CacheStocks),
+        quotes:            redisClient.NewQuoteStore(),
+        apiLimiter:        apiLimiter,
+        books:             depth.NewBooks(config.DepthLevels),
+        depthLevels:       config.DepthLevels,
+        depthInterval:     parseDuration("DEPTH_SNAPSHOT_INTERVAL", config.DepthSnapshotInterval),
+        optionUnderlyings: splitList(strings.ToUpper(config.OptionUnderlyings)),
+        optionChains:      storage.NewCache[*derivatives.Chain](redisClient, storage.CacheOptionChain),
+        optionInterval:    parseDuration("OPTION_CHAIN_INTERVAL", config.OptionChainInterval),
+        riskFreeRate:      riskFreeRate,
+        indices:           indices,
+        universeSync:      make(chan struct{}, 1),
+        patterns:          patternDetector,
+        technicals:        technicalEngine,
+        screenerWorkers:   config.ScreenerWorkers,
+        alerts:            alertEngine,
+        alertSync:         make(chan struct{}, 1),
     }
+    if alertEngine != nil {
+        service.notifications = newDispatcher(config, service)
+    }
+    service.registerPipelineHandlers()
     
     // Start servers
     var wg sync.WaitGroup
@@ -106,12 +361,33 @@ func main() {
         }
     }()
     
-    // Start data collection
-    wg.Add(1)
-    go func() {
-        defer wg.Done()
-        service.StartDataCollection()
-    }()
+    // Start data collection. With several replicas behind a load balancer
+    // only one should collect; the others serve clients through the relay.
+    if config.CollectData {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            service.StartDataCollection(ctx)
+        }()
+    }
+    
+    // Deliver alert notifications
+    if service.notifications != nil {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            service.notifications.Run(ctx, notificationWorkers)
+        }()
+    }
+    
+    // Push the reports of saved screens to their subscribers on this replica
+    if config.ScreenerSchedules {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            service.runScreens(ctx)
+        }()
+    }
     
     // Wait for interrupt signal
     c := make(chan os.Signal, 1)
@@ -122,32 +398,234 @@ func main() {
     
     log.Println("Shutting down gracefully...")
     // Graceful shutdown logic here
+    cancel()
     
     wg.Wait()
     log.Println("Market Data Service stopped")
 }
 
 type MarketDataService struct {
-    db         *storage.Database
-    redis      *storage.RedisClient
-    apiManager *api.APIManager
-    wsHub      *websocket.Hub
+    db                *storage.Database
+    redis             *storage.RedisClient
+    apiManager        *api.APIManager
+    wsHub             *websocket.Hub
+    calendar          *calendar.Calendar
+    calendars         map[string]*calendar.Calendar
+    producer          *events.Producer
+    pipeline          *ingestion.Pipeline
+    symbols           []string
+    tickStream        *storage.TickStream
+    fanout            bool
+    stockCache        *storage.Cache[[]models.Stock]
+    quotes            *storage.QuoteStore
+    apiLimiter        *storage.RateLimiter
+    books             *depth.Books
+    depthLevels       int
+    depthInterval     time.Duration
+    optionUnderlyings []string
+    optionChains      *storage.Cache[*derivatives.Chain]
+    optionInterval    time.Duration
+    riskFreeRate      float64
+    indices           *aggregates.Engine
+    universeSync      chan struct{}
+    patterns          *patterns.Detector
+    technicals        *technicals.Engine
+    screenerWorkers   int
+    alerts            *alerts.Engine
+    notifications     *alerts.Dispatcher
+    alertSync         chan struct{}
 }
 
-func (s *MarketDataService) StartDataCollection() {
-    // TODO: Implement data collection logic
+// registerPipelineHandlers fans ticks and completed bars out to storage, Redis,
+// WebSocket clients and Kafka
+func (s *MarketDataService) registerPipelineHandlers() {
+    if s.indices != nil {
+        s.pipeline.OnTick(s.indices.ProcessTick)
+    }
+    
+    s.pipeline.OnTick(func(ctx context.Context, tick *models.Tick) {
+        if err := s.quotes.Update(ctx, tick); err != nil {
+            log.Printf("Failed to update quote for %s: %v", tick.Symbol, err)
+        }
+        
+        if s.fanout {
+            if err := s.redis.PublishTick(ctx, tick.Symbol, tick); err != nil {
+                log.Printf("Failed to publish tick for %s to Redis: %v", tick.Symbol, err)
+            }
+        } else {
+            s.wsHub.SendTick(tick.Symbol, tick)
+        }
+        
+        if err := s.producer.PublishTick(ctx, tick); err != nil {
+            log.Printf("Failed to publish tick for %s: %v", tick.Symbol, err)
+        }
+        
+        if s.tickStream != nil {
+            if _, err := s.tickStream.Append(ctx, tick); err != nil {
+                log.Printf("Failed to append tick for %s to stream: %v", tick.Symbol, err)
+            }
+        }
+        
+        if s.alerts != nil {
+            s.handleAlertEvents(s.alerts.OnTick(tick))
+        }
+    })
+    
+    s.pipeline.OnBar(func(ctx context.Context, bar *models.OHLCV) {
+        if err := s.db.InsertOHLCV(bar); err != nil {
+            log.Printf("Failed to store %s bar for %s: %v", bar.Timeframe, bar.Symbol, err)
+        }
+        
+        if s.fanout {
+            if err := s.redis.PublishOHLCV(ctx, bar.Symbol, bar); err != nil {
+                log.Printf("Failed to publish %s bar for %s to Redis: %v", bar.Timeframe, bar.Symbol, err)
+            }
+        } else {
+            s.wsHub.SendOHLCV(bar.Symbol, bar)
+        }
+        
+        if err := s.producer.PublishOHLCV(ctx, bar); err != nil {
+            log.Printf("Failed to publish %s bar for %s: %v", bar.Timeframe, bar.Symbol, err)
+        }
+        
+        if s.patterns != nil {
+            for _, event := range s.patterns.Update(*bar) {
+                s.wsHub.SendPattern(bar.Symbol, event)
+            }
+        }
+        
+        if s.technicals != nil {
+            values := s.technicals.Update(*bar)
+            for i := range values {
+                s.publishIndicator(ctx, &values[i])
+            }
+        }
+        
+        if s.alerts != nil {
+            s.handleAlertEvents(s.alerts.OnBar(bar))
+        }
+    })
+}
+
+func (s *MarketDataService) StartDataCollection(ctx context.Context) {
+    if err := s.apiManager.ConnectAll(ctx); err != nil {
+        log.Printf("Failed to connect providers: %v", err)
+    }
+    
+    provider := s.apiManager.GetActiveProvider()
+    if provider == nil {
+        log.Println("No active market data provider, data collection disabled")
+        return
+    }
+    
+    // Collect the configured symbols plus those of active watchlists, picking
+    // up watchlist changes from the API and from other replicas
+    subscriptions := universe.New(providerSubscriber{s: s, provider: provider}, s.symbols)
+    s.syncUniverse(ctx, subscriptions)
+    universeTicker := time.NewTicker(30 * time.Second)
+    defer universeTicker.Stop()
+    
+    // Persist order book snapshots at a fixed interval rather than per update
+    depthTicker := time.NewTicker(s.depthInterval)
+    defer depthTicker.Stop()
+    
+    // Snapshot option chains and their open interest
+    for _, underlying := range s.optionUnderlyings {
+        if err := s.refreshInstruments(ctx, underlying); err != nil {
+            log.Printf("Failed to refresh instruments: %v", err)
+        }
+    }
+    optionTicker := time.NewTicker(s.optionInterval)
+    defer optionTicker.Stop()
+    
+    // Close bars whose period ended even if no further ticks arrive
     ticker := time.NewTicker(1 * time.Second)
     defer ticker.Stop()
     
+    // Publish market status for other services reading it from Redis
+    statusTicker := time.NewTicker(30 * time.Second)
+    defer statusTicker.Stop()
+    s.cacheMarketStatus(time.Now())
+    
+    // Evaluate alerts, picking up changes made through any replica
+    alertTicker := time.NewTicker(30 * time.Second)
+    defer alertTicker.Stop()
+    if s.alerts != nil {
+        s.syncAlerts(time.Now())
+    }
+    
     for {
         select {
-        case <-ticker.C:
-            // Collect and process market data
-            // This will be implemented in the next iteration
+        case <-ctx.Done():
+            return
+            
+        case now := <-ticker.C:
+            s.pipeline.Flush(ctx, now)
+            
+        case now := <-statusTicker.C:
+            s.cacheMarketStatus(now)
+            
+        case <-depthTicker.C:
+            s.persistDepth()
+            
+        case <-universeTicker.C:
+            s.syncUniverse(ctx, subscriptions)
+            
+        case <-s.universeSync:
+            s.syncUniverse(ctx, subscriptions)
+            
+        case now := <-alertTicker.C:
+            if s.alerts != nil {
+                s.syncAlerts(now)
+            }
+            
+        case <-s.alertSync:
+            if s.alerts != nil {
+                s.syncAlerts(time.Now())
+            }
+            
+        case now := <-optionTicker.C:
+            if s.calendar.IsOpen(now) {
+                s.collectOptionChains(ctx, now)
+            }
+        }
+    }
+}
+
+// handleDepth applies a provider depth snapshot to the symbol's order book and
+// streams the resulting book to subscribers
+func (s *MarketDataService) handleDepth(snapshot *depth.Depth) {
+    if !s.calendar.IsOpen(snapshot.Time) {
+        return
+    }
+    
+    book := s.books.Book(snapshot.Symbol)
+    if err := book.Apply(snapshot); err != nil {
+        log.Printf("Failed to apply depth for %s: %v", snapshot.Symbol, err)
+        return
+    }
+    
+    s.wsHub.SendDepth(snapshot.Symbol, book.Snapshot())
+}
+
+func (s *MarketDataService) persistDepth() {
+    for _, snapshot := range s.books.Changed() {
+        if err := s.db.InsertDepthSnapshot(snapshot); err != nil {
+            log.Printf("Failed to store depth snapshot for %s: %v", snapshot.Symbol, err)
         }
     }
 }
 
+func (s *MarketDataService) cacheMarketStatus(now time.Time) {
+    ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+    defer cancel()
+    
+    status := s.calendar.Status(now)
+    if err := s.redis.CacheMarketStatus(ctx, string(status.Session)); err != nil {
+        log.Printf("Failed to cache market status: %v", err)
+    }
+}
+
 func startHTTPServer(service *MarketDataService, port string) error {
     router := gin.Default()
     
@@ -158,10 +636,49 @@ func startHTTPServer(service *MarketDataService, port string) error {
     
     // API routes
     v1 := router.Group("/api/v1")
+    if service.apiLimiter != nil {
+        v1.Use(service.rateLimit)
+    }
     {
         v1.GET("/stocks", service.getStocks)
+        v1.POST("/stocks", service.createStock)
+        v1.GET("/stocks/:symbol", service.getStock)
+        v1.PUT("/stocks/:symbol", service.updateStock)
+        v1.DELETE("/stocks/:symbol", service.deleteStock)
         v1.GET("/stocks/:symbol/ohlcv", service.getOHLCV)
         v1.GET("/stocks/:symbol/ticks", service.getTicks)
+        v1.POST("/stocks/:symbol/evaluate", service.evaluateExpression)
+        v1.GET("/stocks/:symbol/indicators", service.getStockIndicators)
+        v1.GET("/stocks/:symbol/analytics", service.getStockAnalytics)
+        v1.GET("/stocks/:symbol/risk", service.getStockRisk)
+        v1.GET("/market/status", service.getMarketStatus)
+        v1.GET("/quotes", service.getQuotes)
+        v1.GET("/indices", service.getIndices)
+        v1.GET("/indicators", service.getIndicators)
+        v1.GET("/watchlists", service.getWatchlists)
+        v1.POST("/watchlists", service.createWatchlist)
+        v1.GET("/watchlists/:name", service.getWatchlist)
+        v1.PUT("/watchlists/:name", service.updateWatchlist)
+        v1.DELETE("/watchlists/:name", service.deleteWatchlist)
+        v1.POST("/watchlists/:name/symbols", service.addWatchlistSymbols)
+        v1.DELETE("/watchlists/:name/symbols/:symbol", service.removeWatchlistSymbol)
+        v1.POST("/screener", service.screen)
+        v1.GET("/screener/screens", service.getScreens)
+        v1.GET("/screener/screens/:name", service.getScreen)
+        v1.PUT("/screener/screens/:name", service.saveScreen)
+        v1.DELETE("/screener/screens/:name", service.deleteScreen)
+        v1.GET("/derivatives/:symbol/expiries", service.getExpiries)
+        v1.GET("/derivatives/:symbol/chain", service.getOptionChain)
+        v1.GET("/derivatives/:symbol/oi", service.getOpenInterest)
+        
+        // Alerts are evaluated by the collecting replica and managed from any
+        if service.notifications != nil {
+            v1.GET("/alerts", service.getAlerts)
+            v1.POST("/alerts", service.createAlert)
+            v1.GET("/alerts/:id", service.getAlert)
+            v1.DELETE("/alerts/:id", service.deleteAlert)
+            v1.POST("/alerts/:id/arm", service.armAlert)
+        }
     }
     
     // WebSocket endpoint
@@ -184,9 +701,41 @@ func startGRPCServer(service *MarketDataService, port string) error {
     return s.Serve(lis)
 }
 
+// rateLimit limits requests per client IP. API keys are not authenticated,
+// so keying on them would let a client reset its limit by changing the
+// header. If Redis is unavailable requests are let through.
+func (s *MarketDataService) rateLimit(c *gin.Context) {
+    key := "ip:" + c.ClientIP()
+    
+    result, err := s.apiLimiter.Allow(c.Request.Context(), key)
+    if err != nil {
+        log.Printf("Rate limit check failed: %v", err)
+        c.Next()
+        return
+    }
+    
+    c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+    c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+    if !result.Allowed {
+        retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+        c.Header("Retry-After", strconv.Itoa(retryAfter))
+        c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+        return
+    }
+    
+    c.Next()
+}
+
 func (s *MarketDataService) getStocks(c *gin.Context) {
-    // TODO: Implement get stocks endpoint
-    c.JSON(http.StatusOK, gin.H{"message": "Get stocks endpoint"})
+    stocks, err := s.stockCache.GetOrLoad(c.Request.Context(), s.stockCache.Key(), func(ctx context.Context) ([]models.Stock, error) {
+        return s.db.GetStocks()
+    })
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    
+    c.JSON(http.StatusOK, gin.H{"stocks": stocks, "count": len(stocks)})
 }
 
 func (s *MarketDataService) getOHLCV(c *gin.Context) {
@@ -201,6 +750,53 @@ func (s *MarketDataService) getTicks(c *gin.Context) {
     c.JSON(http.StatusOK, gin.H{"symbol": symbol, "message": "Get ticks endpoint"})
 }
 
+func (s *MarketDataService) getMarketStatus(c *gin.Context) {
+    cal := s.calendar
+    if exchange := c.Query("exchange"); exchange != "" {
+        var exists bool
+        cal, exists = s.calendars[strings.ToUpper(exchange)]
+        if !exists {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported exchange: " + exchange})
+            return
+        }
+    }
+    
+    c.JSON(http.StatusOK, cal.Status(time.Now()))
+}
+
+// maxQuoteSymbols bounds the batch size of the quotes endpoint
+const maxQuoteSymbols = 200
+
+func (s *MarketDataService) getQuotes(c *gin.Context) {
+    symbols := splitList(strings.ToUpper(c.Query("symbols")))
+    if len(symbols) == 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "symbols query parameter is required"})
+        return
+    }
+    if len(symbols) > maxQuoteSymbols {
+        c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d symbols per request", maxQuoteSymbols)})
+        return
+    }
+    
+    quotes, err := s.quotes.GetQuotes(c.Request.Context(), symbols)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    
+    result := make([]*storage.Quote, 0, len(quotes))
+    var missing []string
+    for _, symbol := range symbols {
+        if quote, exists := quotes[symbol]; exists {
+            result = append(result, quote)
+        } else {
+            missing = append(missing, symbol)
+        }
+    }
+    
+    c.JSON(http.StatusOK, gin.H{"quotes": result, "missing": missing})
+}
+
 func (s *MarketDataService) handleWebSocket(c *gin.Context) {
     websocket.HandleWebSocket(s.wsHub, c.Writer, c.Request)
 }
@@ -0,0 +1,168 @@
+package main
+
+import (
+    "context"
+    "log"
+    "net/http"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "github.com/algo-trading/market-data-service/internal/models"
+    "github.com/algo-trading/market-data-service/internal/screener"
+)
+
+// screenTick is how often the scheduler looks for saved screens that are due
+const screenTick = 15 * time.Second
+
+// runScreen screens the whole stock universe
+func (s *MarketDataService) runScreen(ctx context.Context, screen *screener.Screen) (*screener.Report, error) {
+    stocks, err := s.stockCache.GetOrLoad(ctx, s.stockCache.Key(), func(ctx context.Context) ([]models.Stock, error) {
+        return s.db.GetStocks()
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return screen.Run(ctx, stocks, func(symbol, timeframe string, limit int) ([]models.OHLCV, error) {
+        return latestBars(s.db, symbol, timeframe, limit)
+    }, s.screenerWorkers)
+}
+
+func (s *MarketDataService) screen(c *gin.Context) {
+    var criteria screener.Criteria
+    if err := c.ShouldBindJSON(&criteria); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    screen, err := screener.Compile(criteria)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    report, err := s.runScreen(c.Request.Context(), screen)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, report)
+}
+
+func (s *MarketDataService) getScreens(c *gin.Context) {
+    screens, err := s.db.GetScreens()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"screens": screens})
+}
+
+func (s *MarketDataService) getScreen(c *gin.Context) {
+    screen, err := s.db.GetScreen(c.Param("name"))
+    if err != nil {
+        c.JSON(storageErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"screen": screen})
+}
+
+type screenRequest struct {
+    Criteria screener.Criteria `json:"criteria"`
+    Interval string            `json:"interval"`
+    Active   *bool             `json:"active"`
+}
+
+// saveScreen creates or replaces a saved screen
+func (s *MarketDataService) saveScreen(c *gin.Context) {
+    var req screenRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    saved := &screener.Saved{Name: c.Param("name"), Criteria: req.Criteria, Interval: req.Interval, Active: true}
+    if saved.Interval == "" {
+        saved.Interval = "5m"
+    }
+    if req.Active != nil {
+        saved.Active = *req.Active
+    }
+
+    screen, _, err := saved.Validate()
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    saved.Criteria = screen.Criteria()
+
+    if err := s.db.SaveScreen(saved); err != nil {
+        c.JSON(storageErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"screen": saved, "channel": screener.Channel(saved.Name)})
+}
+
+func (s *MarketDataService) deleteScreen(c *gin.Context) {
+    if err := s.db.DeleteScreen(c.Param("name")); err != nil {
+        c.JSON(storageErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "screen deleted"})
+}
+
+// runScreens runs the active saved screens on their intervals while the
+// market is open, pushing each report to the clients of this replica
+// subscribed to the screen's channel. Screens nobody is subscribed to are
+// skipped, so every replica can run the scheduler.
+func (s *MarketDataService) runScreens(ctx context.Context) {
+    ticker := time.NewTicker(screenTick)
+    defer ticker.Stop()
+
+    lastRun := make(map[string]time.Time)
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case now := <-ticker.C:
+            if !s.calendar.IsOpen(now) {
+                continue
+            }
+
+            saved, err := s.db.GetScreens()
+            if err != nil {
+                log.Printf("Failed to load saved screens: %v", err)
+                continue
+            }
+
+            for i := range saved {
+                if !saved[i].Active || s.wsHub.SubscriberCount(screener.Channel(saved[i].Name)) == 0 {
+                    continue
+                }
+
+                screen, interval, err := saved[i].Validate()
+                if err != nil {
+                    log.Printf("Skipping invalid screen %s: %v", saved[i].Name, err)
+                    continue
+                }
+                if now.Sub(lastRun[saved[i].Name]) < interval {
+                    continue
+                }
+                lastRun[saved[i].Name] = now
+
+                report, err := s.runScreen(ctx, screen)
+                if err != nil {
+                    log.Printf("Failed to run screen %s: %v", saved[i].Name, err)
+                    continue
+                }
+                report.Name = saved[i].Name
+                s.wsHub.SendScreen(report)
+            }
+        }
+    }
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+    "context"
+    "log"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "github.com/algo-trading/market-data-service/internal/models"
+    "github.com/algo-trading/market-data-service/internal/storage"
+)
+
+// maxIndicatorRows bounds the history returned by the indicators endpoint
+const maxIndicatorRows = 5000
+
+// latestBars returns up to limit of the latest stored bars of a series,
+// oldest first
+func latestBars(db *storage.Database, symbol, timeframe string, limit int) ([]models.OHLCV, error) {
+    bars, err := db.GetOHLCV(symbol, timeframe, time.Time{}, time.Now(), limit)
+    if err != nil {
+        return nil, err
+    }
+    // Stored bars come newest first
+    for i, j := 0, len(bars)-1; i < j; i, j = i+1, j-1 {
+        bars[i], bars[j] = bars[j], bars[i]
+    }
+    return bars, nil
+}
+
+// publishIndicator stores an indicator value, caches it as the latest value
+// and pushes it to WebSocket clients and Kafka
+func (s *MarketDataService) publishIndicator(ctx context.Context, indicator *models.TechnicalIndicator) {
+    if err := s.db.InsertTechnicalIndicator(indicator); err != nil {
+        log.Printf("Failed to store %s for %s: %v", indicator.IndicatorName, indicator.Symbol, err)
+    }
+
+    if err := s.redis.CacheTechnicalIndicator(ctx, indicator.Symbol, indicator.Timeframe, indicator.IndicatorName, indicator.Value); err != nil {
+        log.Printf("Failed to cache %s for %s: %v", indicator.IndicatorName, indicator.Symbol, err)
+    }
+
+    s.wsHub.SendTechnicalIndicator(indicator.Symbol, indicator)
+
+    if err := s.producer.PublishIndicator(ctx, indicator); err != nil {
+        log.Printf("Failed to publish %s for %s: %v", indicator.IndicatorName, indicator.Symbol, err)
+    }
+}
+
+// getStockIndicators returns the history of one stored indicator with name,
+// or the latest value of each configured indicator without it
+func (s *MarketDataService) getStockIndicators(c *gin.Context) {
+    symbol := strings.ToUpper(c.Param("symbol"))
+    timeframe := c.DefaultQuery("timeframe", "1d")
+
+    name := c.Query("name")
+    if name == "" {
+        var latest []models.TechnicalIndicator
+        if s.technicals != nil {
+            for _, spec := range s.technicals.Specs() {
+                rows, err := s.db.GetTechnicalIndicators(symbol, timeframe, spec.Name, time.Time{}, time.Now(), 1)
+                if err != nil {
+                    c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+                    return
+                }
+                latest = append(latest, rows...)
+            }
+        }
+
+        c.JSON(http.StatusOK, gin.H{"symbol": symbol, "timeframe": timeframe, "indicators": latest, "count": len(latest)})
+        return
+    }
+
+    var start time.Time
+    end := time.Now()
+    if value := c.Query("from"); value != "" {
+        parsed, err := time.Parse(time.RFC3339, value)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "from must be an RFC3339 timestamp"})
+            return
+        }
+        start = parsed
+    }
+    if value := c.Query("to"); value != "" {
+        parsed, err := time.Parse(time.RFC3339, value)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "to must be an RFC3339 timestamp"})
+            return
+        }
+        end = parsed
+    }
+
+    limit := 500
+    if value := c.Query("limit"); value != "" {
+        parsed, err := strconv.Atoi(value)
+        if err != nil || parsed <= 0 || parsed > maxIndicatorRows {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 5000"})
+            return
+        }
+        limit = parsed
+    }
+
+    rows, err := s.db.GetTechnicalIndicators(symbol, timeframe, name, start, end, limit)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "symbol":     symbol,
+        "timeframe":  timeframe,
+        "name":       name,
+        "indicators": rows,
+        "count":      len(rows),
+    })
+}
@@ -0,0 +1,355 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "log"
+    "net/http"
+    "strings"
+
+    "github.com/gin-gonic/gin"
+    "github.com/algo-trading/market-data-service/internal/api"
+    "github.com/algo-trading/market-data-service/internal/calendar"
+    "github.com/algo-trading/market-data-service/internal/models"
+    "github.com/algo-trading/market-data-service/internal/storage"
+    "github.com/algo-trading/market-data-service/internal/universe"
+)
+
+// providerSubscriber feeds ticks and market depth of the universe's symbols
+// from the provider into the pipeline
+type providerSubscriber struct {
+    s        *MarketDataService
+    provider api.MarketDataProvider
+}
+
+func (p providerSubscriber) Subscribe(ctx context.Context, symbols []string) error {
+    err := p.provider.SubscribeToTicks(ctx, symbols, func(tick *models.Tick) {
+        p.s.pipeline.ProcessTick(ctx, tick)
+    })
+    if err != nil {
+        return err
+    }
+    
+    if err := p.s.apiManager.SubscribeToDepth(ctx, symbols, p.s.depthLevels, p.s.handleDepth); err != nil {
+        log.Printf("Market depth unavailable for %v: %v", symbols, err)
+    }
+    
+    log.Printf("Subscribed to %v on %s", symbols, p.provider.GetName())
+    return nil
+}
+
+func (p providerSubscriber) Unsubscribe(ctx context.Context, symbols []string) error {
+    if err := p.provider.UnsubscribeFromTicks(ctx, symbols); err != nil {
+        return err
+    }
+    
+    if err := p.s.apiManager.UnsubscribeFromDepth(ctx, symbols); err != nil {
+        log.Printf("Failed to unsubscribe from market depth for %v: %v", symbols, err)
+    }
+    
+    log.Printf("Unsubscribed from %v on %s", symbols, p.provider.GetName())
+    return nil
+}
+
+// syncUniverse reconciles the subscriptions with the watchlists. If they
+// cannot be loaded before anything is subscribed, the static symbols are
+// collected on their own.
+func (s *MarketDataService) syncUniverse(ctx context.Context, u *universe.Universe) {
+    watchlists, err := s.db.GetWatchlists()
+    if err != nil {
+        log.Printf("Failed to load watchlists: %v", err)
+        if len(u.Symbols()) > 0 {
+            return
+        }
+    }
+    
+    if err := u.Load(ctx, watchlists); err != nil {
+        log.Printf("Failed to update subscriptions: %v", err)
+    }
+}
+
+// requestUniverseSync asks the data collection loop to reload the watchlists.
+// Replicas not collecting data, or with a sync already pending, drop it.
+func (s *MarketDataService) requestUniverseSync() {
+    select {
+    case s.universeSync <- struct{}{}:
+    default:
+    }
+}
+
+func storageErrorStatus(err error) int {
+    switch {
+    case errors.Is(err, storage.ErrNotFound):
+        return http.StatusNotFound
+    case errors.Is(err, storage.ErrExists):
+        return http.StatusConflict
+    default:
+        return http.StatusInternalServerError
+    }
+}
+
+type stockRequest struct {
+    Symbol      string `json:"symbol"`
+    CompanyName string `json:"company_name"`
+    Sector      string `json:"sector"`
+    MarketCap   int64  `json:"market_cap"`
+    Exchange    string `json:"exchange"`
+}
+
+// bindStock parses a stock from the request body, taking the symbol from the
+// path when present
+func bindStock(c *gin.Context) (*models.Stock, bool) {
+    var req stockRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return nil, false
+    }
+    
+    if symbol := c.Param("symbol"); symbol != "" {
+        req.Symbol = symbol
+    }
+    symbol, err := universe.NormalizeSymbol(req.Symbol)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return nil, false
+    }
+    
+    exchange := strings.ToUpper(req.Exchange)
+    if exchange == "" {
+        exchange = calendar.NSE
+    }
+    if exchange != calendar.NSE && exchange != calendar.BSE {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported exchange: " + req.Exchange})
+        return nil, false
+    }
+    if req.MarketCap < 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "market_cap must not be negative"})
+        return nil, false
+    }
+    
+    return &models.Stock{
+        Symbol:      symbol,
+        CompanyName: req.CompanyName,
+        Sector:      req.Sector,
+        MarketCap:   req.MarketCap,
+        Exchange:    exchange,
+    }, true
+}
+
+// invalidateStocks drops the cached stock list after a change
+func (s *MarketDataService) invalidateStocks(ctx context.Context) {
+    if err := s.stockCache.Invalidate(ctx, s.stockCache.Key()); err != nil {
+        log.Printf("Failed to invalidate stock cache: %v", err)
+    }
+}
+
+func (s *MarketDataService) getStock(c *gin.Context) {
+    stock, err := s.db.GetStock(strings.ToUpper(c.Param("symbol")))
+    if err != nil {
+        c.JSON(storageErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+    
+    c.JSON(http.StatusOK, gin.H{"stock": stock})
+}
+
+func (s *MarketDataService) createStock(c *gin.Context) {
+    stock, ok := bindStock(c)
+    if !ok {
+        return
+    }
+    
+    if err := s.db.CreateStock(stock); err != nil {
+        c.JSON(storageErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+    s.invalidateStocks(c.Request.Context())
+    
+    c.JSON(http.StatusCreated, gin.H{"stock": stock})
+}
+
+func (s *MarketDataService) updateStock(c *gin.Context) {
+    stock, ok := bindStock(c)
+    if !ok {
+        return
+    }
+    
+    if err := s.db.UpdateStock(stock); err != nil {
+        c.JSON(storageErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+    s.invalidateStocks(c.Request.Context())
+    
+    c.JSON(http.StatusOK, gin.H{"stock": stock})
+}
+
+func (s *MarketDataService) deleteStock(c *gin.Context) {
+    if err := s.db.DeleteStock(strings.ToUpper(c.Param("symbol"))); err != nil {
+        c.JSON(storageErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+    s.invalidateStocks(c.Request.Context())
+    
+    // The stock is removed from its watchlists as well
+    s.requestUniverseSync()
+    
+    c.Status(http.StatusNoContent)
+}
+
+type watchlistRequest struct {
+    Name        string   `json:"name"`
+    Description *string  `json:"description"`
+    Active      *bool    `json:"active"`
+    Symbols     []string `json:"symbols"`
+}
+
+func normalizeSymbols(symbols []string) ([]string, error) {
+    normalized := make([]string, 0, len(symbols))
+    for _, symbol := range symbols {
+        symbol, err := universe.NormalizeSymbol(symbol)
+        if err != nil {
+            return nil, err
+        }
+        normalized = append(normalized, symbol)
+    }
+    return normalized, nil
+}
+
+func (s *MarketDataService) getWatchlists(c *gin.Context) {
+    watchlists, err := s.db.GetWatchlists()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    
+    c.JSON(http.StatusOK, gin.H{"watchlists": watchlists, "count": len(watchlists)})
+}
+
+func (s *MarketDataService) getWatchlist(c *gin.Context) {
+    watchlist, err := s.db.GetWatchlist(c.Param("name"))
+    if err != nil {
+        c.JSON(storageErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+    
+    c.JSON(http.StatusOK, gin.H{"watchlist": watchlist})
+}
+
+func (s *MarketDataService) createWatchlist(c *gin.Context) {
+    var req watchlistRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    
+    name := strings.TrimSpace(req.Name)
+    if name == "" || len(name) > 100 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "name must be 1 to 100 characters"})
+        return
+    }
+    symbols, err := normalizeSymbols(req.Symbols)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    
+    watchlist := &universe.Watchlist{Name: name, Active: true, Symbols: symbols}
+    if req.Description != nil {
+        watchlist.Description = *req.Description
+    }
+    if req.Active != nil {
+        watchlist.Active = *req.Active
+    }
+    
+    if err := s.db.CreateWatchlist(watchlist); err != nil {
+        c.JSON(storageErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+    s.requestUniverseSync()
+    
+    c.JSON(http.StatusCreated, gin.H{"watchlist": watchlist})
+}
+
+// updateWatchlist changes the description or active flag; symbols are
+// managed through the symbols endpoints
+func (s *MarketDataService) updateWatchlist(c *gin.Context) {
+    var req watchlistRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    
+    watchlist, err := s.db.GetWatchlist(c.Param("name"))
+    if err != nil {
+        c.JSON(storageErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+    if req.Description != nil {
+        watchlist.Description = *req.Description
+    }
+    if req.Active != nil {
+        watchlist.Active = *req.Active
+    }
+    
+    if err := s.db.UpdateWatchlist(watchlist); err != nil {
+        c.JSON(storageErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+    s.requestUniverseSync()
+    
+    c.JSON(http.StatusOK, gin.H{"watchlist": watchlist})
+}
+
+func (s *MarketDataService) deleteWatchlist(c *gin.Context) {
+    if err := s.db.DeleteWatchlist(c.Param("name")); err != nil {
+        c.JSON(storageErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+    s.requestUniverseSync()
+    
+    c.Status(http.StatusNoContent)
+}
+
+func (s *MarketDataService) addWatchlistSymbols(c *gin.Context) {
+    var req watchlistRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    
+    symbols, err := normalizeSymbols(req.Symbols)
+    if err != nil || len(symbols) == 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "symbols must be a non-empty list of valid symbols"})
+        return
+    }
+    
+    name := c.Param("name")
+    if err := s.db.AddWatchlistSymbols(name, symbols); err != nil {
+        c.JSON(storageErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+    s.requestUniverseSync()
+    
+    s.respondWatchlist(c, name)
+}
+
+func (s *MarketDataService) removeWatchlistSymbol(c *gin.Context) {
+    name := c.Param("name")
+    if err := s.db.RemoveWatchlistSymbol(name, strings.ToUpper(c.Param("symbol"))); err != nil {
+        c.JSON(storageErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+    s.requestUniverseSync()
+    
+    s.respondWatchlist(c, name)
+}
+
+func (s *MarketDataService) respondWatchlist(c *gin.Context, name string) {
+    watchlist, err := s.db.GetWatchlist(name)
+    if err != nil {
+        c.JSON(storageErrorStatus(err), gin.H{"error": err.Error()})
+        return
+    }
+    
+    c.JSON(http.StatusOK, gin.H{"watchlist": watchlist})
+}
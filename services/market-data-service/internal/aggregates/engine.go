@@ -0,0 +1,337 @@
+package aggregates
+
+import (
+    "context"
+    "fmt"
+    "regexp"
+    "sort"
+    "strings"
+    "sync"
+
+    "github.com/algo-trading/market-data-service/internal/calendar"
+    "github.com/algo-trading/market-data-service/internal/models"
+)
+
+// Weighting determines how constituents contribute to an index
+type Weighting string
+
+const (
+    EqualWeighted     Weighting = "equal"
+    MarketCapWeighted Weighting = "market_cap"
+)
+
+// DefaultBaseValue is the value of an index when it is first computed
+const DefaultBaseValue = 1000.0
+
+// Definition describes a custom index or sector aggregate. Weights hold the
+// market cap of each constituent for market-cap weighting and 1 otherwise.
+type Definition struct {
+    Symbol    string             `json:"symbol"`
+    Name      string             `json:"name"`
+    Weighting Weighting          `json:"weighting"`
+    Weights   map[string]float64 `json:"weights"`
+}
+
+// NewDefinition builds a definition from the stocks, weighting them by market
+// cap or equally. Stocks without a market cap get the average market cap of
+// the others so that missing reference data does not drop them.
+func NewDefinition(symbol, name string, weighting Weighting, stocks []models.Stock) (Definition, error) {
+    if len(stocks) == 0 {
+        return Definition{}, fmt.Errorf("index %s has no constituents", symbol)
+    }
+    if weighting != EqualWeighted && weighting != MarketCapWeighted {
+        return Definition{}, fmt.Errorf("invalid weighting for index %s: %s", symbol, weighting)
+    }
+
+    var total float64
+    var known int
+    for _, stock := range stocks {
+        if stock.MarketCap > 0 {
+            total += float64(stock.MarketCap)
+            known++
+        }
+    }
+
+    def := Definition{
+        Symbol:    symbol,
+        Name:      name,
+        Weighting: weighting,
+        Weights:   make(map[string]float64, len(stocks)),
+    }
+    for _, stock := range stocks {
+        weight := 1.0
+        if weighting == MarketCapWeighted && known > 0 {
+            weight = total / float64(known)
+            if stock.MarketCap > 0 {
+                weight = float64(stock.MarketCap)
+            }
+        }
+        def.Weights[stock.Symbol] = weight
+    }
+
+    return def, nil
+}
+
+var nonAlphanumeric = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// SectorSymbol returns the synthetic symbol of a sector aggregate, e.g.
+// SECTOR_INFORMATION_TECHNOLOGY
+func SectorSymbol(sector string) string {
+    return "SECTOR_" + strings.Trim(nonAlphanumeric.ReplaceAllString(strings.ToUpper(sector), "_"), "_")
+}
+
+// SectorDefinitions builds one aggregate per sector of the stocks
+func SectorDefinitions(stocks []models.Stock, weighting Weighting) ([]Definition, error) {
+    bySector := make(map[string][]models.Stock)
+    for _, stock := range stocks {
+        if stock.Sector != "" {
+            bySector[stock.Sector] = append(bySector[stock.Sector], stock)
+        }
+    }
+
+    sectors := make([]string, 0, len(bySector))
+    for sector := range bySector {
+        sectors = append(sectors, sector)
+    }
+    sort.Strings(sectors)
+
+    var defs []Definition
+    for _, sector := range sectors {
+        def, err := NewDefinition(SectorSymbol(sector), sector, weighting, bySector[sector])
+        if err != nil {
+            return nil, err
+        }
+        defs = append(defs, def)
+    }
+    return defs, nil
+}
+
+// ParseDefinitions parses custom indices in the form
+// "SYMBOL=weighting:CONST1|CONST2;SYMBOL2=...", e.g.
+// "TOPIT=equal:TCS|INFY;MEGACAP=market_cap:RELIANCE|TCS|HDFCBANK".
+// Constituents are looked up in stocks for their market cap.
+func ParseDefinitions(spec string, stocks []models.Stock) ([]Definition, error) {
+    bySymbol := make(map[string]models.Stock, len(stocks))
+    for _, stock := range stocks {
+        bySymbol[stock.Symbol] = stock
+    }
+
+    var defs []Definition
+    for _, entry := range strings.Split(spec, ";") {
+        entry = strings.TrimSpace(entry)
+        if entry == "" {
+            continue
+        }
+
+        symbol, rest, ok1 := strings.Cut(entry, "=")
+        weighting, list, ok2 := strings.Cut(rest, ":")
+        if !ok1 || !ok2 || symbol == "" {
+            return nil, fmt.Errorf("invalid index definition: %q", entry)
+        }
+
+        var constituents []models.Stock
+        for _, name := range strings.Split(list, "|") {
+            name = strings.ToUpper(strings.TrimSpace(name))
+            if name == "" {
+                continue
+            }
+            stock, exists := bySymbol[name]
+            if !exists {
+                stock = models.Stock{Symbol: name}
+            }
+            constituents = append(constituents, stock)
+        }
+
+        symbol = strings.ToUpper(strings.TrimSpace(symbol))
+        def, err := NewDefinition(symbol, symbol, Weighting(strings.TrimSpace(weighting)), constituents)
+        if err != nil {
+            return nil, err
+        }
+        defs = append(defs, def)
+    }
+    return defs, nil
+}
+
+// TickEmitter receives the synthetic ticks of the indices
+type TickEmitter func(ctx context.Context, tick *models.Tick)
+
+// Engine computes index values in real time from constituent ticks. Each
+// index is chain-linked: at the first tick of a trading day, and when a
+// constituent trades for the first time, the current value becomes the base
+// and the constituents' last prices become their base prices, so the index
+// never jumps when its composition changes. Constituents that have not
+// traded yet are left out.
+type Engine struct {
+    indices      map[string]*index
+    constituents map[string][]*index
+    emit         TickEmitter
+    mu           sync.Mutex
+}
+
+type index struct {
+    def        Definition
+    weights    map[string]float64
+    value      float64
+    baseValue  float64
+    basePrices map[string]float64
+    prices     map[string]float64
+    date       string
+}
+
+func NewEngine(emit TickEmitter) *Engine {
+    return &Engine{
+        indices:      make(map[string]*index),
+        constituents: make(map[string][]*index),
+        emit:         emit,
+    }
+}
+
+// Add registers an index starting from baseValue, e.g. its last stored close
+func (e *Engine) Add(def Definition, baseValue float64) error {
+    e.mu.Lock()
+    defer e.mu.Unlock()
+
+    if _, exists := e.indices[def.Symbol]; exists {
+        return fmt.Errorf("index %s already exists", def.Symbol)
+    }
+    if e.reaches(def.Weights, def.Symbol, make(map[string]bool)) {
+        return fmt.Errorf("index %s cannot contain itself, directly or through another index", def.Symbol)
+    }
+    if baseValue <= 0 {
+        baseValue = DefaultBaseValue
+    }
+
+    weights := make(map[string]float64, len(def.Weights))
+    for symbol, weight := range def.Weights {
+        weights[symbol] = weight
+    }
+
+    idx := &index{
+        def:        def,
+        weights:    weights,
+        value:      baseValue,
+        baseValue:  baseValue,
+        basePrices: make(map[string]float64),
+        prices:     make(map[string]float64),
+    }
+    e.indices[def.Symbol] = idx
+    for symbol := range def.Weights {
+        e.constituents[symbol] = append(e.constituents[symbol], idx)
+    }
+    return nil
+}
+
+// reaches reports whether target is among the constituents or, since index
+// ticks are fed back into the engine, the nested constituents of any
+// registered index among them
+func (e *Engine) reaches(constituents map[string]float64, target string, visited map[string]bool) bool {
+    for symbol := range constituents {
+        if symbol == target {
+            return true
+        }
+        if visited[symbol] {
+            continue
+        }
+        visited[symbol] = true
+        if idx, exists := e.indices[symbol]; exists && e.reaches(idx.def.Weights, target, visited) {
+            return true
+        }
+    }
+    return false
+}
+
+// Definitions returns the registered indices ordered by symbol
+func (e *Engine) Definitions() []Definition {
+    e.mu.Lock()
+    defer e.mu.Unlock()
+
+    defs := make([]Definition, 0, len(e.indices))
+    for _, idx := range e.indices {
+        defs = append(defs, idx.def)
+    }
+    sort.Slice(defs, func(i, j int) bool { return defs[i].Symbol < defs[j].Symbol })
+    return defs
+}
+
+// Value returns the current value of an index
+func (e *Engine) Value(symbol string) (float64, bool) {
+    e.mu.Lock()
+    defer e.mu.Unlock()
+
+    idx, exists := e.indices[symbol]
+    if !exists {
+        return 0, false
+    }
+    return idx.value, true
+}
+
+// ProcessTick updates the indices containing the tick's symbol and emits a
+// synthetic tick for each of them. Index ticks carry no volume of their own.
+func (e *Engine) ProcessTick(ctx context.Context, tick *models.Tick) {
+    e.mu.Lock()
+    affected := e.constituents[tick.Symbol]
+    ticks := make([]*models.Tick, 0, len(affected))
+    for _, idx := range affected {
+        if value, ok := idx.update(tick); ok {
+            ticks = append(ticks, &models.Tick{
+                Time:   tick.Time,
+                Symbol: idx.def.Symbol,
+                Price:  value,
+            })
+        }
+    }
+    e.mu.Unlock()
+
+    for _, indexTick := range ticks {
+        e.emit(ctx, indexTick)
+    }
+}
+
+func (idx *index) update(tick *models.Tick) (float64, bool) {
+    if tick.Price <= 0 {
+        return 0, false
+    }
+
+    // Rebase before applying the tick so that its move counts towards the
+    // value; a new constituent enters at its first price
+    _, known := idx.prices[tick.Symbol]
+    if !known {
+        idx.prices[tick.Symbol] = tick.Price
+    }
+    date := tick.Time.In(calendar.IST).Format("2006-01-02")
+    if date != idx.date || !known {
+        idx.rebase()
+        idx.date = date
+    }
+    idx.prices[tick.Symbol] = tick.Price
+
+    var weighted, total float64
+    for symbol, base := range idx.basePrices {
+        weight := idx.weights[symbol]
+        weighted += weight * idx.prices[symbol] / base
+        total += weight
+    }
+    if total == 0 {
+        return 0, false
+    }
+
+    idx.value = idx.baseValue * weighted / total
+    return idx.value, true
+}
+
+// rebase chain-links the index at its current value. Market-cap weights
+// drift with prices so that the index keeps tracking total market cap, while
+// equal-weighted indices are rebalanced to equal weights.
+func (idx *index) rebase() {
+    if idx.def.Weighting == MarketCapWeighted {
+        for symbol, base := range idx.basePrices {
+            idx.weights[symbol] *= idx.prices[symbol] / base
+        }
+    }
+
+    idx.baseValue = idx.value
+    idx.basePrices = make(map[string]float64, len(idx.prices))
+    for symbol, price := range idx.prices {
+        idx.basePrices[symbol] = price
+    }
+}
@@ -0,0 +1,173 @@
+package aggregates
+
+import (
+    "context"
+    "math"
+    "testing"
+    "time"
+
+    "github.com/algo-trading/market-data-service/internal/calendar"
+    "github.com/algo-trading/market-data-service/internal/models"
+)
+
+var testStocks = []models.Stock{
+    {Symbol: "TCS", Sector: "Information Technology", MarketCap: 300},
+    {Symbol: "INFY", Sector: "Information Technology", MarketCap: 100},
+    {Symbol: "HDFCBANK", Sector: "Financial Services", MarketCap: 200},
+    {Symbol: "SBIN", Sector: "Financial Services"},
+}
+
+type recorder struct {
+    ticks []*models.Tick
+}
+
+func (r *recorder) emit(ctx context.Context, tick *models.Tick) {
+    r.ticks = append(r.ticks, tick)
+}
+
+func (r *recorder) last() *models.Tick {
+    return r.ticks[len(r.ticks)-1]
+}
+
+func tick(t time.Time, symbol string, price float64) *models.Tick {
+    return &models.Tick{Time: t, Symbol: symbol, Price: price, Volume: 10}
+}
+
+func TestSectorDefinitions(t *testing.T) {
+    defs, err := SectorDefinitions(testStocks, MarketCapWeighted)
+    if err != nil {
+        t.Fatalf("Failed to build sector definitions: %v", err)
+    }
+    if len(defs) != 2 {
+        t.Fatalf("Expected 2 sectors, got %d", len(defs))
+    }
+
+    financials := defs[0]
+    if financials.Symbol != "SECTOR_FINANCIAL_SERVICES" || financials.Name != "Financial Services" {
+        t.Errorf("Unexpected sector definition: %+v", financials)
+    }
+    // SBIN has no market cap and gets the average of the others
+    if financials.Weights["HDFCBANK"] != 200 || financials.Weights["SBIN"] != 200 {
+        t.Errorf("Unexpected weights: %v", financials.Weights)
+    }
+}
+
+func TestParseDefinitions(t *testing.T) {
+    defs, err := ParseDefinitions("topit=equal:TCS|infy; MEGA=market_cap:TCS|HDFCBANK", testStocks)
+    if err != nil {
+        t.Fatalf("Failed to parse definitions: %v", err)
+    }
+    if len(defs) != 2 || defs[0].Symbol != "TOPIT" || defs[0].Weights["INFY"] != 1 || defs[1].Weights["TCS"] != 300 {
+        t.Errorf("Unexpected definitions: %+v", defs)
+    }
+
+    for _, spec := range []string{"TOPIT", "TOPIT=equal", "TOPIT=price:TCS", "TOPIT=equal:"} {
+        if _, err := ParseDefinitions(spec, testStocks); err == nil {
+            t.Errorf("Expected error parsing %q", spec)
+        }
+    }
+}
+
+func TestEngineMarketCapWeighted(t *testing.T) {
+    def, _ := NewDefinition("IT", "IT", MarketCapWeighted, testStocks[:2])
+    rec := &recorder{}
+    engine := NewEngine(rec.emit)
+    if err := engine.Add(def, 0); err != nil {
+        t.Fatalf("Failed to add index: %v", err)
+    }
+    ctx := context.Background()
+    day := time.Date(2025, time.October, 14, 10, 0, 0, 0, calendar.IST)
+
+    engine.ProcessTick(ctx, tick(day, "TCS", 100))
+    engine.ProcessTick(ctx, tick(day, "INFY", 50))
+    engine.ProcessTick(ctx, tick(day, "RELIANCE", 1000))
+    if len(rec.ticks) != 2 || rec.last().Symbol != "IT" || rec.last().Price != 1000 {
+        t.Fatalf("Expected index to start at the base value, got %+v", rec.ticks)
+    }
+
+    // TCS +10% with 75% weight
+    engine.ProcessTick(ctx, tick(day, "TCS", 110))
+    if math.Abs(rec.last().Price-1075) > 1e-9 {
+        t.Errorf("Expected 1075, got %f", rec.last().Price)
+    }
+
+    // The next day continues from the previous value with drifted weights,
+    // so the index keeps tracking total market cap: 400 -> 430 -> 440
+    engine.ProcessTick(ctx, tick(day.AddDate(0, 0, 1), "INFY", 55))
+    expected := 1100.0
+    if math.Abs(rec.last().Price-expected) > 1e-9 {
+        t.Errorf("Expected %f, got %f", expected, rec.last().Price)
+    }
+    if value, _ := engine.Value("IT"); value != rec.last().Price {
+        t.Errorf("Expected Value to return the last emitted value")
+    }
+}
+
+func TestEngineEqualWeighted(t *testing.T) {
+    def, _ := NewDefinition("EQ", "EQ", EqualWeighted, testStocks[:2])
+    rec := &recorder{}
+    engine := NewEngine(rec.emit)
+    engine.Add(def, 500)
+    ctx := context.Background()
+    now := time.Date(2025, time.October, 14, 10, 0, 0, 0, calendar.IST)
+
+    engine.ProcessTick(ctx, tick(now, "TCS", 100))
+    engine.ProcessTick(ctx, tick(now, "TCS", 120))
+    if rec.last().Price != 600 {
+        t.Errorf("Expected 600 with a single constituent, got %f", rec.last().Price)
+    }
+
+    // INFY joins without moving the index, then both count equally
+    engine.ProcessTick(ctx, tick(now, "INFY", 50))
+    if rec.last().Price != 600 {
+        t.Errorf("Expected index unchanged when a constituent joins, got %f", rec.last().Price)
+    }
+    engine.ProcessTick(ctx, tick(now, "INFY", 55))
+    if math.Abs(rec.last().Price-630) > 1e-9 {
+        t.Errorf("Expected 630, got %f", rec.last().Price)
+    }
+
+    if err := engine.Add(def, 0); err == nil {
+        t.Errorf("Expected error adding a duplicate index")
+    }
+}
+
+func TestEngineRejectsCycles(t *testing.T) {
+    engine := NewEngine((&recorder{}).emit)
+    add := func(symbol string, constituents ...string) error {
+        stocks := make([]models.Stock, len(constituents))
+        for i, constituent := range constituents {
+            stocks[i] = models.Stock{Symbol: constituent}
+        }
+        def, _ := NewDefinition(symbol, symbol, EqualWeighted, stocks)
+        return engine.Add(def, 0)
+    }
+
+    if err := add("SELF", "TCS", "SELF"); err == nil {
+        t.Errorf("Expected error adding an index containing itself")
+    }
+    if err := add("A", "TCS", "B"); err != nil {
+        t.Fatalf("Failed to add index: %v", err)
+    }
+    if err := add("B", "INFY", "C"); err != nil {
+        t.Fatalf("Failed to add index: %v", err)
+    }
+    if err := add("C", "WIPRO", "A"); err == nil {
+        t.Errorf("Expected error adding an index that contains itself through others")
+    }
+    if err := add("D", "A", "B"); err != nil {
+        t.Errorf("Expected nesting without a cycle to be accepted, got %v", err)
+    }
+}
+
+func TestEngineIndexTicksHaveNoVolume(t *testing.T) {
+    def, _ := NewDefinition("EQ", "EQ", EqualWeighted, testStocks[:2])
+    rec := &recorder{}
+    engine := NewEngine(rec.emit)
+    engine.Add(def, 0)
+
+    engine.ProcessTick(context.Background(), tick(time.Date(2025, time.October, 14, 10, 0, 0, 0, calendar.IST), "TCS", 100))
+    if len(rec.ticks) != 1 || rec.last().Volume != 0 {
+        t.Errorf("Expected an index tick without volume, got %+v", rec.ticks)
+    }
+}
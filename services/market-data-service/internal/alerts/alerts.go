@@ -0,0 +1,216 @@
+// Package alerts evaluates user-defined price, move, indicator and volume
+// alerts on the live tick and bar streams and delivers their notifications
+package alerts
+
+import (
+    "fmt"
+    "net/mail"
+    "net/url"
+    "strconv"
+    "strings"
+    "time"
+    "unicode"
+
+    "github.com/algo-trading/market-data-service/internal/calendar"
+    "github.com/algo-trading/market-data-service/pkg/expr"
+)
+
+// Type is what an alert watches for
+type Type string
+
+const (
+    // PriceAbove triggers when the price crosses above Threshold
+    PriceAbove Type = "price_above"
+    // PriceBelow triggers when the price crosses below Threshold
+    PriceBelow Type = "price_below"
+    // PercentMove triggers when the price moves Threshold percent either way
+    // from Reference
+    PercentMove Type = "percent_move"
+    // Condition triggers when Expression holds on a completed bar
+    Condition Type = "condition"
+    // VolumeSpike triggers when a completed bar's volume is at least
+    // Threshold times the average of the Period bars before it
+    VolumeSpike Type = "volume_spike"
+)
+
+// OnBars reports whether the alert type is evaluated on completed bars of
+// the alert's timeframe rather than on ticks
+func (t Type) OnBars() bool {
+    return t == Condition || t == VolumeSpike
+}
+
+// Status is the state of an alert. Armed alerts are evaluated; a triggered
+// alert fires no more unless it repeats, in which case it is armed again
+// once its condition has cleared. Alerts past their expiry are expired.
+type Status string
+
+const (
+    Armed     Status = "armed"
+    Triggered Status = "triggered"
+    Expired   Status = "expired"
+)
+
+// ChannelType is a way of delivering notifications
+type ChannelType string
+
+const (
+    WebSocket ChannelType = "websocket"
+    Webhook   ChannelType = "webhook"
+    Email     ChannelType = "email"
+    Telegram  ChannelType = "telegram"
+)
+
+// Channel is where an alert's notifications go. Target is the webhook URL,
+// the email address or the Telegram chat ID; WebSocket notifications go to
+// the subscribers of the alert's Topic.
+type Channel struct {
+    Type   ChannelType `json:"type"`
+    Target string      `json:"target,omitempty"`
+}
+
+// Topic is the WebSocket subscription on which the notifications of an alert
+// are pushed, so that they reach the client that created it rather than
+// everyone watching the symbol
+func Topic(id int) string {
+    return TopicPrefix + strconv.Itoa(id)
+}
+
+// TopicPrefix starts the subscription names of alerts, which cannot clash
+// with symbols
+const TopicPrefix = "alert:"
+
+const (
+    // DefaultPeriod is the number of bars a volume spike is measured against
+    DefaultPeriod = 20
+    // DefaultSpike is the volume multiple of a volume spike
+    DefaultSpike = 2
+    // MaxPeriod bounds the bars a volume spike averages
+    MaxPeriod = 200
+)
+
+// Alert is a user-defined alert with its persisted state
+type Alert struct {
+    ID           int        `json:"id"`
+    Name         string     `json:"name,omitempty"`
+    Symbol       string     `json:"symbol"`
+    Type         Type       `json:"type"`
+    Timeframe    string     `json:"timeframe,omitempty"`
+    Threshold    float64    `json:"threshold,omitempty"`
+    Reference    float64    `json:"reference,omitempty"`
+    Expression   string     `json:"expression,omitempty"`
+    Period       int        `json:"period,omitempty"`
+    Repeat       bool       `json:"repeat"`
+    Channels     []Channel  `json:"channels"`
+    Status       Status     `json:"status"`
+    ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+    TriggeredAt  *time.Time `json:"triggered_at,omitempty"`
+    TriggerCount int        `json:"trigger_count"`
+    LastValue    *float64   `json:"last_value,omitempty"`
+    CreatedAt    time.Time  `json:"created_at"`
+    UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// Validate checks the definition of an alert, applying defaults
+func (a *Alert) Validate() error {
+    if a.Symbol == "" {
+        return fmt.Errorf("symbol is required")
+    }
+    if len(a.Name) > 100 {
+        return fmt.Errorf("name must be at most 100 characters")
+    }
+    // The name is the subject of notification emails
+    if strings.IndexFunc(a.Name, unicode.IsControl) >= 0 {
+        return fmt.Errorf("name must not contain control characters")
+    }
+
+    switch a.Type {
+    case PriceAbove, PriceBelow:
+        if a.Threshold <= 0 {
+            return fmt.Errorf("threshold must be a positive price")
+        }
+    case PercentMove:
+        if a.Threshold <= 0 {
+            return fmt.Errorf("threshold must be a positive percentage")
+        }
+        if a.Reference < 0 {
+            return fmt.Errorf("reference must not be negative")
+        }
+    case Condition:
+        if _, err := expr.Parse(a.Expression); err != nil {
+            return fmt.Errorf("invalid expression %q: %w", a.Expression, err)
+        }
+    case VolumeSpike:
+        if a.Threshold == 0 {
+            a.Threshold = DefaultSpike
+        }
+        if a.Threshold <= 0 {
+            return fmt.Errorf("threshold must be a positive volume multiple")
+        }
+        if a.Period == 0 {
+            a.Period = DefaultPeriod
+        }
+        if a.Period < 1 || a.Period > MaxPeriod {
+            return fmt.Errorf("period must be between 1 and %d", MaxPeriod)
+        }
+    default:
+        return fmt.Errorf("unknown alert type %q", a.Type)
+    }
+
+    if a.Type.OnBars() {
+        if a.Timeframe == "" {
+            a.Timeframe = "1d"
+        }
+        if _, err := calendar.TimeframeDuration(a.Timeframe); err != nil {
+            return err
+        }
+    } else {
+        a.Timeframe = ""
+    }
+
+    if len(a.Channels) == 0 {
+        a.Channels = []Channel{{Type: WebSocket}}
+    }
+    for i := range a.Channels {
+        if err := a.Channels[i].validate(); err != nil {
+            return err
+        }
+    }
+
+    if a.Status == "" {
+        a.Status = Armed
+    }
+    return nil
+}
+
+func (c *Channel) validate() error {
+    c.Target = strings.TrimSpace(c.Target)
+    switch c.Type {
+    case WebSocket:
+        c.Target = ""
+    case Webhook:
+        u, err := url.Parse(c.Target)
+        if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+            return fmt.Errorf("invalid webhook URL %q", c.Target)
+        }
+    case Email:
+        // Only the bare address is used, as the SMTP recipient and in the
+        // To header
+        addr, err := mail.ParseAddress(c.Target)
+        if err != nil {
+            return fmt.Errorf("invalid email address %q", c.Target)
+        }
+        c.Target = addr.Address
+    case Telegram:
+        if c.Target == "" {
+            return fmt.Errorf("telegram channel requires a chat ID")
+        }
+    default:
+        return fmt.Errorf("unknown channel type %q", c.Type)
+    }
+    return nil
+}
+
+// Expired reports whether the alert is past its expiry at t
+func (a *Alert) Expired(t time.Time) bool {
+    return a.ExpiresAt != nil && !t.Before(*a.ExpiresAt)
+}
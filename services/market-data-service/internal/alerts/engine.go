@@ -0,0 +1,314 @@
+package alerts
+
+import (
+    "fmt"
+    "log"
+    "math"
+    "sort"
+    "sync"
+    "time"
+
+    "github.com/algo-trading/market-data-service/internal/models"
+    "github.com/algo-trading/market-data-service/pkg/expr"
+)
+
+// DefaultHistory is the number of bars per series condition alerts are
+// evaluated over
+const DefaultHistory = 300
+
+// Loader returns up to limit of the latest stored bars of a series, oldest
+// first
+type Loader func(symbol, timeframe string, limit int) ([]models.OHLCV, error)
+
+// Event is a status change of an alert: Triggered when it fires, Armed when
+// a repeating alert's condition has cleared. Alert is the alert after the
+// change.
+type Event struct {
+    Alert   Alert     `json:"alert"`
+    Status  Status    `json:"status"`
+    Time    time.Time `json:"time"`
+    Value   float64   `json:"value"`
+    Message string    `json:"message"`
+}
+
+// watch is the evaluation state of one alert
+type watch struct {
+    alert     Alert
+    expr      *expr.Expr
+    armed     bool
+    last      float64
+    seen      bool
+}
+
+// Engine evaluates the loaded alerts on ticks and completed bars, keeping the
+// recent bars of the series bar alerts watch. Series are locked
+// independently, so seeding or evaluating one does not hold up ticks or
+// other series.
+type Engine struct {
+    history  int
+    load     Loader
+    watches  map[int]*watch
+    bySymbol map[string][]*watch
+    series   map[string]*barSeries
+    mu       sync.Mutex
+}
+
+// barSeries holds the recent bars of a symbol and timeframe
+type barSeries struct {
+    bars   []models.OHLCV
+    seeded bool
+    mu     sync.Mutex
+}
+
+// NewEngine creates an engine. load, if not nil, seeds a series from storage
+// on its first bar so conditions can be evaluated straight away.
+func NewEngine(history int, load Loader) *Engine {
+    if history <= 0 {
+        history = DefaultHistory
+    }
+    return &Engine{
+        history:  history,
+        load:     load,
+        watches:  make(map[int]*watch),
+        bySymbol: make(map[string][]*watch),
+        series:   make(map[string]*barSeries),
+    }
+}
+
+// Load replaces the evaluated alerts with the armed ones and the triggered
+// ones that repeat. Alerts still loaded with an unchanged definition keep the
+// last price they saw, so a crossing spanning a reload is not missed.
+func (e *Engine) Load(alerts []Alert) {
+    e.mu.Lock()
+    defer e.mu.Unlock()
+
+    watches := make(map[int]*watch)
+    bySymbol := make(map[string][]*watch)
+    needed := make(map[string]bool)
+    for _, alert := range alerts {
+        if alert.Status != Armed && !(alert.Status == Triggered && alert.Repeat) {
+            continue
+        }
+
+        w := &watch{alert: alert, armed: alert.Status == Armed}
+        // A reference taken from a price seen here would be lost on the
+        // next reload, so alerts stored without one are not evaluated
+        if alert.Type == PercentMove && alert.Reference <= 0 {
+            log.Printf("Skipping alert %d: percent move without a reference", alert.ID)
+            continue
+        }
+        if alert.Type == Condition {
+            parsed, err := expr.Parse(alert.Expression)
+            if err != nil {
+                log.Printf("Skipping alert %d: invalid expression: %v", alert.ID, err)
+                continue
+            }
+            w.expr = parsed
+        }
+        if prev, exists := e.watches[alert.ID]; exists && sameDefinition(prev.alert, alert) {
+            w.last, w.seen = prev.last, prev.seen
+        }
+
+        watches[alert.ID] = w
+        bySymbol[alert.Symbol] = append(bySymbol[alert.Symbol], w)
+        if alert.Type.OnBars() {
+            needed[alert.Symbol+"|"+alert.Timeframe] = true
+        }
+    }
+
+    for _, ws := range bySymbol {
+        sort.Slice(ws, func(i, j int) bool { return ws[i].alert.ID < ws[j].alert.ID })
+    }
+    for key := range e.series {
+        if !needed[key] {
+            delete(e.series, key)
+        }
+    }
+    e.watches = watches
+    e.bySymbol = bySymbol
+}
+
+// sameDefinition reports whether two versions of an alert watch for the same
+// thing
+func sameDefinition(a, b Alert) bool {
+    return a.Symbol == b.Symbol && a.Type == b.Type && a.Timeframe == b.Timeframe &&
+        a.Threshold == b.Threshold && a.Reference == b.Reference &&
+        a.Expression == b.Expression && a.Period == b.Period
+}
+
+// OnTick evaluates the price alerts of the tick's symbol
+func (e *Engine) OnTick(tick *models.Tick) []Event {
+    e.mu.Lock()
+    defer e.mu.Unlock()
+
+    var events []Event
+    for _, w := range e.bySymbol[tick.Symbol] {
+        if w.alert.Type.OnBars() || w.alert.Expired(tick.Time) {
+            continue
+        }
+        if event, ok := w.onTick(tick); ok {
+            events = append(events, event)
+        }
+    }
+    return events
+}
+
+func (w *watch) onTick(tick *models.Tick) (Event, bool) {
+    price := tick.Price
+    prev, seen := w.last, w.seen
+    w.last, w.seen = price, true
+
+    level := w.alert.Threshold
+    switch w.alert.Type {
+    case PriceAbove:
+        return w.step(price >= level, seen && prev < level, tick.Time, price,
+            fmt.Sprintf("%s crossed above %.2f at %.2f", w.alert.Symbol, level, price))
+
+    case PriceBelow:
+        return w.step(price <= level, seen && prev > level, tick.Time, price,
+            fmt.Sprintf("%s crossed below %.2f at %.2f", w.alert.Symbol, level, price))
+
+    case PercentMove:
+        reference := w.alert.Reference
+        move := (price - reference) / reference * 100
+        return w.step(math.Abs(move) >= level, true, tick.Time, move,
+            fmt.Sprintf("%s moved %+.2f%% from %.2f to %.2f", w.alert.Symbol, move, reference, price))
+    }
+    return Event{}, false
+}
+
+// OnBar evaluates the bar alerts of the bar's symbol and timeframe. Bars not
+// after the last one of their series are ignored.
+func (e *Engine) OnBar(bar *models.OHLCV) []Event {
+    watches, s := e.barWatches(bar)
+    if len(watches) == 0 {
+        return nil
+    }
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    if !s.seeded {
+        s.seeded = true
+        if e.load != nil {
+            s.bars = e.seed(bar)
+        }
+    }
+    bars := s.bars
+    if n := len(bars); n > 0 && !bars[n-1].Time.Before(bar.Time) {
+        return nil
+    }
+    bars = append(bars, *bar)
+    if len(bars) > e.history {
+        bars = append(bars[:0:0], bars[len(bars)-e.history:]...)
+    }
+    s.bars = bars
+
+    var events []Event
+    for _, w := range watches {
+        if event, ok := w.onBar(bars); ok {
+            events = append(events, event)
+        }
+    }
+    return events
+}
+
+// barWatches returns the bar alerts the bar is evaluated for and their
+// series, creating it on first use
+func (e *Engine) barWatches(bar *models.OHLCV) ([]*watch, *barSeries) {
+    e.mu.Lock()
+    defer e.mu.Unlock()
+
+    var watches []*watch
+    for _, w := range e.bySymbol[bar.Symbol] {
+        if w.alert.Type.OnBars() && w.alert.Timeframe == bar.Timeframe && !w.alert.Expired(bar.Time) {
+            watches = append(watches, w)
+        }
+    }
+    if len(watches) == 0 {
+        return nil, nil
+    }
+
+    key := bar.Symbol + "|" + bar.Timeframe
+    s, exists := e.series[key]
+    if !exists {
+        s = &barSeries{}
+        e.series[key] = s
+    }
+    return watches, s
+}
+
+func (w *watch) onBar(bars []models.OHLCV) (Event, bool) {
+    n := len(bars)
+    bar := bars[n-1]
+
+    switch w.alert.Type {
+    case Condition:
+        // Conditions longer than the bars seen so far cannot hold yet
+        values, err := w.expr.Evaluate(bars)
+        if err != nil {
+            return Event{}, false
+        }
+        return w.step(expr.Truthy(values[n-1]), true, bar.Time, bar.Close,
+            fmt.Sprintf("%s %s: %s at %.2f", w.alert.Symbol, bar.Timeframe, w.alert.Expression, bar.Close))
+
+    case VolumeSpike:
+        period := w.alert.Period
+        if n < period+1 {
+            return Event{}, false
+        }
+        var sum float64
+        for _, b := range bars[n-1-period : n-1] {
+            sum += float64(b.Volume)
+        }
+        if sum <= 0 {
+            return Event{}, false
+        }
+        ratio := float64(bar.Volume) / (sum / float64(period))
+        return w.step(ratio >= w.alert.Threshold, true, bar.Time, float64(bar.Volume),
+            fmt.Sprintf("%s %s volume %d is %.1fx the %d bar average", w.alert.Symbol, bar.Timeframe, bar.Volume, ratio, period))
+    }
+    return Event{}, false
+}
+
+// step fires an armed alert whose condition is active, when crossing, and
+// arms a repeating alert again once its condition has cleared
+func (w *watch) step(active, crossing bool, t time.Time, value float64, message string) (Event, bool) {
+    switch {
+    case w.armed && active && crossing:
+        w.armed = false
+        w.alert.Status = Triggered
+        w.alert.TriggeredAt = &t
+        w.alert.TriggerCount++
+        w.alert.LastValue = &value
+
+    case !w.armed && !active && w.alert.Repeat:
+        w.armed = true
+        w.alert.Status = Armed
+
+    default:
+        return Event{}, false
+    }
+
+    if w.alert.Name != "" {
+        message = w.alert.Name + ": " + message
+    }
+    return Event{Alert: w.alert, Status: w.alert.Status, Time: t, Value: value, Message: message}, true
+}
+
+// seed loads the stored bars of a series that precede bar
+func (e *Engine) seed(bar *models.OHLCV) []models.OHLCV {
+    stored, err := e.load(bar.Symbol, bar.Timeframe, e.history)
+    if err != nil {
+        log.Printf("Failed to load %s history for %s: %v", bar.Timeframe, bar.Symbol, err)
+        return nil
+    }
+
+    var bars []models.OHLCV
+    for _, b := range stored {
+        if b.Time.Before(bar.Time) {
+            bars = append(bars, b)
+        }
+    }
+    return bars
+}
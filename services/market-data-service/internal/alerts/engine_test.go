@@ -0,0 +1,204 @@
+package alerts
+
+import (
+    "testing"
+    "time"
+
+    "github.com/algo-trading/market-data-service/internal/models"
+)
+
+var start = time.Date(2024, 3, 1, 9, 15, 0, 0, time.UTC)
+
+// feed sends ticks at the prices, a second apart, returning every event
+func feed(e *Engine, symbol string, prices ...float64) []Event {
+    var events []Event
+    for i, price := range prices {
+        events = append(events, e.OnTick(&models.Tick{Time: start.Add(time.Duration(i) * time.Second), Symbol: symbol, Price: price})...)
+    }
+    return events
+}
+
+func statuses(events []Event) []Status {
+    var out []Status
+    for _, event := range events {
+        out = append(out, event.Status)
+    }
+    return out
+}
+
+func TestPriceCrossFiresOnce(t *testing.T) {
+    e := NewEngine(0, nil)
+    e.Load([]Alert{{ID: 1, Symbol: "TCS", Type: PriceAbove, Threshold: 100, Status: Armed}})
+
+    // Already above on the first tick is not a crossing
+    events := feed(e, "TCS", 101, 99, 100.5, 98, 102)
+    if len(events) != 1 || events[0].Status != Triggered || events[0].Value != 100.5 {
+        t.Fatalf("Expected a single trigger at 100.5, got %+v", events)
+    }
+    if events[0].Alert.Status != Triggered || events[0].Alert.TriggerCount != 1 {
+        t.Errorf("Expected the event to carry the triggered alert, got %+v", events[0].Alert)
+    }
+}
+
+func TestRepeatingAlertRearms(t *testing.T) {
+    e := NewEngine(0, nil)
+    e.Load([]Alert{{ID: 1, Symbol: "TCS", Type: PriceBelow, Threshold: 100, Repeat: true, Status: Armed}})
+
+    events := feed(e, "TCS", 101, 99, 98, 101, 99)
+    got := statuses(events)
+    if len(got) != 3 || got[0] != Triggered || got[1] != Armed || got[2] != Triggered {
+        t.Fatalf("Expected triggered, armed, triggered, got %v", got)
+    }
+    if events[2].Alert.TriggerCount != 2 {
+        t.Errorf("Expected a trigger count of 2, got %d", events[2].Alert.TriggerCount)
+    }
+}
+
+func TestPercentMove(t *testing.T) {
+    e := NewEngine(0, nil)
+    e.Load([]Alert{
+        {ID: 1, Symbol: "INFY", Type: PercentMove, Threshold: 5, Reference: 200, Status: Armed},
+        {ID: 2, Symbol: "INFY", Type: PercentMove, Threshold: 5, Reference: 190, Status: Armed},
+        {ID: 3, Symbol: "INFY", Type: PercentMove, Threshold: 5, Status: Armed},
+    })
+
+    // Alert 3 has no reference to measure from, so it is never evaluated
+    events := feed(e, "INFY", 190, 195, 199.5)
+    if len(events) != 2 || events[0].Alert.ID != 1 || events[1].Alert.ID != 2 {
+        t.Fatalf("Expected alert 1 on the 5%% drop from 200 and alert 2 on the 5%% rise from 190, got %+v", events)
+    }
+    if events[0].Value != -5 {
+        t.Errorf("Expected a move of -5%%, got %v", events[0].Value)
+    }
+}
+
+func TestExpiredAlertIsSkipped(t *testing.T) {
+    expiry := start.Add(time.Second)
+    e := NewEngine(0, nil)
+    e.Load([]Alert{{ID: 1, Symbol: "TCS", Type: PriceAbove, Threshold: 100, Status: Armed, ExpiresAt: &expiry}})
+
+    if events := feed(e, "TCS", 99, 101); len(events) != 0 {
+        t.Errorf("Expected no events past expiry, got %+v", events)
+    }
+}
+
+func TestReloadKeepsLastPrice(t *testing.T) {
+    alert := Alert{ID: 1, Symbol: "TCS", Type: PriceAbove, Threshold: 100, Status: Armed}
+    e := NewEngine(0, nil)
+    e.Load([]Alert{alert})
+    feed(e, "TCS", 99)
+
+    e.Load([]Alert{alert, {ID: 2, Symbol: "TCS", Type: PriceAbove, Threshold: 50, Status: Triggered}})
+    events := e.OnTick(&models.Tick{Time: start.Add(time.Minute), Symbol: "TCS", Price: 101})
+    if len(events) != 1 || events[0].Alert.ID != 1 {
+        t.Errorf("Expected alert 1 to catch the crossing across the reload, got %+v", events)
+    }
+}
+
+func bars(volumes ...int64) []models.OHLCV {
+    out := make([]models.OHLCV, len(volumes))
+    for i, volume := range volumes {
+        c := 100 + float64(i)
+        out[i] = models.OHLCV{Time: start.AddDate(0, 0, i), Symbol: "TCS", Timeframe: "1d", Open: c, High: c + 1, Low: c - 1, Close: c, Volume: volume}
+    }
+    return out
+}
+
+func TestBarAlerts(t *testing.T) {
+    history := bars(100, 100, 100, 100, 350, 100)
+    e := NewEngine(0, func(symbol, timeframe string, limit int) ([]models.OHLCV, error) {
+        return history[:3], nil
+    })
+    e.Load([]Alert{
+        {ID: 1, Symbol: "TCS", Type: VolumeSpike, Timeframe: "1d", Threshold: 3, Period: 3, Status: Armed},
+        {ID: 2, Symbol: "TCS", Type: Condition, Timeframe: "1d", Expression: "close > 104", Status: Armed},
+        {ID: 3, Symbol: "TCS", Type: Condition, Timeframe: "5m", Expression: "close > 0", Status: Armed},
+    })
+
+    var events []Event
+    for i := 3; i < len(history); i++ {
+        events = append(events, e.OnBar(&history[i])...)
+    }
+    // A replayed bar is not evaluated again
+    events = append(events, e.OnBar(&history[len(history)-1])...)
+
+    if len(events) != 2 {
+        t.Fatalf("Expected the volume spike and the condition to fire, got %+v", events)
+    }
+    if events[0].Alert.ID != 1 || events[0].Time != history[4].Time || events[0].Value != 350 {
+        t.Errorf("Expected the volume spike on bar 4, got %+v", events[0])
+    }
+    if events[1].Alert.ID != 2 || events[1].Time != history[5].Time {
+        t.Errorf("Expected the condition on bar 5, got %+v", events[1])
+    }
+}
+
+func TestSeedingDoesNotBlockTicks(t *testing.T) {
+    release := make(chan struct{})
+    e := NewEngine(0, func(symbol, timeframe string, limit int) ([]models.OHLCV, error) {
+        <-release
+        return nil, nil
+    })
+    e.Load([]Alert{
+        {ID: 1, Symbol: "TCS", Type: Condition, Timeframe: "1d", Expression: "close > 0", Status: Armed},
+        {ID: 2, Symbol: "TCS", Type: PriceAbove, Threshold: 100, Status: Armed},
+    })
+
+    seeded := make(chan struct{})
+    go func() {
+        defer close(seeded)
+        e.OnBar(&bars(100)[0])
+    }()
+
+    // Ticks are evaluated while the bar series waits on storage
+    done := make(chan struct{})
+    go func() {
+        defer close(done)
+        feed(e, "TCS", 99, 101)
+    }()
+    select {
+    case <-done:
+    case <-time.After(2 * time.Second):
+        t.Fatal("Expected ticks to be evaluated while a series is seeding")
+    }
+
+    close(release)
+    <-seeded
+}
+
+func TestValidate(t *testing.T) {
+    spike := Alert{Symbol: "TCS", Type: VolumeSpike}
+    if err := spike.Validate(); err != nil {
+        t.Fatalf("Expected a valid volume spike alert: %v", err)
+    }
+    if spike.Threshold != DefaultSpike || spike.Period != DefaultPeriod || spike.Timeframe != "1d" ||
+        spike.Status != Armed || len(spike.Channels) != 1 || spike.Channels[0].Type != WebSocket {
+        t.Errorf("Expected defaults to be applied, got %+v", spike)
+    }
+
+    named := Alert{Symbol: "TCS", Type: PriceAbove, Threshold: 1, Channels: []Channel{{Type: Email, Target: "Ops <ops@example.com>"}}}
+    if err := named.Validate(); err != nil {
+        t.Fatalf("Expected a display-name address to be valid: %v", err)
+    }
+    if named.Channels[0].Target != "ops@example.com" {
+        t.Errorf("Expected the bare address to be kept, got %q", named.Channels[0].Target)
+    }
+
+    for _, alert := range []Alert{
+        {Type: PriceAbove, Threshold: 100},
+        {Symbol: "TCS", Type: PriceAbove},
+        {Name: "TCS\r\nBcc: victim@example.com", Symbol: "TCS", Type: PriceAbove, Threshold: 100},
+        {Symbol: "TCS", Type: "price_sideways", Threshold: 1},
+        {Symbol: "TCS", Type: Condition, Expression: "close >"},
+        {Symbol: "TCS", Type: Condition, Expression: "close > 1", Timeframe: "1x"},
+        {Symbol: "TCS", Type: VolumeSpike, Period: MaxPeriod + 1},
+        {Symbol: "TCS", Type: PriceAbove, Threshold: 1, Channels: []Channel{{Type: Webhook, Target: "ftp://example.com"}}},
+        {Symbol: "TCS", Type: PriceAbove, Threshold: 1, Channels: []Channel{{Type: Email, Target: "nobody"}}},
+        {Symbol: "TCS", Type: PriceAbove, Threshold: 1, Channels: []Channel{{Type: Telegram}}},
+        {Symbol: "TCS", Type: PriceAbove, Threshold: 1, Channels: []Channel{{Type: "pager", Target: "x"}}},
+    } {
+        if err := alert.Validate(); err == nil {
+            t.Errorf("Expected an error for %+v", alert)
+        }
+    }
+}
@@ -0,0 +1,355 @@
+package alerts
+
+import (
+    "bytes"
+    "context"
+    "crypto/tls"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "log"
+    "mime"
+    "net"
+    "net/http"
+    "net/netip"
+    "net/smtp"
+    "net/url"
+    "strings"
+    "sync"
+    "syscall"
+    "time"
+)
+
+// Notification is what is delivered when an alert triggers
+type Notification struct {
+    AlertID      int       `json:"alert_id"`
+    Name         string    `json:"name,omitempty"`
+    Symbol       string    `json:"symbol"`
+    Type         Type      `json:"type"`
+    Timeframe    string    `json:"timeframe,omitempty"`
+    Message      string    `json:"message"`
+    Value        float64   `json:"value"`
+    TriggerCount int       `json:"trigger_count"`
+    Time         time.Time `json:"time"`
+}
+
+// NewNotification describes a triggered event
+func NewNotification(event Event) Notification {
+    return Notification{
+        AlertID:      event.Alert.ID,
+        Name:         event.Alert.Name,
+        Symbol:       event.Alert.Symbol,
+        Type:         event.Alert.Type,
+        Timeframe:    event.Alert.Timeframe,
+        Message:      event.Message,
+        Value:        event.Value,
+        TriggerCount: event.Alert.TriggerCount,
+        Time:         event.Time,
+    }
+}
+
+// Notifier delivers a notification to a channel's target
+type Notifier interface {
+    Notify(ctx context.Context, target string, n *Notification) error
+}
+
+// NotifierFunc adapts a function to a Notifier
+type NotifierFunc func(ctx context.Context, target string, n *Notification) error
+
+func (f NotifierFunc) Notify(ctx context.Context, target string, n *Notification) error {
+    return f(ctx, target, n)
+}
+
+// WebhookNotifier posts notifications as JSON to the target URL
+type WebhookNotifier struct {
+    client *http.Client
+}
+
+// NewWebhookNotifier creates a webhook notifier. Unless allowPrivate is set,
+// webhooks resolving to loopback, private or link-local addresses are refused
+// so that alerts cannot be used to reach internal services.
+func NewWebhookNotifier(timeout time.Duration, allowPrivate bool) *WebhookNotifier {
+    dialer := &net.Dialer{Timeout: timeout}
+    if !allowPrivate {
+        dialer.Control = rejectPrivate
+    }
+    transport := &http.Transport{
+        DialContext:         dialer.DialContext,
+        TLSHandshakeTimeout: timeout,
+        MaxIdleConns:        10,
+        IdleConnTimeout:     90 * time.Second,
+    }
+    return &WebhookNotifier{client: &http.Client{Timeout: timeout, Transport: transport}}
+}
+
+// rejectPrivate refuses connections to addresses that are not publicly
+// routable. It runs on the resolved address, so a public name pointing at an
+// internal host is refused as well.
+func rejectPrivate(network, address string, _ syscall.RawConn) error {
+    addrPort, err := netip.ParseAddrPort(address)
+    if err != nil {
+        return fmt.Errorf("invalid webhook address %s: %w", address, err)
+    }
+    ip := addrPort.Addr().Unmap()
+    if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+        ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+        return fmt.Errorf("webhook address %s is not public", ip)
+    }
+    return nil
+}
+
+func (w *WebhookNotifier) Notify(ctx context.Context, target string, n *Notification) error {
+    body, err := json.Marshal(n)
+    if err != nil {
+        return fmt.Errorf("failed to marshal notification: %w", err)
+    }
+    return post(ctx, w.client, target, body)
+}
+
+// DefaultTelegramURL is the Telegram Bot API
+const DefaultTelegramURL = "https://api.telegram.org"
+
+// TelegramNotifier sends notifications as bot messages to the target chat
+type TelegramNotifier struct {
+    client  *http.Client
+    baseURL string
+    token   string
+}
+
+// NewTelegramNotifier creates a notifier for the bot with the token. baseURL
+// is DefaultTelegramURL unless pointed at a local stub.
+func NewTelegramNotifier(baseURL, token string, timeout time.Duration) *TelegramNotifier {
+    return &TelegramNotifier{
+        client:  &http.Client{Timeout: timeout},
+        baseURL: strings.TrimRight(baseURL, "/"),
+        token:   token,
+    }
+}
+
+func (t *TelegramNotifier) Notify(ctx context.Context, target string, n *Notification) error {
+    body, err := json.Marshal(map[string]string{"chat_id": target, "text": n.Message})
+    if err != nil {
+        return fmt.Errorf("failed to marshal message: %w", err)
+    }
+    return post(ctx, t.client, t.baseURL+"/bot"+t.token+"/sendMessage", body)
+}
+
+// post sends a JSON body, treating any status but 2xx as a failure
+func post(ctx context.Context, client *http.Client, target string, body []byte) error {
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+    if err != nil {
+        return fmt.Errorf("failed to create request: %w", withoutURL(err))
+    }
+    req.Header.Set("Content-Type", "application/json")
+
+    resp, err := client.Do(req)
+    if err != nil {
+        return fmt.Errorf("failed to send request: %w", withoutURL(err))
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+        detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+        return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(detail))
+    }
+    return nil
+}
+
+// withoutURL drops the request URL from an HTTP client error, since it may
+// carry a secret such as the Telegram bot token and the error gets logged
+func withoutURL(err error) error {
+    var urlErr *url.Error
+    if errors.As(err, &urlErr) {
+        return urlErr.Err
+    }
+    return err
+}
+
+// EmailNotifier sends notifications as plain text mail through an SMTP server
+type EmailNotifier struct {
+    host    string
+    addr    string
+    from    string
+    auth    smtp.Auth
+    timeout time.Duration
+}
+
+// NewEmailNotifier creates a notifier sending from the address through the
+// server at host:port, authenticating if username is set. Each message must
+// be handed over to the server within timeout.
+func NewEmailNotifier(host, port, username, password, from string, timeout time.Duration) *EmailNotifier {
+    notifier := &EmailNotifier{host: host, addr: net.JoinHostPort(host, port), from: from, timeout: timeout}
+    if username != "" {
+        notifier.auth = smtp.PlainAuth("", username, password, host)
+    }
+    return notifier
+}
+
+func (e *EmailNotifier) Notify(ctx context.Context, target string, n *Notification) error {
+    subject := "Alert: " + n.Symbol
+    if n.Name != "" {
+        subject = "Alert: " + n.Name
+    }
+
+    var msg bytes.Buffer
+    fmt.Fprintf(&msg, "From: %s\r\nTo: %s\r\nSubject: %s\r\n", e.from, target, mime.QEncoding.Encode("utf-8", subject))
+    fmt.Fprintf(&msg, "Date: %s\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n", n.Time.Format(time.RFC1123Z))
+    fmt.Fprintf(&msg, "%s\r\n\r\nValue: %g\r\nTime: %s\r\n", n.Message, n.Value, n.Time.Format(time.RFC3339))
+
+    if err := e.send(ctx, target, msg.Bytes()); err != nil {
+        return fmt.Errorf("failed to send email: %w", err)
+    }
+    return nil
+}
+
+// send delivers a message like smtp.SendMail, but gives up when the timeout
+// elapses or ctx is cancelled so that a stalled server cannot hold a worker
+func (e *EmailNotifier) send(ctx context.Context, to string, msg []byte) error {
+    dialer := net.Dialer{Timeout: e.timeout}
+    conn, err := dialer.DialContext(ctx, "tcp", e.addr)
+    if err != nil {
+        return err
+    }
+    defer conn.Close()
+    if err := conn.SetDeadline(time.Now().Add(e.timeout)); err != nil {
+        return err
+    }
+    stop := context.AfterFunc(ctx, func() { conn.Close() })
+    defer stop()
+
+    client, err := smtp.NewClient(conn, e.host)
+    if err != nil {
+        return err
+    }
+    defer client.Close()
+
+    if ok, _ := client.Extension("STARTTLS"); ok {
+        if err := client.StartTLS(&tls.Config{ServerName: e.host}); err != nil {
+            return err
+        }
+    }
+    if e.auth != nil {
+        if ok, _ := client.Extension("AUTH"); !ok {
+            return errors.New("server does not support authentication")
+        }
+        if err := client.Auth(e.auth); err != nil {
+            return err
+        }
+    }
+
+    if err := client.Mail(e.from); err != nil {
+        return err
+    }
+    if err := client.Rcpt(to); err != nil {
+        return err
+    }
+    w, err := client.Data()
+    if err != nil {
+        return err
+    }
+    if _, err := w.Write(msg); err != nil {
+        return err
+    }
+    if err := w.Close(); err != nil {
+        return err
+    }
+    return client.Quit()
+}
+
+// deliveryAttempts is how often a failing delivery is tried before it is
+// dropped
+const deliveryAttempts = 3
+
+type delivery struct {
+    channel      Channel
+    notification Notification
+}
+
+// Dispatcher delivers the notifications of triggered alerts to their
+// channels in the background, so slow endpoints do not hold up the streams
+type Dispatcher struct {
+    notifiers map[ChannelType]Notifier
+    queue     chan delivery
+    backoff   time.Duration
+}
+
+func NewDispatcher(queueSize int) *Dispatcher {
+    return &Dispatcher{
+        notifiers: make(map[ChannelType]Notifier),
+        queue:     make(chan delivery, queueSize),
+        backoff:   time.Second,
+    }
+}
+
+// Register sets the notifier of a channel type. Register every notifier
+// before calling Run.
+func (d *Dispatcher) Register(channel ChannelType, notifier Notifier) {
+    d.notifiers[channel] = notifier
+}
+
+// Supports reports whether notifications can be delivered to the channel type
+func (d *Dispatcher) Supports(channel ChannelType) bool {
+    return d.notifiers[channel] != nil
+}
+
+// Dispatch queues the notification of a triggered event for each of the
+// alert's channels. Notifications are dropped when the queue is full.
+func (d *Dispatcher) Dispatch(event Event) {
+    n := NewNotification(event)
+    for _, channel := range event.Alert.Channels {
+        if !d.Supports(channel.Type) {
+            log.Printf("Dropping %s notification of alert %d: channel not configured", channel.Type, n.AlertID)
+            continue
+        }
+
+        select {
+        case d.queue <- delivery{channel: channel, notification: n}:
+        default:
+            log.Printf("Dropping %s notification of alert %d: queue full", channel.Type, n.AlertID)
+        }
+    }
+}
+
+// Run delivers queued notifications with the given number of workers until
+// ctx is cancelled
+func (d *Dispatcher) Run(ctx context.Context, workers int) {
+    var wg sync.WaitGroup
+    for i := 0; i < max(workers, 1); i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for {
+                select {
+                case <-ctx.Done():
+                    return
+                case dl := <-d.queue:
+                    d.deliver(ctx, dl)
+                }
+            }
+        }()
+    }
+    wg.Wait()
+}
+
+// deliver tries a delivery until it succeeds, backing off between attempts
+func (d *Dispatcher) deliver(ctx context.Context, dl delivery) {
+    backoff := d.backoff
+    for attempt := 1; ; attempt++ {
+        err := d.notifiers[dl.channel.Type].Notify(ctx, dl.channel.Target, &dl.notification)
+        if err == nil {
+            return
+        }
+        if attempt == deliveryAttempts {
+            log.Printf("Failed to deliver %s notification of alert %d: %v", dl.channel.Type, dl.notification.AlertID, err)
+            return
+        }
+
+        select {
+        case <-ctx.Done():
+            return
+        case <-time.After(backoff):
+            backoff *= 2
+        }
+    }
+}
@@ -0,0 +1,293 @@
+package alerts
+
+import (
+    "bufio"
+    "context"
+    "encoding/json"
+    "errors"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+)
+
+var notification = Notification{AlertID: 7, Symbol: "TCS", Type: PriceAbove, Message: "TCS crossed above 100.00 at 100.50", Value: 100.5, Time: start}
+
+func TestWebhookNotifier(t *testing.T) {
+    var got Notification
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.Header.Get("Content-Type") != "application/json" {
+            t.Errorf("Expected a JSON body, got %s", r.Header.Get("Content-Type"))
+        }
+        json.NewDecoder(r.Body).Decode(&got)
+    }))
+    defer server.Close()
+
+    if err := NewWebhookNotifier(time.Second, true).Notify(context.Background(), server.URL, &notification); err != nil {
+        t.Fatalf("Failed to notify: %v", err)
+    }
+    if got.AlertID != 7 || got.Message != notification.Message || !got.Time.Equal(start) {
+        t.Errorf("Expected the notification to be posted, got %+v", got)
+    }
+}
+
+func TestWebhookNotifierRejectsErrorStatus(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        http.Error(w, "down for maintenance", http.StatusServiceUnavailable)
+    }))
+    defer server.Close()
+
+    err := NewWebhookNotifier(time.Second, true).Notify(context.Background(), server.URL, &notification)
+    if err == nil || !strings.Contains(err.Error(), "503") {
+        t.Errorf("Expected a 503 error, got %v", err)
+    }
+}
+
+func TestWebhookNotifierRejectsPrivateAddresses(t *testing.T) {
+    called := false
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        called = true
+    }))
+    defer server.Close()
+
+    err := NewWebhookNotifier(time.Second, false).Notify(context.Background(), server.URL, &notification)
+    if err == nil || !strings.Contains(err.Error(), "not public") || called {
+        t.Errorf("Expected the loopback webhook to be refused, got %v", err)
+    }
+}
+
+func TestRejectPrivate(t *testing.T) {
+    tests := []struct {
+        address string
+        allowed bool
+    }{
+        {"93.184.216.34:443", true},
+        {"[2606:2800:220:1::1]:443", true},
+        {"127.0.0.1:80", false},
+        {"10.1.2.3:80", false},
+        {"192.168.0.10:80", false},
+        {"169.254.169.254:80", false},
+        {"0.0.0.0:80", false},
+        {"[::1]:80", false},
+        {"[::ffff:127.0.0.1]:80", false},
+        {"[fe80::1]:80", false},
+        {"[fd00::1]:80", false},
+    }
+
+    for _, tt := range tests {
+        if err := rejectPrivate("tcp", tt.address, nil); (err == nil) != tt.allowed {
+            t.Errorf("Expected %s allowed=%v, got %v", tt.address, tt.allowed, err)
+        }
+    }
+}
+
+func TestTelegramNotifier(t *testing.T) {
+    var path string
+    var body map[string]string
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        path = r.URL.Path
+        json.NewDecoder(r.Body).Decode(&body)
+        w.Write([]byte(`{"ok":true}`))
+    }))
+    defer server.Close()
+
+    if err := NewTelegramNotifier(server.URL+"/", "123:abc", time.Second).Notify(context.Background(), "-1001", &notification); err != nil {
+        t.Fatalf("Failed to notify: %v", err)
+    }
+    if path != "/bot123:abc/sendMessage" || body["chat_id"] != "-1001" || body["text"] != notification.Message {
+        t.Errorf("Expected a sendMessage call to chat -1001, got %s %v", path, body)
+    }
+}
+
+func TestTelegramNotifierErrorOmitsToken(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+    server.Close()
+
+    err := NewTelegramNotifier(server.URL, "123:secret", time.Second).Notify(context.Background(), "-1001", &notification)
+    if err == nil {
+        t.Fatal("Expected an error from a closed server")
+    }
+    if strings.Contains(err.Error(), "secret") {
+        t.Errorf("Expected the bot token to be left out of the error, got %v", err)
+    }
+}
+
+// smtpStub accepts one message per connection, recording its envelope and data
+type smtpStub struct {
+    listener net.Listener
+    mu       sync.Mutex
+    from     string
+    to       []string
+    data     string
+}
+
+func newSMTPStub(t *testing.T) *smtpStub {
+    listener, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatalf("Failed to listen: %v", err)
+    }
+    stub := &smtpStub{listener: listener}
+    go func() {
+        for {
+            conn, err := listener.Accept()
+            if err != nil {
+                return
+            }
+            go stub.serve(conn)
+        }
+    }()
+    return stub
+}
+
+func (s *smtpStub) serve(conn net.Conn) {
+    defer conn.Close()
+    r := bufio.NewReader(conn)
+    reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+    reply("220 localhost ESMTP stub")
+    for {
+        line, err := r.ReadString('\n')
+        if err != nil {
+            return
+        }
+        line = strings.TrimSpace(line)
+        command := strings.ToUpper(strings.Fields(line + " ")[0])
+
+        s.mu.Lock()
+        switch command {
+        case "EHLO", "HELO":
+            reply("250 localhost")
+        case "MAIL":
+            s.from = line
+            reply("250 OK")
+        case "RCPT":
+            s.to = append(s.to, line)
+            reply("250 OK")
+        case "DATA":
+            reply("354 End data with <CR><LF>.<CR><LF>")
+            var data strings.Builder
+            for {
+                l, err := r.ReadString('\n')
+                if err != nil || l == ".\r\n" {
+                    break
+                }
+                data.WriteString(l)
+            }
+            s.data = data.String()
+            reply("250 OK")
+        case "QUIT":
+            reply("221 Bye")
+            s.mu.Unlock()
+            return
+        default:
+            reply("250 OK")
+        }
+        s.mu.Unlock()
+    }
+}
+
+func TestEmailNotifier(t *testing.T) {
+    stub := newSMTPStub(t)
+    defer stub.listener.Close()
+
+    host, port, _ := net.SplitHostPort(stub.listener.Addr().String())
+    notifier := NewEmailNotifier(host, port, "", "", "alerts@algo.local", time.Second)
+    if err := notifier.Notify(context.Background(), "trader@example.com", &notification); err != nil {
+        t.Fatalf("Failed to send email: %v", err)
+    }
+
+    stub.mu.Lock()
+    defer stub.mu.Unlock()
+    if !strings.Contains(stub.from, "alerts@algo.local") || len(stub.to) != 1 || !strings.Contains(stub.to[0], "trader@example.com") {
+        t.Errorf("Expected mail from alerts@algo.local to trader@example.com, got %q %q", stub.from, stub.to)
+    }
+    if !strings.Contains(stub.data, "Subject: Alert: TCS") || !strings.Contains(stub.data, notification.Message) {
+        t.Errorf("Expected the alert in the message, got %q", stub.data)
+    }
+}
+
+func TestEmailNotifierEncodesSubject(t *testing.T) {
+    stub := newSMTPStub(t)
+    defer stub.listener.Close()
+
+    host, port, _ := net.SplitHostPort(stub.listener.Addr().String())
+    named := notification
+    named.Name = "Nifty über 25000"
+    if err := NewEmailNotifier(host, port, "", "", "alerts@algo.local", time.Second).Notify(context.Background(), "trader@example.com", &named); err != nil {
+        t.Fatalf("Failed to send email: %v", err)
+    }
+
+    stub.mu.Lock()
+    defer stub.mu.Unlock()
+    if !strings.Contains(stub.data, "Subject: =?utf-8?q?Alert:_Nifty_=C3=BCber_25000?=") {
+        t.Errorf("Expected an encoded subject, got %q", stub.data)
+    }
+}
+
+func TestEmailNotifierTimesOut(t *testing.T) {
+    // A server that accepts connections but never greets
+    listener, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatalf("Failed to listen: %v", err)
+    }
+    defer listener.Close()
+    go func() {
+        for {
+            conn, err := listener.Accept()
+            if err != nil {
+                return
+            }
+            defer conn.Close()
+        }
+    }()
+
+    host, port, _ := net.SplitHostPort(listener.Addr().String())
+    begin := time.Now()
+    err = NewEmailNotifier(host, port, "", "", "alerts@algo.local", 100*time.Millisecond).Notify(context.Background(), "trader@example.com", &notification)
+    if err == nil {
+        t.Fatal("Expected a stalled server to fail the delivery")
+    }
+    if elapsed := time.Since(begin); elapsed > time.Second {
+        t.Errorf("Expected the delivery to give up after the timeout, took %v", elapsed)
+    }
+}
+
+func TestDispatcherRetriesAndSkipsUnconfiguredChannels(t *testing.T) {
+    delivered := make(chan string, 10)
+    failures := 1
+    d := NewDispatcher(10)
+    d.backoff = time.Millisecond
+    d.Register(Webhook, NotifierFunc(func(ctx context.Context, target string, n *Notification) error {
+        if failures > 0 {
+            failures--
+            return errors.New("connection refused")
+        }
+        delivered <- target + " " + n.Message
+        return nil
+    }))
+
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    go d.Run(ctx, 1)
+
+    d.Dispatch(Event{
+        Alert:   Alert{ID: 7, Symbol: "TCS", Channels: []Channel{{Type: Email, Target: "trader@example.com"}, {Type: Webhook, Target: "http://hooks.local/alert"}}},
+        Status:  Triggered,
+        Message: "TCS crossed above 100.00 at 100.50",
+    })
+
+    select {
+    case got := <-delivered:
+        if got != "http://hooks.local/alert TCS crossed above 100.00 at 100.50" {
+            t.Errorf("Unexpected delivery %q", got)
+        }
+    case <-time.After(time.Second):
+        t.Fatal("Expected the webhook to be delivered after a retry")
+    }
+    if d.Supports(Email) {
+        t.Error("Expected email not to be supported without a notifier")
+    }
+}
@@ -4,8 +4,13 @@ import (
     "context"
     "fmt"
     "log"
+    "math"
+    "sync"
     "time"
 
+    "github.com/algo-trading/market-data-service/internal/calendar"
+    "github.com/algo-trading/market-data-service/internal/depth"
+    "github.com/algo-trading/market-data-service/internal/derivatives"
     "github.com/algo-trading/market-data-service/internal/models"
 )
 
@@ -21,10 +26,30 @@ type MarketDataProvider interface {
     GetName() string
 }
 
+// DepthProvider is implemented by providers that stream market depth. levels
+// is the number of price levels requested per side, typically 5 or 20.
+type DepthProvider interface {
+    SubscribeToDepth(ctx context.Context, symbols []string, levels int, callback func(*depth.Depth)) error
+    UnsubscribeFromDepth(ctx context.Context, symbols []string) error
+}
+
+// DerivativesProvider is implemented by providers with F&O market data
+type DerivativesProvider interface {
+    GetInstruments(ctx context.Context, underlying string) ([]derivatives.Instrument, error)
+    GetOptionChain(ctx context.Context, underlying string, expiry time.Time) (*derivatives.Chain, error)
+}
+
+// Throttle limits the rate of outbound calls to a provider. Wait blocks
+// until a call is allowed or ctx is done.
+type Throttle interface {
+    Wait(ctx context.Context, key string) error
+}
+
 // APIManager manages multiple market data providers
 type APIManager struct {
     providers map[string]MarketDataProvider
     active    MarketDataProvider
+    throttle  Throttle
 }
 
 func NewAPIManager() *APIManager {
@@ -53,6 +78,22 @@ func (am *APIManager) GetActiveProvider() MarketDataProvider {
     return am.active
 }
 
+// SetThrottle rate limits quote and OHLCV requests per provider so that
+// broker limits are respected across all instances
+func (am *APIManager) SetThrottle(throttle Throttle) {
+    am.throttle = throttle
+}
+
+func (am *APIManager) wait(ctx context.Context, provider MarketDataProvider) error {
+    if am.throttle == nil {
+        return nil
+    }
+    if err := am.throttle.Wait(ctx, provider.GetName()); err != nil {
+        return fmt.Errorf("rate limit wait for provider %s failed: %w", provider.GetName(), err)
+    }
+    return nil
+}
+
 func (am *APIManager) ConnectAll(ctx context.Context) error {
     for name, provider := range am.providers {
         if err := provider.Connect(ctx); err != nil {
@@ -78,6 +119,9 @@ func (am *APIManager) DisconnectAll(ctx context.Context) error {
 // GetQuote gets quote from active provider with fallback
 func (am *APIManager) GetQuote(ctx context.Context, symbol string) (*models.Tick, error) {
     if am.active != nil && am.active.IsConnected() {
+        if err := am.wait(ctx, am.active); err != nil {
+            return nil, err
+        }
         return am.active.GetQuote(ctx, symbol)
     }
     
@@ -85,6 +129,9 @@ func (am *APIManager) GetQuote(ctx context.Context, symbol string) (*models.Tick
     for name, provider := range am.providers {
         if provider.IsConnected() {
             log.Printf("Using fallback provider %s for quote", name)
+            if err := am.wait(ctx, provider); err != nil {
+                return nil, err
+            }
             return provider.GetQuote(ctx, symbol)
         }
     }
@@ -95,6 +142,9 @@ func (am *APIManager) GetQuote(ctx context.Context, symbol string) (*models.Tick
 // GetOHLCV gets OHLCV data from active provider with fallback
 func (am *APIManager) GetOHLCV(ctx context.Context, symbol, timeframe string, from, to time.Time) ([]models.OHLCV, error) {
     if am.active != nil && am.active.IsConnected() {
+        if err := am.wait(ctx, am.active); err != nil {
+            return nil, err
+        }
         return am.active.GetOHLCV(ctx, symbol, timeframe, from, to)
     }
     
@@ -102,6 +152,9 @@ func (am *APIManager) GetOHLCV(ctx context.Context, symbol, timeframe string, fr
     for name, provider := range am.providers {
         if provider.IsConnected() {
             log.Printf("Using fallback provider %s for OHLCV", name)
+            if err := am.wait(ctx, provider); err != nil {
+                return nil, err
+            }
             return provider.GetOHLCV(ctx, symbol, timeframe, from, to)
         }
     }
@@ -109,10 +162,122 @@ func (am *APIManager) GetOHLCV(ctx context.Context, symbol, timeframe string, fr
     return nil, fmt.Errorf("no connected providers available")
 }
 
+// SubscribeToDepth subscribes to market depth on the active provider
+func (am *APIManager) SubscribeToDepth(ctx context.Context, symbols []string, levels int, callback func(*depth.Depth)) error {
+    if am.active == nil {
+        return fmt.Errorf("no active provider")
+    }
+    
+    provider, ok := am.active.(DepthProvider)
+    if !ok {
+        return fmt.Errorf("provider %s does not support market depth", am.active.GetName())
+    }
+    
+    return provider.SubscribeToDepth(ctx, symbols, levels, callback)
+}
+
+// UnsubscribeFromDepth stops market depth on the active provider
+func (am *APIManager) UnsubscribeFromDepth(ctx context.Context, symbols []string) error {
+    if am.active == nil {
+        return fmt.Errorf("no active provider")
+    }
+    
+    provider, ok := am.active.(DepthProvider)
+    if !ok {
+        return fmt.Errorf("provider %s does not support market depth", am.active.GetName())
+    }
+    
+    return provider.UnsubscribeFromDepth(ctx, symbols)
+}
+
+func (am *APIManager) derivativesProvider() (DerivativesProvider, error) {
+    if am.active == nil {
+        return nil, fmt.Errorf("no active provider")
+    }
+    
+    provider, ok := am.active.(DerivativesProvider)
+    if !ok {
+        return nil, fmt.Errorf("provider %s does not support derivatives", am.active.GetName())
+    }
+    return provider, nil
+}
+
+// GetInstruments lists the futures and options contracts of an underlying
+func (am *APIManager) GetInstruments(ctx context.Context, underlying string) ([]derivatives.Instrument, error) {
+    provider, err := am.derivativesProvider()
+    if err != nil {
+        return nil, err
+    }
+    if err := am.wait(ctx, am.active); err != nil {
+        return nil, err
+    }
+    return provider.GetInstruments(ctx, underlying)
+}
+
+// GetOptionChain gets the option chain of an underlying for an expiry
+func (am *APIManager) GetOptionChain(ctx context.Context, underlying string, expiry time.Time) (*derivatives.Chain, error) {
+    provider, err := am.derivativesProvider()
+    if err != nil {
+        return nil, err
+    }
+    if err := am.wait(ctx, am.active); err != nil {
+        return nil, err
+    }
+    return provider.GetOptionChain(ctx, underlying, expiry)
+}
+
 // Mock Provider for testing and development
 type MockProvider struct {
     name      string
     connected bool
+    ticks     mockSubscriptions
+    depth     mockSubscriptions
+}
+
+// mockSubscriptions records which subscription currently owns each symbol so
+// that unsubscribed or resubscribed symbols stop being generated by older
+// subscriptions
+type mockSubscriptions struct {
+    owner map[string]int
+    next  int
+    mu    sync.Mutex
+}
+
+func (ms *mockSubscriptions) add(symbols []string) int {
+    ms.mu.Lock()
+    defer ms.mu.Unlock()
+
+    if ms.owner == nil {
+        ms.owner = make(map[string]int)
+    }
+    ms.next++
+    for _, symbol := range symbols {
+        ms.owner[symbol] = ms.next
+    }
+    return ms.next
+}
+
+func (ms *mockSubscriptions) remove(symbols []string) {
+    ms.mu.Lock()
+    defer ms.mu.Unlock()
+
+    for _, symbol := range symbols {
+        delete(ms.owner, symbol)
+    }
+}
+
+// active returns the symbols still owned by the subscription
+func (ms *mockSubscriptions) active(id int, symbols []string) []string {
+    ms.mu.Lock()
+    defer ms.mu.Unlock()
+
+    var active []string
+    for _, symbol := range symbols {
+        if ms.owner[symbol] == id {
+            active = append(active, symbol)
+        }
+    }
+    return active
 }
 
 func NewMockProvider(name string) *MockProvider {
@@ -199,7 +364,8 @@ func (mp *MockProvider) SubscribeToTicks(ctx context.Context, symbols []string,
         return fmt.Errorf("provider not connected")
     }
     
-    // Start mock tick generation
+    // Start mock tick generation until all symbols are unsubscribed
+    id := mp.ticks.add(symbols)
     go func() {
         ticker := time.NewTicker(1 * time.Second)
         defer ticker.Stop()
@@ -209,7 +375,11 @@ func (mp *MockProvider) SubscribeToTicks(ctx context.Context, symbols []string,
             case <-ctx.Done():
                 return
             case <-ticker.C:
-                for _, symbol := range symbols {
+                active := mp.ticks.active(id, symbols)
+                if len(active) == 0 {
+                    return
+                }
+                for _, symbol := range active {
                     tick := &models.Tick{
                         Time:   time.Now(),
                         Symbol: symbol,
@@ -226,10 +396,152 @@ func (mp *MockProvider) SubscribeToTicks(ctx context.Context, symbols []string,
 }
 
 func (mp *MockProvider) UnsubscribeFromTicks(ctx context.Context, symbols []string) error {
+    mp.ticks.remove(symbols)
     log.Printf("Mock provider %s unsubscribed from ticks: %v", mp.name, symbols)
     return nil
 }
 
+func (mp *MockProvider) SubscribeToDepth(ctx context.Context, symbols []string, levels int, callback func(*depth.Depth)) error {
+    if !mp.connected {
+        return fmt.Errorf("provider not connected")
+    }
+    
+    // Start mock depth generation around the mock tick price
+    id := mp.depth.add(symbols)
+    go func() {
+        ticker := time.NewTicker(1 * time.Second)
+        defer ticker.Stop()
+        
+        for {
+            select {
+            case <-ctx.Done():
+                return
+            case <-ticker.C:
+                active := mp.depth.active(id, symbols)
+                if len(active) == 0 {
+                    return
+                }
+                mid := 1000.00 + float64(time.Now().Unix()%100)
+                for _, symbol := range active {
+                    snapshot := &depth.Depth{
+                        Time:   time.Now(),
+                        Symbol: symbol,
+                    }
+                    for i := 0; i < levels; i++ {
+                        offset := 0.05 * float64(i+1)
+                        quantity := int64(100 * (i + 1))
+                        snapshot.Bids = append(snapshot.Bids, depth.Level{Price: mid - offset, Quantity: quantity, Orders: i + 1})
+                        snapshot.Asks = append(snapshot.Asks, depth.Level{Price: mid + offset, Quantity: quantity, Orders: i + 1})
+                    }
+                    callback(snapshot)
+                }
+            }
+        }
+    }()
+    
+    return nil
+}
+
+func (mp *MockProvider) UnsubscribeFromDepth(ctx context.Context, symbols []string) error {
+    mp.depth.remove(symbols)
+    log.Printf("Mock provider %s unsubscribed from depth: %v", mp.name, symbols)
+    return nil
+}
+
+// mockSpot is the base price of the mock index underlyings
+var mockSpot = map[string]float64{
+    "NIFTY":     25000,
+    "BANKNIFTY": 56000,
+    "FINNIFTY":  26500,
+}
+
+// mockStrikes is the number of strikes listed on each side of the money
+const mockStrikes = 10
+
+func (mp *MockProvider) GetInstruments(ctx context.Context, underlying string) ([]derivatives.Instrument, error) {
+    if !mp.connected {
+        return nil, fmt.Errorf("provider not connected")
+    }
+    
+    spec, ok := derivatives.Underlyings[underlying]
+    if !ok {
+        return nil, fmt.Errorf("unknown underlying: %s", underlying)
+    }
+    
+    cal, err := calendar.NewCalendar(calendar.NSE)
+    if err != nil {
+        return nil, err
+    }
+    
+    now := time.Now()
+    var instruments []derivatives.Instrument
+    for _, expiry := range spec.MonthlyExpiries(cal, now, 3) {
+        instruments = append(instruments, derivatives.NewFuture(underlying, expiry, spec.LotSize))
+    }
+    
+    atm := spec.ATMStrike(mockSpot[underlying])
+    for _, expiry := range spec.Expiries(cal, now, 4) {
+        for i := -mockStrikes; i <= mockStrikes; i++ {
+            strike := atm + float64(i)*spec.StrikeStep
+            instruments = append(instruments,
+                derivatives.NewOption(underlying, derivatives.Call, expiry, strike, spec.LotSize),
+                derivatives.NewOption(underlying, derivatives.Put, expiry, strike, spec.LotSize))
+        }
+    }
+    
+    return instruments, nil
+}
+
+func (mp *MockProvider) GetOptionChain(ctx context.Context, underlying string, expiry time.Time) (*derivatives.Chain, error) {
+    if !mp.connected {
+        return nil, fmt.Errorf("provider not connected")
+    }
+    
+    spec, ok := derivatives.Underlyings[underlying]
+    if !ok {
+        return nil, fmt.Errorf("unknown underlying: %s", underlying)
+    }
+    
+    now := time.Now()
+    spot := mockSpot[underlying] + float64(now.Unix()%100)
+    atm := spec.ATMStrike(mockSpot[underlying])
+    years := math.Max(expiry.Sub(now).Hours()/24/365, 1.0/365)
+    
+    // Mock premiums: intrinsic value plus a time value decaying away from
+    // the money, with a volatility smile
+    var quotes []derivatives.OptionQuote
+    for i := -mockStrikes; i <= mockStrikes; i++ {
+        strike := atm + float64(i)*spec.StrikeStep
+        moneyness := math.Log(strike / spot)
+        iv := 0.13 + 2*moneyness*moneyness
+        timeValue := 0.4 * spot * iv * math.Sqrt(years) * math.Exp(-moneyness*moneyness/(2*iv*iv*years))
+        oi := int64(float64(spec.LotSize) * 2000 * math.Exp(-math.Abs(float64(i))/4))
+        
+        for _, optionType := range []derivatives.InstrumentType{derivatives.Call, derivatives.Put} {
+            intrinsic := math.Max(spot-strike, 0)
+            if optionType == derivatives.Put {
+                intrinsic = math.Max(strike-spot, 0)
+            }
+            price := math.Round((intrinsic+timeValue)*20) / 20
+            
+            quotes = append(quotes, derivatives.OptionQuote{
+                Symbol:       derivatives.FormatSymbol(underlying, optionType, expiry, strike),
+                Type:         optionType,
+                Strike:       strike,
+                Time:         now,
+                LastPrice:    price,
+                Bid:          price - 0.05,
+                Ask:          price + 0.05,
+                Volume:       oi / 4,
+                OpenInterest: oi,
+                IV:           iv * 100,
+            })
+        }
+    }
+    
+    return derivatives.BuildChain(underlying, expiry, spot, quotes), nil
+}
+
 func (mp *MockProvider) IsConnected() bool {
     return mp.connected
 }
@@ -304,6 +616,38 @@ func (aop *AngelOneProvider) UnsubscribeFromTicks(ctx context.Context, symbols [
     return nil
 }
 
+func (aop *AngelOneProvider) SubscribeToDepth(ctx context.Context, symbols []string, levels int, callback func(*depth.Depth)) error {
+    if !aop.connected {
+        return fmt.Errorf("Angel One provider not connected")
+    }
+    
+    // TODO: Implement Angel One SmartStream depth subscription (5 and 20 levels)
+    return nil
+}
+
+func (aop *AngelOneProvider) UnsubscribeFromDepth(ctx context.Context, symbols []string) error {
+    // TODO: Implement Angel One SmartStream depth unsubscription
+    return nil
+}
+
+func (aop *AngelOneProvider) GetInstruments(ctx context.Context, underlying string) ([]derivatives.Instrument, error) {
+    if !aop.connected {
+        return nil, fmt.Errorf("Angel One provider not connected")
+    }
+    
+    // TODO: Load contracts from the Angel One instrument master
+    return []derivatives.Instrument{}, nil
+}
+
+func (aop *AngelOneProvider) GetOptionChain(ctx context.Context, underlying string, expiry time.Time) (*derivatives.Chain, error) {
+    if !aop.connected {
+        return nil, fmt.Errorf("Angel One provider not connected")
+    }
+    
+    // TODO: Implement actual Angel One option chain call
+    return derivatives.BuildChain(underlying, expiry, 0, nil), nil
+}
+
 func (aop *AngelOneProvider) IsConnected() bool {
     return aop.connected
 }
@@ -0,0 +1,348 @@
+package calendar
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+)
+
+// IST is Indian Standard Time. India observes no daylight saving, so a fixed
+// zone avoids depending on tzdata being present in the container.
+var IST = time.FixedZone("IST", 5*3600+30*60)
+
+// Supported exchanges
+const (
+    NSE = "NSE"
+    BSE = "BSE"
+)
+
+// SessionType identifies a phase of the trading day
+type SessionType string
+
+const (
+    SessionPreOpen   SessionType = "pre_open"
+    SessionRegular   SessionType = "regular"
+    SessionClosing   SessionType = "closing"
+    SessionPostClose SessionType = "post_close"
+    SessionClosed    SessionType = "closed"
+)
+
+// SessionWindow is a session expressed as minutes since midnight IST
+type SessionWindow struct {
+    Type  SessionType `json:"type"`
+    Start int         `json:"start"`
+    End   int         `json:"end"`
+}
+
+// SpecialSession replaces the regular schedule for a single day, e.g. Muhurat trading
+type SpecialSession struct {
+    Name    string          `json:"name"`
+    Windows []SessionWindow `json:"windows"`
+}
+
+// MarketStatus describes the state of an exchange at a point in time
+type MarketStatus struct {
+    Exchange       string      `json:"exchange"`
+    Session        SessionType `json:"session"`
+    IsOpen         bool        `json:"is_open"`
+    IsTradingDay   bool        `json:"is_trading_day"`
+    Holiday        string      `json:"holiday,omitempty"`
+    SpecialSession string      `json:"special_session,omitempty"`
+    SessionStart   *time.Time  `json:"session_start,omitempty"`
+    SessionEnd     *time.Time  `json:"session_end,omitempty"`
+    NextOpen       time.Time   `json:"next_open"`
+    Timestamp      time.Time   `json:"timestamp"`
+}
+
+// Calendar knows the trading sessions and holidays of a single exchange
+type Calendar struct {
+    exchange string
+    schedule []SessionWindow
+    holidays map[string]string
+    special  map[string]SpecialSession
+    mu       sync.RWMutex
+}
+
+// DefaultSchedule returns the NSE/BSE equity cash market schedule
+func DefaultSchedule() []SessionWindow {
+    return []SessionWindow{
+        {Type: SessionPreOpen, Start: clock(9, 0), End: clock(9, 15)},
+        {Type: SessionRegular, Start: clock(9, 15), End: clock(15, 30)},
+        {Type: SessionClosing, Start: clock(15, 30), End: clock(15, 40)},
+        {Type: SessionPostClose, Start: clock(15, 40), End: clock(16, 0)},
+    }
+}
+
+// NewCalendar creates a calendar for the exchange preloaded with the default
+// schedule, published holidays and special sessions
+func NewCalendar(exchange string) (*Calendar, error) {
+    exchange = strings.ToUpper(exchange)
+    if exchange != NSE && exchange != BSE {
+        return nil, fmt.Errorf("unsupported exchange: %s", exchange)
+    }
+
+    c := &Calendar{
+        exchange: exchange,
+        schedule: DefaultSchedule(),
+        holidays: make(map[string]string),
+        special:  make(map[string]SpecialSession),
+    }
+
+    for date, name := range equityHolidays {
+        c.holidays[date] = name
+    }
+    for date, session := range specialSessions {
+        c.special[date] = session
+    }
+
+    return c, nil
+}
+
+// Exchange returns the exchange this calendar belongs to
+func (c *Calendar) Exchange() string {
+    return c.exchange
+}
+
+// AddHoliday marks the given date as a trading holiday
+func (c *Calendar) AddHoliday(date time.Time, name string) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    c.holidays[dateKey(date)] = name
+}
+
+// AddSpecialSession registers a one-off schedule for the given date. Special
+// sessions take precedence over weekends and holidays.
+func (c *Calendar) AddSpecialSession(date time.Time, session SpecialSession) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    c.special[dateKey(date)] = session
+}
+
+// Holiday returns the holiday name for the date, if any
+func (c *Calendar) Holiday(t time.Time) (string, bool) {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+
+    name, ok := c.holidays[dateKey(t)]
+    return name, ok
+}
+
+// IsTradingDay reports whether the exchange holds any session on the date
+func (c *Calendar) IsTradingDay(t time.Time) bool {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+
+    return len(c.windowsFor(t)) > 0
+}
+
+// IsOpen reports whether the regular (continuous trading) session is running
+func (c *Calendar) IsOpen(t time.Time) bool {
+    return c.SessionAt(t) == SessionRegular
+}
+
+// SessionAt returns the session in effect at the given time
+func (c *Calendar) SessionAt(t time.Time) SessionType {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+
+    if w := findWindow(c.windowsFor(t), minuteOfDay(t)); w != nil {
+        return w.Type
+    }
+    return SessionClosed
+}
+
+// Status computes the full market status at the given time
+func (c *Calendar) Status(t time.Time) *MarketStatus {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+
+    local := t.In(IST)
+    windows := c.windowsFor(local)
+
+    status := &MarketStatus{
+        Exchange:     c.exchange,
+        Session:      SessionClosed,
+        IsTradingDay: len(windows) > 0,
+        NextOpen:     c.nextOpen(local),
+        Timestamp:    local,
+    }
+
+    key := dateKey(local)
+    if name, ok := c.holidays[key]; ok {
+        status.Holiday = name
+    }
+    if special, ok := c.special[key]; ok {
+        status.SpecialSession = special.Name
+    }
+
+    if w := findWindow(windows, minuteOfDay(local)); w != nil {
+        start := atClock(local, w.Start)
+        end := atClock(local, w.End)
+        status.Session = w.Type
+        status.IsOpen = w.Type == SessionRegular
+        status.SessionStart = &start
+        status.SessionEnd = &end
+    }
+
+    return status
+}
+
+// NextOpen returns the start of the next regular session strictly after t
+func (c *Calendar) NextOpen(t time.Time) time.Time {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+
+    return c.nextOpen(t.In(IST))
+}
+
+// RegularSession returns the start and end of the regular session on the
+// date of t. ok is false when the exchange does not trade that day.
+func (c *Calendar) RegularSession(t time.Time) (start, end time.Time, ok bool) {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+
+    local := t.In(IST)
+    for _, w := range c.windowsFor(local) {
+        if w.Type == SessionRegular {
+            return atClock(local, w.Start), atClock(local, w.End), true
+        }
+    }
+    return time.Time{}, time.Time{}, false
+}
+
+// BarStart returns the open time of the bar containing t. Intraday bars are
+// anchored to the regular session open (09:15 IST) rather than the top of the
+// hour, and daily bars start at the session open.
+func (c *Calendar) BarStart(t time.Time, timeframe string) (time.Time, error) {
+    duration, err := TimeframeDuration(timeframe)
+    if err != nil {
+        return time.Time{}, err
+    }
+
+    open, end, ok := c.RegularSession(t)
+    if !ok {
+        return time.Time{}, fmt.Errorf("%s is not a trading day on %s", dateKey(t), c.exchange)
+    }
+    if t.Before(open) || !t.Before(end) {
+        return time.Time{}, fmt.Errorf("%s is outside the regular session", t.In(IST).Format(time.RFC3339))
+    }
+
+    if duration >= 24*time.Hour {
+        return open, nil
+    }
+
+    elapsed := t.Sub(open)
+    return open.Add(elapsed - elapsed%duration), nil
+}
+
+// BarEnd returns the close time of the bar starting at start. The last bar of
+// the day is truncated at the regular session close.
+func (c *Calendar) BarEnd(start time.Time, timeframe string) (time.Time, error) {
+    duration, err := TimeframeDuration(timeframe)
+    if err != nil {
+        return time.Time{}, err
+    }
+
+    _, sessionEnd, ok := c.RegularSession(start)
+    if !ok {
+        return time.Time{}, fmt.Errorf("%s is not a trading day on %s", dateKey(start), c.exchange)
+    }
+
+    end := start.Add(duration)
+    if end.After(sessionEnd) {
+        end = sessionEnd
+    }
+    return end, nil
+}
+
+// TimeframeDuration converts a timeframe such as 1m, 15m, 1h or 1d to a duration
+func TimeframeDuration(timeframe string) (time.Duration, error) {
+    if len(timeframe) < 2 {
+        return 0, fmt.Errorf("invalid timeframe: %q", timeframe)
+    }
+
+    n, err := strconv.Atoi(timeframe[:len(timeframe)-1])
+    if err != nil || n <= 0 {
+        return 0, fmt.Errorf("invalid timeframe: %q", timeframe)
+    }
+
+    switch timeframe[len(timeframe)-1] {
+    case 'm':
+        return time.Duration(n) * time.Minute, nil
+    case 'h':
+        return time.Duration(n) * time.Hour, nil
+    case 'd':
+        return time.Duration(n) * 24 * time.Hour, nil
+    default:
+        return 0, fmt.Errorf("invalid timeframe: %q", timeframe)
+    }
+}
+
+// windowsFor returns the sessions held on the date of t. Callers must hold mu.
+func (c *Calendar) windowsFor(t time.Time) []SessionWindow {
+    key := dateKey(t)
+
+    if special, ok := c.special[key]; ok {
+        return special.Windows
+    }
+    if _, ok := c.holidays[key]; ok {
+        return nil
+    }
+
+    switch t.In(IST).Weekday() {
+    case time.Saturday, time.Sunday:
+        return nil
+    }
+    return c.schedule
+}
+
+// nextOpen scans forward day by day for the next regular session. Callers must hold mu.
+func (c *Calendar) nextOpen(t time.Time) time.Time {
+    day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, IST)
+
+    // Exchange closures never exceed a couple of weeks, a year is a safe bound
+    for i := 0; i < 366; i++ {
+        for _, w := range c.windowsFor(day) {
+            if w.Type != SessionRegular {
+                continue
+            }
+            if open := atClock(day, w.Start); open.After(t) {
+                return open
+            }
+        }
+        day = day.AddDate(0, 0, 1)
+    }
+
+    return time.Time{}
+}
+
+func findWindow(windows []SessionWindow, minute int) *SessionWindow {
+    for i := range windows {
+        if minute >= windows[i].Start && minute < windows[i].End {
+            return &windows[i]
+        }
+    }
+    return nil
+}
+
+func clock(hour, minute int) int {
+    return hour*60 + minute
+}
+
+func minuteOfDay(t time.Time) int {
+    local := t.In(IST)
+    return local.Hour()*60 + local.Minute()
+}
+
+func atClock(t time.Time, minute int) time.Time {
+    local := t.In(IST)
+    return time.Date(local.Year(), local.Month(), local.Day(), minute/60, minute%60, 0, 0, IST)
+}
+
+func dateKey(t time.Time) string {
+    return t.In(IST).Format("2006-01-02")
+}
@@ -0,0 +1,140 @@
+package calendar
+
+import (
+    "testing"
+    "time"
+)
+
+func ist(year int, month time.Month, day, hour, minute int) time.Time {
+    return time.Date(year, month, day, hour, minute, 0, 0, IST)
+}
+
+func newTestCalendar(t *testing.T) *Calendar {
+    c, err := NewCalendar(NSE)
+    if err != nil {
+        t.Fatalf("Failed to create calendar: %v", err)
+    }
+    return c
+}
+
+func TestSessionAt(t *testing.T) {
+    c := newTestCalendar(t)
+
+    tests := []struct {
+        at       time.Time
+        expected SessionType
+    }{
+        {ist(2026, time.October, 16, 8, 59), SessionClosed},
+        {ist(2026, time.October, 16, 9, 0), SessionPreOpen},
+        {ist(2026, time.October, 16, 9, 15), SessionRegular},
+        {ist(2026, time.October, 16, 15, 29), SessionRegular},
+        {ist(2026, time.October, 16, 15, 30), SessionClosing},
+        {ist(2026, time.October, 16, 15, 45), SessionPostClose},
+        {ist(2026, time.October, 16, 16, 0), SessionClosed},
+        {ist(2026, time.October, 17, 11, 0), SessionClosed}, // Saturday
+        {ist(2026, time.October, 20, 11, 0), SessionClosed}, // Dussehra
+    }
+
+    for _, tt := range tests {
+        if got := c.SessionAt(tt.at); got != tt.expected {
+            t.Errorf("SessionAt(%s) = %s, expected %s", tt.at, got, tt.expected)
+        }
+    }
+
+    // Times in other zones are converted to IST
+    utc := time.Date(2026, time.October, 16, 4, 0, 0, 0, time.UTC)
+    if !c.IsOpen(utc) {
+        t.Errorf("Expected market to be open at %s", utc)
+    }
+}
+
+func TestStatusHoliday(t *testing.T) {
+    c := newTestCalendar(t)
+
+    status := c.Status(ist(2026, time.October, 20, 10, 0))
+    if status.IsOpen || status.IsTradingDay {
+        t.Errorf("Expected market closed on holiday, got %+v", status)
+    }
+    if status.Holiday != "Dussehra" {
+        t.Errorf("Expected holiday Dussehra, got %q", status.Holiday)
+    }
+
+    expected := ist(2026, time.October, 21, 9, 15)
+    if !status.NextOpen.Equal(expected) {
+        t.Errorf("Expected next open %s, got %s", expected, status.NextOpen)
+    }
+}
+
+func TestSpecialSession(t *testing.T) {
+    c := newTestCalendar(t)
+
+    // Muhurat trading runs on the Diwali holiday
+    status := c.Status(ist(2025, time.October, 21, 14, 0))
+    if !status.IsOpen {
+        t.Errorf("Expected Muhurat session to be open, got %+v", status)
+    }
+    if status.SpecialSession == "" {
+        t.Errorf("Expected special session name to be set")
+    }
+    if c.IsOpen(ist(2025, time.October, 21, 10, 0)) {
+        t.Errorf("Expected market closed outside the Muhurat session")
+    }
+
+    sunday := ist(2026, time.November, 8, 0, 0)
+    c.AddSpecialSession(sunday, SpecialSession{
+        Name:    "Muhurat Trading",
+        Windows: []SessionWindow{{Type: SessionRegular, Start: clock(18, 0), End: clock(19, 0)}},
+    })
+    if !c.IsOpen(ist(2026, time.November, 8, 18, 30)) {
+        t.Errorf("Expected registered special session to be open")
+    }
+}
+
+func TestAddHoliday(t *testing.T) {
+    c := newTestCalendar(t)
+    day := ist(2026, time.October, 16, 11, 0)
+
+    c.AddHoliday(day, "Unscheduled closure")
+    if c.IsTradingDay(day) {
+        t.Errorf("Expected %s to be a holiday", day)
+    }
+}
+
+func TestBarBoundaries(t *testing.T) {
+    c := newTestCalendar(t)
+
+    start, err := c.BarStart(ist(2026, time.October, 16, 10, 20), "1h")
+    if err != nil {
+        t.Fatalf("Failed to compute bar start: %v", err)
+    }
+    if expected := ist(2026, time.October, 16, 10, 15); !start.Equal(expected) {
+        t.Errorf("Expected 1h bar start %s, got %s", expected, start)
+    }
+
+    start, err = c.BarStart(ist(2026, time.October, 16, 15, 20), "1h")
+    if err != nil {
+        t.Fatalf("Failed to compute bar start: %v", err)
+    }
+    end, err := c.BarEnd(start, "1h")
+    if err != nil {
+        t.Fatalf("Failed to compute bar end: %v", err)
+    }
+    if expected := ist(2026, time.October, 16, 15, 30); !end.Equal(expected) {
+        t.Errorf("Expected last bar to end at session close %s, got %s", expected, end)
+    }
+
+    start, err = c.BarStart(ist(2026, time.October, 16, 12, 7), "5m")
+    if err != nil {
+        t.Fatalf("Failed to compute bar start: %v", err)
+    }
+    if expected := ist(2026, time.October, 16, 12, 5); !start.Equal(expected) {
+        t.Errorf("Expected 5m bar start %s, got %s", expected, start)
+    }
+
+    if _, err := c.BarStart(ist(2026, time.October, 16, 8, 0), "1m"); err == nil {
+        t.Errorf("Expected error for time outside the session, got nil")
+    }
+    if _, err := c.BarStart(ist(2026, time.October, 16, 10, 0), "1x"); err == nil {
+        t.Errorf("Expected error for invalid timeframe, got nil")
+    }
+}
@@ -0,0 +1,53 @@
+package calendar
+
+// equityHolidays lists trading holidays for the NSE/BSE equity segment as
+// published in the exchange circulars. Weekends are handled separately, so
+// holidays falling on a Saturday or Sunday are omitted.
+var equityHolidays = map[string]string{
+    // 2025
+    "2025-02-26": "Mahashivratri",
+    "2025-03-14": "Holi",
+    "2025-03-31": "Id-Ul-Fitr (Ramadan Eid)",
+    "2025-04-10": "Shri Mahavir Jayanti",
+    "2025-04-14": "Dr. Baba Saheb Ambedkar Jayanti",
+    "2025-04-18": "Good Friday",
+    "2025-05-01": "Maharashtra Day",
+    "2025-08-15": "Independence Day",
+    "2025-08-27": "Ganesh Chaturthi",
+    "2025-10-02": "Mahatma Gandhi Jayanti / Dussehra",
+    "2025-10-21": "Diwali Laxmi Pujan",
+    "2025-10-22": "Diwali Balipratipada",
+    "2025-11-05": "Prakash Gurpurb Sri Guru Nanak Dev",
+    "2025-12-25": "Christmas",
+
+    // 2026
+    "2026-01-26": "Republic Day",
+    "2026-03-03": "Holi",
+    "2026-03-26": "Shri Ram Navami",
+    "2026-03-31": "Shri Mahavir Jayanti",
+    "2026-04-03": "Good Friday",
+    "2026-04-14": "Dr. Baba Saheb Ambedkar Jayanti",
+    "2026-05-01": "Maharashtra Day",
+    "2026-05-28": "Bakri Id",
+    "2026-06-26": "Muharram",
+    "2026-09-14": "Ganesh Chaturthi",
+    "2026-10-02": "Mahatma Gandhi Jayanti",
+    "2026-10-20": "Dussehra",
+    "2026-11-10": "Diwali Balipratipada",
+    "2026-11-24": "Prakash Gurpurb Sri Guru Nanak Dev",
+    "2026-12-25": "Christmas",
+}
+
+// specialSessions lists one-off sessions such as Muhurat trading on Diwali.
+// Timings are announced by the exchanges a few weeks in advance; sessions not
+// yet listed here can be registered at runtime with AddSpecialSession.
+var specialSessions = map[string]SpecialSession{
+    "2025-10-21": {
+        Name: "Muhurat Trading",
+        Windows: []SessionWindow{
+            {Type: SessionPreOpen, Start: clock(13, 30), End: clock(13, 45)},
+            {Type: SessionRegular, Start: clock(13, 45), End: clock(14, 45)},
+            {Type: SessionClosing, Start: clock(14, 45), End: clock(14, 55)},
+        },
+    },
+}
@@ -0,0 +1,251 @@
+package depth
+
+import (
+    "fmt"
+    "sort"
+    "sync"
+    "time"
+)
+
+// Side of the order book
+type Side string
+
+const (
+    Bid Side = "bid"
+    Ask Side = "ask"
+)
+
+// Level is an aggregated price level of the order book
+type Level struct {
+    Price    float64 `json:"price"`
+    Quantity int64   `json:"quantity"`
+    Orders   int     `json:"orders"`
+}
+
+// Depth is a market depth snapshot. Bids are sorted by descending price and
+// asks by ascending price, so index 0 is the best level on each side.
+type Depth struct {
+    Time   time.Time `json:"time"`
+    Symbol string    `json:"symbol"`
+    Bids   []Level   `json:"bids"`
+    Asks   []Level   `json:"asks"`
+}
+
+// BestBid returns the best bid level
+func (d *Depth) BestBid() (Level, bool) {
+    if len(d.Bids) == 0 {
+        return Level{}, false
+    }
+    return d.Bids[0], true
+}
+
+// BestAsk returns the best ask level
+func (d *Depth) BestAsk() (Level, bool) {
+    if len(d.Asks) == 0 {
+        return Level{}, false
+    }
+    return d.Asks[0], true
+}
+
+// Spread returns the difference between the best ask and best bid
+func (d *Depth) Spread() (float64, bool) {
+    bid, okBid := d.BestBid()
+    ask, okAsk := d.BestAsk()
+    if !okBid || !okAsk {
+        return 0, false
+    }
+    return ask.Price - bid.Price, true
+}
+
+// Mid returns the midpoint of the best bid and best ask
+func (d *Depth) Mid() (float64, bool) {
+    bid, okBid := d.BestBid()
+    ask, okAsk := d.BestAsk()
+    if !okBid || !okAsk {
+        return 0, false
+    }
+    return (bid.Price + ask.Price) / 2, true
+}
+
+// Update is an incremental change to one price level. A zero quantity
+// removes the level.
+type Update struct {
+    Side     Side
+    Price    float64
+    Quantity int64
+    Orders   int
+}
+
+// OrderBook maintains the depth of a single symbol from snapshots and
+// incremental updates
+type OrderBook struct {
+    symbol  string
+    levels  int
+    updated time.Time
+    bids    []Level
+    asks    []Level
+    mu      sync.RWMutex
+}
+
+// NewOrderBook creates a book that exposes at most levels price levels per side
+func NewOrderBook(symbol string, levels int) *OrderBook {
+    return &OrderBook{
+        symbol: symbol,
+        levels: levels,
+    }
+}
+
+// Apply replaces the book with a full depth snapshot
+func (b *OrderBook) Apply(d *Depth) error {
+    if d.Symbol != b.symbol {
+        return fmt.Errorf("depth for %s applied to order book of %s", d.Symbol, b.symbol)
+    }
+
+    bids := append([]Level(nil), d.Bids...)
+    asks := append([]Level(nil), d.Asks...)
+    sort.Slice(bids, func(i, j int) bool { return bids[i].Price > bids[j].Price })
+    sort.Slice(asks, func(i, j int) bool { return asks[i].Price < asks[j].Price })
+
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    b.bids = removeEmpty(bids)
+    b.asks = removeEmpty(asks)
+    b.updated = d.Time
+    return nil
+}
+
+// ApplyUpdates applies incremental level changes received at t
+func (b *OrderBook) ApplyUpdates(t time.Time, updates []Update) error {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    for _, u := range updates {
+        switch u.Side {
+        case Bid:
+            b.bids = upsert(b.bids, u, func(x, y float64) bool { return x > y })
+        case Ask:
+            b.asks = upsert(b.asks, u, func(x, y float64) bool { return x < y })
+        default:
+            return fmt.Errorf("invalid order book side: %q", u.Side)
+        }
+    }
+    b.updated = t
+    return nil
+}
+
+// Snapshot returns a copy of the top levels of the book
+func (b *OrderBook) Snapshot() *Depth {
+    b.mu.RLock()
+    defer b.mu.RUnlock()
+
+    return &Depth{
+        Time:   b.updated,
+        Symbol: b.symbol,
+        Bids:   top(b.bids, b.levels),
+        Asks:   top(b.asks, b.levels),
+    }
+}
+
+// Updated returns the time of the last change to the book
+func (b *OrderBook) Updated() time.Time {
+    b.mu.RLock()
+    defer b.mu.RUnlock()
+
+    return b.updated
+}
+
+// upsert inserts, replaces or removes the level for u.Price keeping levels
+// ordered by better
+func upsert(levels []Level, u Update, better func(a, b float64) bool) []Level {
+    i := sort.Search(len(levels), func(i int) bool { return !better(levels[i].Price, u.Price) })
+    exists := i < len(levels) && levels[i].Price == u.Price
+
+    switch {
+    case u.Quantity <= 0 && exists:
+        return append(levels[:i], levels[i+1:]...)
+    case u.Quantity <= 0:
+        return levels
+    case exists:
+        levels[i] = Level{Price: u.Price, Quantity: u.Quantity, Orders: u.Orders}
+        return levels
+    }
+
+    levels = append(levels, Level{})
+    copy(levels[i+1:], levels[i:])
+    levels[i] = Level{Price: u.Price, Quantity: u.Quantity, Orders: u.Orders}
+    return levels
+}
+
+func removeEmpty(levels []Level) []Level {
+    kept := levels[:0]
+    for _, level := range levels {
+        if level.Quantity > 0 {
+            kept = append(kept, level)
+        }
+    }
+    return kept
+}
+
+func top(levels []Level, n int) []Level {
+    if n > 0 && len(levels) > n {
+        levels = levels[:n]
+    }
+    return append([]Level{}, levels...)
+}
+
+// Books holds the order books of all symbols
+type Books struct {
+    levels  int
+    books   map[string]*OrderBook
+    flushed map[string]time.Time
+    mu      sync.Mutex
+}
+
+func NewBooks(levels int) *Books {
+    return &Books{
+        levels:  levels,
+        books:   make(map[string]*OrderBook),
+        flushed: make(map[string]time.Time),
+    }
+}
+
+// Book returns the symbol's order book, creating it on first use
+func (bs *Books) Book(symbol string) *OrderBook {
+    bs.mu.Lock()
+    defer bs.mu.Unlock()
+
+    book, exists := bs.books[symbol]
+    if !exists {
+        book = NewOrderBook(symbol, bs.levels)
+        bs.books[symbol] = book
+    }
+    return book
+}
+
+// Get returns the symbol's order book if it exists
+func (bs *Books) Get(symbol string) (*OrderBook, bool) {
+    bs.mu.Lock()
+    defer bs.mu.Unlock()
+
+    book, exists := bs.books[symbol]
+    return book, exists
+}
+
+// Changed returns snapshots of the books updated since the previous call,
+// used to persist snapshots at a fixed interval instead of on every update
+func (bs *Books) Changed() []*Depth {
+    bs.mu.Lock()
+    defer bs.mu.Unlock()
+
+    var snapshots []*Depth
+    for symbol, book := range bs.books {
+        updated := book.Updated()
+        if !updated.After(bs.flushed[symbol]) {
+            continue
+        }
+        bs.flushed[symbol] = updated
+        snapshots = append(snapshots, book.Snapshot())
+    }
+    return snapshots
+}
@@ -0,0 +1,112 @@
+package depth
+
+import (
+    "testing"
+    "time"
+)
+
+func TestOrderBookApplySnapshot(t *testing.T) {
+    book := NewOrderBook("TCS", 2)
+    now := time.Now()
+
+    err := book.Apply(&Depth{
+        Time:   now,
+        Symbol: "TCS",
+        Bids:   []Level{{Price: 99, Quantity: 10}, {Price: 100, Quantity: 5}, {Price: 98, Quantity: 0}},
+        Asks:   []Level{{Price: 102, Quantity: 7}, {Price: 101, Quantity: 3}, {Price: 103, Quantity: 1}},
+    })
+    if err != nil {
+        t.Fatalf("Failed to apply snapshot: %v", err)
+    }
+
+    snapshot := book.Snapshot()
+    if len(snapshot.Bids) != 2 || snapshot.Bids[0].Price != 100 || snapshot.Bids[1].Price != 99 {
+        t.Errorf("Unexpected bids: %+v", snapshot.Bids)
+    }
+    if len(snapshot.Asks) != 2 || snapshot.Asks[0].Price != 101 || snapshot.Asks[1].Price != 102 {
+        t.Errorf("Unexpected asks: %+v", snapshot.Asks)
+    }
+
+    if spread, ok := snapshot.Spread(); !ok || spread != 1 {
+        t.Errorf("Expected spread 1, got %v", spread)
+    }
+    if mid, ok := snapshot.Mid(); !ok || mid != 100.5 {
+        t.Errorf("Expected mid 100.5, got %v", mid)
+    }
+
+    if err := book.Apply(&Depth{Symbol: "INFY"}); err == nil {
+        t.Errorf("Expected error applying depth of another symbol")
+    }
+}
+
+func TestOrderBookApplyUpdates(t *testing.T) {
+    book := NewOrderBook("TCS", 5)
+    start := time.Now()
+
+    book.Apply(&Depth{
+        Time:   start,
+        Symbol: "TCS",
+        Bids:   []Level{{Price: 100, Quantity: 5}, {Price: 98, Quantity: 10}},
+        Asks:   []Level{{Price: 101, Quantity: 3}},
+    })
+
+    err := book.ApplyUpdates(start.Add(time.Second), []Update{
+        {Side: Bid, Price: 99, Quantity: 4},
+        {Side: Bid, Price: 100, Quantity: 0},
+        {Side: Ask, Price: 101, Quantity: 8, Orders: 2},
+        {Side: Ask, Price: 100.5, Quantity: 1},
+        {Side: Ask, Price: 105, Quantity: 0},
+    })
+    if err != nil {
+        t.Fatalf("Failed to apply updates: %v", err)
+    }
+
+    snapshot := book.Snapshot()
+    wantBids := []Level{{Price: 99, Quantity: 4}, {Price: 98, Quantity: 10}}
+    wantAsks := []Level{{Price: 100.5, Quantity: 1}, {Price: 101, Quantity: 8, Orders: 2}}
+    if !equalLevels(snapshot.Bids, wantBids) {
+        t.Errorf("Expected bids %+v, got %+v", wantBids, snapshot.Bids)
+    }
+    if !equalLevels(snapshot.Asks, wantAsks) {
+        t.Errorf("Expected asks %+v, got %+v", wantAsks, snapshot.Asks)
+    }
+    if !snapshot.Time.Equal(start.Add(time.Second)) {
+        t.Errorf("Expected snapshot time to follow the update")
+    }
+
+    if err := book.ApplyUpdates(start, []Update{{Side: "mid", Price: 1, Quantity: 1}}); err == nil {
+        t.Errorf("Expected error for invalid side")
+    }
+}
+
+func TestBooksChanged(t *testing.T) {
+    books := NewBooks(5)
+    now := time.Now()
+
+    books.Book("TCS").Apply(&Depth{Time: now, Symbol: "TCS", Bids: []Level{{Price: 100, Quantity: 1}}})
+    books.Book("INFY")
+
+    if changed := books.Changed(); len(changed) != 1 || changed[0].Symbol != "TCS" {
+        t.Fatalf("Expected only TCS to have changed, got %+v", changed)
+    }
+    if changed := books.Changed(); len(changed) != 0 {
+        t.Errorf("Expected no changes after flush, got %d", len(changed))
+    }
+
+    books.Book("TCS").ApplyUpdates(now.Add(time.Second), []Update{{Side: Ask, Price: 101, Quantity: 1}})
+    if changed := books.Changed(); len(changed) != 1 {
+        t.Errorf("Expected TCS to have changed again, got %d", len(changed))
+    }
+}
+
+func equalLevels(a, b []Level) bool {
+    if len(a) != len(b) {
+        return false
+    }
+    for i := range a {
+        if a[i] != b[i] {
+            return false
+        }
+    }
+    return true
+}
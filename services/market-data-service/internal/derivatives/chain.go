@@ -0,0 +1,189 @@
+package derivatives
+
+import (
+    "math"
+    "sort"
+    "time"
+
+    "github.com/algo-trading/market-data-service/pkg/indicators"
+)
+
+// OptionQuote is the market snapshot of a single option contract. IV is in
+// percent.
+type OptionQuote struct {
+    Symbol       string             `json:"symbol"`
+    Type         InstrumentType     `json:"type"`
+    Strike       float64            `json:"strike"`
+    Time         time.Time          `json:"time"`
+    LastPrice    float64            `json:"last_price"`
+    Bid          float64            `json:"bid,omitempty"`
+    Ask          float64            `json:"ask,omitempty"`
+    Volume       int64              `json:"volume"`
+    OpenInterest int64              `json:"open_interest"`
+    OIChange     int64              `json:"oi_change"`
+    IV           float64            `json:"iv"`
+    Greeks       *indicators.Greeks `json:"greeks,omitempty"`
+}
+
+// ChainRow holds the call and put quotes of one strike
+type ChainRow struct {
+    Strike float64      `json:"strike"`
+    Call   *OptionQuote `json:"call,omitempty"`
+    Put    *OptionQuote `json:"put,omitempty"`
+}
+
+// Chain is the option chain of an underlying for a single expiry
+type Chain struct {
+    Underlying string     `json:"underlying"`
+    Expiry     time.Time  `json:"expiry"`
+    SpotPrice  float64    `json:"spot_price"`
+    Time       time.Time  `json:"time"`
+    Rows       []ChainRow `json:"rows"`
+}
+
+// BuildChain groups option quotes by strike in ascending order
+func BuildChain(underlying string, expiry time.Time, spot float64, quotes []OptionQuote) *Chain {
+    rows := make(map[float64]*ChainRow)
+    var latest time.Time
+    for i := range quotes {
+        quote := quotes[i]
+        row, exists := rows[quote.Strike]
+        if !exists {
+            row = &ChainRow{Strike: quote.Strike}
+            rows[quote.Strike] = row
+        }
+
+        switch quote.Type {
+        case Call:
+            row.Call = &quote
+        case Put:
+            row.Put = &quote
+        }
+        if quote.Time.After(latest) {
+            latest = quote.Time
+        }
+    }
+
+    chain := &Chain{
+        Underlying: underlying,
+        Expiry:     ExpiryDate(expiry),
+        SpotPrice:  spot,
+        Time:       latest,
+        Rows:       make([]ChainRow, 0, len(rows)),
+    }
+    for _, row := range rows {
+        chain.Rows = append(chain.Rows, *row)
+    }
+    sort.Slice(chain.Rows, func(i, j int) bool { return chain.Rows[i].Strike < chain.Rows[j].Strike })
+
+    return chain
+}
+
+// Quotes returns all option quotes in the chain
+func (c *Chain) Quotes() []OptionQuote {
+    var quotes []OptionQuote
+    for _, row := range c.Rows {
+        if row.Call != nil {
+            quotes = append(quotes, *row.Call)
+        }
+        if row.Put != nil {
+            quotes = append(quotes, *row.Put)
+        }
+    }
+    return quotes
+}
+
+// PutCallRatio returns total put open interest divided by total call open interest
+func (c *Chain) PutCallRatio() float64 {
+    var calls, puts int64
+    for _, row := range c.Rows {
+        if row.Call != nil {
+            calls += row.Call.OpenInterest
+        }
+        if row.Put != nil {
+            puts += row.Put.OpenInterest
+        }
+    }
+    if calls == 0 {
+        return 0
+    }
+    return float64(puts) / float64(calls)
+}
+
+// expiryClose is when contracts stop trading on their expiry date
+const expiryClose = 15*time.Hour + 30*time.Minute
+
+// YearsToExpiry returns the time from now to the close of the expiry date in years
+func YearsToExpiry(expiry, now time.Time) float64 {
+    closeTime := ExpiryDate(expiry).Add(expiryClose)
+    return closeTime.Sub(now).Hours() / 24 / 365
+}
+
+// ApplyGreeks computes Greeks for every quote with Black-Scholes on the spot
+// price, solving the implied volatility from the last price where the
+// provider did not supply one
+func (c *Chain) ApplyGreeks(now time.Time, rate float64) {
+    years := YearsToExpiry(c.Expiry, now)
+    if years <= 0 || c.SpotPrice <= 0 {
+        return
+    }
+
+    for i := range c.Rows {
+        for _, quote := range []*OptionQuote{c.Rows[i].Call, c.Rows[i].Put} {
+            if quote == nil {
+                continue
+            }
+
+            optionType := indicators.Call
+            if quote.Type == Put {
+                optionType = indicators.Put
+            }
+            contract := indicators.OptionContract{
+                Type:       optionType,
+                Model:      indicators.BlackScholes,
+                Underlying: c.SpotPrice,
+                Strike:     quote.Strike,
+                Expiry:     years,
+                Rate:       rate,
+            }
+
+            if quote.IV <= 0 {
+                sigma, err := indicators.ImpliedVolatility(contract, quote.LastPrice)
+                if err != nil {
+                    continue
+                }
+                quote.IV = math.Round(sigma*10000) / 100
+            }
+
+            greeks := contract.Greeks(quote.IV / 100)
+            quote.Greeks = &greeks
+        }
+    }
+}
+
+// OpenInterestHistory converts the chain's quotes to open interest history points
+func (c *Chain) OpenInterestHistory() []OpenInterest {
+    quotes := c.Quotes()
+    points := make([]OpenInterest, 0, len(quotes))
+    for _, quote := range quotes {
+        points = append(points, OpenInterest{
+            Time:         quote.Time,
+            Symbol:       quote.Symbol,
+            OpenInterest: quote.OpenInterest,
+            Volume:       quote.Volume,
+            Price:        quote.LastPrice,
+            IV:           quote.IV,
+        })
+    }
+    return points
+}
+
+// OpenInterest is a point in the open interest history of a contract
+type OpenInterest struct {
+    Time         time.Time `json:"time"`
+    Symbol       string    `json:"symbol"`
+    OpenInterest int64     `json:"open_interest"`
+    Volume       int64     `json:"volume"`
+    Price        float64   `json:"price"`
+    IV           float64   `json:"iv"`
+}
@@ -0,0 +1,126 @@
+package derivatives
+
+import (
+    "math"
+    "testing"
+    "time"
+
+    "github.com/algo-trading/market-data-service/internal/calendar"
+    "github.com/algo-trading/market-data-service/pkg/indicators"
+)
+
+func TestSymbolRoundTrip(t *testing.T) {
+    expiry := time.Date(2025, time.October, 28, 0, 0, 0, 0, calendar.IST)
+
+    tests := []struct {
+        instrument Instrument
+        symbol     string
+    }{
+        {NewOption("NIFTY", Call, expiry, 24500, 75), "NIFTY28OCT2524500CE"},
+        {NewOption("BANKNIFTY", Put, expiry, 56000, 35), "BANKNIFTY28OCT2556000PE"},
+        {NewFuture("NIFTY", expiry, 75), "NIFTY28OCT25FUT"},
+    }
+
+    for _, tt := range tests {
+        if tt.instrument.Symbol != tt.symbol {
+            t.Errorf("Expected symbol %s, got %s", tt.symbol, tt.instrument.Symbol)
+        }
+
+        parsed, err := ParseSymbol(tt.symbol)
+        if err != nil {
+            t.Fatalf("Failed to parse %s: %v", tt.symbol, err)
+        }
+        if *parsed != tt.instrument {
+            t.Errorf("Parsed %s as %+v, expected %+v", tt.symbol, *parsed, tt.instrument)
+        }
+    }
+
+    for _, symbol := range []string{"RELIANCE", "NIFTY28OCT25", "NIFTY28XYZ2524500CE", "NIFTY28OCT2524500XX"} {
+        if _, err := ParseSymbol(symbol); err == nil {
+            t.Errorf("Expected error parsing %s", symbol)
+        }
+    }
+}
+
+func TestExpiries(t *testing.T) {
+    cal, err := calendar.NewCalendar(calendar.NSE)
+    if err != nil {
+        t.Fatalf("Failed to create calendar: %v", err)
+    }
+    from := time.Date(2025, time.September, 29, 10, 0, 0, 0, calendar.IST)
+
+    // Weekly expiries, 2025-10-21 is Diwali Laxmi Pujan so expiry moves to Monday
+    weekly := Underlyings["NIFTY"].Expiries(cal, from, 4)
+    want := []string{"2025-09-30", "2025-10-07", "2025-10-14", "2025-10-20"}
+    for i, expiry := range weekly {
+        if got := expiry.Format("2006-01-02"); got != want[i] {
+            t.Errorf("Weekly expiry %d: expected %s, got %s", i, want[i], got)
+        }
+    }
+
+    monthly := Underlyings["BANKNIFTY"].Expiries(cal, from, 3)
+    want = []string{"2025-09-30", "2025-10-28", "2025-11-25"}
+    for i, expiry := range monthly {
+        if got := expiry.Format("2006-01-02"); got != want[i] {
+            t.Errorf("Monthly expiry %d: expected %s, got %s", i, want[i], got)
+        }
+    }
+}
+
+func TestBuildChain(t *testing.T) {
+    expiry := time.Date(2025, time.October, 28, 0, 0, 0, 0, calendar.IST)
+    now := time.Now()
+
+    chain := BuildChain("NIFTY", expiry, 24520, []OptionQuote{
+        {Symbol: "NIFTY28OCT2524600CE", Type: Call, Strike: 24600, OpenInterest: 100, Time: now},
+        {Symbol: "NIFTY28OCT2524500PE", Type: Put, Strike: 24500, OpenInterest: 300, Time: now},
+        {Symbol: "NIFTY28OCT2524500CE", Type: Call, Strike: 24500, OpenInterest: 200, Time: now.Add(-time.Second)},
+    })
+
+    if len(chain.Rows) != 2 || chain.Rows[0].Strike != 24500 || chain.Rows[1].Strike != 24600 {
+        t.Fatalf("Unexpected chain rows: %+v", chain.Rows)
+    }
+    if chain.Rows[0].Call == nil || chain.Rows[0].Put == nil || chain.Rows[1].Put != nil {
+        t.Errorf("Quotes were not grouped by strike: %+v", chain.Rows)
+    }
+    if !chain.Time.Equal(now) {
+        t.Errorf("Expected chain time to be the latest quote time")
+    }
+    if pcr := chain.PutCallRatio(); pcr != 1 {
+        t.Errorf("Expected put call ratio 1, got %v", pcr)
+    }
+    if len(chain.Quotes()) != 3 {
+        t.Errorf("Expected 3 quotes, got %d", len(chain.Quotes()))
+    }
+}
+
+func TestApplyGreeks(t *testing.T) {
+    expiry := time.Date(2025, time.October, 28, 0, 0, 0, 0, calendar.IST)
+    now := time.Date(2025, time.October, 14, 15, 30, 0, 0, calendar.IST)
+    years := YearsToExpiry(expiry, now)
+    if math.Abs(years-14.0/365) > 1e-9 {
+        t.Fatalf("Expected 14 days to expiry, got %v years", years)
+    }
+
+    contract := indicators.OptionContract{Type: indicators.Put, Underlying: 25000, Strike: 24800, Expiry: years, Rate: 0.065}
+    price := contract.Price(0.15)
+
+    chain := BuildChain("NIFTY", expiry, 25000, []OptionQuote{
+        {Symbol: "NIFTY28OCT2524800PE", Type: Put, Strike: 24800, LastPrice: price},
+        {Symbol: "NIFTY28OCT2524800CE", Type: Call, Strike: 24800, LastPrice: 400, IV: 14},
+    })
+    chain.ApplyGreeks(now, 0.065)
+
+    put := chain.Rows[0].Put
+    if math.Abs(put.IV-15) > 0.01 {
+        t.Errorf("Expected solved IV of 15%%, got %v", put.IV)
+    }
+    if put.Greeks == nil || put.Greeks.Delta >= 0 {
+        t.Errorf("Expected negative put delta, got %+v", put.Greeks)
+    }
+
+    call := chain.Rows[0].Call
+    if call.IV != 14 || call.Greeks == nil || call.Greeks.Delta <= 0.5 {
+        t.Errorf("Expected ITM call Greeks at the provider IV, got %+v", call)
+    }
+}
@@ -0,0 +1,188 @@
+package derivatives
+
+import (
+    "fmt"
+    "regexp"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/algo-trading/market-data-service/internal/calendar"
+)
+
+// Exchange segment for NSE futures and options
+const ExchangeNFO = "NFO"
+
+// InstrumentType identifies a futures or options contract
+type InstrumentType string
+
+const (
+    Future InstrumentType = "FUT"
+    Call   InstrumentType = "CE"
+    Put    InstrumentType = "PE"
+)
+
+// Instrument is a futures or options contract on an underlying
+type Instrument struct {
+    Symbol     string         `json:"symbol"`
+    Underlying string         `json:"underlying"`
+    Type       InstrumentType `json:"type"`
+    Expiry     time.Time      `json:"expiry"`
+    Strike     float64        `json:"strike,omitempty"`
+    LotSize    int            `json:"lot_size"`
+    Exchange   string         `json:"exchange"`
+}
+
+// IsOption reports whether the instrument is a call or put
+func (i *Instrument) IsOption() bool {
+    return i.Type == Call || i.Type == Put
+}
+
+// NewFuture creates a futures contract
+func NewFuture(underlying string, expiry time.Time, lotSize int) Instrument {
+    return Instrument{
+        Symbol:     FormatSymbol(underlying, Future, expiry, 0),
+        Underlying: underlying,
+        Type:       Future,
+        Expiry:     ExpiryDate(expiry),
+        LotSize:    lotSize,
+        Exchange:   ExchangeNFO,
+    }
+}
+
+// NewOption creates a call or put contract
+func NewOption(underlying string, optionType InstrumentType, expiry time.Time, strike float64, lotSize int) Instrument {
+    return Instrument{
+        Symbol:     FormatSymbol(underlying, optionType, expiry, strike),
+        Underlying: underlying,
+        Type:       optionType,
+        Expiry:     ExpiryDate(expiry),
+        Strike:     strike,
+        LotSize:    lotSize,
+        Exchange:   ExchangeNFO,
+    }
+}
+
+// ExpiryDate normalises an expiry to midnight IST of its date
+func ExpiryDate(t time.Time) time.Time {
+    t = t.In(calendar.IST)
+    return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, calendar.IST)
+}
+
+// expiryLayout is the DDMONYY date used in trading symbols, e.g. 28OCT25
+const expiryLayout = "02Jan06"
+
+// FormatSymbol builds the trading symbol, e.g. NIFTY28OCT2524500CE or
+// BANKNIFTY28OCT25FUT. The full expiry date is encoded so weekly and
+// monthly contracts never collide.
+func FormatSymbol(underlying string, instrumentType InstrumentType, expiry time.Time, strike float64) string {
+    date := strings.ToUpper(expiry.In(calendar.IST).Format(expiryLayout))
+    if instrumentType == Future {
+        return underlying + date + string(Future)
+    }
+    return underlying + date + strconv.FormatFloat(strike, 'f', -1, 64) + string(instrumentType)
+}
+
+var symbolPattern = regexp.MustCompile(`^([A-Z&-]+)(\d{2}[A-Z]{3}\d{2})(?:(FUT)|(\d+(?:\.\d+)?)(CE|PE))$`)
+
+// ParseSymbol parses a trading symbol produced by FormatSymbol. The lot size
+// is taken from the known underlyings and is zero otherwise.
+func ParseSymbol(symbol string) (*Instrument, error) {
+    match := symbolPattern.FindStringSubmatch(symbol)
+    if match == nil {
+        return nil, fmt.Errorf("invalid derivatives symbol: %s", symbol)
+    }
+
+    date := match[2][:2] + match[2][2:3] + strings.ToLower(match[2][3:5]) + match[2][5:]
+    expiry, err := time.ParseInLocation(expiryLayout, date, calendar.IST)
+    if err != nil {
+        return nil, fmt.Errorf("invalid expiry in %s: %w", symbol, err)
+    }
+
+    instrument := &Instrument{
+        Symbol:     symbol,
+        Underlying: match[1],
+        Type:       Future,
+        Expiry:     expiry,
+        Exchange:   ExchangeNFO,
+    }
+    if match[3] == "" {
+        instrument.Type = InstrumentType(match[5])
+        instrument.Strike, err = strconv.ParseFloat(match[4], 64)
+        if err != nil {
+            return nil, fmt.Errorf("invalid strike in %s: %w", symbol, err)
+        }
+    }
+    if spec, ok := Underlyings[instrument.Underlying]; ok {
+        instrument.LotSize = spec.LotSize
+    }
+
+    return instrument, nil
+}
+
+// Underlying describes the contract specification of an F&O underlying
+type Underlying struct {
+    Symbol     string
+    LotSize    int
+    StrikeStep float64
+    Weekly     bool
+    ExpiryDay  time.Weekday
+}
+
+// Underlyings are the index derivatives traded on NSE
+var Underlyings = map[string]Underlying{
+    "NIFTY":     {Symbol: "NIFTY", LotSize: 75, StrikeStep: 50, Weekly: true, ExpiryDay: time.Tuesday},
+    "BANKNIFTY": {Symbol: "BANKNIFTY", LotSize: 35, StrikeStep: 100, ExpiryDay: time.Tuesday},
+    "FINNIFTY":  {Symbol: "FINNIFTY", LotSize: 65, StrikeStep: 50, ExpiryDay: time.Tuesday},
+}
+
+// Expiries returns the next n option expiries on or after from. Monthly
+// contracts expire on the last ExpiryDay of the month; an expiry falling on a
+// holiday moves to the previous trading day.
+func (u Underlying) Expiries(cal *calendar.Calendar, from time.Time, n int) []time.Time {
+    from = ExpiryDate(from)
+
+    var expiries []time.Time
+    for day := from; len(expiries) < n; day = day.AddDate(0, 0, 1) {
+        if day.Weekday() != u.ExpiryDay {
+            continue
+        }
+        if !u.Weekly && day.AddDate(0, 0, 7).Month() == day.Month() {
+            continue
+        }
+
+        expiry := day
+        for !isExpiryDay(cal, expiry) {
+            expiry = expiry.AddDate(0, 0, -1)
+        }
+        if !expiry.Before(from) {
+            expiries = append(expiries, expiry)
+        }
+    }
+    return expiries
+}
+
+// isExpiryDay excludes holidays even when a special session such as Muhurat
+// trading is held on them
+func isExpiryDay(cal *calendar.Calendar, t time.Time) bool {
+    if _, holiday := cal.Holiday(t); holiday {
+        return false
+    }
+    return cal.IsTradingDay(t)
+}
+
+// MonthlyExpiries returns the next n monthly expiries, used for futures
+func (u Underlying) MonthlyExpiries(cal *calendar.Calendar, from time.Time, n int) []time.Time {
+    monthly := u
+    monthly.Weekly = false
+    return monthly.Expiries(cal, from, n)
+}
+
+// ATMStrike rounds a price to the nearest listed strike
+func (u Underlying) ATMStrike(price float64) float64 {
+    if u.StrikeStep <= 0 {
+        return price
+    }
+    steps := int64(price/u.StrikeStep + 0.5)
+    return float64(steps) * u.StrikeStep
+}
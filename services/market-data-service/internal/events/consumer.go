@@ -0,0 +1,84 @@
+package events
+
+import (
+    "context"
+    "fmt"
+    "log"
+
+    "github.com/segmentio/kafka-go"
+)
+
+// maxSeenEvents bounds the number of event IDs remembered for deduplication
+const maxSeenEvents = 10000
+
+// Consumer reads market data events as part of a consumer group. It is the
+// reference consumer for downstream services and is used by the integration
+// tests against a local broker.
+type Consumer struct {
+    reader *kafka.Reader
+    seen   map[string]struct{}
+    order  []string
+}
+
+func NewConsumer(brokers []string, groupID string, topics ...string) *Consumer {
+    reader := kafka.NewReader(kafka.ReaderConfig{
+        Brokers:     brokers,
+        GroupID:     groupID,
+        GroupTopics: topics,
+        MinBytes:    1,
+        MaxBytes:    10e6,
+        StartOffset: kafka.FirstOffset,
+    })
+
+    return &Consumer{
+        reader: reader,
+        seen:   make(map[string]struct{}),
+    }
+}
+
+func (c *Consumer) Close() error {
+    return c.reader.Close()
+}
+
+// Consume delivers events to handler until ctx is cancelled or handler fails.
+// Offsets are committed only after handler succeeds, so a failed event is
+// redelivered after a restart. Duplicate deliveries of the same event ID are
+// skipped.
+func (c *Consumer) Consume(ctx context.Context, handler func(*Envelope) error) error {
+    for {
+        msg, err := c.reader.FetchMessage(ctx)
+        if err != nil {
+            return fmt.Errorf("failed to fetch message: %w", err)
+        }
+
+        env, err := Decode(msg.Value)
+        if err != nil {
+            // Poison messages are skipped so they do not block the partition
+            log.Printf("Skipping undecodable event at %s/%d/%d: %v", msg.Topic, msg.Partition, msg.Offset, err)
+        } else if c.markSeen(env.ID) {
+            if err := handler(env); err != nil {
+                return fmt.Errorf("failed to handle event %s: %w", env.ID, err)
+            }
+        }
+
+        if err := c.reader.CommitMessages(ctx, msg); err != nil {
+            return fmt.Errorf("failed to commit offset: %w", err)
+        }
+    }
+}
+
+// markSeen records the ID and reports whether it was new
+func (c *Consumer) markSeen(id string) bool {
+    if _, exists := c.seen[id]; exists {
+        return false
+    }
+
+    c.seen[id] = struct{}{}
+    c.order = append(c.order, id)
+    if len(c.order) > maxSeenEvents {
+        delete(c.seen, c.order[0])
+        c.order = c.order[1:]
+    }
+
+    return true
+}
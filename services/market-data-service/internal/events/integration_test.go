@@ -0,0 +1,73 @@
+//go:build integration
+
+package events
+
+import (
+    "context"
+    "fmt"
+    "os"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/algo-trading/market-data-service/internal/models"
+)
+
+// Run with a local broker, e.g. `make infra-start` followed by
+// KAFKA_BROKERS=localhost:9092 go test -tags integration ./internal/events/
+func TestProduceConsume(t *testing.T) {
+    brokers := strings.Split(os.Getenv("KAFKA_BROKERS"), ",")
+    if brokers[0] == "" {
+        brokers = []string{"localhost:9092"}
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+    defer cancel()
+
+    topics := DefaultTopics(fmt.Sprintf("test-%d", time.Now().UnixNano()))
+    producer := NewProducer(brokers, topics)
+    defer producer.Close()
+
+    tick := &models.Tick{Time: time.Now().UTC(), Symbol: "INFY", Price: 1500, Volume: 10}
+    bar := &models.OHLCV{Time: time.Now().UTC(), Symbol: "INFY", Open: 1, High: 2, Low: 1, Close: 2, Volume: 10, Timeframe: "1m"}
+
+    if err := producer.PublishTick(ctx, tick); err != nil {
+        t.Fatalf("Failed to publish tick: %v", err)
+    }
+    // Publishing the same tick twice must be delivered once
+    if err := producer.PublishTick(ctx, tick); err != nil {
+        t.Fatalf("Failed to republish tick: %v", err)
+    }
+    if err := producer.PublishOHLCV(ctx, bar); err != nil {
+        t.Fatalf("Failed to publish bar: %v", err)
+    }
+
+    consumer := NewConsumer(brokers, topics.Ticks+"-group", topics.Ticks, topics.OHLCV)
+    defer consumer.Close()
+
+    received := make(map[EventType]int)
+    consumeCtx, stop := context.WithCancel(ctx)
+    defer stop()
+
+    err := consumer.Consume(consumeCtx, func(env *Envelope) error {
+        received[env.Type]++
+        if env.Symbol != "INFY" {
+            t.Errorf("Expected symbol INFY, got %s", env.Symbol)
+        }
+        if received[EventTick] >= 1 && received[EventOHLCV] >= 1 {
+            // Give a duplicate a chance to arrive before stopping
+            time.AfterFunc(2*time.Second, stop)
+        }
+        return nil
+    })
+    if err != nil && consumeCtx.Err() == nil {
+        t.Fatalf("Consume failed: %v", err)
+    }
+
+    if received[EventTick] != 1 {
+        t.Errorf("Expected exactly 1 tick, got %d", received[EventTick])
+    }
+    if received[EventOHLCV] != 1 {
+        t.Errorf("Expected exactly 1 bar, got %d", received[EventOHLCV])
+    }
+}
@@ -0,0 +1,137 @@
+package events
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "log"
+    "strconv"
+    "time"
+
+    "github.com/segmentio/kafka-go"
+
+    "github.com/algo-trading/market-data-service/internal/models"
+)
+
+// Kafka header names set on every message
+const (
+    HeaderEventType     = "event-type"
+    HeaderSchemaVersion = "schema-version"
+    HeaderContentType   = "content-type"
+)
+
+// Topics holds the topic name used for each event type
+type Topics struct {
+    Ticks      string
+    OHLCV      string
+    Indicators string
+}
+
+// DefaultTopics derives topic names from a prefix, e.g. market.ticks
+func DefaultTopics(prefix string) Topics {
+    return Topics{
+        Ticks:      prefix + ".ticks",
+        OHLCV:      prefix + ".ohlcv",
+        Indicators: prefix + ".indicators",
+    }
+}
+
+// Producer publishes market data events to Kafka. Messages are keyed by
+// symbol so that all events for a symbol land on the same partition in order.
+//
+// kafka-go has no idempotent producer, so delivery is at-least-once: writes
+// wait for all in-sync replicas and are retried, and every envelope carries a
+// deterministic ID that consumers use to drop duplicates.
+//
+// Writes are asynchronous so publishing never blocks the tick path: messages
+// are batched in the background, Publish* only reports encoding errors, and
+// delivery failures are logged once retries are exhausted. Close flushes any
+// pending batches.
+type Producer struct {
+    writer *kafka.Writer
+    topics Topics
+}
+
+func NewProducer(brokers []string, topics Topics) *Producer {
+    writer := &kafka.Writer{
+        Addr:                   kafka.TCP(brokers...),
+        Balancer:               &kafka.Hash{},
+        RequiredAcks:           kafka.RequireAll,
+        MaxAttempts:            10,
+        BatchTimeout:           10 * time.Millisecond,
+        AllowAutoTopicCreation: true,
+        Async:                  true,
+        Completion:             logFailedWrites,
+    }
+
+    return &Producer{
+        writer: writer,
+        topics: topics,
+    }
+}
+
+// logFailedWrites reports batches that could not be delivered in async mode
+func logFailedWrites(messages []kafka.Message, err error) {
+    if err == nil || len(messages) == 0 {
+        return
+    }
+    log.Printf("Failed to publish %d events to %s: %v", len(messages), messages[0].Topic, err)
+}
+
+func (p *Producer) Close() error {
+    return p.writer.Close()
+}
+
+// Topics returns the topics this producer writes to
+func (p *Producer) Topics() Topics {
+    return p.topics
+}
+
+func (p *Producer) PublishTick(ctx context.Context, tick *models.Tick) error {
+    env, err := NewTickEnvelope(tick)
+    if err != nil {
+        return err
+    }
+    return p.publish(ctx, p.topics.Ticks, env)
+}
+
+func (p *Producer) PublishOHLCV(ctx context.Context, bar *models.OHLCV) error {
+    env, err := NewOHLCVEnvelope(bar)
+    if err != nil {
+        return err
+    }
+    return p.publish(ctx, p.topics.OHLCV, env)
+}
+
+func (p *Producer) PublishIndicator(ctx context.Context, indicator *models.TechnicalIndicator) error {
+    env, err := NewIndicatorEnvelope(indicator)
+    if err != nil {
+        return err
+    }
+    return p.publish(ctx, p.topics.Indicators, env)
+}
+
+func (p *Producer) publish(ctx context.Context, topic string, env *Envelope) error {
+    data, err := json.Marshal(env)
+    if err != nil {
+        return fmt.Errorf("failed to marshal %s event: %w", env.Type, err)
+    }
+
+    msg := kafka.Message{
+        Topic: topic,
+        Key:   []byte(env.Symbol),
+        Value: data,
+        Time:  env.Time,
+        Headers: []kafka.Header{
+            {Key: HeaderEventType, Value: []byte(env.Type)},
+            {Key: HeaderSchemaVersion, Value: []byte(strconv.Itoa(env.SchemaVersion))},
+            {Key: HeaderContentType, Value: []byte("application/json")},
+        },
+    }
+
+    if err := p.writer.WriteMessages(ctx, msg); err != nil {
+        return fmt.Errorf("failed to publish %s event to %s: %w", env.Type, topic, err)
+    }
+
+    return nil
+}
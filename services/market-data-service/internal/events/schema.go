@@ -0,0 +1,137 @@
+package events
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "hash/fnv"
+    "time"
+
+    "github.com/algo-trading/market-data-service/internal/models"
+)
+
+// EventType identifies the payload carried by an Envelope
+type EventType string
+
+const (
+    EventTick      EventType = "tick"
+    EventOHLCV     EventType = "ohlcv"
+    EventIndicator EventType = "indicator"
+)
+
+// SchemaVersion is the current version of the event envelope and payloads.
+// Bump it when a payload changes incompatibly; consumers reject versions
+// newer than the one they were built against.
+const SchemaVersion = 1
+
+// ErrUnsupportedSchema is returned when decoding an event from a newer producer
+var ErrUnsupportedSchema = errors.New("unsupported event schema version")
+
+// Envelope wraps every event published to Kafka
+type Envelope struct {
+    ID            string          `json:"id"`
+    Type          EventType       `json:"type"`
+    SchemaVersion int             `json:"schema_version"`
+    Symbol        string          `json:"symbol"`
+    Time          time.Time       `json:"time"`
+    Payload       json.RawMessage `json:"payload"`
+}
+
+// NewTickEnvelope wraps a tick. Distinct ticks can share a timestamp, so the
+// ID also carries a hash of the payload.
+func NewTickEnvelope(tick *models.Tick) (*Envelope, error) {
+    id := fmt.Sprintf("%s:%s:%d", EventTick, tick.Symbol, tick.Time.UnixNano())
+    env, err := newEnvelope(id, EventTick, tick.Symbol, tick.Time, tick)
+    if err != nil {
+        return nil, err
+    }
+
+    h := fnv.New64a()
+    h.Write(env.Payload)
+    env.ID = fmt.Sprintf("%s:%016x", env.ID, h.Sum64())
+    return env, nil
+}
+
+// NewOHLCVEnvelope wraps a completed bar
+func NewOHLCVEnvelope(bar *models.OHLCV) (*Envelope, error) {
+    id := fmt.Sprintf("%s:%s:%s:%d", EventOHLCV, bar.Symbol, bar.Timeframe, bar.Time.UnixNano())
+    return newEnvelope(id, EventOHLCV, bar.Symbol, bar.Time, bar)
+}
+
+// NewIndicatorEnvelope wraps an indicator value
+func NewIndicatorEnvelope(indicator *models.TechnicalIndicator) (*Envelope, error) {
+    id := fmt.Sprintf("%s:%s:%s:%s:%d", EventIndicator, indicator.Symbol, indicator.Timeframe,
+        indicator.IndicatorName, indicator.Time.UnixNano())
+    return newEnvelope(id, EventIndicator, indicator.Symbol, indicator.Time, indicator)
+}
+
+// The ID is derived from the event's identity rather than generated, so a
+// retried publish of the same event carries the same ID and can be dropped
+// by consumers.
+func newEnvelope(id string, eventType EventType, symbol string, t time.Time, payload interface{}) (*Envelope, error) {
+    data, err := json.Marshal(payload)
+    if err != nil {
+        return nil, fmt.Errorf("failed to marshal %s payload: %w", eventType, err)
+    }
+
+    return &Envelope{
+        ID:            id,
+        Type:          eventType,
+        SchemaVersion: SchemaVersion,
+        Symbol:        symbol,
+        Time:          t,
+        Payload:       data,
+    }, nil
+}
+
+// Decode parses an envelope and checks that its schema version is supported
+func Decode(data []byte) (*Envelope, error) {
+    var env Envelope
+    if err := json.Unmarshal(data, &env); err != nil {
+        return nil, fmt.Errorf("failed to unmarshal event: %w", err)
+    }
+
+    if env.SchemaVersion < 1 || env.SchemaVersion > SchemaVersion {
+        return nil, fmt.Errorf("%w: %d", ErrUnsupportedSchema, env.SchemaVersion)
+    }
+
+    return &env, nil
+}
+
+// Tick decodes the payload of a tick event
+func (e *Envelope) Tick() (*models.Tick, error) {
+    var tick models.Tick
+    if err := e.decodePayload(EventTick, &tick); err != nil {
+        return nil, err
+    }
+    return &tick, nil
+}
+
+// OHLCV decodes the payload of a bar event
+func (e *Envelope) OHLCV() (*models.OHLCV, error) {
+    var bar models.OHLCV
+    if err := e.decodePayload(EventOHLCV, &bar); err != nil {
+        return nil, err
+    }
+    return &bar, nil
+}
+
+// Indicator decodes the payload of an indicator event
+func (e *Envelope) Indicator() (*models.TechnicalIndicator, error) {
+    var indicator models.TechnicalIndicator
+    if err := e.decodePayload(EventIndicator, &indicator); err != nil {
+        return nil, err
+    }
+    return &indicator, nil
+}
+
+func (e *Envelope) decodePayload(expected EventType, dest interface{}) error {
+    if e.Type != expected {
+        return fmt.Errorf("event %s is a %s, not a %s", e.ID, e.Type, expected)
+    }
+
+    if err := json.Unmarshal(e.Payload, dest); err != nil {
+        return fmt.Errorf("failed to unmarshal %s payload: %w", e.Type, err)
+    }
+    return nil
+}
@@ -0,0 +1,91 @@
+package events
+
+import (
+    "encoding/json"
+    "errors"
+    "testing"
+    "time"
+
+    "github.com/algo-trading/market-data-service/internal/models"
+)
+
+func TestTickEnvelopeRoundTrip(t *testing.T) {
+    bid := 999.5
+    tick := &models.Tick{
+        Time:   time.Date(2026, time.October, 16, 10, 0, 0, 0, time.UTC),
+        Symbol: "RELIANCE",
+        Price:  1000.25,
+        Volume: 100,
+        Bid:    &bid,
+    }
+
+    env, err := NewTickEnvelope(tick)
+    if err != nil {
+        t.Fatalf("Failed to create envelope: %v", err)
+    }
+
+    data, err := json.Marshal(env)
+    if err != nil {
+        t.Fatalf("Failed to marshal envelope: %v", err)
+    }
+
+    decoded, err := Decode(data)
+    if err != nil {
+        t.Fatalf("Failed to decode envelope: %v", err)
+    }
+
+    if decoded.ID != env.ID || decoded.Type != EventTick || decoded.Symbol != "RELIANCE" {
+        t.Errorf("Unexpected envelope after round trip: %+v", decoded)
+    }
+
+    got, err := decoded.Tick()
+    if err != nil {
+        t.Fatalf("Failed to decode tick payload: %v", err)
+    }
+    if got.Price != tick.Price || got.Bid == nil || *got.Bid != bid {
+        t.Errorf("Expected %+v, got %+v", tick, got)
+    }
+
+    if _, err := decoded.OHLCV(); err == nil {
+        t.Errorf("Expected error decoding a tick as OHLCV, got nil")
+    }
+}
+
+func TestEnvelopeIDIsDeterministic(t *testing.T) {
+    bar := &models.OHLCV{
+        Time:      time.Date(2026, time.October, 16, 9, 15, 0, 0, time.UTC),
+        Symbol:    "TCS",
+        Timeframe: "1m",
+    }
+
+    first, _ := NewOHLCVEnvelope(bar)
+    second, _ := NewOHLCVEnvelope(bar)
+    if first.ID != second.ID {
+        t.Errorf("Expected identical IDs for the same bar, got %s and %s", first.ID, second.ID)
+    }
+}
+
+func TestTickEnvelopeIDsDistinguishTicksAtTheSameTime(t *testing.T) {
+    tick := &models.Tick{Time: time.Date(2026, time.October, 16, 10, 0, 0, 0, time.UTC), Symbol: "NIFTY", Price: 25000}
+    other := *tick
+    other.Price = 25001.5
+
+    first, _ := NewTickEnvelope(tick)
+    retried, _ := NewTickEnvelope(tick)
+    second, _ := NewTickEnvelope(&other)
+    if first.ID != retried.ID {
+        t.Errorf("Expected identical IDs for the same tick, got %s and %s", first.ID, retried.ID)
+    }
+    if first.ID == second.ID {
+        t.Errorf("Expected distinct IDs for different ticks at the same time, got %s", first.ID)
+    }
+}
+
+func TestDecodeRejectsNewerSchema(t *testing.T) {
+    data := []byte(`{"id":"x","type":"tick","schema_version":99,"symbol":"TCS","payload":{}}`)
+
+    _, err := Decode(data)
+    if !errors.Is(err, ErrUnsupportedSchema) {
+        t.Errorf("Expected ErrUnsupportedSchema, got %v", err)
+    }
+}
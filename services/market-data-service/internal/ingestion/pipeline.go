@@ -0,0 +1,160 @@
+package ingestion
+
+import (
+    "context"
+    "log"
+    "sync"
+    "time"
+
+    "github.com/algo-trading/market-data-service/internal/calendar"
+    "github.com/algo-trading/market-data-service/internal/models"
+)
+
+// TickHandler is invoked for every tick accepted by the pipeline
+type TickHandler func(ctx context.Context, tick *models.Tick)
+
+// BarHandler is invoked for every completed OHLCV bar
+type BarHandler func(ctx context.Context, bar *models.OHLCV)
+
+// Pipeline turns raw provider ticks into tick and completed bar events.
+// Ticks outside the regular session are dropped and bars are aligned to the
+// exchange session using the market calendar.
+type Pipeline struct {
+    calendar     *calendar.Calendar
+    timeframes   []string
+    bars         map[barKey]*models.OHLCV
+    emitted      map[barKey]time.Time
+    tickHandlers []TickHandler
+    barHandlers  []BarHandler
+    mu           sync.Mutex
+}
+
+type barKey struct {
+    symbol    string
+    timeframe string
+}
+
+func NewPipeline(cal *calendar.Calendar, timeframes []string) *Pipeline {
+    return &Pipeline{
+        calendar:   cal,
+        timeframes: timeframes,
+        bars:       make(map[barKey]*models.OHLCV),
+        emitted:    make(map[barKey]time.Time),
+    }
+}
+
+// OnTick registers a handler for accepted ticks
+func (p *Pipeline) OnTick(handler TickHandler) {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+
+    p.tickHandlers = append(p.tickHandlers, handler)
+}
+
+// OnBar registers a handler for completed bars
+func (p *Pipeline) OnBar(handler BarHandler) {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+
+    p.barHandlers = append(p.barHandlers, handler)
+}
+
+// ProcessTick feeds a tick into the pipeline
+func (p *Pipeline) ProcessTick(ctx context.Context, tick *models.Tick) {
+    if !p.calendar.IsOpen(tick.Time) {
+        return
+    }
+
+    p.mu.Lock()
+    completed := p.updateBars(tick)
+    tickHandlers := p.tickHandlers
+    barHandlers := p.barHandlers
+    p.mu.Unlock()
+
+    for _, handler := range tickHandlers {
+        handler(ctx, tick)
+    }
+    for _, bar := range completed {
+        for _, handler := range barHandlers {
+            handler(ctx, bar)
+        }
+    }
+}
+
+// Flush completes every bar whose period has ended by now. It should be
+// called periodically so that bars close even when a symbol stops trading.
+func (p *Pipeline) Flush(ctx context.Context, now time.Time) {
+    p.mu.Lock()
+    var completed []*models.OHLCV
+    for key, bar := range p.bars {
+        end, err := p.calendar.BarEnd(bar.Time, bar.Timeframe)
+        if err != nil || !now.Before(end) {
+            completed = append(completed, bar)
+            p.emitted[key] = bar.Time
+            delete(p.bars, key)
+        }
+    }
+    barHandlers := p.barHandlers
+    p.mu.Unlock()
+
+    for _, bar := range completed {
+        for _, handler := range barHandlers {
+            handler(ctx, bar)
+        }
+    }
+}
+
+// updateBars applies the tick to the open bar of each timeframe and returns
+// the bars it completed. Callers must hold mu.
+func (p *Pipeline) updateBars(tick *models.Tick) []*models.OHLCV {
+    var completed []*models.OHLCV
+
+    for _, timeframe := range p.timeframes {
+        start, err := p.calendar.BarStart(tick.Time, timeframe)
+        if err != nil {
+            log.Printf("Failed to compute %s bar for %s: %v", timeframe, tick.Symbol, err)
+            continue
+        }
+
+        key := barKey{symbol: tick.Symbol, timeframe: timeframe}
+        if last, ok := p.emitted[key]; ok && !start.After(last) {
+            // Late tick for a bar that has already been emitted
+            continue
+        }
+
+        bar, exists := p.bars[key]
+        if exists && start.Before(bar.Time) {
+            continue
+        }
+        if exists && bar.Time.Before(start) {
+            completed = append(completed, bar)
+            p.emitted[key] = bar.Time
+            exists = false
+        }
+
+        if !exists {
+            p.bars[key] = &models.OHLCV{
+                Time:      start,
+                Symbol:    tick.Symbol,
+                Open:      tick.Price,
+                High:      tick.Price,
+                Low:       tick.Price,
+                Close:     tick.Price,
+                Volume:    tick.Volume,
+                Timeframe: timeframe,
+            }
+            continue
+        }
+
+        if tick.Price > bar.High {
+            bar.High = tick.Price
+        }
+        if tick.Price < bar.Low {
+            bar.Low = tick.Price
+        }
+        bar.Close = tick.Price
+        bar.Volume += tick.Volume
+    }
+
+    return completed
+}
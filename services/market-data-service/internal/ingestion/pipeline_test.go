@@ -0,0 +1,85 @@
+package ingestion
+
+import (
+    "context"
+    "testing"
+    "time"
+
+    "github.com/algo-trading/market-data-service/internal/calendar"
+    "github.com/algo-trading/market-data-service/internal/models"
+)
+
+func newTestPipeline(t *testing.T, timeframes ...string) *Pipeline {
+    cal, err := calendar.NewCalendar(calendar.NSE)
+    if err != nil {
+        t.Fatalf("Failed to create calendar: %v", err)
+    }
+    return NewPipeline(cal, timeframes)
+}
+
+func tickAt(hour, minute, second int, price float64) *models.Tick {
+    return &models.Tick{
+        Time:   time.Date(2026, time.October, 16, hour, minute, second, 0, calendar.IST),
+        Symbol: "RELIANCE",
+        Price:  price,
+        Volume: 10,
+    }
+}
+
+func TestPipelineAggregatesBars(t *testing.T) {
+    p := newTestPipeline(t, "1m")
+    ctx := context.Background()
+
+    var ticks int
+    var bars []*models.OHLCV
+    p.OnTick(func(ctx context.Context, tick *models.Tick) { ticks++ })
+    p.OnBar(func(ctx context.Context, bar *models.OHLCV) { bars = append(bars, bar) })
+
+    p.ProcessTick(ctx, tickAt(9, 15, 5, 100))
+    p.ProcessTick(ctx, tickAt(9, 15, 20, 105))
+    p.ProcessTick(ctx, tickAt(9, 15, 40, 98))
+    p.ProcessTick(ctx, tickAt(9, 15, 59, 101))
+
+    if len(bars) != 0 {
+        t.Fatalf("Expected no completed bars yet, got %d", len(bars))
+    }
+
+    // First tick of the next minute closes the previous bar
+    p.ProcessTick(ctx, tickAt(9, 16, 1, 102))
+
+    if ticks != 5 {
+        t.Errorf("Expected 5 ticks, got %d", ticks)
+    }
+    if len(bars) != 1 {
+        t.Fatalf("Expected 1 completed bar, got %d", len(bars))
+    }
+
+    bar := bars[0]
+    if bar.Open != 100 || bar.High != 105 || bar.Low != 98 || bar.Close != 101 || bar.Volume != 40 {
+        t.Errorf("Unexpected bar: %+v", bar)
+    }
+    if expected := time.Date(2026, time.October, 16, 9, 15, 0, 0, calendar.IST); !bar.Time.Equal(expected) {
+        t.Errorf("Expected bar time %s, got %s", expected, bar.Time)
+    }
+
+    // A late tick for the emitted bar must not reopen it
+    p.ProcessTick(ctx, tickAt(9, 15, 59, 200))
+    p.Flush(ctx, time.Date(2026, time.October, 16, 9, 17, 0, 0, calendar.IST))
+    if len(bars) != 2 || bars[1].High == 200 {
+        t.Errorf("Expected flush to emit only the 09:16 bar, got %d bars", len(bars))
+    }
+}
+
+func TestPipelineDropsTicksOutsideSession(t *testing.T) {
+    p := newTestPipeline(t, "1m")
+
+    var ticks int
+    p.OnTick(func(ctx context.Context, tick *models.Tick) { ticks++ })
+
+    p.ProcessTick(context.Background(), tickAt(9, 5, 0, 100))
+    p.ProcessTick(context.Background(), tickAt(15, 35, 0, 100))
+
+    if ticks != 0 {
+        t.Errorf("Expected ticks outside the regular session to be dropped, got %d", ticks)
+    }
+}
@@ -0,0 +1,390 @@
+// Package screener finds the stocks of the universe matching price, volume,
+// sector, indicator and pattern criteria and ranks them
+package screener
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "math"
+    "regexp"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/algo-trading/market-data-service/internal/calendar"
+    "github.com/algo-trading/market-data-service/internal/models"
+    "github.com/algo-trading/market-data-service/pkg/expr"
+    "github.com/algo-trading/market-data-service/pkg/patterns"
+)
+
+const (
+    DefaultBars    = 250
+    MaxBars        = 5000
+    DefaultLimit   = 50
+    MaxLimit       = 500
+    DefaultWorkers = 8
+    // MaxPatternBars bounds the pattern search, which rescans the series
+    // for every bar searched
+    MaxPatternBars = 50
+    // MinInterval is the shortest schedule of a saved screen
+    MinInterval = time.Minute
+)
+
+// Criteria selects and ranks stocks. Conditions are expressions, e.g.
+// "rsi(14) < 30", that must hold on the latest bar; Patterns match when any
+// of them completed on the last PatternBars bars. RankBy is an expression
+// ranked in Order, "desc" by default.
+type Criteria struct {
+    Timeframe   string   `json:"timeframe"`
+    Bars        int      `json:"bars,omitempty"`
+    Symbols     []string `json:"symbols,omitempty"`
+    Sectors     []string `json:"sectors,omitempty"`
+    MinPrice    *float64 `json:"min_price,omitempty"`
+    MaxPrice    *float64 `json:"max_price,omitempty"`
+    MinVolume   *int64   `json:"min_volume,omitempty"`
+    Conditions  []string `json:"conditions,omitempty"`
+    Patterns    []string `json:"patterns,omitempty"`
+    PatternBars int      `json:"pattern_bars,omitempty"`
+    RankBy      string   `json:"rank_by,omitempty"`
+    Order       string   `json:"order,omitempty"`
+    Limit       int      `json:"limit,omitempty"`
+}
+
+// Screen is a compiled Criteria
+type Screen struct {
+    criteria   Criteria
+    conditions []*expr.Expr
+    rankBy     *expr.Expr
+    patterns   map[patterns.Pattern]bool
+    sectors    map[string]bool
+    symbols    map[string]bool
+}
+
+// Compile validates criteria, applying defaults, and parses its expressions
+func Compile(criteria Criteria) (*Screen, error) {
+    if criteria.Timeframe == "" {
+        criteria.Timeframe = "1d"
+    }
+    if _, err := calendar.TimeframeDuration(criteria.Timeframe); err != nil {
+        return nil, err
+    }
+
+    if criteria.Bars == 0 {
+        criteria.Bars = DefaultBars
+    }
+    if criteria.Bars < 1 || criteria.Bars > MaxBars {
+        return nil, fmt.Errorf("bars must be between 1 and %d", MaxBars)
+    }
+    if criteria.Limit == 0 {
+        criteria.Limit = DefaultLimit
+    }
+    if criteria.Limit < 1 || criteria.Limit > MaxLimit {
+        return nil, fmt.Errorf("limit must be between 1 and %d", MaxLimit)
+    }
+    if criteria.PatternBars == 0 {
+        criteria.PatternBars = 1
+    }
+    if criteria.PatternBars < 1 || criteria.PatternBars > min(criteria.Bars, MaxPatternBars) {
+        return nil, fmt.Errorf("pattern_bars must be between 1 and the lesser of bars and %d", MaxPatternBars)
+    }
+    if criteria.MinPrice != nil && criteria.MaxPrice != nil && *criteria.MinPrice > *criteria.MaxPrice {
+        return nil, fmt.Errorf("min_price exceeds max_price")
+    }
+
+    criteria.Order = strings.ToLower(criteria.Order)
+    if criteria.Order == "" {
+        criteria.Order = "desc"
+    }
+    if criteria.Order != "asc" && criteria.Order != "desc" {
+        return nil, fmt.Errorf("order must be asc or desc")
+    }
+    if criteria.RankBy == "" {
+        criteria.RankBy = "volume"
+    }
+
+    s := &Screen{criteria: criteria}
+    for _, condition := range criteria.Conditions {
+        e, err := expr.Parse(condition)
+        if err != nil {
+            return nil, fmt.Errorf("invalid condition %q: %w", condition, err)
+        }
+        s.conditions = append(s.conditions, e)
+    }
+    rankBy, err := expr.Parse(criteria.RankBy)
+    if err != nil {
+        return nil, fmt.Errorf("invalid rank_by %q: %w", criteria.RankBy, err)
+    }
+    s.rankBy = rankBy
+
+    if len(criteria.Patterns) > 0 {
+        s.patterns = make(map[patterns.Pattern]bool)
+        for _, name := range criteria.Patterns {
+            pattern, err := patterns.ParsePattern(name)
+            if err != nil {
+                return nil, err
+            }
+            s.patterns[pattern] = true
+        }
+    }
+    if len(criteria.Sectors) > 0 {
+        s.sectors = make(map[string]bool)
+        for _, sector := range criteria.Sectors {
+            s.sectors[strings.ToLower(sector)] = true
+        }
+    }
+    if len(criteria.Symbols) > 0 {
+        s.symbols = make(map[string]bool)
+        for _, symbol := range criteria.Symbols {
+            s.symbols[strings.ToUpper(symbol)] = true
+        }
+    }
+
+    return s, nil
+}
+
+// Criteria returns the criteria with defaults applied
+func (s *Screen) Criteria() Criteria {
+    return s.criteria
+}
+
+// Loader returns up to limit of the latest stored bars of a series, oldest
+// first
+type Loader func(symbol, timeframe string, limit int) ([]models.OHLCV, error)
+
+// Result is a stock that passed a screen, with Score the value of RankBy
+type Result struct {
+    Rank        int              `json:"rank"`
+    Symbol      string           `json:"symbol"`
+    CompanyName string           `json:"company_name"`
+    Sector      string           `json:"sector"`
+    Time        time.Time        `json:"time"`
+    Close       float64          `json:"close"`
+    Volume      int64            `json:"volume"`
+    ChangePct   float64          `json:"change_pct"`
+    Score       *float64         `json:"score"`
+    Patterns    []patterns.Event `json:"patterns,omitempty"`
+}
+
+// Report is the outcome of running a screen. Matched counts every stock that
+// passed, Results holds the best Limit of them.
+type Report struct {
+    Name      string    `json:"name,omitempty"`
+    Timeframe string    `json:"timeframe"`
+    Time      time.Time `json:"time"`
+    Scanned   int       `json:"scanned"`
+    Matched   int       `json:"matched"`
+    Results   []Result  `json:"results"`
+}
+
+// Run screens the stocks, loading up to workers series at a time. Stocks
+// whose bars cannot be loaded or evaluated do not match.
+func (s *Screen) Run(ctx context.Context, stocks []models.Stock, load Loader, workers int) (*Report, error) {
+    if workers <= 0 {
+        workers = DefaultWorkers
+    }
+
+    var candidates []models.Stock
+    for _, stock := range stocks {
+        if s.symbols != nil && !s.symbols[stock.Symbol] {
+            continue
+        }
+        if s.sectors != nil && !s.sectors[strings.ToLower(stock.Sector)] {
+            continue
+        }
+        candidates = append(candidates, stock)
+    }
+
+    jobs := make(chan models.Stock)
+    var results []Result
+    var mu sync.Mutex
+    var wg sync.WaitGroup
+    for i := 0; i < min(workers, len(candidates)); i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for stock := range jobs {
+                if ctx.Err() != nil {
+                    continue
+                }
+                result, ok := s.safeEvaluate(stock, load)
+                if !ok {
+                    continue
+                }
+                mu.Lock()
+                results = append(results, result)
+                mu.Unlock()
+            }
+        }()
+    }
+
+    var err error
+feed:
+    for _, stock := range candidates {
+        select {
+        case jobs <- stock:
+        case <-ctx.Done():
+            err = ctx.Err()
+            break feed
+        }
+    }
+    close(jobs)
+    wg.Wait()
+    if err == nil {
+        err = ctx.Err()
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    s.rank(results)
+    report := &Report{
+        Timeframe: s.criteria.Timeframe,
+        Time:      time.Now(),
+        Scanned:   len(candidates),
+        Matched:   len(results),
+        Results:   results,
+    }
+    if len(results) > s.criteria.Limit {
+        report.Results = results[:s.criteria.Limit]
+    }
+    return report, nil
+}
+
+// safeEvaluate evaluates one stock, recovering from a panic so that one bad
+// series does not take down the server
+func (s *Screen) safeEvaluate(stock models.Stock, load Loader) (result Result, ok bool) {
+    defer func() {
+        if r := recover(); r != nil {
+            log.Printf("Panic while screening %s: %v", stock.Symbol, r)
+            result, ok = Result{}, false
+        }
+    }()
+    return s.evaluate(stock, load)
+}
+
+// evaluate applies the screen to one stock
+func (s *Screen) evaluate(stock models.Stock, load Loader) (Result, bool) {
+    bars, err := load(stock.Symbol, s.criteria.Timeframe, s.criteria.Bars)
+    if err != nil {
+        log.Printf("Failed to load %s bars for %s: %v", s.criteria.Timeframe, stock.Symbol, err)
+        return Result{}, false
+    }
+    if len(bars) == 0 {
+        return Result{}, false
+    }
+
+    last := bars[len(bars)-1]
+    if s.criteria.MinPrice != nil && last.Close < *s.criteria.MinPrice {
+        return Result{}, false
+    }
+    if s.criteria.MaxPrice != nil && last.Close > *s.criteria.MaxPrice {
+        return Result{}, false
+    }
+    if s.criteria.MinVolume != nil && last.Volume < *s.criteria.MinVolume {
+        return Result{}, false
+    }
+
+    // Conditions too long for the loaded bars fail rather than match
+    for _, condition := range s.conditions {
+        values, err := condition.Evaluate(bars)
+        if err != nil || !expr.Truthy(values[len(values)-1]) {
+            return Result{}, false
+        }
+    }
+
+    result := Result{
+        Symbol:      stock.Symbol,
+        CompanyName: stock.CompanyName,
+        Sector:      stock.Sector,
+        Time:        last.Time,
+        Close:       last.Close,
+        Volume:      last.Volume,
+    }
+    if len(bars) > 1 && bars[len(bars)-2].Close != 0 {
+        result.ChangePct = (last.Close/bars[len(bars)-2].Close - 1) * 100
+    }
+
+    if s.patterns != nil {
+        for _, event := range patterns.Recent(bars, patterns.DefaultOptions(), s.criteria.PatternBars) {
+            if s.patterns[event.Pattern] {
+                result.Patterns = append(result.Patterns, event)
+            }
+        }
+        if len(result.Patterns) == 0 {
+            return Result{}, false
+        }
+    }
+
+    if values, err := s.rankBy.Evaluate(bars); err == nil {
+        if score := values[len(values)-1]; !math.IsNaN(score) && !math.IsInf(score, 0) {
+            result.Score = &score
+        }
+    }
+
+    return result, true
+}
+
+// rank orders results by score, those without one last, and numbers them
+func (s *Screen) rank(results []Result) {
+    sort.Slice(results, func(i, j int) bool {
+        a, b := results[i].Score, results[j].Score
+        switch {
+        case a == nil || b == nil:
+            if (a == nil) != (b == nil) {
+                return b == nil
+            }
+        case *a != *b:
+            if s.criteria.Order == "asc" {
+                return *a < *b
+            }
+            return *a > *b
+        }
+        return results[i].Symbol < results[j].Symbol
+    })
+    for i := range results {
+        results[i].Rank = i + 1
+    }
+}
+
+// Channel is the WebSocket subscription on which the reports of a saved
+// screen are pushed
+func Channel(name string) string {
+    return ChannelPrefix + name
+}
+
+// ChannelPrefix starts the subscription names of saved screens, which cannot
+// clash with symbols
+const ChannelPrefix = "screener:"
+
+// savedName is the format of saved screen names
+var savedName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,49}$`)
+
+// Saved is a named screen whose reports are pushed to subscribers of its
+// Channel every Interval while the market is open
+type Saved struct {
+    Name      string    `json:"name"`
+    Criteria  Criteria  `json:"criteria"`
+    Interval  string    `json:"interval"`
+    Active    bool      `json:"active"`
+    CreatedAt time.Time `json:"created_at"`
+    UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Validate checks the name and interval of a saved screen and compiles its
+// criteria
+func (s *Saved) Validate() (*Screen, time.Duration, error) {
+    if !savedName.MatchString(s.Name) {
+        return nil, 0, fmt.Errorf("invalid screen name %q: expected lower-case letters, digits, - and _", s.Name)
+    }
+    interval, err := time.ParseDuration(s.Interval)
+    if err != nil || interval < MinInterval {
+        return nil, 0, fmt.Errorf("invalid interval %q: expected a duration of at least %s", s.Interval, MinInterval)
+    }
+    screen, err := Compile(s.Criteria)
+    if err != nil {
+        return nil, 0, err
+    }
+    return screen, interval, nil
+}
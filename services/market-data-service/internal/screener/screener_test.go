@@ -0,0 +1,187 @@
+package screener
+
+import (
+    "context"
+    "fmt"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/algo-trading/market-data-service/internal/models"
+    "github.com/algo-trading/market-data-service/internal/testutil"
+)
+
+func testUniverse() ([]models.Stock, Loader) {
+    stocks := []models.Stock{
+        {Symbol: "UP", Sector: "Technology"},
+        {Symbol: "DOWN", Sector: "Technology"},
+        {Symbol: "FLAT", Sector: "Banking"},
+        {Symbol: "BIG", Sector: "Banking"},
+        {Symbol: "MISSING", Sector: "Banking"},
+    }
+    series := map[string][]models.OHLCV{
+        "UP":   testutil.Trend("UP", 100, 100, 1, 1000),
+        "DOWN": testutil.Trend("DOWN", 100, 300, -1, 2000),
+        "FLAT": testutil.Trend("FLAT", 100, 50, 0, 3000),
+        "BIG":  testutil.Trend("BIG", 100, 2000, 2, 500),
+    }
+    load := func(symbol, timeframe string, limit int) ([]models.OHLCV, error) {
+        bars, ok := series[symbol]
+        if !ok {
+            return nil, fmt.Errorf("no bars for %s", symbol)
+        }
+        return bars[max(len(bars)-limit, 0):], nil
+    }
+    return stocks, load
+}
+
+func runScreen(t *testing.T, criteria Criteria) *Report {
+    t.Helper()
+    screen, err := Compile(criteria)
+    if err != nil {
+        t.Fatalf("Failed to compile criteria: %v", err)
+    }
+    stocks, load := testUniverse()
+    report, err := screen.Run(context.Background(), stocks, load, 2)
+    if err != nil {
+        t.Fatalf("Failed to run screen: %v", err)
+    }
+    return report
+}
+
+func resultSymbols(report *Report) string {
+    var symbols []string
+    for _, result := range report.Results {
+        symbols = append(symbols, result.Symbol)
+    }
+    return strings.Join(symbols, ",")
+}
+
+func TestScreenRanksByVolume(t *testing.T) {
+    report := runScreen(t, Criteria{})
+
+    if report.Scanned != 5 || report.Matched != 4 {
+        t.Errorf("Expected 5 scanned and 4 matched, got %d and %d", report.Scanned, report.Matched)
+    }
+    if got := resultSymbols(report); got != "FLAT,DOWN,UP,BIG" {
+        t.Errorf("Expected results by descending volume, got %s", got)
+    }
+    for i, result := range report.Results {
+        if result.Rank != i+1 {
+            t.Errorf("Expected %s to be ranked %d, got %d", result.Symbol, i+1, result.Rank)
+        }
+    }
+}
+
+func TestScreenFilters(t *testing.T) {
+    maxPrice := 1000.0
+    minVolume := int64(1000)
+
+    tests := []struct {
+        name     string
+        criteria Criteria
+        expected string
+    }{
+        {"sector", Criteria{Sectors: []string{"technology"}}, "DOWN,UP"},
+        {"symbols", Criteria{Symbols: []string{"big", "up"}}, "UP,BIG"},
+        {"price and volume", Criteria{MaxPrice: &maxPrice, MinVolume: &minVolume, RankBy: "close", Order: "asc"}, "FLAT,UP,DOWN"},
+        {"condition", Criteria{Conditions: []string{"close > sma(close, 20)"}, RankBy: "change(close, 10)"}, "BIG,UP"},
+        {"limit", Criteria{Limit: 2}, "FLAT,DOWN"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := resultSymbols(runScreen(t, tt.criteria)); got != tt.expected {
+                t.Errorf("Expected %s, got %s", tt.expected, got)
+            }
+        })
+    }
+}
+
+func TestScreenConditionTooLongForBars(t *testing.T) {
+    report := runScreen(t, Criteria{Bars: 10, Conditions: []string{"close > sma(close, 50)"}})
+    if report.Matched != 0 {
+        t.Errorf("Expected no matches without enough bars, got %s", resultSymbols(report))
+    }
+}
+
+func TestScreenSkipsPanickingSeries(t *testing.T) {
+    screen, err := Compile(Criteria{})
+    if err != nil {
+        t.Fatalf("Failed to compile criteria: %v", err)
+    }
+    stocks, load := testUniverse()
+    panicky := func(symbol, timeframe string, limit int) ([]models.OHLCV, error) {
+        if symbol == "UP" {
+            panic("corrupt series")
+        }
+        return load(symbol, timeframe, limit)
+    }
+
+    report, err := screen.Run(context.Background(), stocks, panicky, 2)
+    if err != nil {
+        t.Fatalf("Failed to run screen: %v", err)
+    }
+    if got := resultSymbols(report); got != "FLAT,DOWN,BIG" {
+        t.Errorf("Expected the panicking series to be skipped, got %s", got)
+    }
+}
+
+func TestScreenStopsWhenCancelled(t *testing.T) {
+    screen, err := Compile(Criteria{})
+    if err != nil {
+        t.Fatalf("Failed to compile criteria: %v", err)
+    }
+    stocks, load := testUniverse()
+    ctx, cancel := context.WithCancel(context.Background())
+    loaded := 0
+    cancelling := func(symbol, timeframe string, limit int) ([]models.OHLCV, error) {
+        loaded++
+        cancel()
+        return load(symbol, timeframe, limit)
+    }
+
+    if _, err := screen.Run(ctx, stocks, cancelling, 1); err != context.Canceled {
+        t.Errorf("Expected context.Canceled, got %v", err)
+    }
+    if loaded != 1 {
+        t.Errorf("Expected loading to stop after cancellation, loaded %d series", loaded)
+    }
+}
+
+func TestCompileErrors(t *testing.T) {
+    tests := []Criteria{
+        {Timeframe: "1x"},
+        {Bars: MaxBars + 1},
+        {Limit: -1},
+        {Order: "sideways"},
+        {Conditions: []string{"close >"}},
+        {RankBy: "nosuch(3)"},
+        {Patterns: []string{"unicorn"}},
+        {PatternBars: 11, Bars: 10},
+        {PatternBars: MaxPatternBars + 1},
+    }
+
+    for _, criteria := range tests {
+        if _, err := Compile(criteria); err == nil {
+            t.Errorf("Expected an error for %+v", criteria)
+        }
+    }
+}
+
+func TestSavedValidate(t *testing.T) {
+    valid := Saved{Name: "oversold-tech", Interval: "5m"}
+    if _, interval, err := valid.Validate(); err != nil || interval != 5*time.Minute {
+        t.Errorf("Expected a valid screen every 5m, got %v and %v", interval, err)
+    }
+
+    for _, saved := range []Saved{
+        {Name: "Oversold Tech", Interval: "5m"},
+        {Name: "fast", Interval: "10s"},
+        {Name: "broken", Interval: "5m", Criteria: Criteria{Order: "up"}},
+    } {
+        if _, _, err := saved.Validate(); err == nil {
+            t.Errorf("Expected an error for %+v", saved)
+        }
+    }
+}
@@ -0,0 +1,150 @@
+package storage
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log"
+    "strings"
+    "time"
+
+    "golang.org/x/sync/singleflight"
+)
+
+// ErrCacheMiss is returned when a key is not present in the cache. Any other
+// error from a cache read means Redis itself failed.
+var ErrCacheMiss = errors.New("cache miss")
+
+// Cache names, used as key prefixes and to look up TTLs
+const (
+    CacheCurrentPrice = "current_price"
+    CacheMarketStatus = "market_status"
+    CacheIndicator    = "indicator"
+    CacheWSSession    = "ws_session"
+    CacheStocks       = "stocks"
+    CacheOptionChain  = "option_chain"
+)
+
+// DefaultCacheTTLs are the TTLs used unless overridden with SetCacheTTL
+var DefaultCacheTTLs = map[string]time.Duration{
+    CacheCurrentPrice: 30 * time.Second,
+    CacheMarketStatus: 1 * time.Minute,
+    CacheIndicator:    5 * time.Minute,
+    CacheWSSession:    1 * time.Hour,
+    CacheStocks:       5 * time.Minute,
+    CacheOptionChain:  1 * time.Minute,
+}
+
+// defaultCacheTTL applies to caches without a configured TTL
+const defaultCacheTTL = 1 * time.Minute
+
+// redisDownBackoff is how long caches bypass Redis after it fails, so that
+// requests degrade to the database instead of waiting on Redis timeouts
+const redisDownBackoff = 5 * time.Second
+
+// cacheLoadTimeout bounds a shared load, which runs detached from the
+// context of the caller that started it
+const cacheLoadTimeout = 10 * time.Second
+
+// Cache is a typed read-through/write-through cache on top of Redis. Keys are
+// built from the cache name and key parts, e.g. indicator:TCS:1m:rsi.
+type Cache[T any] struct {
+    redis *RedisClient
+    name  string
+    group singleflight.Group
+}
+
+func NewCache[T any](redisClient *RedisClient, name string) *Cache[T] {
+    return &Cache[T]{
+        redis: redisClient,
+        name:  name,
+    }
+}
+
+// Key builds the Redis key for the given parts
+func (c *Cache[T]) Key(parts ...string) string {
+    if len(parts) == 0 {
+        return c.name
+    }
+    return c.name + ":" + strings.Join(parts, ":")
+}
+
+// Get returns the cached value, ErrCacheMiss if absent, or the Redis error
+func (c *Cache[T]) Get(ctx context.Context, key string) (T, error) {
+    var value T
+    if c.redis.isDown() {
+        return value, fmt.Errorf("redis unavailable, skipping read of %s", key)
+    }
+
+    err := c.redis.Get(ctx, key, &value)
+    if err != nil && !errors.Is(err, ErrCacheMiss) {
+        c.redis.markDown(err)
+    }
+    return value, err
+}
+
+// Set stores the value with the cache's TTL
+func (c *Cache[T]) Set(ctx context.Context, key string, value T) error {
+    if c.redis.isDown() {
+        return fmt.Errorf("redis unavailable, skipping write of %s", key)
+    }
+
+    if err := c.redis.Set(ctx, key, value, c.redis.CacheTTL(c.name)); err != nil {
+        c.redis.markDown(err)
+        return err
+    }
+    return nil
+}
+
+// Invalidate removes the key from the cache
+func (c *Cache[T]) Invalidate(ctx context.Context, key string) error {
+    return c.redis.Del(ctx, key)
+}
+
+// GetOrLoad returns the cached value or calls load and caches its result.
+// Concurrent misses for the same key share a single load, which is not
+// cancelled when the caller that started it goes away. When Redis is
+// unavailable the value is loaded directly so callers only see load errors.
+func (c *Cache[T]) GetOrLoad(ctx context.Context, key string, load func(ctx context.Context) (T, error)) (T, error) {
+    value, err := c.Get(ctx, key)
+    if err == nil {
+        return value, nil
+    }
+    if !errors.Is(err, ErrCacheMiss) {
+        log.Printf("Cache read for %s failed, loading from source: %v", key, err)
+        return load(ctx)
+    }
+
+    result, err, _ := c.group.Do(key, func() (interface{}, error) {
+        loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cacheLoadTimeout)
+        defer cancel()
+
+        loaded, err := load(loadCtx)
+        if err != nil {
+            return loaded, err
+        }
+        if err := c.Set(loadCtx, key, loaded); err != nil {
+            log.Printf("Failed to cache %s: %v", key, err)
+        }
+        return loaded, nil
+    })
+    if err != nil {
+        var zero T
+        return zero, err
+    }
+    return result.(T), nil
+}
+
+// Store writes the value to the source of truth with save and then updates
+// the cache. A cache failure is logged but does not fail the write.
+func (c *Cache[T]) Store(ctx context.Context, key string, value T, save func(ctx context.Context, value T) error) error {
+    if err := save(ctx, value); err != nil {
+        return err
+    }
+
+    if err := c.Set(ctx, key, value); err != nil {
+        log.Printf("Failed to cache %s, invalidating: %v", key, err)
+        c.Invalidate(ctx, key)
+    }
+    return nil
+}
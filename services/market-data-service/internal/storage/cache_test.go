@@ -0,0 +1,162 @@
+package storage
+
+import (
+    "context"
+    "errors"
+    "sync"
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+func TestCacheMissIsTyped(t *testing.T) {
+    client, _ := newTestRedisClient(t)
+
+    _, err := client.GetCurrentPrice(context.Background(), "TCS")
+    if !errors.Is(err, ErrCacheMiss) {
+        t.Errorf("Expected ErrCacheMiss, got %v", err)
+    }
+
+    if err := client.CacheCurrentPrice(context.Background(), "TCS", 3500.5); err != nil {
+        t.Fatalf("Failed to cache price: %v", err)
+    }
+    price, err := client.GetCurrentPrice(context.Background(), "TCS")
+    if err != nil || price != 3500.5 {
+        t.Errorf("Expected cached price 3500.5, got %f (%v)", price, err)
+    }
+}
+
+func TestCacheTTLConfig(t *testing.T) {
+    client, server := newTestRedisClient(t)
+    client.SetCacheTTL(CacheCurrentPrice, 2*time.Second)
+
+    if err := client.CacheCurrentPrice(context.Background(), "INFY", 1500); err != nil {
+        t.Fatalf("Failed to cache price: %v", err)
+    }
+    if ttl := server.TTL("current_price:INFY"); ttl != 2*time.Second {
+        t.Errorf("Expected TTL 2s, got %s", ttl)
+    }
+
+    server.FastForward(3 * time.Second)
+    if _, err := client.GetCurrentPrice(context.Background(), "INFY"); !errors.Is(err, ErrCacheMiss) {
+        t.Errorf("Expected expired key to be a miss, got %v", err)
+    }
+}
+
+func TestCacheGetOrLoadSingleflight(t *testing.T) {
+    client, _ := newTestRedisClient(t)
+    cache := NewCache[[]string](client, CacheStocks)
+
+    var loads int32
+    load := func(ctx context.Context) ([]string, error) {
+        atomic.AddInt32(&loads, 1)
+        time.Sleep(50 * time.Millisecond)
+        return []string{"TCS", "INFY"}, nil
+    }
+
+    var wg sync.WaitGroup
+    for i := 0; i < 10; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            value, err := cache.GetOrLoad(context.Background(), cache.Key(), load)
+            if err != nil || len(value) != 2 {
+                t.Errorf("Unexpected result %v (%v)", value, err)
+            }
+        }()
+    }
+    wg.Wait()
+
+    if loads != 1 {
+        t.Errorf("Expected a single load for concurrent misses, got %d", loads)
+    }
+
+    // Subsequent reads are served from Redis
+    if _, err := cache.GetOrLoad(context.Background(), cache.Key(), load); err != nil {
+        t.Fatalf("Unexpected error: %v", err)
+    }
+    if loads != 1 {
+        t.Errorf("Expected cached value to be used, got %d loads", loads)
+    }
+}
+
+func TestCacheDegradesWhenRedisDown(t *testing.T) {
+    client, server := newTestRedisClient(t)
+    cache := NewCache[int](client, "answer")
+    server.Close()
+
+    var loads int
+    load := func(ctx context.Context) (int, error) {
+        loads++
+        return 42, nil
+    }
+
+    for i := 0; i < 2; i++ {
+        value, err := cache.GetOrLoad(context.Background(), cache.Key(), load)
+        if err != nil || value != 42 {
+            t.Errorf("Expected fallback value 42, got %d (%v)", value, err)
+        }
+    }
+    if loads != 2 {
+        t.Errorf("Expected every read to hit the source while Redis is down, got %d", loads)
+    }
+
+    saved := false
+    err := cache.Store(context.Background(), cache.Key(), 7, func(ctx context.Context, value int) error {
+        saved = true
+        return nil
+    })
+    if err != nil || !saved {
+        t.Errorf("Expected write-through to succeed without Redis, got %v", err)
+    }
+}
+
+func TestCacheOnlyConnectionErrorsMarkRedisDown(t *testing.T) {
+    client, server := newTestRedisClient(t)
+    cache := NewCache[int](client, "answer")
+
+    server.Set(cache.Key(), "not a number")
+    if _, err := cache.Get(context.Background(), cache.Key()); err == nil {
+        t.Fatalf("Expected a decode error")
+    }
+
+    ctx, cancel := context.WithCancel(context.Background())
+    cancel()
+    if _, err := cache.Get(ctx, cache.Key()); err == nil {
+        t.Fatalf("Expected a cancellation error")
+    }
+
+    if client.isDown() {
+        t.Errorf("Expected decode and cancellation errors to leave Redis marked up")
+    }
+
+    server.Close()
+    if _, err := cache.Get(context.Background(), cache.Key()); err == nil {
+        t.Fatalf("Expected a connection error")
+    }
+    if !client.isDown() {
+        t.Errorf("Expected a connection error to mark Redis down")
+    }
+}
+
+func TestCacheGetOrLoadOutlivesCaller(t *testing.T) {
+    client, _ := newTestRedisClient(t)
+    cache := NewCache[int](client, "answer")
+
+    ctx, cancel := context.WithCancel(context.Background())
+    load := func(loadCtx context.Context) (int, error) {
+        // The caller gives up while the shared load is still running
+        cancel()
+        if err := loadCtx.Err(); err != nil {
+            return 0, err
+        }
+        return 42, nil
+    }
+
+    if value, err := cache.GetOrLoad(ctx, cache.Key(), load); err != nil || value != 42 {
+        t.Fatalf("Expected the load to complete, got %d (%v)", value, err)
+    }
+    if value, err := cache.Get(context.Background(), cache.Key()); err != nil || value != 42 {
+        t.Errorf("Expected the loaded value to be cached, got %d (%v)", value, err)
+    }
+}
@@ -2,13 +2,38 @@ package storage
 
 import (
     "database/sql"
+    "encoding/json"
+    "errors"
     "fmt"
     "time"
 
-    _ "github.com/lib/pq"
+    "github.com/lib/pq"
+    "github.com/algo-trading/market-data-service/internal/alerts"
+    "github.com/algo-trading/market-data-service/internal/depth"
+    "github.com/algo-trading/market-data-service/internal/derivatives"
     "github.com/algo-trading/market-data-service/internal/models"
+    "github.com/algo-trading/market-data-service/internal/screener"
+    "github.com/algo-trading/market-data-service/internal/universe"
 )
 
+var (
+    // ErrNotFound is returned when a stock or watchlist does not exist
+    ErrNotFound = errors.New("not found")
+    // ErrExists is returned when creating a stock or watchlist that exists
+    ErrExists = errors.New("already exists")
+)
+
+// Postgres error codes mapped to ErrExists and ErrNotFound
+const (
+    uniqueViolation     = "23505"
+    foreignKeyViolation = "23503"
+)
+
+func isPQError(err error, code string) bool {
+    var pqErr *pq.Error
+    return errors.As(err, &pqErr) && string(pqErr.Code) == code
+}
+
 type Database struct {
     db *sql.DB
 }
@@ -87,7 +112,7 @@ func (d *Database) GetStock(symbol string) (*models.Stock, error) {
     
     if err != nil {
         if err == sql.ErrNoRows {
-            return nil, fmt.Errorf("stock %s not found", symbol)
+            return nil, fmt.Errorf("stock %s %w", symbol, ErrNotFound)
         }
         return nil, fmt.Errorf("failed to get stock: %w", err)
     }
@@ -95,6 +120,64 @@ func (d *Database) GetStock(symbol string) (*models.Stock, error) {
     return &stock, nil
 }
 
+// CreateStock inserts a stock, filling in its ID and timestamps
+func (d *Database) CreateStock(stock *models.Stock) error {
+    query := `
+        INSERT INTO market_data.stocks (symbol, company_name, sector, market_cap, exchange)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, created_at, updated_at
+    `
+    
+    err := d.db.QueryRow(query, stock.Symbol, stock.CompanyName, stock.Sector,
+        stock.MarketCap, stock.Exchange).Scan(&stock.ID, &stock.CreatedAt, &stock.UpdatedAt)
+    
+    if err != nil {
+        if isPQError(err, uniqueViolation) {
+            return fmt.Errorf("stock %s %w", stock.Symbol, ErrExists)
+        }
+        return fmt.Errorf("failed to insert stock: %w", err)
+    }
+
+    return nil
+}
+
+// UpdateStock updates the reference data of a stock by symbol
+func (d *Database) UpdateStock(stock *models.Stock) error {
+    query := `
+        UPDATE market_data.stocks
+        SET company_name = $2, sector = $3, market_cap = $4, exchange = $5, updated_at = NOW()
+        WHERE symbol = $1
+        RETURNING id, created_at, updated_at
+    `
+    
+    err := d.db.QueryRow(query, stock.Symbol, stock.CompanyName, stock.Sector,
+        stock.MarketCap, stock.Exchange).Scan(&stock.ID, &stock.CreatedAt, &stock.UpdatedAt)
+    
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return fmt.Errorf("stock %s %w", stock.Symbol, ErrNotFound)
+        }
+        return fmt.Errorf("failed to update stock: %w", err)
+    }
+
+    return nil
+}
+
+// DeleteStock deletes a stock, removing it from all watchlists. Its market
+// data history is kept.
+func (d *Database) DeleteStock(symbol string) error {
+    result, err := d.db.Exec(`DELETE FROM market_data.stocks WHERE symbol = $1`, symbol)
+    if err != nil {
+        return fmt.Errorf("failed to delete stock: %w", err)
+    }
+
+    if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+        return fmt.Errorf("stock %s %w", symbol, ErrNotFound)
+    }
+
+    return nil
+}
+
 // OHLCV operations
 func (d *Database) InsertOHLCV(ohlcv *models.OHLCV) error {
     query := `
@@ -206,6 +289,396 @@ func (d *Database) GetTicks(symbol string, start, end time.Time, limit int) ([]m
     return ticks, nil
 }
 
+// Market depth operations
+func (d *Database) InsertDepthSnapshot(snapshot *depth.Depth) error {
+    query := `
+        INSERT INTO market_data.depth_snapshots (time, symbol, bids, asks)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (time, symbol) DO UPDATE SET
+            bids = EXCLUDED.bids,
+            asks = EXCLUDED.asks
+    `
+    
+    bids, err := json.Marshal(snapshot.Bids)
+    if err != nil {
+        return fmt.Errorf("failed to marshal bids: %w", err)
+    }
+    asks, err := json.Marshal(snapshot.Asks)
+    if err != nil {
+        return fmt.Errorf("failed to marshal asks: %w", err)
+    }
+    
+    _, err = d.db.Exec(query, snapshot.Time, snapshot.Symbol, bids, asks)
+    
+    if err != nil {
+        return fmt.Errorf("failed to insert depth snapshot: %w", err)
+    }
+
+    return nil
+}
+
+func (d *Database) GetDepthSnapshots(symbol string, start, end time.Time, limit int) ([]depth.Depth, error) {
+    query := `
+        SELECT time, symbol, bids, asks
+        FROM market_data.depth_snapshots
+        WHERE symbol = $1 AND time >= $2 AND time <= $3
+        ORDER BY time DESC
+        LIMIT $4
+    `
+    
+    rows, err := d.db.Query(query, symbol, start, end, limit)
+    if err != nil {
+        return nil, fmt.Errorf("failed to query depth snapshots: %w", err)
+    }
+    defer rows.Close()
+
+    var snapshots []depth.Depth
+    for rows.Next() {
+        var snapshot depth.Depth
+        var bids, asks []byte
+        if err := rows.Scan(&snapshot.Time, &snapshot.Symbol, &bids, &asks); err != nil {
+            return nil, fmt.Errorf("failed to scan depth snapshot row: %w", err)
+        }
+        if err := json.Unmarshal(bids, &snapshot.Bids); err != nil {
+            return nil, fmt.Errorf("failed to unmarshal bids: %w", err)
+        }
+        if err := json.Unmarshal(asks, &snapshot.Asks); err != nil {
+            return nil, fmt.Errorf("failed to unmarshal asks: %w", err)
+        }
+        snapshots = append(snapshots, snapshot)
+    }
+
+    if err = rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating depth snapshots: %w", err)
+    }
+
+    return snapshots, nil
+}
+
+// Derivatives operations
+func (d *Database) UpsertInstruments(instruments []derivatives.Instrument) error {
+    query := `
+        INSERT INTO market_data.instruments (symbol, underlying, instrument_type, expiry, strike, lot_size, exchange)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        ON CONFLICT (symbol) DO UPDATE SET
+            lot_size = EXCLUDED.lot_size,
+            updated_at = NOW()
+    `
+    
+    tx, err := d.db.Begin()
+    if err != nil {
+        return fmt.Errorf("failed to begin transaction: %w", err)
+    }
+    defer tx.Rollback()
+    
+    stmt, err := tx.Prepare(query)
+    if err != nil {
+        return fmt.Errorf("failed to prepare instrument insert: %w", err)
+    }
+    defer stmt.Close()
+    
+    for _, instrument := range instruments {
+        var strike *float64
+        if instrument.IsOption() {
+            strike = &instrument.Strike
+        }
+        _, err := stmt.Exec(instrument.Symbol, instrument.Underlying, instrument.Type,
+            instrument.Expiry, strike, instrument.LotSize, instrument.Exchange)
+        if err != nil {
+            return fmt.Errorf("failed to insert instrument %s: %w", instrument.Symbol, err)
+        }
+    }
+    
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("failed to commit instruments: %w", err)
+    }
+
+    return nil
+}
+
+// GetExpiries returns the option expiries of an underlying on or after from
+func (d *Database) GetExpiries(underlying string, from time.Time) ([]time.Time, error) {
+    query := `
+        SELECT DISTINCT expiry
+        FROM market_data.instruments
+        WHERE underlying = $1 AND instrument_type IN ('CE', 'PE') AND expiry >= $2
+        ORDER BY expiry
+    `
+    
+    rows, err := d.db.Query(query, underlying, derivatives.ExpiryDate(from))
+    if err != nil {
+        return nil, fmt.Errorf("failed to query expiries: %w", err)
+    }
+    defer rows.Close()
+
+    var expiries []time.Time
+    for rows.Next() {
+        var expiry time.Time
+        if err := rows.Scan(&expiry); err != nil {
+            return nil, fmt.Errorf("failed to scan expiry row: %w", err)
+        }
+        expiries = append(expiries, derivatives.ExpiryDate(expiry))
+    }
+
+    if err = rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating expiries: %w", err)
+    }
+
+    return expiries, nil
+}
+
+func (d *Database) InsertOpenInterest(points []derivatives.OpenInterest) error {
+    query := `
+        INSERT INTO market_data.open_interest (time, symbol, open_interest, volume, price, iv)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (time, symbol) DO UPDATE SET
+            open_interest = EXCLUDED.open_interest,
+            volume = EXCLUDED.volume,
+            price = EXCLUDED.price,
+            iv = EXCLUDED.iv
+    `
+    
+    tx, err := d.db.Begin()
+    if err != nil {
+        return fmt.Errorf("failed to begin transaction: %w", err)
+    }
+    defer tx.Rollback()
+    
+    stmt, err := tx.Prepare(query)
+    if err != nil {
+        return fmt.Errorf("failed to prepare open interest insert: %w", err)
+    }
+    defer stmt.Close()
+    
+    for _, point := range points {
+        _, err := stmt.Exec(point.Time, point.Symbol, point.OpenInterest, point.Volume, point.Price, point.IV)
+        if err != nil {
+            return fmt.Errorf("failed to insert open interest for %s: %w", point.Symbol, err)
+        }
+    }
+    
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("failed to commit open interest: %w", err)
+    }
+
+    return nil
+}
+
+func (d *Database) GetOpenInterest(symbol string, start, end time.Time, limit int) ([]derivatives.OpenInterest, error) {
+    query := `
+        SELECT time, symbol, open_interest, volume, price, iv
+        FROM market_data.open_interest
+        WHERE symbol = $1 AND time >= $2 AND time <= $3
+        ORDER BY time DESC
+        LIMIT $4
+    `
+    
+    rows, err := d.db.Query(query, symbol, start, end, limit)
+    if err != nil {
+        return nil, fmt.Errorf("failed to query open interest: %w", err)
+    }
+    defer rows.Close()
+
+    var points []derivatives.OpenInterest
+    for rows.Next() {
+        var point derivatives.OpenInterest
+        err := rows.Scan(&point.Time, &point.Symbol, &point.OpenInterest, &point.Volume, &point.Price, &point.IV)
+        if err != nil {
+            return nil, fmt.Errorf("failed to scan open interest row: %w", err)
+        }
+        points = append(points, point)
+    }
+
+    if err = rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating open interest: %w", err)
+    }
+
+    return points, nil
+}
+
+// Watchlist operations
+const watchlistQuery = `
+    SELECT w.id, w.name, w.description, w.active, w.created_at, w.updated_at,
+        COALESCE(array_agg(s.symbol ORDER BY s.symbol) FILTER (WHERE s.symbol IS NOT NULL), '{}')
+    FROM market_data.watchlists w
+    LEFT JOIN market_data.watchlist_symbols s ON s.watchlist_id = w.id
+`
+
+func scanWatchlist(row interface{ Scan(...interface{}) error }) (universe.Watchlist, error) {
+    var watchlist universe.Watchlist
+    err := row.Scan(&watchlist.ID, &watchlist.Name, &watchlist.Description, &watchlist.Active,
+        &watchlist.CreatedAt, &watchlist.UpdatedAt, pq.Array(&watchlist.Symbols))
+    return watchlist, err
+}
+
+func (d *Database) GetWatchlists() ([]universe.Watchlist, error) {
+    rows, err := d.db.Query(watchlistQuery + ` GROUP BY w.id ORDER BY w.name`)
+    if err != nil {
+        return nil, fmt.Errorf("failed to query watchlists: %w", err)
+    }
+    defer rows.Close()
+
+    var watchlists []universe.Watchlist
+    for rows.Next() {
+        watchlist, err := scanWatchlist(rows)
+        if err != nil {
+            return nil, fmt.Errorf("failed to scan watchlist row: %w", err)
+        }
+        watchlists = append(watchlists, watchlist)
+    }
+
+    if err = rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating watchlists: %w", err)
+    }
+
+    return watchlists, nil
+}
+
+func (d *Database) GetWatchlist(name string) (*universe.Watchlist, error) {
+    watchlist, err := scanWatchlist(d.db.QueryRow(watchlistQuery+` WHERE w.name = $1 GROUP BY w.id`, name))
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, fmt.Errorf("watchlist %s %w", name, ErrNotFound)
+        }
+        return nil, fmt.Errorf("failed to get watchlist: %w", err)
+    }
+
+    return &watchlist, nil
+}
+
+// CreateWatchlist inserts a watchlist with its symbols, which must exist in
+// the stocks table
+func (d *Database) CreateWatchlist(watchlist *universe.Watchlist) error {
+    tx, err := d.db.Begin()
+    if err != nil {
+        return fmt.Errorf("failed to begin transaction: %w", err)
+    }
+    defer tx.Rollback()
+    
+    err = tx.QueryRow(`
+        INSERT INTO market_data.watchlists (name, description, active)
+        VALUES ($1, $2, $3)
+        RETURNING id, created_at, updated_at
+    `, watchlist.Name, watchlist.Description, watchlist.Active).Scan(&watchlist.ID, &watchlist.CreatedAt, &watchlist.UpdatedAt)
+    if err != nil {
+        if isPQError(err, uniqueViolation) {
+            return fmt.Errorf("watchlist %s %w", watchlist.Name, ErrExists)
+        }
+        return fmt.Errorf("failed to insert watchlist: %w", err)
+    }
+    
+    if err := addWatchlistSymbols(tx, watchlist.ID, watchlist.Symbols); err != nil {
+        return err
+    }
+    
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("failed to commit watchlist: %w", err)
+    }
+
+    return nil
+}
+
+// UpdateWatchlist updates the description and active flag of a watchlist
+func (d *Database) UpdateWatchlist(watchlist *universe.Watchlist) error {
+    query := `
+        UPDATE market_data.watchlists
+        SET description = $2, active = $3, updated_at = NOW()
+        WHERE name = $1
+    `
+    
+    result, err := d.db.Exec(query, watchlist.Name, watchlist.Description, watchlist.Active)
+    if err != nil {
+        return fmt.Errorf("failed to update watchlist: %w", err)
+    }
+
+    if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+        return fmt.Errorf("watchlist %s %w", watchlist.Name, ErrNotFound)
+    }
+
+    return nil
+}
+
+func (d *Database) DeleteWatchlist(name string) error {
+    result, err := d.db.Exec(`DELETE FROM market_data.watchlists WHERE name = $1`, name)
+    if err != nil {
+        return fmt.Errorf("failed to delete watchlist: %w", err)
+    }
+
+    if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+        return fmt.Errorf("watchlist %s %w", name, ErrNotFound)
+    }
+
+    return nil
+}
+
+// AddWatchlistSymbols adds symbols to a watchlist, ignoring ones already in it
+func (d *Database) AddWatchlistSymbols(name string, symbols []string) error {
+    tx, err := d.db.Begin()
+    if err != nil {
+        return fmt.Errorf("failed to begin transaction: %w", err)
+    }
+    defer tx.Rollback()
+    
+    var id int
+    err = tx.QueryRow(`
+        UPDATE market_data.watchlists SET updated_at = NOW() WHERE name = $1 RETURNING id
+    `, name).Scan(&id)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return fmt.Errorf("watchlist %s %w", name, ErrNotFound)
+        }
+        return fmt.Errorf("failed to get watchlist: %w", err)
+    }
+    
+    if err := addWatchlistSymbols(tx, id, symbols); err != nil {
+        return err
+    }
+    
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("failed to commit watchlist symbols: %w", err)
+    }
+
+    return nil
+}
+
+func addWatchlistSymbols(tx *sql.Tx, watchlistID int, symbols []string) error {
+    query := `
+        INSERT INTO market_data.watchlist_symbols (watchlist_id, symbol)
+        VALUES ($1, $2)
+        ON CONFLICT DO NOTHING
+    `
+    
+    for _, symbol := range symbols {
+        if _, err := tx.Exec(query, watchlistID, symbol); err != nil {
+            if isPQError(err, foreignKeyViolation) {
+                return fmt.Errorf("stock %s %w", symbol, ErrNotFound)
+            }
+            return fmt.Errorf("failed to add %s to watchlist: %w", symbol, err)
+        }
+    }
+
+    return nil
+}
+
+func (d *Database) RemoveWatchlistSymbol(name, symbol string) error {
+    query := `
+        DELETE FROM market_data.watchlist_symbols s
+        USING market_data.watchlists w
+        WHERE s.watchlist_id = w.id AND w.name = $1 AND s.symbol = $2
+    `
+    
+    result, err := d.db.Exec(query, name, symbol)
+    if err != nil {
+        return fmt.Errorf("failed to remove %s from watchlist: %w", symbol, err)
+    }
+
+    if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+        return fmt.Errorf("%s in watchlist %s %w", symbol, name, ErrNotFound)
+    }
+
+    return nil
+}
+
 // Technical Indicators operations
 func (d *Database) InsertTechnicalIndicator(indicator *models.TechnicalIndicator) error {
     query := `
@@ -262,6 +735,276 @@ func (d *Database) GetTechnicalIndicators(symbol, timeframe, indicatorName strin
     return indicators, nil
 }
 
+// Screen operations
+const screenQuery = `
+    SELECT name, criteria, run_interval, active, created_at, updated_at
+    FROM analytics.screens
+`
+
+func scanScreen(row interface{ Scan(...interface{}) error }) (screener.Saved, error) {
+    var screen screener.Saved
+    var criteria []byte
+    err := row.Scan(&screen.Name, &criteria, &screen.Interval, &screen.Active, &screen.CreatedAt, &screen.UpdatedAt)
+    if err != nil {
+        return screen, err
+    }
+    if err := json.Unmarshal(criteria, &screen.Criteria); err != nil {
+        return screen, fmt.Errorf("failed to unmarshal criteria of screen %s: %w", screen.Name, err)
+    }
+    return screen, nil
+}
+
+func (d *Database) GetScreens() ([]screener.Saved, error) {
+    rows, err := d.db.Query(screenQuery + ` ORDER BY name`)
+    if err != nil {
+        return nil, fmt.Errorf("failed to query screens: %w", err)
+    }
+    defer rows.Close()
+
+    var screens []screener.Saved
+    for rows.Next() {
+        screen, err := scanScreen(rows)
+        if err != nil {
+            return nil, fmt.Errorf("failed to scan screen row: %w", err)
+        }
+        screens = append(screens, screen)
+    }
+
+    if err = rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating screens: %w", err)
+    }
+
+    return screens, nil
+}
+
+func (d *Database) GetScreen(name string) (*screener.Saved, error) {
+    screen, err := scanScreen(d.db.QueryRow(screenQuery+` WHERE name = $1`, name))
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, fmt.Errorf("screen %s %w", name, ErrNotFound)
+        }
+        return nil, fmt.Errorf("failed to get screen: %w", err)
+    }
+
+    return &screen, nil
+}
+
+// SaveScreen creates the screen or replaces the one with the same name
+func (d *Database) SaveScreen(screen *screener.Saved) error {
+    criteria, err := json.Marshal(screen.Criteria)
+    if err != nil {
+        return fmt.Errorf("failed to marshal criteria: %w", err)
+    }
+
+    query := `
+        INSERT INTO analytics.screens (name, criteria, run_interval, active)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (name) DO UPDATE SET
+            criteria = EXCLUDED.criteria,
+            run_interval = EXCLUDED.run_interval,
+            active = EXCLUDED.active,
+            updated_at = NOW()
+        RETURNING created_at, updated_at
+    `
+
+    err = d.db.QueryRow(query, screen.Name, criteria, screen.Interval, screen.Active).Scan(&screen.CreatedAt, &screen.UpdatedAt)
+    if err != nil {
+        return fmt.Errorf("failed to save screen: %w", err)
+    }
+
+    return nil
+}
+
+func (d *Database) DeleteScreen(name string) error {
+    result, err := d.db.Exec(`DELETE FROM analytics.screens WHERE name = $1`, name)
+    if err != nil {
+        return fmt.Errorf("failed to delete screen: %w", err)
+    }
+
+    if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+        return fmt.Errorf("screen %s %w", name, ErrNotFound)
+    }
+
+    return nil
+}
+
+// Alert operations
+const alertQuery = `
+    SELECT id, name, symbol, alert_type, timeframe, threshold, reference, expression, period,
+        repeat, channels, status, expires_at, triggered_at, trigger_count, last_value, created_at, updated_at
+    FROM analytics.alerts
+`
+
+func scanAlert(row interface{ Scan(...interface{}) error }) (alerts.Alert, error) {
+    var alert alerts.Alert
+    var channels []byte
+    var lastValue sql.NullFloat64
+    err := row.Scan(&alert.ID, &alert.Name, &alert.Symbol, &alert.Type, &alert.Timeframe, &alert.Threshold,
+        &alert.Reference, &alert.Expression, &alert.Period, &alert.Repeat, &channels, &alert.Status,
+        &alert.ExpiresAt, &alert.TriggeredAt, &alert.TriggerCount, &lastValue, &alert.CreatedAt, &alert.UpdatedAt)
+    if err != nil {
+        return alert, err
+    }
+    if lastValue.Valid {
+        alert.LastValue = &lastValue.Float64
+    }
+    if err := json.Unmarshal(channels, &alert.Channels); err != nil {
+        return alert, fmt.Errorf("failed to unmarshal channels of alert %d: %w", alert.ID, err)
+    }
+    return alert, nil
+}
+
+// GetAlerts returns the alerts, optionally only those with the status or
+// for the symbol
+func (d *Database) GetAlerts(status alerts.Status, symbol string) ([]alerts.Alert, error) {
+    query := alertQuery + `
+        WHERE ($1 = '' OR status = $1) AND ($2 = '' OR symbol = $2)
+        ORDER BY id
+    `
+
+    rows, err := d.db.Query(query, string(status), symbol)
+    if err != nil {
+        return nil, fmt.Errorf("failed to query alerts: %w", err)
+    }
+    defer rows.Close()
+
+    var result []alerts.Alert
+    for rows.Next() {
+        alert, err := scanAlert(rows)
+        if err != nil {
+            return nil, fmt.Errorf("failed to scan alert row: %w", err)
+        }
+        result = append(result, alert)
+    }
+
+    if err = rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating alerts: %w", err)
+    }
+
+    return result, nil
+}
+
+func (d *Database) GetAlert(id int) (*alerts.Alert, error) {
+    alert, err := scanAlert(d.db.QueryRow(alertQuery+` WHERE id = $1`, id))
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return nil, fmt.Errorf("alert %d %w", id, ErrNotFound)
+        }
+        return nil, fmt.Errorf("failed to get alert: %w", err)
+    }
+
+    return &alert, nil
+}
+
+func (d *Database) CreateAlert(alert *alerts.Alert) error {
+    channels, err := json.Marshal(alert.Channels)
+    if err != nil {
+        return fmt.Errorf("failed to marshal channels: %w", err)
+    }
+
+    query := `
+        INSERT INTO analytics.alerts (name, symbol, alert_type, timeframe, threshold, reference,
+            expression, period, repeat, channels, status, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+        RETURNING id, created_at, updated_at
+    `
+
+    err = d.db.QueryRow(query, alert.Name, alert.Symbol, alert.Type, alert.Timeframe, alert.Threshold,
+        alert.Reference, alert.Expression, alert.Period, alert.Repeat, channels, alert.Status, alert.ExpiresAt,
+    ).Scan(&alert.ID, &alert.CreatedAt, &alert.UpdatedAt)
+    if err != nil {
+        return fmt.Errorf("failed to insert alert: %w", err)
+    }
+
+    return nil
+}
+
+func (d *Database) DeleteAlert(id int) error {
+    result, err := d.db.Exec(`DELETE FROM analytics.alerts WHERE id = $1`, id)
+    if err != nil {
+        return fmt.Errorf("failed to delete alert: %w", err)
+    }
+
+    if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+        return fmt.Errorf("alert %d %w", id, ErrNotFound)
+    }
+
+    return nil
+}
+
+// TriggerAlert marks an armed alert triggered at the time. It reports false
+// if the alert was not armed or already triggered at or after the time, so
+// each trigger is notified once however many evaluators see it.
+func (d *Database) TriggerAlert(id int, at time.Time, value float64) (bool, error) {
+    query := `
+        UPDATE analytics.alerts
+        SET status = 'triggered', triggered_at = $2, last_value = $3,
+            trigger_count = trigger_count + 1, updated_at = NOW()
+        WHERE id = $1 AND status = 'armed' AND (triggered_at IS NULL OR triggered_at < $2)
+    `
+
+    result, err := d.db.Exec(query, id, at, value)
+    if err != nil {
+        return false, fmt.Errorf("failed to trigger alert: %w", err)
+    }
+
+    affected, err := result.RowsAffected()
+    if err != nil {
+        return false, fmt.Errorf("failed to trigger alert: %w", err)
+    }
+    return affected > 0, nil
+}
+
+// RearmAlert arms a triggered repeating alert again
+func (d *Database) RearmAlert(id int) error {
+    _, err := d.db.Exec(`
+        UPDATE analytics.alerts SET status = 'armed', updated_at = NOW()
+        WHERE id = $1 AND status = 'triggered' AND repeat
+    `, id)
+    if err != nil {
+        return fmt.Errorf("failed to rearm alert: %w", err)
+    }
+
+    return nil
+}
+
+// ArmAlert arms a triggered or expired alert. Without a new expiry it keeps
+// its current one, unless that has already passed.
+func (d *Database) ArmAlert(id int, expiresAt *time.Time) error {
+    result, err := d.db.Exec(`
+        UPDATE analytics.alerts SET status = 'armed',
+            expires_at = CASE
+                WHEN $2::timestamptz IS NOT NULL THEN $2::timestamptz
+                WHEN status = 'expired' OR expires_at <= NOW() THEN NULL
+                ELSE expires_at
+            END,
+            updated_at = NOW()
+        WHERE id = $1
+    `, id, expiresAt)
+    if err != nil {
+        return fmt.Errorf("failed to arm alert: %w", err)
+    }
+
+    if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+        return fmt.Errorf("alert %d %w", id, ErrNotFound)
+    }
+
+    return nil
+}
+
+// ExpireAlerts marks the alerts past their expiry expired
+func (d *Database) ExpireAlerts(now time.Time) (int64, error) {
+    result, err := d.db.Exec(`
+        UPDATE analytics.alerts SET status = 'expired', updated_at = NOW()
+        WHERE status <> 'expired' AND expires_at <= $1
+    `, now)
+    if err != nil {
+        return 0, fmt.Errorf("failed to expire alerts: %w", err)
+    }
+
+    return result.RowsAffected()
+}
+
 // Health check
 func (d *Database) HealthCheck() error {
     return d.db.Ping()
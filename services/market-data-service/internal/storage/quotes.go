@@ -0,0 +1,184 @@
+package storage
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "strconv"
+    "time"
+
+    "github.com/redis/go-redis/v9"
+    "github.com/algo-trading/market-data-service/internal/calendar"
+    "github.com/algo-trading/market-data-service/internal/models"
+)
+
+// quoteTTL keeps snapshots of symbols that stopped trading from lingering forever
+const quoteTTL = 7 * 24 * time.Hour
+
+// Quote is the level 1 snapshot of a symbol for the current trading day
+type Quote struct {
+    Symbol        string       `json:"symbol"`
+    Date          string       `json:"date"`
+    LastPrice     float64      `json:"last_price"`
+    Open          float64      `json:"open"`
+    High          float64      `json:"high"`
+    Low           float64      `json:"low"`
+    PrevClose     float64      `json:"prev_close,omitempty"`
+    Volume        int64        `json:"volume"`
+    VWAP          float64      `json:"vwap"`
+    ChangePercent float64      `json:"change_percent"`
+    LastTick      *models.Tick `json:"last_tick"`
+}
+
+// updateQuoteScript applies a tick to the snapshot hash in a single step so
+// that concurrent writers and readers never see a partially updated quote.
+// The day's aggregates are reset when the first tick of a new date arrives,
+// carrying the previous last price over as prev_close.
+var updateQuoteScript = redis.NewScript(`
+local key = KEYS[1]
+local date = ARGV[1]
+local price = tonumber(ARGV[2])
+local volume = tonumber(ARGV[3])
+
+local cur = redis.call('HMGET', key, 'date', 'open', 'high', 'low', 'volume', 'pv', 'last', 'prev_close')
+local open, high, low, vol, pv
+local prev = tonumber(cur[8])
+
+if cur[1] ~= date then
+    if cur[7] then
+        prev = tonumber(cur[7])
+    end
+    open, high, low, vol, pv = price, price, price, 0, 0
+else
+    open = tonumber(cur[2])
+    high = math.max(tonumber(cur[3]), price)
+    low = math.min(tonumber(cur[4]), price)
+    vol = tonumber(cur[5])
+    pv = tonumber(cur[6])
+end
+
+vol = vol + volume
+pv = pv + price * volume
+
+local vwap = price
+if vol > 0 then
+    vwap = pv / vol
+end
+
+local change = 0
+if prev and prev > 0 then
+    change = (price - prev) / prev * 100
+end
+
+redis.call('HSET', key,
+    'date', date, 'open', open, 'high', high, 'low', low,
+    'volume', vol, 'pv', pv, 'last', price, 'vwap', vwap,
+    'change_pct', change, 'tick', ARGV[4])
+if prev then
+    redis.call('HSET', key, 'prev_close', prev)
+end
+redis.call('PEXPIRE', key, ARGV[5])
+return 1
+`)
+
+// QuoteStore keeps the latest quote snapshot per symbol in Redis hashes
+type QuoteStore struct {
+    client *redis.Client
+}
+
+func (r *RedisClient) NewQuoteStore() *QuoteStore {
+    return &QuoteStore{client: r.client}
+}
+
+func quoteKey(symbol string) string {
+    return fmt.Sprintf("quote:%s", symbol)
+}
+
+// Update applies a tick to the symbol's snapshot
+func (q *QuoteStore) Update(ctx context.Context, tick *models.Tick) error {
+    data, err := json.Marshal(tick)
+    if err != nil {
+        return fmt.Errorf("failed to marshal tick data: %w", err)
+    }
+
+    date := tick.Time.In(calendar.IST).Format("2006-01-02")
+    err = updateQuoteScript.Run(ctx, q.client, []string{quoteKey(tick.Symbol)},
+        date, tick.Price, tick.Volume, data, quoteTTL.Milliseconds()).Err()
+    if err != nil {
+        return fmt.Errorf("failed to update quote for %s: %w", tick.Symbol, err)
+    }
+
+    return nil
+}
+
+// GetQuotes returns snapshots for the symbols in one round trip. Symbols
+// without a snapshot are omitted from the result.
+func (q *QuoteStore) GetQuotes(ctx context.Context, symbols []string) (map[string]*Quote, error) {
+    pipe := q.client.Pipeline()
+    cmds := make([]*redis.MapStringStringCmd, len(symbols))
+    for i, symbol := range symbols {
+        cmds[i] = pipe.HGetAll(ctx, quoteKey(symbol))
+    }
+    if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+        return nil, fmt.Errorf("failed to get quotes: %w", err)
+    }
+
+    quotes := make(map[string]*Quote, len(symbols))
+    for i, symbol := range symbols {
+        fields := cmds[i].Val()
+        if len(fields) == 0 {
+            continue
+        }
+
+        quote, err := parseQuote(symbol, fields)
+        if err != nil {
+            return nil, err
+        }
+        quotes[symbol] = quote
+    }
+
+    return quotes, nil
+}
+
+func parseQuote(symbol string, fields map[string]string) (*Quote, error) {
+    quote := &Quote{
+        Symbol: symbol,
+        Date:   fields["date"],
+    }
+
+    floats := map[string]*float64{
+        "last":       &quote.LastPrice,
+        "open":       &quote.Open,
+        "high":       &quote.High,
+        "low":        &quote.Low,
+        "prev_close": &quote.PrevClose,
+        "vwap":       &quote.VWAP,
+        "change_pct": &quote.ChangePercent,
+    }
+    for field, dest := range floats {
+        value, ok := fields[field]
+        if !ok {
+            continue
+        }
+        parsed, err := strconv.ParseFloat(value, 64)
+        if err != nil {
+            return nil, fmt.Errorf("invalid %s in quote for %s: %w", field, symbol, err)
+        }
+        *dest = parsed
+    }
+
+    // Lua formats large integers in exponent notation
+    volume, err := strconv.ParseFloat(fields["volume"], 64)
+    if err != nil {
+        return nil, fmt.Errorf("invalid volume in quote for %s: %w", symbol, err)
+    }
+    quote.Volume = int64(volume)
+
+    var tick models.Tick
+    if err := json.Unmarshal([]byte(fields["tick"]), &tick); err != nil {
+        return nil, fmt.Errorf("invalid last tick in quote for %s: %w", symbol, err)
+    }
+    quote.LastTick = &tick
+
+    return quote, nil
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+    "context"
+    "math"
+    "testing"
+    "time"
+
+    "github.com/algo-trading/market-data-service/internal/calendar"
+    "github.com/algo-trading/market-data-service/internal/models"
+)
+
+func TestQuoteStoreAggregatesDay(t *testing.T) {
+    client, _ := newTestRedisClient(t)
+    store := client.NewQuoteStore()
+    ctx := context.Background()
+
+    day1 := time.Date(2026, time.October, 15, 15, 0, 0, 0, calendar.IST)
+    day2 := time.Date(2026, time.October, 16, 9, 20, 0, 0, calendar.IST)
+
+    updates := []*models.Tick{
+        {Time: day1, Symbol: "TCS", Price: 100, Volume: 10},
+        {Time: day2, Symbol: "TCS", Price: 102, Volume: 10},
+        {Time: day2.Add(time.Minute), Symbol: "TCS", Price: 108, Volume: 30},
+        {Time: day2.Add(2 * time.Minute), Symbol: "TCS", Price: 101, Volume: 10},
+    }
+    for _, tick := range updates {
+        if err := store.Update(ctx, tick); err != nil {
+            t.Fatalf("Failed to update quote: %v", err)
+        }
+    }
+
+    quotes, err := store.GetQuotes(ctx, []string{"TCS", "UNKNOWN"})
+    if err != nil {
+        t.Fatalf("Failed to get quotes: %v", err)
+    }
+    if _, exists := quotes["UNKNOWN"]; exists {
+        t.Errorf("Expected no quote for unknown symbol")
+    }
+
+    q := quotes["TCS"]
+    if q == nil {
+        t.Fatalf("Expected quote for TCS")
+    }
+
+    if q.Date != "2026-10-16" || q.Open != 102 || q.High != 108 || q.Low != 101 || q.LastPrice != 101 {
+        t.Errorf("Unexpected day aggregates: %+v", q)
+    }
+    if q.Volume != 50 {
+        t.Errorf("Expected volume to reset on the new day and total 50, got %d", q.Volume)
+    }
+    if q.PrevClose != 100 {
+        t.Errorf("Expected previous close 100, got %f", q.PrevClose)
+    }
+
+    expectedVWAP := (102.0*10 + 108.0*30 + 101.0*10) / 50
+    if math.Abs(q.VWAP-expectedVWAP) > 1e-9 {
+        t.Errorf("Expected VWAP %f, got %f", expectedVWAP, q.VWAP)
+    }
+    if math.Abs(q.ChangePercent-1) > 1e-9 {
+        t.Errorf("Expected change of 1%%, got %f", q.ChangePercent)
+    }
+    if q.LastTick == nil || q.LastTick.Price != 101 {
+        t.Errorf("Expected last tick to be stored, got %+v", q.LastTick)
+    }
+}
@@ -0,0 +1,119 @@
+package storage
+
+import (
+    "context"
+    "fmt"
+    "math/rand"
+    "time"
+
+    "github.com/redis/go-redis/v9"
+)
+
+// slidingWindowScript implements a sliding window log: each allowed request
+// is a sorted set member scored by its timestamp, entries older than the
+// window are discarded, and a request is allowed while fewer than limit
+// entries remain. Time comes from the Redis server so that all instances
+// share one clock. Returns {allowed, remaining, retry_after_ms}.
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local window = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local member = ARGV[3]
+
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+local count = redis.call('ZCARD', key)
+
+if count < limit then
+    redis.call('ZADD', key, now, member)
+    redis.call('PEXPIRE', key, window)
+    return {1, limit - count - 1, 0}
+end
+
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+local retry = tonumber(oldest[2]) + window - now
+if retry < 1 then
+    retry = 1
+end
+return {0, 0, retry}
+`)
+
+// fixedWindowScript increments the counter and sets its expiry in one step
+// so a counter can never be left without a TTL
+var fixedWindowScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+if count == 1 or redis.call('PTTL', KEYS[1]) < 0 then
+    redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return count
+`)
+
+// RateLimitResult describes the outcome of a rate limit check
+type RateLimitResult struct {
+    Allowed    bool
+    Limit      int
+    Remaining  int
+    RetryAfter time.Duration
+}
+
+// RateLimiter is a distributed sliding-window rate limiter shared by all
+// instances through Redis
+type RateLimiter struct {
+    client *redis.Client
+    prefix string
+    limit  int
+    window time.Duration
+}
+
+func (r *RedisClient) NewRateLimiter(prefix string, limit int, window time.Duration) *RateLimiter {
+    return &RateLimiter{
+        client: r.client,
+        prefix: prefix,
+        limit:  limit,
+        window: window,
+    }
+}
+
+// Allow records a request for key if it is within the limit
+func (l *RateLimiter) Allow(ctx context.Context, key string) (*RateLimitResult, error) {
+    member := fmt.Sprintf("%d-%d", time.Now().UnixNano(), rand.Int63())
+    values, err := slidingWindowScript.Run(ctx, l.client, []string{l.key(key)},
+        l.window.Milliseconds(), l.limit, member).Int64Slice()
+    if err != nil {
+        return nil, fmt.Errorf("failed to check rate limit for %s: %w", key, err)
+    }
+
+    return &RateLimitResult{
+        Allowed:    values[0] == 1,
+        Limit:      l.limit,
+        Remaining:  int(values[1]),
+        RetryAfter: time.Duration(values[2]) * time.Millisecond,
+    }, nil
+}
+
+// Wait blocks until a request for key is allowed or ctx is done
+func (l *RateLimiter) Wait(ctx context.Context, key string) error {
+    for {
+        result, err := l.Allow(ctx, key)
+        if err != nil {
+            return err
+        }
+        if result.Allowed {
+            return nil
+        }
+
+        timer := time.NewTimer(result.RetryAfter)
+        select {
+        case <-ctx.Done():
+            timer.Stop()
+            return ctx.Err()
+        case <-timer.C:
+        }
+    }
+}
+
+func (l *RateLimiter) key(key string) string {
+    return fmt.Sprintf("ratelimit:%s:%s", l.prefix, key)
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+    "context"
+    "testing"
+    "time"
+)
+
+func TestRateLimiterSlidingWindow(t *testing.T) {
+    client, _ := newTestRedisClient(t)
+    limiter := client.NewRateLimiter("api", 3, time.Minute)
+    ctx := context.Background()
+
+    for i := 0; i < 3; i++ {
+        result, err := limiter.Allow(ctx, "key-1")
+        if err != nil {
+            t.Fatalf("Failed to check rate limit: %v", err)
+        }
+        if !result.Allowed || result.Remaining != 2-i {
+            t.Errorf("Request %d: expected allowed with %d remaining, got %+v", i, 2-i, result)
+        }
+    }
+
+    result, err := limiter.Allow(ctx, "key-1")
+    if err != nil {
+        t.Fatalf("Failed to check rate limit: %v", err)
+    }
+    if result.Allowed {
+        t.Errorf("Expected 4th request to be limited")
+    }
+    if result.RetryAfter <= 0 || result.RetryAfter > time.Minute {
+        t.Errorf("Expected retry after within the window, got %s", result.RetryAfter)
+    }
+
+    // Keys are limited independently
+    result, err = limiter.Allow(ctx, "key-2")
+    if err != nil || !result.Allowed {
+        t.Errorf("Expected other key to be allowed, got %+v (%v)", result, err)
+    }
+}
+
+func TestRateLimiterWait(t *testing.T) {
+    client, _ := newTestRedisClient(t)
+    limiter := client.NewRateLimiter("provider", 1, 100*time.Millisecond)
+    ctx := context.Background()
+
+    start := time.Now()
+    for i := 0; i < 3; i++ {
+        if err := limiter.Wait(ctx, "mock"); err != nil {
+            t.Fatalf("Failed to wait: %v", err)
+        }
+    }
+    if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+        t.Errorf("Expected calls to be spaced by the window, took %s", elapsed)
+    }
+
+    cancelled, cancel := context.WithCancel(ctx)
+    cancel()
+    if err := limiter.Wait(cancelled, "mock"); err == nil {
+        t.Errorf("Expected error when context is cancelled")
+    }
+}
+
+func TestIsRateLimitedSetsTTL(t *testing.T) {
+    client, server := newTestRedisClient(t)
+    ctx := context.Background()
+
+    for i := 0; i < 2; i++ {
+        limited, err := client.IsRateLimited(ctx, "fixed", 1, time.Minute)
+        if err != nil {
+            t.Fatalf("Failed to check rate limit: %v", err)
+        }
+        if limited != (i == 1) {
+            t.Errorf("Request %d: unexpected limited=%v", i, limited)
+        }
+    }
+
+    if ttl := server.TTL("fixed"); ttl <= 0 {
+        t.Errorf("Expected counter to have a TTL, got %s", ttl)
+    }
+}
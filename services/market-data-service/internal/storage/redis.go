@@ -3,7 +3,13 @@ package storage
 import (
     "context"
     "encoding/json"
+    "errors"
     "fmt"
+    "io"
+    "log"
+    "net"
+    "sync"
+    "sync/atomic"
     "time"
 
     "github.com/redis/go-redis/v9"
@@ -11,6 +17,18 @@ import (
 
 type RedisClient struct {
     client *redis.Client
+
+    // Per-cache TTLs, see DefaultCacheTTLs
+    ttls  map[string]time.Duration
+    ttlMu sync.RWMutex
+
+    // Unix nanoseconds until which caches bypass Redis after a failure
+    downUntil atomic.Int64
+
+    prices     *Cache[float64]
+    status     *Cache[string]
+    indicators *Cache[float64]
+    sessions   *Cache[map[string]interface{}]
 }
 
 func NewRedisClient(host, port string) *RedisClient {
@@ -22,7 +40,63 @@ func NewRedisClient(host, port string) *RedisClient {
         MinIdleConns: 5,
     })
 
-    return &RedisClient{client: rdb}
+    r := &RedisClient{
+        client: rdb,
+        ttls:   make(map[string]time.Duration),
+    }
+    for name, ttl := range DefaultCacheTTLs {
+        r.ttls[name] = ttl
+    }
+
+    r.prices = NewCache[float64](r, CacheCurrentPrice)
+    r.status = NewCache[string](r, CacheMarketStatus)
+    r.indicators = NewCache[float64](r, CacheIndicator)
+    r.sessions = NewCache[map[string]interface{}](r, CacheWSSession)
+
+    return r
+}
+
+// SetCacheTTL overrides the TTL of the named cache
+func (r *RedisClient) SetCacheTTL(name string, ttl time.Duration) {
+    r.ttlMu.Lock()
+    defer r.ttlMu.Unlock()
+
+    r.ttls[name] = ttl
+}
+
+// CacheTTL returns the TTL of the named cache
+func (r *RedisClient) CacheTTL(name string) time.Duration {
+    r.ttlMu.RLock()
+    defer r.ttlMu.RUnlock()
+
+    if ttl, ok := r.ttls[name]; ok {
+        return ttl
+    }
+    return defaultCacheTTL
+}
+
+func (r *RedisClient) isDown() bool {
+    return time.Now().UnixNano() < r.downUntil.Load()
+}
+
+// markDown starts the bypass backoff if err means Redis cannot be reached.
+// Cancelled requests and bad cached values say nothing about Redis health.
+func (r *RedisClient) markDown(err error) {
+    if !isConnectionError(err) {
+        return
+    }
+    if r.downUntil.Swap(time.Now().Add(redisDownBackoff).UnixNano()) < time.Now().UnixNano() {
+        log.Printf("Redis unavailable, bypassing cache for %s: %v", redisDownBackoff, err)
+    }
+}
+
+func isConnectionError(err error) bool {
+    if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+        return false
+    }
+
+    var netErr net.Error
+    return errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, redis.ErrClosed)
 }
 
 func (r *RedisClient) Close() error {
@@ -43,7 +117,7 @@ func (r *RedisClient) Get(ctx context.Context, key string, dest interface{}) err
     val, err := r.client.Get(ctx, key).Result()
     if err != nil {
         if err == redis.Nil {
-            return fmt.Errorf("key %s not found", key)
+            return fmt.Errorf("key %s: %w", key, ErrCacheMiss)
         }
         return fmt.Errorf("failed to get value: %w", err)
     }
@@ -61,46 +135,41 @@ func (r *RedisClient) Exists(ctx context.Context, keys ...string) (int64, error)
 
 // Stock price caching
 func (r *RedisClient) CacheCurrentPrice(ctx context.Context, symbol string, price float64) error {
-    key := fmt.Sprintf("current_price:%s", symbol)
-    return r.Set(ctx, key, price, 30*time.Second) // Cache for 30 seconds
+    return r.prices.Set(ctx, r.prices.Key(symbol), price)
 }
 
 func (r *RedisClient) GetCurrentPrice(ctx context.Context, symbol string) (float64, error) {
-    key := fmt.Sprintf("current_price:%s", symbol)
-    var price float64
-    err := r.Get(ctx, key, &price)
-    return price, err
+    return r.prices.Get(ctx, r.prices.Key(symbol))
 }
 
 // Market status caching
 func (r *RedisClient) CacheMarketStatus(ctx context.Context, status string) error {
-    key := "market_status"
-    return r.Set(ctx, key, status, 1*time.Minute) // Cache for 1 minute
+    return r.status.Set(ctx, r.status.Key(), status)
 }
 
 func (r *RedisClient) GetMarketStatus(ctx context.Context) (string, error) {
-    key := "market_status"
-    var status string
-    err := r.Get(ctx, key, &status)
-    return status, err
+    return r.status.Get(ctx, r.status.Key())
 }
 
 // Technical indicators caching
 func (r *RedisClient) CacheTechnicalIndicator(ctx context.Context, symbol, timeframe, indicator string, value float64) error {
-    key := fmt.Sprintf("indicator:%s:%s:%s", symbol, timeframe, indicator)
-    return r.Set(ctx, key, value, 5*time.Minute) // Cache for 5 minutes
+    return r.indicators.Set(ctx, r.indicators.Key(symbol, timeframe, indicator), value)
 }
 
 func (r *RedisClient) GetTechnicalIndicator(ctx context.Context, symbol, timeframe, indicator string) (float64, error) {
-    key := fmt.Sprintf("indicator:%s:%s:%s", symbol, timeframe, indicator)
-    var value float64
-    err := r.Get(ctx, key, &value)
-    return value, err
+    return r.indicators.Get(ctx, r.indicators.Key(symbol, timeframe, indicator))
 }
 
+// Channel prefixes used to fan real-time data out between replicas
+const (
+    TickChannelPrefix    = "ticks:"
+    OHLCVChannelPrefix   = "ohlcv:"
+    MessageChannelPrefix = "ws:"
+)
+
 // Publish/Subscribe for real-time data
 func (r *RedisClient) PublishTick(ctx context.Context, symbol string, data interface{}) error {
-    channel := fmt.Sprintf("ticks:%s", symbol)
+    channel := TickChannelPrefix + symbol
     jsonData, err := json.Marshal(data)
     if err != nil {
         return fmt.Errorf("failed to marshal tick data: %w", err)
@@ -110,12 +179,12 @@ func (r *RedisClient) PublishTick(ctx context.Context, symbol string, data inter
 }
 
 func (r *RedisClient) SubscribeTicks(ctx context.Context, symbol string) *redis.PubSub {
-    channel := fmt.Sprintf("ticks:%s", symbol)
+    channel := TickChannelPrefix + symbol
     return r.client.Subscribe(ctx, channel)
 }
 
 func (r *RedisClient) PublishOHLCV(ctx context.Context, symbol string, data interface{}) error {
-    channel := fmt.Sprintf("ohlcv:%s", symbol)
+    channel := OHLCVChannelPrefix + symbol
     jsonData, err := json.Marshal(data)
     if err != nil {
         return fmt.Errorf("failed to marshal OHLCV data: %w", err)
@@ -125,21 +194,29 @@ func (r *RedisClient) PublishOHLCV(ctx context.Context, symbol string, data inte
 }
 
 func (r *RedisClient) SubscribeOHLCV(ctx context.Context, symbol string) *redis.PubSub {
-    channel := fmt.Sprintf("ohlcv:%s", symbol)
+    channel := OHLCVChannelPrefix + symbol
     return r.client.Subscribe(ctx, channel)
 }
 
-// Rate limiting
+// PublishMessage publishes an encoded WebSocket message for the clients
+// subscribed to key on every replica
+func (r *RedisClient) PublishMessage(ctx context.Context, key string, message []byte) error {
+    return r.client.Publish(ctx, MessageChannelPrefix+key, message).Err()
+}
+
+// NewPubSub returns a subscription with no channels; channels are added and
+// removed with Subscribe and Unsubscribe on the returned PubSub
+func (r *RedisClient) NewPubSub(ctx context.Context) *redis.PubSub {
+    return r.client.Subscribe(ctx)
+}
+
+// Rate limiting (fixed window). Prefer RateLimiter for a sliding window.
 func (r *RedisClient) IsRateLimited(ctx context.Context, key string, limit int, window time.Duration) (bool, error) {
-    count, err := r.client.Incr(ctx, key).Result()
+    count, err := fixedWindowScript.Run(ctx, r.client, []string{key}, window.Milliseconds()).Int64()
     if err != nil {
         return false, err
     }
 
-    if count == 1 {
-        r.client.Expire(ctx, key, window)
-    }
-
     return count > int64(limit), nil
 }
 
@@ -150,18 +227,13 @@ func (r *RedisClient) HealthCheck(ctx context.Context) error {
 
 // Session storage for WebSocket connections
 func (r *RedisClient) StoreWebSocketSession(ctx context.Context, sessionID string, data map[string]interface{}) error {
-    key := fmt.Sprintf("ws_session:%s", sessionID)
-    return r.Set(ctx, key, data, 1*time.Hour) // Session expires in 1 hour
+    return r.sessions.Set(ctx, r.sessions.Key(sessionID), data)
 }
 
 func (r *RedisClient) GetWebSocketSession(ctx context.Context, sessionID string) (map[string]interface{}, error) {
-    key := fmt.Sprintf("ws_session:%s", sessionID)
-    var data map[string]interface{}
-    err := r.Get(ctx, key, &data)
-    return data, err
+    return r.sessions.Get(ctx, r.sessions.Key(sessionID))
 }
 
 func (r *RedisClient) DeleteWebSocketSession(ctx context.Context, sessionID string) error {
-    key := fmt.Sprintf("ws_session:%s", sessionID)
-    return r.Del(ctx, key)
+    return r.sessions.Invalidate(ctx, r.sessions.Key(sessionID))
 }
@@ -0,0 +1,263 @@
+package storage
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "hash/fnv"
+    "strings"
+    "time"
+
+    "github.com/redis/go-redis/v9"
+    "github.com/algo-trading/market-data-service/internal/models"
+)
+
+// StreamTick is a tick read from a Redis stream together with its entry ID.
+// Tick is nil for pending entries that were trimmed from the stream before
+// they were acknowledged; only their ID is left to acknowledge.
+type StreamTick struct {
+    Stream string
+    ID     string
+    Tick   *models.Tick
+}
+
+// TickStream distributes ticks over Redis Streams. Unlike PublishTick, entries
+// are retained (up to MaxLen per stream) so consumers can acknowledge what they
+// processed and resume after a restart, or replay from any entry ID.
+//
+// Ticks go either to one stream per symbol (shards == 0) or to a fixed number
+// of streams selected by hashing the symbol, which keeps the number of keys a
+// consumer has to read bounded for large universes.
+type TickStream struct {
+    client *redis.Client
+    shards int
+    maxLen int64
+}
+
+func (r *RedisClient) NewTickStream(shards int, maxLen int64) *TickStream {
+    return &TickStream{
+        client: r.client,
+        shards: shards,
+        maxLen: maxLen,
+    }
+}
+
+// StreamKey returns the stream holding ticks for the symbol
+func (s *TickStream) StreamKey(symbol string) string {
+    if s.shards <= 0 {
+        return fmt.Sprintf("stream:ticks:%s", symbol)
+    }
+
+    h := fnv.New32a()
+    h.Write([]byte(symbol))
+    return fmt.Sprintf("stream:ticks:shard:%d", h.Sum32()%uint32(s.shards))
+}
+
+// StreamKeys returns the distinct streams holding ticks for the symbols
+func (s *TickStream) StreamKeys(symbols []string) []string {
+    seen := make(map[string]bool)
+    var keys []string
+    for _, symbol := range symbols {
+        key := s.StreamKey(symbol)
+        if !seen[key] {
+            seen[key] = true
+            keys = append(keys, key)
+        }
+    }
+    return keys
+}
+
+// Append adds a tick to its stream, trimming the stream to roughly maxLen
+// entries, and returns the entry ID
+func (s *TickStream) Append(ctx context.Context, tick *models.Tick) (string, error) {
+    data, err := json.Marshal(tick)
+    if err != nil {
+        return "", fmt.Errorf("failed to marshal tick data: %w", err)
+    }
+
+    args := &redis.XAddArgs{
+        Stream: s.StreamKey(tick.Symbol),
+        Values: map[string]interface{}{"symbol": tick.Symbol, "data": data},
+    }
+    if s.maxLen > 0 {
+        args.MaxLen = s.maxLen
+        args.Approx = true
+    }
+
+    id, err := s.client.XAdd(ctx, args).Result()
+    if err != nil {
+        return "", fmt.Errorf("failed to append tick to stream: %w", err)
+    }
+    return id, nil
+}
+
+// CreateGroup creates a consumer group on the stream starting at startID
+// ("$" for new entries only, "0" for the whole stream). An existing group is
+// left untouched so that it keeps its position.
+func (s *TickStream) CreateGroup(ctx context.Context, stream, group, startID string) error {
+    err := s.client.XGroupCreateMkStream(ctx, stream, group, startID).Err()
+    if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+        return fmt.Errorf("failed to create consumer group %s on %s: %w", group, stream, err)
+    }
+    return nil
+}
+
+// SetGroupID moves a consumer group to startID, e.g. to replay from a known entry
+func (s *TickStream) SetGroupID(ctx context.Context, stream, group, startID string) error {
+    if err := s.client.XGroupSetID(ctx, stream, group, startID).Err(); err != nil {
+        return fmt.Errorf("failed to set consumer group %s on %s to %s: %w", group, stream, startID, err)
+    }
+    return nil
+}
+
+// ReadGroup reads entries for the consumer. With pending set it returns
+// entries already delivered to this consumer but not yet acknowledged, which
+// is how a restarted consumer picks up where it left off; otherwise it waits
+// up to block for new entries.
+func (s *TickStream) ReadGroup(ctx context.Context, group, consumer string, streams []string, count int64, block time.Duration, pending bool) ([]StreamTick, error) {
+    id := ">"
+    if pending {
+        id = "0"
+        block = -1
+    }
+
+    args := make([]string, 0, len(streams)*2)
+    args = append(args, streams...)
+    for range streams {
+        args = append(args, id)
+    }
+
+    result, err := s.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+        Group:    group,
+        Consumer: consumer,
+        Streams:  args,
+        Count:    count,
+        Block:    block,
+    }).Result()
+    if err != nil {
+        if err == redis.Nil {
+            return nil, nil
+        }
+        return nil, fmt.Errorf("failed to read from consumer group %s: %w", group, err)
+    }
+
+    var ticks []StreamTick
+    for _, stream := range result {
+        decoded, err := decodeStreamTicks(stream.Stream, stream.Messages)
+        if err != nil {
+            return nil, err
+        }
+        ticks = append(ticks, decoded...)
+    }
+    return ticks, nil
+}
+
+// Ack acknowledges processed entries so they are not redelivered
+func (s *TickStream) Ack(ctx context.Context, stream, group string, ids ...string) error {
+    if err := s.client.XAck(ctx, stream, group, ids...).Err(); err != nil {
+        return fmt.Errorf("failed to acknowledge entries on %s: %w", stream, err)
+    }
+    return nil
+}
+
+// ClaimStale transfers entries that another consumer has held unacknowledged
+// for longer than minIdle, e.g. because it crashed, to this consumer
+func (s *TickStream) ClaimStale(ctx context.Context, stream, group, consumer string, minIdle time.Duration, count int64) ([]StreamTick, error) {
+    messages, _, err := s.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+        Stream:   stream,
+        Group:    group,
+        Consumer: consumer,
+        MinIdle:  minIdle,
+        Start:    "0",
+        Count:    count,
+    }).Result()
+    if err != nil {
+        return nil, fmt.Errorf("failed to claim stale entries on %s: %w", stream, err)
+    }
+
+    return decodeStreamTicks(stream, messages)
+}
+
+// Replay returns up to count entries after afterID (exclusive) without
+// involving a consumer group. An empty afterID starts from the oldest entry.
+func (s *TickStream) Replay(ctx context.Context, stream, afterID string, count int64) ([]StreamTick, error) {
+    start := "-"
+    if afterID != "" {
+        start = "(" + afterID
+    }
+
+    messages, err := s.client.XRangeN(ctx, stream, start, "+", count).Result()
+    if err != nil {
+        return nil, fmt.Errorf("failed to replay %s from %s: %w", stream, afterID, err)
+    }
+
+    return decodeStreamTicks(stream, messages)
+}
+
+func decodeStreamTicks(stream string, messages []redis.XMessage) ([]StreamTick, error) {
+    ticks := make([]StreamTick, 0, len(messages))
+    for _, msg := range messages {
+        if msg.Values == nil {
+            ticks = append(ticks, StreamTick{Stream: stream, ID: msg.ID})
+            continue
+        }
+
+        data, ok := msg.Values["data"].(string)
+        if !ok {
+            return nil, fmt.Errorf("stream entry %s on %s has no tick data", msg.ID, stream)
+        }
+
+        var tick models.Tick
+        if err := json.Unmarshal([]byte(data), &tick); err != nil {
+            return nil, fmt.Errorf("failed to unmarshal stream entry %s: %w", msg.ID, err)
+        }
+
+        ticks = append(ticks, StreamTick{Stream: stream, ID: msg.ID, Tick: &tick})
+    }
+    return ticks, nil
+}
+
+// Consume delivers entries to handler until ctx is cancelled or handler
+// fails. It first drains entries left pending by a previous run of the same
+// consumer, then waits for new ones. Each entry is acknowledged only after
+// handler succeeds, so nothing is lost if the consumer stops midway. Pending
+// entries trimmed by MAXLEN before they were processed cannot be recovered and
+// are acknowledged without calling handler.
+func (s *TickStream) Consume(ctx context.Context, group, consumer string, streams []string, handler func(StreamTick) error) error {
+    for _, stream := range streams {
+        if err := s.CreateGroup(ctx, stream, group, "$"); err != nil {
+            return err
+        }
+    }
+
+    pending := true
+    for {
+        if err := ctx.Err(); err != nil {
+            return err
+        }
+
+        ticks, err := s.ReadGroup(ctx, group, consumer, streams, 100, 5*time.Second, pending)
+        if err != nil {
+            return err
+        }
+        if pending && len(ticks) == 0 {
+            pending = false
+            continue
+        }
+
+        for _, tick := range ticks {
+            if tick.Tick == nil {
+                if err := s.Ack(ctx, tick.Stream, group, tick.ID); err != nil {
+                    return err
+                }
+                continue
+            }
+            if err := handler(tick); err != nil {
+                return fmt.Errorf("failed to handle stream entry %s: %w", tick.ID, err)
+            }
+            if err := s.Ack(ctx, tick.Stream, group, tick.ID); err != nil {
+                return err
+            }
+        }
+    }
+}
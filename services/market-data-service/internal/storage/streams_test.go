@@ -0,0 +1,195 @@
+package storage
+
+import (
+    "context"
+    "errors"
+    "testing"
+    "time"
+
+    "github.com/alicebob/miniredis/v2"
+    "github.com/redis/go-redis/v9"
+    "github.com/algo-trading/market-data-service/internal/models"
+)
+
+func newTestRedisClient(t *testing.T) (*RedisClient, *miniredis.Miniredis) {
+    server := miniredis.RunT(t)
+    client := NewRedisClient(server.Host(), server.Port())
+    t.Cleanup(func() { client.Close() })
+    return client, server
+}
+
+func appendTicks(t *testing.T, stream *TickStream, symbol string, n int) []string {
+    var ids []string
+    for i := 0; i < n; i++ {
+        id, err := stream.Append(context.Background(), &models.Tick{
+            Time:   time.Now(),
+            Symbol: symbol,
+            Price:  100 + float64(i),
+            Volume: 10,
+        })
+        if err != nil {
+            t.Fatalf("Failed to append tick: %v", err)
+        }
+        ids = append(ids, id)
+    }
+    return ids
+}
+
+func TestTickStreamResumeAfterRestart(t *testing.T) {
+    client, _ := newTestRedisClient(t)
+    stream := client.NewTickStream(0, 1000)
+    ctx := context.Background()
+    key := stream.StreamKey("TCS")
+
+    if err := stream.CreateGroup(ctx, key, "engine", "0"); err != nil {
+        t.Fatalf("Failed to create group: %v", err)
+    }
+    // Creating the group again must not reset its position
+    if err := stream.CreateGroup(ctx, key, "engine", "0"); err != nil {
+        t.Fatalf("Expected existing group to be accepted, got %v", err)
+    }
+
+    appendTicks(t, stream, "TCS", 3)
+
+    ticks, err := stream.ReadGroup(ctx, "engine", "worker-1", []string{key}, 10, -1, false)
+    if err != nil {
+        t.Fatalf("Failed to read group: %v", err)
+    }
+    if len(ticks) != 3 {
+        t.Fatalf("Expected 3 ticks, got %d", len(ticks))
+    }
+    if ticks[0].Tick.Symbol != "TCS" || ticks[0].Tick.Price != 100 {
+        t.Errorf("Unexpected first tick: %+v", ticks[0].Tick)
+    }
+
+    // Only the first tick is processed before the consumer "crashes"
+    if err := stream.Ack(ctx, key, "engine", ticks[0].ID); err != nil {
+        t.Fatalf("Failed to ack: %v", err)
+    }
+
+    pending, err := stream.ReadGroup(ctx, "engine", "worker-1", []string{key}, 10, -1, true)
+    if err != nil {
+        t.Fatalf("Failed to read pending entries: %v", err)
+    }
+    if len(pending) != 2 || pending[0].ID != ticks[1].ID {
+        t.Errorf("Expected the 2 unacknowledged ticks to be redelivered, got %d", len(pending))
+    }
+
+    fresh, err := stream.ReadGroup(ctx, "engine", "worker-1", []string{key}, 10, -1, false)
+    if err != nil {
+        t.Fatalf("Failed to read group: %v", err)
+    }
+    if len(fresh) != 0 {
+        t.Errorf("Expected no new ticks, got %d", len(fresh))
+    }
+}
+
+func TestTickStreamReplay(t *testing.T) {
+    client, _ := newTestRedisClient(t)
+    stream := client.NewTickStream(0, 0)
+    key := stream.StreamKey("INFY")
+
+    ids := appendTicks(t, stream, "INFY", 5)
+
+    ticks, err := stream.Replay(context.Background(), key, ids[1], 10)
+    if err != nil {
+        t.Fatalf("Failed to replay: %v", err)
+    }
+    if len(ticks) != 3 || ticks[0].ID != ids[2] {
+        t.Errorf("Expected replay to start after %s, got %d ticks", ids[1], len(ticks))
+    }
+
+    all, err := stream.Replay(context.Background(), key, "", 10)
+    if err != nil {
+        t.Fatalf("Failed to replay: %v", err)
+    }
+    if len(all) != 5 {
+        t.Errorf("Expected 5 ticks from the start, got %d", len(all))
+    }
+}
+
+func TestTickStreamTrimming(t *testing.T) {
+    client, server := newTestRedisClient(t)
+    stream := client.NewTickStream(0, 10)
+
+    appendTicks(t, stream, "SBIN", 50)
+
+    entries, err := server.Stream(stream.StreamKey("SBIN"))
+    if err != nil {
+        t.Fatalf("Failed to inspect stream: %v", err)
+    }
+    // MAXLEN ~ allows Redis to keep slightly more than requested
+    if len(entries) >= 50 {
+        t.Errorf("Expected stream to be trimmed, got %d entries", len(entries))
+    }
+}
+
+func TestTickStreamSharding(t *testing.T) {
+    client, _ := newTestRedisClient(t)
+    stream := client.NewTickStream(4, 0)
+
+    symbols := []string{"RELIANCE", "TCS", "HDFCBANK", "INFY", "HINDUNILVR", "ITC", "SBIN", "LT"}
+    keys := stream.StreamKeys(symbols)
+    if len(keys) == 0 || len(keys) > 4 {
+        t.Errorf("Expected between 1 and 4 shard streams, got %d", len(keys))
+    }
+    if stream.StreamKey("TCS") != stream.StreamKey("TCS") {
+        t.Errorf("Expected shard assignment to be stable")
+    }
+}
+
+func TestTickStreamConsumeSkipsTrimmedEntries(t *testing.T) {
+    client, _ := newTestRedisClient(t)
+    stream := client.NewTickStream(0, 0)
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+    key := stream.StreamKey("ITC")
+
+    if err := stream.CreateGroup(ctx, key, "engine", "0"); err != nil {
+        t.Fatalf("Failed to create group: %v", err)
+    }
+    ids := appendTicks(t, stream, "ITC", 3)
+
+    // Deliver everything, then let MAXLEN trim the oldest entry while it is
+    // still pending
+    if _, err := stream.ReadGroup(ctx, "engine", "worker-1", []string{key}, 10, -1, false); err != nil {
+        t.Fatalf("Failed to read group: %v", err)
+    }
+    if err := client.client.XTrimMaxLen(ctx, key, 2).Err(); err != nil {
+        t.Fatalf("Failed to trim stream: %v", err)
+    }
+
+    var handled []string
+    err := stream.Consume(ctx, "engine", "worker-1", []string{key}, func(tick StreamTick) error {
+        handled = append(handled, tick.ID)
+        if len(handled) == 2 {
+            cancel()
+        }
+        return nil
+    })
+    if !errors.Is(err, context.Canceled) {
+        t.Fatalf("Expected Consume to stop on cancellation, got %v", err)
+    }
+    if len(handled) != 2 || handled[0] != ids[1] {
+        t.Errorf("Expected the 2 remaining entries to be handled, got %v", handled)
+    }
+}
+
+func TestDecodeStreamTicksTrimmedEntry(t *testing.T) {
+    // Redis returns pending entries trimmed from the stream with no values
+    messages := []redis.XMessage{
+        {ID: "1-0"},
+        {ID: "2-0", Values: map[string]interface{}{"symbol": "ITC", "data": `{"symbol":"ITC","price":450}`}},
+    }
+
+    ticks, err := decodeStreamTicks("stream:ticks:ITC", messages)
+    if err != nil {
+        t.Fatalf("Expected trimmed entry to be skipped, got %v", err)
+    }
+    if len(ticks) != 2 || ticks[0].Tick != nil || ticks[0].ID != "1-0" {
+        t.Fatalf("Expected trimmed entry to be returned without a tick, got %+v", ticks)
+    }
+    if ticks[1].Tick == nil || ticks[1].Tick.Price != 450 {
+        t.Errorf("Unexpected tick: %+v", ticks[1].Tick)
+    }
+}
@@ -0,0 +1,191 @@
+// Package technicals computes the configured indicators of each symbol and
+// timeframe as bars complete
+package technicals
+
+import (
+    "encoding/json"
+    "fmt"
+    "log"
+    "math"
+    "regexp"
+    "strings"
+    "sync"
+
+    "github.com/algo-trading/market-data-service/internal/models"
+    "github.com/algo-trading/market-data-service/pkg/expr"
+)
+
+// DefaultHistory is the number of bars per series indicators are computed over
+const DefaultHistory = 300
+
+// DefaultSpecs is the indicator set computed unless configured otherwise
+const DefaultSpecs = "sma_20=sma(20);ema_20=ema(20);ema_50=ema(50);rsi_14=rsi(14);" +
+    "macd=macd(12, 26, 9);macd_signal=macd(12, 26, 9).signal;macd_histogram=macd(12, 26, 9).histogram;" +
+    "bb_upper=bollinger_bands(20, 2).upper;bb_middle=bollinger_bands(20, 2);bb_lower=bollinger_bands(20, 2).lower;" +
+    "atr_14=atr(14);vwap=vwap()"
+
+// Spec is an indicator expression stored under Name
+type Spec struct {
+    Name string
+    Expr *expr.Expr
+}
+
+// specName fits analytics.technical_indicators.indicator_name
+var specName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// ParseSpecs parses semicolon separated name=expression entries, e.g.
+// "rsi_14=rsi(14);golden_cross=sma(50) crosses above sma(200)"
+func ParseSpecs(value string) ([]Spec, error) {
+    var specs []Spec
+    seen := make(map[string]bool)
+    for _, entry := range strings.Split(value, ";") {
+        entry = strings.TrimSpace(entry)
+        if entry == "" {
+            continue
+        }
+
+        name, source, found := strings.Cut(entry, "=")
+        name = strings.TrimSpace(name)
+        if !found || !specName.MatchString(name) {
+            return nil, fmt.Errorf("invalid indicator entry %q: expected name=expression with a lower-case name", entry)
+        }
+        if seen[name] {
+            return nil, fmt.Errorf("duplicate indicator %s", name)
+        }
+        seen[name] = true
+
+        e, err := expr.Parse(source)
+        if err != nil {
+            return nil, fmt.Errorf("invalid expression for indicator %s: %w", name, err)
+        }
+        specs = append(specs, Spec{Name: name, Expr: e})
+    }
+
+    return specs, nil
+}
+
+// Loader returns up to limit of the latest stored bars of a series, oldest
+// first
+type Loader func(symbol, timeframe string, limit int) ([]models.OHLCV, error)
+
+// Engine keeps the recent bars of each series and evaluates the specs on
+// every completed bar. Series are locked independently, so a slow seed or
+// evaluation of one does not hold up the others.
+type Engine struct {
+    specs   []Spec
+    history int
+    load    Loader
+    series  map[string]*barSeries
+    mu      sync.Mutex
+}
+
+// barSeries holds the recent bars of a symbol and timeframe
+type barSeries struct {
+    bars   []models.OHLCV
+    seeded bool
+    mu     sync.Mutex
+}
+
+// NewEngine creates an engine. load, if not nil, seeds a series from storage
+// on its first bar so values are available straight after a restart.
+func NewEngine(specs []Spec, history int, load Loader) *Engine {
+    if history <= 0 {
+        history = DefaultHistory
+    }
+    return &Engine{
+        specs:   specs,
+        history: history,
+        load:    load,
+        series:  make(map[string]*barSeries),
+    }
+}
+
+// Specs returns the configured indicators
+func (e *Engine) Specs() []Spec {
+    return e.specs
+}
+
+// Update adds a completed bar and returns the value of each indicator on it.
+// Indicators without a value yet, e.g. during warm-up, are left out, and bars
+// not after the last one of their series are ignored.
+func (e *Engine) Update(bar models.OHLCV) []models.TechnicalIndicator {
+    s := e.seriesOf(bar.Symbol + "|" + bar.Timeframe)
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    if !s.seeded {
+        s.seeded = true
+        if e.load != nil {
+            s.bars = e.seed(bar)
+        }
+    }
+    // Bars replayed after a reconnect are not computed twice
+    bars := s.bars
+    if n := len(bars); n > 0 && !bars[n-1].Time.Before(bar.Time) {
+        return nil
+    }
+    bars = append(bars, bar)
+    if len(bars) > e.history {
+        bars = append(bars[:0:0], bars[len(bars)-e.history:]...)
+    }
+    s.bars = bars
+
+    var values []models.TechnicalIndicator
+    for _, spec := range e.specs {
+        series, err := spec.Expr.Evaluate(bars)
+        if err != nil {
+            // Indicators longer than the bars seen so far fail until enough
+            // bars have completed
+            if len(bars) == e.history {
+                log.Printf("Failed to compute %s for %s %s: %v", spec.Name, bar.Symbol, bar.Timeframe, err)
+            }
+            continue
+        }
+
+        value := series[len(series)-1]
+        if math.IsNaN(value) || math.IsInf(value, 0) {
+            continue
+        }
+        metadata, _ := json.Marshal(map[string]string{"expression": spec.Expr.String()})
+        values = append(values, models.TechnicalIndicator{
+            Time:          bar.Time,
+            Symbol:        bar.Symbol,
+            Timeframe:     bar.Timeframe,
+            IndicatorName: spec.Name,
+            Value:         value,
+            Metadata:      metadata,
+        })
+    }
+
+    return values
+}
+
+// seriesOf returns the series for key, creating it on first use
+func (e *Engine) seriesOf(key string) *barSeries {
+    e.mu.Lock()
+    defer e.mu.Unlock()
+
+    s, exists := e.series[key]
+    if !exists {
+        s = &barSeries{}
+        e.series[key] = s
+    }
+    return s
+}
+
+// seed loads the stored bars of a series that precede bar
+func (e *Engine) seed(bar models.OHLCV) []models.OHLCV {
+    stored, err := e.load(bar.Symbol, bar.Timeframe, e.history)
+    if err != nil {
+        log.Printf("Failed to load %s history for %s: %v", bar.Timeframe, bar.Symbol, err)
+        return nil
+    }
+
+    var bars []models.OHLCV
+    for _, b := range stored {
+        if b.Time.Before(bar.Time) {
+            bars = append(bars, b)
+        }
+    }
+    return bars
+}
@@ -0,0 +1,156 @@
+package technicals
+
+import (
+    "encoding/json"
+    "math"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/algo-trading/market-data-service/internal/models"
+    "github.com/algo-trading/market-data-service/internal/testutil"
+    "github.com/algo-trading/market-data-service/pkg/indicators"
+)
+
+func valueOf(values []models.TechnicalIndicator, name string) (float64, bool) {
+    for _, v := range values {
+        if v.IndicatorName == name {
+            return v.Value, true
+        }
+    }
+    return 0, false
+}
+
+func TestParseSpecs(t *testing.T) {
+    specs, err := ParseSpecs(DefaultSpecs)
+    if err != nil {
+        t.Fatalf("Failed to parse default specs: %v", err)
+    }
+    if len(specs) != 12 || specs[0].Name != "sma_20" {
+        t.Errorf("Unexpected default specs: %d, %s", len(specs), specs[0].Name)
+    }
+
+    specs, err = ParseSpecs(" rsi_14 = rsi(14) ; golden_cross=sma(5) crosses above sma(10); ")
+    if err != nil || len(specs) != 2 || specs[1].Expr.String() != "sma(5) crosses above sma(10)" {
+        t.Fatalf("Unexpected specs %v: %v", specs, err)
+    }
+
+    for value, message := range map[string]string{
+        "RSI=rsi(14)":           "lower-case name",
+        "rsi(14)":               "expected name=expression",
+        "a=rsi(14);a=rsi(7)":    "duplicate indicator a",
+        "bad=rsi(14) <":         "invalid expression for indicator bad",
+        "unknown=frobnicate(3)": "unknown function",
+    } {
+        if _, err := ParseSpecs(value); err == nil || !strings.Contains(err.Error(), message) {
+            t.Errorf("ParseSpecs(%q): expected error containing %q, got %v", value, message, err)
+        }
+    }
+}
+
+func TestEngineUpdate(t *testing.T) {
+    specs, _ := ParseSpecs("rsi_14=rsi(14);ema_10=ema(10);sma_50=sma(50)")
+    engine := NewEngine(specs, 30, nil)
+    bars := testutil.RandomWalk("TCS", 60, 1)
+
+    for i, bar := range bars {
+        values := engine.Update(bar)
+
+        _, hasRSI := valueOf(values, "rsi_14")
+        if hasRSI != (i >= 14) {
+            t.Fatalf("Bar %d: expected rsi_14 present %v", i, i >= 14)
+        }
+        // sma_50 never fits in 30 bars of history
+        if _, ok := valueOf(values, "sma_50"); ok {
+            t.Fatalf("Bar %d: unexpected sma_50", i)
+        }
+
+        if i < 29 {
+            continue
+        }
+        // Values are computed over the last 30 bars
+        window := bars[i-29 : i+1]
+        ema, _ := indicators.CalculateEMA(window, 10)
+        got, _ := valueOf(values, "ema_10")
+        if math.Abs(got-ema[29]) > 1e-9 {
+            t.Fatalf("Bar %d: expected ema_10 %v, got %v", i, ema[29], got)
+        }
+    }
+
+    if values := engine.Update(bars[59]); values != nil {
+        t.Errorf("Expected a repeated bar to be ignored, got %d values", len(values))
+    }
+    other := bars[0]
+    other.Symbol = "INFY"
+    if values := engine.Update(other); len(values) != 0 {
+        t.Errorf("Expected a new symbol to start without history, got %d values", len(values))
+    }
+
+    last := engine.Update(models.OHLCV{Time: bars[59].Time.Add(time.Minute), Symbol: "TCS", Timeframe: "1m", Close: 120, High: 121, Low: 119})
+    if len(last) != 2 || last[0].Symbol != "TCS" || last[0].Timeframe != "1m" {
+        t.Fatalf("Unexpected values %+v", last)
+    }
+    var metadata map[string]string
+    if err := json.Unmarshal(last[0].Metadata, &metadata); err != nil || metadata["expression"] != "rsi(14)" {
+        t.Errorf("Expected expression metadata, got %s", last[0].Metadata)
+    }
+}
+
+func TestEngineSeedsFromStorage(t *testing.T) {
+    specs, _ := ParseSpecs("rsi_14=rsi(14)")
+    bars := testutil.RandomWalk("TCS", 40, 1)
+
+    loads := 0
+    engine := NewEngine(specs, 100, func(symbol, timeframe string, limit int) ([]models.OHLCV, error) {
+        loads++
+        if symbol != "TCS" || timeframe != "1m" || limit != 100 {
+            t.Errorf("Unexpected load of %s %s %d", symbol, timeframe, limit)
+        }
+        // The completed bar is already stored
+        return bars, nil
+    })
+
+    values := engine.Update(bars[39])
+    rsi, _ := indicators.CalculateRSI(bars, 14)
+    got, ok := valueOf(values, "rsi_14")
+    if !ok || math.Abs(got-rsi[39]) > 1e-9 {
+        t.Errorf("Expected rsi_14 %v from stored history, got %v (%v)", rsi[39], got, ok)
+    }
+
+    engine.Update(models.OHLCV{Time: bars[39].Time.Add(time.Minute), Symbol: "TCS", Timeframe: "1m", Close: 100})
+    if loads != 1 {
+        t.Errorf("Expected history to be loaded once, got %d", loads)
+    }
+}
+
+func TestEngineSeedsSeriesIndependently(t *testing.T) {
+    specs, _ := ParseSpecs("rsi_14=rsi(14)")
+    release := make(chan struct{})
+    engine := NewEngine(specs, 100, func(symbol, timeframe string, limit int) ([]models.OHLCV, error) {
+        if symbol == "SLOW" {
+            <-release
+        }
+        return nil, nil
+    })
+
+    slow := make(chan struct{})
+    go func() {
+        defer close(slow)
+        engine.Update(models.OHLCV{Time: testutil.Start, Symbol: "SLOW", Timeframe: "1m", Close: 100})
+    }()
+
+    // A series waiting on storage does not hold up the others
+    done := make(chan struct{})
+    go func() {
+        defer close(done)
+        engine.Update(models.OHLCV{Time: testutil.Start, Symbol: "FAST", Timeframe: "1m", Close: 100})
+    }()
+    select {
+    case <-done:
+    case <-time.After(2 * time.Second):
+        t.Fatal("Expected FAST to be updated while SLOW is seeding")
+    }
+
+    close(release)
+    <-slow
+}
@@ -0,0 +1,66 @@
+// Package testutil provides OHLCV fixtures shared by the tests of several
+// packages
+package testutil
+
+import (
+    "math"
+    "math/rand"
+    "time"
+
+    "github.com/algo-trading/market-data-service/internal/models"
+)
+
+// Start is the time of the first bar of every fixture
+var Start = time.Date(2025, time.October, 14, 9, 15, 0, 0, time.UTC)
+
+// RandomWalk returns n one-minute bars of a random walk starting at 1000
+// with 1% moves. The same seed always gives the same bars. Every 50th bar
+// starting at the 10th is flat, which exercises zero-change and zero-range
+// branches.
+func RandomWalk(symbol string, n int, seed int64) []models.OHLCV {
+    rng := rand.New(rand.NewSource(seed))
+    data := make([]models.OHLCV, n)
+    price := 1000.0
+
+    for i := range data {
+        open := price
+        price *= 1 + 0.01*rng.NormFloat64()
+        high := math.Max(open, price) * (1 + 0.005*rng.Float64())
+        low := math.Min(open, price) * (1 - 0.005*rng.Float64())
+        if i%50 == 10 {
+            price, high, low = open, open, open
+        }
+        data[i] = models.OHLCV{
+            Time:      Start.Add(time.Duration(i) * time.Minute),
+            Symbol:    symbol,
+            Open:      open,
+            High:      high,
+            Low:       low,
+            Close:     price,
+            Volume:    int64(1000 + rng.Intn(5000)),
+            Timeframe: "1m",
+        }
+    }
+    return data
+}
+
+// Trend returns n daily bars whose close moves by step per bar from start.
+// Each bar opens 1 below its close, with the high 1 above and the low 2
+// below it.
+func Trend(symbol string, n int, start, step float64, volume int64) []models.OHLCV {
+    data := make([]models.OHLCV, n)
+    for i := range data {
+        price := start + step*float64(i)
+        data[i] = models.OHLCV{
+            Time:      Start.AddDate(0, 0, i),
+            Symbol:    symbol,
+            Open:      price - 1,
+            High:      price + 1,
+            Low:       price - 2,
+            Close:     price,
+            Volume:    volume,
+            Timeframe: "1d",
+        }
+    }
+    return data
+}
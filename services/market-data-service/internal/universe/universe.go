@@ -0,0 +1,131 @@
+package universe
+
+import (
+    "context"
+    "fmt"
+    "regexp"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+)
+
+// Watchlist is a named list of symbols. The symbols of active watchlists are
+// collected in addition to the statically configured ones.
+type Watchlist struct {
+    ID          int       `json:"id"`
+    Name        string    `json:"name"`
+    Description string    `json:"description"`
+    Active      bool      `json:"active"`
+    Symbols     []string  `json:"symbols"`
+    CreatedAt   time.Time `json:"created_at"`
+    UpdatedAt   time.Time `json:"updated_at"`
+}
+
+var symbolPattern = regexp.MustCompile(`^[A-Z0-9&_-]{1,50}$`)
+
+// NormalizeSymbol upper-cases a symbol and checks that it is a valid exchange
+// symbol, e.g. RELIANCE, M&M or BAJAJ-AUTO
+func NormalizeSymbol(symbol string) (string, error) {
+    symbol = strings.ToUpper(strings.TrimSpace(symbol))
+    if !symbolPattern.MatchString(symbol) {
+        return "", fmt.Errorf("invalid symbol: %q", symbol)
+    }
+    return symbol, nil
+}
+
+// Subscriber starts and stops market data collection for symbols
+type Subscriber interface {
+    Subscribe(ctx context.Context, symbols []string) error
+    Unsubscribe(ctx context.Context, symbols []string) error
+}
+
+// Universe tracks the set of collected symbols, the static symbols plus those
+// of active watchlists, and subscribes or unsubscribes the difference
+// whenever the watchlists change
+type Universe struct {
+    subscriber Subscriber
+    static     []string
+    subscribed map[string]bool
+    mu         sync.Mutex
+}
+
+func New(subscriber Subscriber, static []string) *Universe {
+    return &Universe{
+        subscriber: subscriber,
+        static:     static,
+        subscribed: make(map[string]bool),
+    }
+}
+
+// Symbols returns the subscribed symbols in order
+func (u *Universe) Symbols() []string {
+    u.mu.Lock()
+    defer u.mu.Unlock()
+
+    return sortedKeys(u.subscribed)
+}
+
+// Load reconciles the subscriptions with the watchlists. Symbols are only
+// recorded as subscribed once the subscriber accepted them, so a failed
+// call is retried on the next Load.
+func (u *Universe) Load(ctx context.Context, watchlists []Watchlist) error {
+    u.mu.Lock()
+    defer u.mu.Unlock()
+
+    desired := make(map[string]bool)
+    for _, symbol := range u.static {
+        desired[symbol] = true
+    }
+    for _, watchlist := range watchlists {
+        if !watchlist.Active {
+            continue
+        }
+        for _, symbol := range watchlist.Symbols {
+            desired[symbol] = true
+        }
+    }
+
+    var added, removed []string
+    for symbol := range desired {
+        if !u.subscribed[symbol] {
+            added = append(added, symbol)
+        }
+    }
+    for symbol := range u.subscribed {
+        if !desired[symbol] {
+            removed = append(removed, symbol)
+        }
+    }
+    sort.Strings(added)
+    sort.Strings(removed)
+
+    if len(removed) > 0 {
+        if err := u.subscriber.Unsubscribe(ctx, removed); err != nil {
+            return fmt.Errorf("failed to unsubscribe from %v: %w", removed, err)
+        }
+        for _, symbol := range removed {
+            delete(u.subscribed, symbol)
+        }
+    }
+
+    if len(added) > 0 {
+        if err := u.subscriber.Subscribe(ctx, added); err != nil {
+            return fmt.Errorf("failed to subscribe to %v: %w", added, err)
+        }
+        for _, symbol := range added {
+            u.subscribed[symbol] = true
+        }
+    }
+
+    return nil
+}
+
+func sortedKeys(set map[string]bool) []string {
+    keys := make([]string, 0, len(set))
+    for key := range set {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+    return keys
+}
@@ -0,0 +1,94 @@
+package universe
+
+import (
+    "context"
+    "errors"
+    "reflect"
+    "testing"
+)
+
+type recordingSubscriber struct {
+    subscribed   [][]string
+    unsubscribed [][]string
+    err          error
+}
+
+func (r *recordingSubscriber) Subscribe(ctx context.Context, symbols []string) error {
+    if r.err != nil {
+        return r.err
+    }
+    r.subscribed = append(r.subscribed, symbols)
+    return nil
+}
+
+func (r *recordingSubscriber) Unsubscribe(ctx context.Context, symbols []string) error {
+    r.unsubscribed = append(r.unsubscribed, symbols)
+    return nil
+}
+
+func TestUniverseLoad(t *testing.T) {
+    ctx := context.Background()
+    sub := &recordingSubscriber{}
+    u := New(sub, []string{"RELIANCE", "TCS"})
+
+    if err := u.Load(ctx, nil); err != nil {
+        t.Fatalf("Failed to load universe: %v", err)
+    }
+    if !reflect.DeepEqual(sub.subscribed, [][]string{{"RELIANCE", "TCS"}}) {
+        t.Fatalf("Expected static symbols to be subscribed, got %v", sub.subscribed)
+    }
+
+    watchlists := []Watchlist{
+        {Name: "banks", Active: true, Symbols: []string{"HDFCBANK", "SBIN", "TCS"}},
+        {Name: "paused", Active: false, Symbols: []string{"ITC"}},
+    }
+    if err := u.Load(ctx, watchlists); err != nil {
+        t.Fatalf("Failed to load universe: %v", err)
+    }
+    if !reflect.DeepEqual(sub.subscribed[1], []string{"HDFCBANK", "SBIN"}) {
+        t.Errorf("Expected only new active symbols to be subscribed, got %v", sub.subscribed[1])
+    }
+
+    // Removing SBIN from the watchlist unsubscribes it but keeps the static TCS
+    watchlists[0].Symbols = []string{"HDFCBANK", "TCS"}
+    if err := u.Load(ctx, watchlists); err != nil {
+        t.Fatalf("Failed to load universe: %v", err)
+    }
+    if !reflect.DeepEqual(sub.unsubscribed, [][]string{{"SBIN"}}) || len(sub.subscribed) != 2 {
+        t.Errorf("Expected SBIN to be unsubscribed, got %v / %v", sub.unsubscribed, sub.subscribed)
+    }
+    if symbols := u.Symbols(); !reflect.DeepEqual(symbols, []string{"HDFCBANK", "RELIANCE", "TCS"}) {
+        t.Errorf("Unexpected symbols: %v", symbols)
+    }
+}
+
+func TestUniverseRetriesFailedSubscriptions(t *testing.T) {
+    ctx := context.Background()
+    sub := &recordingSubscriber{err: errors.New("provider not connected")}
+    u := New(sub, []string{"INFY"})
+
+    if err := u.Load(ctx, nil); err == nil {
+        t.Fatalf("Expected subscription error")
+    }
+    if len(u.Symbols()) != 0 {
+        t.Errorf("Expected no symbols after a failed subscription")
+    }
+
+    sub.err = nil
+    if err := u.Load(ctx, nil); err != nil || !reflect.DeepEqual(u.Symbols(), []string{"INFY"}) {
+        t.Errorf("Expected retry to subscribe INFY, got %v (%v)", u.Symbols(), err)
+    }
+}
+
+func TestNormalizeSymbol(t *testing.T) {
+    for input, expected := range map[string]string{"reliance": "RELIANCE", " m&m ": "M&M", "BAJAJ-AUTO": "BAJAJ-AUTO"} {
+        if got, err := NormalizeSymbol(input); err != nil || got != expected {
+            t.Errorf("NormalizeSymbol(%q) = %q, %v", input, got, err)
+        }
+    }
+    for _, input := range []string{"", "TCS;DROP", "A B"} {
+        if _, err := NormalizeSymbol(input); err == nil {
+            t.Errorf("Expected error for %q", input)
+        }
+    }
+}
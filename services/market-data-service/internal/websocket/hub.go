@@ -1,7 +1,6 @@
 package websocket
 
 import (
-    "context"
     "encoding/json"
     "log"
     "net/http"
@@ -9,7 +8,12 @@ import (
     "time"
 
     "github.com/gorilla/websocket"
+    "github.com/algo-trading/market-data-service/internal/alerts"
+    "github.com/algo-trading/market-data-service/internal/depth"
+    "github.com/algo-trading/market-data-service/internal/derivatives"
     "github.com/algo-trading/market-data-service/internal/models"
+    "github.com/algo-trading/market-data-service/internal/screener"
+    "github.com/algo-trading/market-data-service/pkg/patterns"
 )
 
 var upgrader = websocket.Upgrader{
@@ -35,6 +39,13 @@ type Hub struct {
     // Symbol subscriptions
     subscriptions map[string]map[*Client]bool
 
+    // Called when a symbol gains its first subscriber or loses its last one
+    demandHandler func(symbol string)
+
+    // Called instead of broadcasting locally for messages other than ticks
+    // and bars, so they can be fanned out to every replica
+    publisher func(key string, message []byte)
+
     mu sync.RWMutex
 }
 
@@ -58,6 +69,7 @@ func (h *Hub) Run() {
             log.Printf("Client connected: %s", client.id)
 
         case client := <-h.unregister:
+            var released []string
             h.mu.Lock()
             if _, ok := h.clients[client]; ok {
                 delete(h.clients, client)
@@ -69,6 +81,7 @@ func (h *Hub) Run() {
                         delete(clients, client)
                         if len(clients) == 0 {
                             delete(h.subscriptions, symbol)
+                            released = append(released, symbol)
                         }
                     }
                 }
@@ -76,6 +89,10 @@ func (h *Hub) Run() {
                 log.Printf("Client disconnected: %s", client.id)
             }
             h.mu.Unlock()
+            
+            for _, symbol := range released {
+                h.notifyDemand(symbol)
+            }
 
         case message := <-h.broadcast:
             h.mu.RLock()
@@ -92,29 +109,92 @@ func (h *Hub) Run() {
     }
 }
 
-func (h *Hub) Subscribe(client *Client, symbol string) {
+// SetDemandHandler registers a callback invoked, outside the hub lock, when a
+// symbol gains its first subscriber or loses its last one. The handler should
+// check SubscriberCount rather than assume the direction of the change.
+func (h *Hub) SetDemandHandler(handler func(symbol string)) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    
+    h.demandHandler = handler
+}
+
+// SetPublisher routes depth, option chain, pattern, indicator and alert
+// messages through publisher, which is responsible for delivering them back
+// to the hub with BroadcastToSymbol
+func (h *Hub) SetPublisher(publisher func(key string, message []byte)) {
     h.mu.Lock()
     defer h.mu.Unlock()
     
+    h.publisher = publisher
+}
+
+func (h *Hub) Subscribe(client *Client, symbol string) {
+    h.mu.Lock()
+    
     if h.subscriptions[symbol] == nil {
         h.subscriptions[symbol] = make(map[*Client]bool)
     }
     
     h.subscriptions[symbol][client] = true
+    first := len(h.subscriptions[symbol]) == 1
     log.Printf("Client %s subscribed to %s", client.id, symbol)
+    h.mu.Unlock()
+    
+    if first {
+        h.notifyDemand(symbol)
+    }
 }
 
 func (h *Hub) Unsubscribe(client *Client, symbol string) {
     h.mu.Lock()
-    defer h.mu.Unlock()
     
+    released := false
     if clients, exists := h.subscriptions[symbol]; exists {
         delete(clients, client)
         if len(clients) == 0 {
             delete(h.subscriptions, symbol)
+            released = true
         }
         log.Printf("Client %s unsubscribed from %s", client.id, symbol)
     }
+    h.mu.Unlock()
+    
+    if released {
+        h.notifyDemand(symbol)
+    }
+}
+
+// SubscriberCount returns the number of clients subscribed to the symbol
+func (h *Hub) SubscriberCount(symbol string) int {
+    h.mu.RLock()
+    defer h.mu.RUnlock()
+    
+    return len(h.subscriptions[symbol])
+}
+
+func (h *Hub) notifyDemand(symbol string) {
+    h.mu.RLock()
+    handler := h.demandHandler
+    h.mu.RUnlock()
+    
+    if handler != nil {
+        handler(symbol)
+    }
+}
+
+// deliver hands a message to the publisher if one is set, or broadcasts it
+// to the local subscribers of key otherwise
+func (h *Hub) deliver(key string, message []byte) {
+    h.mu.RLock()
+    publisher := h.publisher
+    h.mu.RUnlock()
+    
+    if publisher != nil {
+        publisher(key, message)
+        return
+    }
+    h.BroadcastToSymbol(key, message)
 }
 
 func (h *Hub) BroadcastToSymbol(symbol string, message []byte) {
@@ -350,6 +430,97 @@ func (h *Hub) SendOHLCV(symbol string, ohlcv *models.OHLCV) {
     h.BroadcastToSymbol(symbol, data)
 }
 
+func (h *Hub) SendDepth(symbol string, snapshot *depth.Depth) {
+    msg := models.WebSocketMessage{
+        Type:      "depth",
+        Symbol:    symbol,
+        Data:      snapshot,
+        Timestamp: time.Now(),
+    }
+
+    data, err := json.Marshal(msg)
+    if err != nil {
+        log.Printf("Error marshaling depth message: %v", err)
+        return
+    }
+
+    h.deliver(symbol, data)
+}
+
+// SendOptionChain sends the chain to clients subscribed to its underlying
+func (h *Hub) SendOptionChain(chain *derivatives.Chain) {
+    msg := models.WebSocketMessage{
+        Type:      "option_chain",
+        Symbol:    chain.Underlying,
+        Data:      chain,
+        Timestamp: time.Now(),
+    }
+
+    data, err := json.Marshal(msg)
+    if err != nil {
+        log.Printf("Error marshaling option chain message: %v", err)
+        return
+    }
+
+    h.deliver(chain.Underlying, data)
+}
+
+// SendPattern sends a detected candlestick pattern or chart structure
+func (h *Hub) SendPattern(symbol string, event patterns.Event) {
+    msg := models.WebSocketMessage{
+        Type:      "pattern",
+        Symbol:    symbol,
+        Data:      event,
+        Timestamp: time.Now(),
+    }
+
+    data, err := json.Marshal(msg)
+    if err != nil {
+        log.Printf("Error marshaling pattern message: %v", err)
+        return
+    }
+
+    h.deliver(symbol, data)
+}
+
+// SendAlert sends a triggered alert to clients subscribed to its topic
+func (h *Hub) SendAlert(n *alerts.Notification) {
+    topic := alerts.Topic(n.AlertID)
+    msg := models.WebSocketMessage{
+        Type:      "alert",
+        Symbol:    topic,
+        Data:      n,
+        Timestamp: time.Now(),
+    }
+
+    data, err := json.Marshal(msg)
+    if err != nil {
+        log.Printf("Error marshaling alert message: %v", err)
+        return
+    }
+
+    h.deliver(topic, data)
+}
+
+// SendScreen sends the report of a saved screen to its channel's subscribers
+func (h *Hub) SendScreen(report *screener.Report) {
+    channel := screener.Channel(report.Name)
+    msg := models.WebSocketMessage{
+        Type:      "screener",
+        Symbol:    channel,
+        Data:      report,
+        Timestamp: time.Now(),
+    }
+
+    data, err := json.Marshal(msg)
+    if err != nil {
+        log.Printf("Error marshaling screener message: %v", err)
+        return
+    }
+
+    h.BroadcastToSymbol(channel, data)
+}
+
 func (h *Hub) SendTechnicalIndicator(symbol string, indicator *models.TechnicalIndicator) {
     msg := models.WebSocketMessage{
         Type:      "indicator",
@@ -364,5 +535,5 @@ func (h *Hub) SendTechnicalIndicator(symbol string, indicator *models.TechnicalI
         return
     }
 
-    h.BroadcastToSymbol(symbol, data)
+    h.deliver(symbol, data)
 }
@@ -0,0 +1,191 @@
+package websocket
+
+import (
+    "context"
+    "encoding/json"
+    "log"
+    "strings"
+    "sync"
+
+    "github.com/redis/go-redis/v9"
+    "github.com/algo-trading/market-data-service/internal/alerts"
+    "github.com/algo-trading/market-data-service/internal/models"
+    "github.com/algo-trading/market-data-service/internal/screener"
+    "github.com/algo-trading/market-data-service/internal/storage"
+)
+
+// RedisRelay feeds the hub from Redis so that a client connected to any
+// replica receives data produced by any other. Ticks and bars arrive on the
+// tick and OHLCV channels; every other message type (depth, option chains,
+// patterns, indicators and alerts) is published already encoded by the hub
+// of the replica that produced it, alerts on their own topic only. A symbol's
+// channels are subscribed only while at least one local client is subscribed
+// to it, all on one shared Redis connection.
+//
+// Screener reports are not relayed: each replica runs the scheduler for its
+// own subscribers.
+type RedisRelay struct {
+    ctx    context.Context
+    hub    *Hub
+    redis  *storage.RedisClient
+    pubsub *redis.PubSub
+    subs   map[string]bool
+    mu     sync.Mutex
+
+    // Symbols whose demand changed, reconciled on the relay's goroutine so
+    // that a slow Redis cannot hold up the hub
+    pending   map[string]bool
+    pendingMu sync.Mutex
+    wake      chan struct{}
+}
+
+// NewRedisRelay attaches a relay to the hub. The subscription is closed when
+// ctx is cancelled.
+func NewRedisRelay(ctx context.Context, hub *Hub, redisClient *storage.RedisClient) *RedisRelay {
+    relay := &RedisRelay{
+        ctx:    ctx,
+        hub:    hub,
+        redis:  redisClient,
+        pubsub:  redisClient.NewPubSub(ctx),
+        subs:    make(map[string]bool),
+        pending: make(map[string]bool),
+        wake:    make(chan struct{}, 1),
+    }
+
+    hub.SetPublisher(relay.publish)
+    hub.SetDemandHandler(relay.Reconcile)
+
+    go relay.forward()
+    go relay.reconcilePending()
+    go func() {
+        <-ctx.Done()
+        relay.Close()
+    }()
+
+    return relay
+}
+
+// Reconcile queues the symbol to have its channels subscribed or
+// unsubscribed to match the current demand on the hub. It does not wait for
+// Redis.
+func (r *RedisRelay) Reconcile(symbol string) {
+    r.pendingMu.Lock()
+    r.pending[symbol] = true
+    r.pendingMu.Unlock()
+
+    select {
+    case r.wake <- struct{}{}:
+    default:
+    }
+}
+
+// reconcilePending reconciles queued symbols until ctx is cancelled
+func (r *RedisRelay) reconcilePending() {
+    for {
+        select {
+        case <-r.ctx.Done():
+            return
+        case <-r.wake:
+        }
+
+        r.pendingMu.Lock()
+        symbols := r.pending
+        r.pending = make(map[string]bool)
+        r.pendingMu.Unlock()
+
+        for symbol := range symbols {
+            r.reconcile(symbol)
+        }
+    }
+}
+
+// reconcile subscribes to or unsubscribes from the symbol's channels to match
+// the current demand on the hub. It is idempotent, so a symbol queued more
+// than once cannot leave a stale subscription behind.
+func (r *RedisRelay) reconcile(symbol string) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    if r.ctx.Err() != nil || strings.HasPrefix(symbol, screener.ChannelPrefix) {
+        return
+    }
+
+    wanted := r.hub.SubscriberCount(symbol) > 0
+    active := r.subs[symbol]
+    channels := relayChannels(symbol)
+
+    switch {
+    case wanted && !active:
+        if err := r.pubsub.Subscribe(r.ctx, channels...); err != nil {
+            log.Printf("Relay failed to subscribe to Redis channels for %s: %v", symbol, err)
+            return
+        }
+        r.subs[symbol] = true
+        log.Printf("Relay subscribed to Redis channels for %s", symbol)
+
+    case !wanted && active:
+        if err := r.pubsub.Unsubscribe(r.ctx, channels...); err != nil {
+            log.Printf("Relay failed to unsubscribe from Redis channels for %s: %v", symbol, err)
+        }
+        delete(r.subs, symbol)
+        log.Printf("Relay unsubscribed from Redis channels for %s", symbol)
+    }
+}
+
+// Close drops all Redis subscriptions
+func (r *RedisRelay) Close() {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    if err := r.pubsub.Close(); err != nil {
+        log.Printf("Error closing relay subscription: %v", err)
+    }
+    clear(r.subs)
+}
+
+func relayChannels(symbol string) []string {
+    if strings.HasPrefix(symbol, alerts.TopicPrefix) {
+        return []string{storage.MessageChannelPrefix + symbol}
+    }
+    return []string{
+        storage.TickChannelPrefix + symbol,
+        storage.OHLCVChannelPrefix + symbol,
+        storage.MessageChannelPrefix + symbol,
+    }
+}
+
+// publish sends a message produced on this replica to every replica,
+// including this one, through Redis
+func (r *RedisRelay) publish(key string, message []byte) {
+    if err := r.redis.PublishMessage(r.ctx, key, message); err != nil {
+        log.Printf("Failed to publish message for %s to Redis: %v", key, err)
+    }
+}
+
+func (r *RedisRelay) forward() {
+    for msg := range r.pubsub.Channel() {
+        switch {
+        case strings.HasPrefix(msg.Channel, storage.TickChannelPrefix):
+            symbol := strings.TrimPrefix(msg.Channel, storage.TickChannelPrefix)
+            var tick models.Tick
+            if err := json.Unmarshal([]byte(msg.Payload), &tick); err != nil {
+                log.Printf("Error unmarshaling relayed tick for %s: %v", symbol, err)
+                continue
+            }
+            r.hub.SendTick(symbol, &tick)
+
+        case strings.HasPrefix(msg.Channel, storage.OHLCVChannelPrefix):
+            symbol := strings.TrimPrefix(msg.Channel, storage.OHLCVChannelPrefix)
+            var ohlcv models.OHLCV
+            if err := json.Unmarshal([]byte(msg.Payload), &ohlcv); err != nil {
+                log.Printf("Error unmarshaling relayed OHLCV for %s: %v", symbol, err)
+                continue
+            }
+            r.hub.SendOHLCV(symbol, &ohlcv)
+
+        case strings.HasPrefix(msg.Channel, storage.MessageChannelPrefix):
+            key := strings.TrimPrefix(msg.Channel, storage.MessageChannelPrefix)
+            r.hub.BroadcastToSymbol(key, []byte(msg.Payload))
+        }
+    }
+}
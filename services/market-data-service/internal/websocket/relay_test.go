@@ -0,0 +1,179 @@
+package websocket
+
+import (
+    "context"
+    "encoding/json"
+    "testing"
+    "time"
+
+    "github.com/alicebob/miniredis/v2"
+    "github.com/algo-trading/market-data-service/internal/alerts"
+    "github.com/algo-trading/market-data-service/internal/models"
+    "github.com/algo-trading/market-data-service/internal/storage"
+)
+
+func TestRedisRelayFollowsDemand(t *testing.T) {
+    server := miniredis.RunT(t)
+    redisClient := storage.NewRedisClient(server.Host(), server.Port())
+    defer redisClient.Close()
+
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+
+    hub := NewHub()
+    relay := NewRedisRelay(ctx, hub, redisClient)
+    client := &Client{hub: hub, send: make(chan []byte, 16), id: "test"}
+
+    hub.Subscribe(client, "TCS")
+
+    // A tick published by another replica reaches the local client. Publish
+    // until received since the subscription is established asynchronously.
+    tick := &models.Tick{Time: time.Now(), Symbol: "TCS", Price: 3500, Volume: 10}
+    deadline := time.After(2 * time.Second)
+    var received []byte
+    for received == nil {
+        if err := redisClient.PublishTick(ctx, "TCS", tick); err != nil {
+            t.Fatalf("Failed to publish tick: %v", err)
+        }
+        select {
+        case received = <-client.send:
+        case <-time.After(50 * time.Millisecond):
+        case <-deadline:
+            t.Fatalf("Timed out waiting for relayed tick")
+        }
+    }
+
+    var msg models.WebSocketMessage
+    if err := json.Unmarshal(received, &msg); err != nil {
+        t.Fatalf("Failed to unmarshal relayed message: %v", err)
+    }
+    if msg.Type != "tick" || msg.Symbol != "TCS" {
+        t.Errorf("Unexpected relayed message: %+v", msg)
+    }
+
+    // Dropping the last subscriber releases the Redis subscription
+    hub.Unsubscribe(client, "TCS")
+    deadline = time.After(2 * time.Second)
+    for {
+        relay.mu.Lock()
+        remaining := len(relay.subs)
+        relay.mu.Unlock()
+        if remaining == 0 {
+            break
+        }
+        select {
+        case <-time.After(10 * time.Millisecond):
+        case <-deadline:
+            t.Fatalf("Expected no active relay subscriptions, got %d", remaining)
+        }
+    }
+}
+
+func TestRedisRelayReconcileDoesNotWaitForRedis(t *testing.T) {
+    server := miniredis.RunT(t)
+    redisClient := storage.NewRedisClient(server.Host(), server.Port())
+    defer redisClient.Close()
+
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+
+    hub := NewHub()
+    relay := NewRedisRelay(ctx, hub, redisClient)
+
+    // While the relay is busy with Redis, demand changes are only queued
+    relay.mu.Lock()
+    done := make(chan struct{})
+    go func() {
+        defer close(done)
+        client := &Client{hub: hub, send: make(chan []byte, 16), id: "test"}
+        hub.Subscribe(client, "TCS")
+        hub.Unsubscribe(client, "TCS")
+        hub.Subscribe(client, "INFY")
+    }()
+    select {
+    case <-done:
+    case <-time.After(time.Second):
+        t.Error("Expected demand changes not to wait for the relay")
+    }
+    relay.mu.Unlock()
+}
+
+func TestRedisRelayFansOutHubMessages(t *testing.T) {
+    server := miniredis.RunT(t)
+    redisClient := storage.NewRedisClient(server.Host(), server.Port())
+    defer redisClient.Close()
+
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+
+    // Two replicas sharing Redis; only the second has a client
+    producer := NewHub()
+    NewRedisRelay(ctx, producer, redisClient)
+    consumer := NewHub()
+    NewRedisRelay(ctx, consumer, redisClient)
+    client := &Client{hub: consumer, send: make(chan []byte, 16), id: "test"}
+    consumer.Subscribe(client, "INFY")
+
+    indicator := &models.TechnicalIndicator{Time: time.Now(), Symbol: "INFY"}
+    deadline := time.After(2 * time.Second)
+    var received []byte
+    for received == nil {
+        producer.SendTechnicalIndicator("INFY", indicator)
+        select {
+        case received = <-client.send:
+        case <-time.After(50 * time.Millisecond):
+        case <-deadline:
+            t.Fatalf("Timed out waiting for relayed indicator")
+        }
+    }
+
+    var msg models.WebSocketMessage
+    if err := json.Unmarshal(received, &msg); err != nil {
+        t.Fatalf("Failed to unmarshal relayed message: %v", err)
+    }
+    if msg.Type != "indicator" || msg.Symbol != "INFY" {
+        t.Errorf("Unexpected relayed message: %+v", msg)
+    }
+}
+
+func TestRedisRelayDeliversAlertsToTheirTopic(t *testing.T) {
+    server := miniredis.RunT(t)
+    redisClient := storage.NewRedisClient(server.Host(), server.Port())
+    defer redisClient.Close()
+
+    ctx, cancel := context.WithCancel(context.Background())
+    defer cancel()
+
+    producer := NewHub()
+    NewRedisRelay(ctx, producer, redisClient)
+    consumer := NewHub()
+    NewRedisRelay(ctx, consumer, redisClient)
+    owner := &Client{hub: consumer, send: make(chan []byte, 16), id: "owner"}
+    consumer.Subscribe(owner, alerts.Topic(7))
+    watcher := &Client{hub: consumer, send: make(chan []byte, 16), id: "watcher"}
+    consumer.Subscribe(watcher, "TCS")
+
+    notification := &alerts.Notification{AlertID: 7, Symbol: "TCS", Message: "TCS crossed above 100.00"}
+    deadline := time.After(2 * time.Second)
+    var received []byte
+    for received == nil {
+        producer.SendAlert(notification)
+        select {
+        case received = <-owner.send:
+        case <-time.After(50 * time.Millisecond):
+        case <-deadline:
+            t.Fatalf("Timed out waiting for relayed alert")
+        }
+    }
+
+    var msg models.WebSocketMessage
+    if err := json.Unmarshal(received, &msg); err != nil {
+        t.Fatalf("Failed to unmarshal relayed message: %v", err)
+    }
+    if msg.Type != "alert" || msg.Symbol != "alert:7" {
+        t.Errorf("Unexpected relayed message: %+v", msg)
+    }
+    if len(watcher.send) != 0 {
+        t.Errorf("Expected the alert not to reach subscribers of the symbol")
+    }
+}
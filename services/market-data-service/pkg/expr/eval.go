@@ -0,0 +1,362 @@
+package expr
+
+import (
+    "fmt"
+    "math"
+    "strconv"
+    "strings"
+
+    "github.com/algo-trading/market-data-service/internal/models"
+)
+
+// env holds the bars an expression is evaluated over. Indicator results are
+// cached so repeated calls with the same arguments are computed once.
+type env struct {
+    data  []models.OHLCV
+    cache map[string][]float64
+}
+
+// nanSeries returns a series of n undefined values
+func nanSeries(n int) []float64 {
+    values := make([]float64, n)
+    for i := range values {
+        values[i] = math.NaN()
+    }
+    return values
+}
+
+func boolValue(b bool) float64 {
+    if b {
+        return 1
+    }
+    return 0
+}
+
+func (n *number) eval(e *env) ([]float64, error) {
+    values := make([]float64, len(e.data))
+    for i := range values {
+        values[i] = n.value
+    }
+    return values, nil
+}
+
+func (n *number) String() string {
+    return strconv.FormatFloat(n.value, 'f', -1, 64)
+}
+
+func (c *column) eval(e *env) ([]float64, error) {
+    values := make([]float64, len(e.data))
+    for i, bar := range e.data {
+        switch c.name {
+        case "open":
+            values[i] = bar.Open
+        case "high":
+            values[i] = bar.High
+        case "low":
+            values[i] = bar.Low
+        case "close":
+            values[i] = bar.Close
+        case "volume":
+            values[i] = float64(bar.Volume)
+        case "hl2":
+            values[i] = (bar.High + bar.Low) / 2
+        case "hlc3":
+            values[i] = (bar.High + bar.Low + bar.Close) / 3
+        case "ohlc4":
+            values[i] = (bar.Open + bar.High + bar.Low + bar.Close) / 4
+        }
+    }
+    return values, nil
+}
+
+func (c *column) String() string {
+    return c.name
+}
+
+// eval computes the indicator over the bars, or over the source series from
+// its first defined value. Values before the first one the indicator defines
+// for its parameters are its warm-up and stay undefined.
+func (ind *indicator) eval(e *env) ([]float64, error) {
+    key := ind.String()
+    if values, exists := e.cache[key]; exists {
+        return values, nil
+    }
+
+    bars, start := e.data, 0
+    if ind.source != nil {
+        source, err := ind.source.eval(e)
+        if err != nil {
+            return nil, err
+        }
+        for start < len(source) && math.IsNaN(source[start]) {
+            start++
+        }
+        bars = sourceBars(e.data[start:], source[start:])
+    }
+
+    values := nanSeries(len(e.data))
+    if len(bars) > 0 {
+        result, err := ind.def.Calculate(bars, ind.params)
+        if err != nil {
+            return nil, fmt.Errorf("failed to calculate %s: %w", key, err)
+        }
+        series, err := ind.def.Series(result, ind.output)
+        if err != nil {
+            return nil, err
+        }
+        // Forward-projected outputs such as the Ichimoku cloud are cut at the
+        // last bar
+        for i := ind.def.FirstValid(ind.params, ind.output); i < len(bars) && i < len(series); i++ {
+            values[start+i] = series[i]
+        }
+    }
+
+    e.cache[key] = values
+    return values, nil
+}
+
+// sourceBars substitutes a series for the prices of bars, keeping their time
+// and volume
+func sourceBars(data []models.OHLCV, source []float64) []models.OHLCV {
+    bars := make([]models.OHLCV, len(data))
+    for i, bar := range data {
+        bar.Open, bar.High, bar.Low, bar.Close = source[i], source[i], source[i], source[i]
+        bars[i] = bar
+    }
+    return bars
+}
+
+func (ind *indicator) String() string {
+    var args []string
+    if ind.source != nil {
+        args = append(args, ind.source.String())
+    }
+    for _, arg := range ind.args {
+        args = append(args, strconv.FormatFloat(arg, 'f', -1, 64))
+    }
+    s := ind.def.Name + "(" + strings.Join(args, ", ") + ")"
+    if ind.selected {
+        s += "." + ind.output
+    }
+    return s
+}
+
+func (c *call) eval(e *env) ([]float64, error) {
+    args := make([][]float64, len(c.args))
+    for i, arg := range c.args {
+        values, err := arg.eval(e)
+        if err != nil {
+            return nil, err
+        }
+        args[i] = values
+    }
+
+    x := args[0]
+    values := nanSeries(len(x))
+    if c.window > len(x) {
+        return values, nil
+    }
+    for i := range values {
+        switch c.name {
+        case "abs":
+            values[i] = math.Abs(x[i])
+        case "min":
+            values[i] = math.Min(x[i], args[1][i])
+        case "max":
+            values[i] = math.Max(x[i], args[1][i])
+        case "change":
+            if i >= c.window {
+                values[i] = x[i] - x[i-c.window]
+            }
+        case "highest", "lowest":
+            if i+1 < c.window {
+                continue
+            }
+            extreme := x[i]
+            for _, v := range x[i+1-c.window : i] {
+                if math.IsNaN(v) {
+                    extreme = v
+                    break
+                }
+                if c.name == "highest" {
+                    extreme = math.Max(extreme, v)
+                } else {
+                    extreme = math.Min(extreme, v)
+                }
+            }
+            values[i] = extreme
+        }
+    }
+    return values, nil
+}
+
+func (c *call) String() string {
+    args := make([]string, len(c.args))
+    for i, arg := range c.args {
+        args[i] = arg.String()
+    }
+    if c.window > 0 {
+        args = append(args, strconv.Itoa(c.window))
+    }
+    return c.name + "(" + strings.Join(args, ", ") + ")"
+}
+
+func (l *lookback) eval(e *env) ([]float64, error) {
+    x, err := l.x.eval(e)
+    if err != nil {
+        return nil, err
+    }
+    values := nanSeries(len(x))
+    if l.bars > len(x) {
+        return values, nil
+    }
+    for i := l.bars; i < len(x); i++ {
+        values[i] = x[i-l.bars]
+    }
+    return values, nil
+}
+
+func (l *lookback) String() string {
+    return fmt.Sprintf("%s[%d]", l.x, l.bars)
+}
+
+func (u *unary) eval(e *env) ([]float64, error) {
+    x, err := u.x.eval(e)
+    if err != nil {
+        return nil, err
+    }
+    values := make([]float64, len(x))
+    for i, v := range x {
+        switch {
+        case u.op == "-":
+            values[i] = -v
+        case math.IsNaN(v):
+            values[i] = v
+        default:
+            values[i] = boolValue(v == 0)
+        }
+    }
+    return values, nil
+}
+
+func (u *unary) String() string {
+    if u.op == "not" {
+        return "not " + u.x.String()
+    }
+    return u.op + u.x.String()
+}
+
+// eval applies arithmetic, comparisons and logic bar by bar. Comparisons
+// with an undefined operand are undefined; and/or are decided by a defined
+// false/true operand even when the other is undefined.
+func (b *binary) eval(e *env) ([]float64, error) {
+    left, err := b.left.eval(e)
+    if err != nil {
+        return nil, err
+    }
+    right, err := b.right.eval(e)
+    if err != nil {
+        return nil, err
+    }
+
+    values := make([]float64, len(left))
+    for i := range values {
+        l, r := left[i], right[i]
+        undefined := math.IsNaN(l) || math.IsNaN(r)
+        switch b.op {
+        case "+":
+            values[i] = l + r
+        case "-":
+            values[i] = l - r
+        case "*":
+            values[i] = l * r
+        case "/":
+            values[i] = l / r
+            if r == 0 {
+                values[i] = math.NaN()
+            }
+        case "and":
+            switch {
+            case l == 0 || r == 0:
+                values[i] = 0
+            case undefined:
+                values[i] = math.NaN()
+            default:
+                values[i] = 1
+            }
+        case "or":
+            switch {
+            case Truthy(l) || Truthy(r):
+                values[i] = 1
+            case undefined:
+                values[i] = math.NaN()
+            default:
+                values[i] = 0
+            }
+        default:
+            if undefined {
+                values[i] = math.NaN()
+                continue
+            }
+            switch b.op {
+            case "<":
+                values[i] = boolValue(l < r)
+            case "<=":
+                values[i] = boolValue(l <= r)
+            case ">":
+                values[i] = boolValue(l > r)
+            case ">=":
+                values[i] = boolValue(l >= r)
+            case "==":
+                values[i] = boolValue(l == r)
+            case "!=":
+                values[i] = boolValue(l != r)
+            }
+        }
+    }
+    return values, nil
+}
+
+func (b *binary) String() string {
+    return "(" + b.left.String() + " " + b.op + " " + b.right.String() + ")"
+}
+
+// eval is true on the bar where left moves from at or below right to above
+// it (above), the reverse (below), or either
+func (c *cross) eval(e *env) ([]float64, error) {
+    left, err := c.left.eval(e)
+    if err != nil {
+        return nil, err
+    }
+    right, err := c.right.eval(e)
+    if err != nil {
+        return nil, err
+    }
+
+    values := nanSeries(len(left))
+    for i := 1; i < len(values); i++ {
+        prev, curr := left[i-1]-right[i-1], left[i]-right[i]
+        if math.IsNaN(prev) || math.IsNaN(curr) {
+            continue
+        }
+        up := prev <= 0 && curr > 0
+        down := prev >= 0 && curr < 0
+        switch c.direction {
+        case "above":
+            values[i] = boolValue(up)
+        case "below":
+            values[i] = boolValue(down)
+        default:
+            values[i] = boolValue(up || down)
+        }
+    }
+    return values, nil
+}
+
+func (c *cross) String() string {
+    op := "crosses"
+    if c.direction != "" {
+        op += " " + c.direction
+    }
+    return "(" + c.left.String() + " " + op + " " + c.right.String() + ")"
+}
@@ -0,0 +1,99 @@
+// Package expr parses and evaluates indicator expressions over OHLCV bars,
+// such as "ema(close, 9) crosses above ema(close, 21) and rsi(14) < 70".
+//
+// An expression yields one value per bar. Series are the bar columns (open,
+// high, low, close, volume, hl2, hlc3, ohlc4), calls of the series
+// indicators in the indicators registry, including registered third-party
+// ones, and the builtins abs, min, max, highest, lowest and change.
+// Indicators take their numeric parameters positionally; source indicators
+// such as moving averages, RSI, MACD and Bollinger Bands accept a leading
+// source series, as in ema(rsi(14), 9). Outputs other than the first are
+// selected with a field, e.g. macd(12, 26, 9).signal or
+// bollinger_bands(20, 2).upper. x[n] is the value of x n bars earlier.
+//
+// Comparisons, crossovers and and/or/not yield 1 for true and 0 for false.
+// Values are NaN where they are undefined, such as during an indicator's
+// warm-up; conditions involving them are neither true nor false.
+package expr
+
+import (
+    "fmt"
+    "math"
+    "strings"
+
+    "github.com/algo-trading/market-data-service/internal/models"
+)
+
+// Expr is a parsed expression, safe for concurrent evaluation
+type Expr struct {
+    source string
+    root   node
+}
+
+// Parse parses an expression, checking function names, arguments and outputs
+func Parse(source string) (*Expr, error) {
+    source = strings.TrimSpace(source)
+    if source == "" {
+        return nil, fmt.Errorf("empty expression")
+    }
+
+    tokens, err := tokenize(source)
+    if err != nil {
+        return nil, err
+    }
+    p := &parser{tokens: tokens}
+    root, err := p.parse()
+    if err != nil {
+        return nil, err
+    }
+
+    return &Expr{source: source, root: root}, nil
+}
+
+// String returns the expression as written
+func (e *Expr) String() string {
+    return e.source
+}
+
+// MarshalText lets expressions be written to JSON and YAML configs
+func (e *Expr) MarshalText() ([]byte, error) {
+    return []byte(e.source), nil
+}
+
+// UnmarshalText parses an expression from a JSON or YAML config
+func (e *Expr) UnmarshalText(text []byte) error {
+    parsed, err := Parse(string(text))
+    if err != nil {
+        return err
+    }
+    *e = *parsed
+    return nil
+}
+
+// Evaluate computes the expression for every bar of data, oldest first
+func (e *Expr) Evaluate(data []models.OHLCV) ([]float64, error) {
+    env := &env{
+        data:  data,
+        cache: make(map[string][]float64),
+    }
+    return e.root.eval(env)
+}
+
+// Signals evaluates a condition, reporting the bars where it is true
+func (e *Expr) Signals(data []models.OHLCV) ([]bool, error) {
+    values, err := e.Evaluate(data)
+    if err != nil {
+        return nil, err
+    }
+
+    signals := make([]bool, len(values))
+    for i, v := range values {
+        signals[i] = Truthy(v)
+    }
+    return signals, nil
+}
+
+// Truthy reports whether an expression value is a true condition
+func Truthy(v float64) bool {
+    return v != 0 && !math.IsNaN(v)
+}
@@ -0,0 +1,306 @@
+package expr
+
+import (
+    "encoding/json"
+    "math"
+    "strings"
+    "testing"
+
+    "github.com/algo-trading/market-data-service/internal/models"
+    "github.com/algo-trading/market-data-service/internal/testutil"
+    "github.com/algo-trading/market-data-service/pkg/indicators"
+)
+
+func mustEvaluate(t *testing.T, source string, data []models.OHLCV) []float64 {
+    t.Helper()
+    e, err := Parse(source)
+    if err != nil {
+        t.Fatalf("Failed to parse %q: %v", source, err)
+    }
+    values, err := e.Evaluate(data)
+    if err != nil {
+        t.Fatalf("Failed to evaluate %q: %v", source, err)
+    }
+    if len(values) != len(data) {
+        t.Fatalf("Expected %d values for %q, got %d", len(data), source, len(values))
+    }
+    return values
+}
+
+// assertSeries compares values with a batch result, expecting NaN before
+// first
+func assertSeries(t *testing.T, name string, values, expected []float64, first int) {
+    t.Helper()
+    for i := range values {
+        if i < first {
+            if !math.IsNaN(values[i]) {
+                t.Fatalf("%s[%d]: expected NaN during warm-up, got %v", name, i, values[i])
+            }
+            continue
+        }
+        if math.Abs(values[i]-expected[i]) > 1e-9 {
+            t.Fatalf("%s[%d]: expected %v, got %v", name, i, expected[i], values[i])
+        }
+    }
+}
+
+func TestParseErrors(t *testing.T) {
+    tests := []struct {
+        source string
+        err    string
+    }{
+        {"", "empty expression"},
+        {"foo(14)", `unknown function "foo"`},
+        {"closing > 1", `unknown series "closing"`},
+        {"sma()", "invalid sma parameter period: is required"},
+        {"sma(close, 14, 2)", "sma takes (period), got 2 arguments"},
+        {"ema(9.5)", "invalid ema parameter period: must be an integer"},
+        {"rsi(0)", "invalid rsi parameter period: must be at least 1"},
+        {"volume_profile(10)", "volume_profile does not return a series"},
+        {"rsi(close)", "invalid rsi parameter period: is required"},
+        {"ma(10, 2)", "ma ma_type must be a number"},
+        {"atr(high)", "atr period must be a number"},
+        {"macd(12, 26, 9).upper", `macd has no output "upper", expected one of macd, signal, histogram`},
+        {"rsi(14).signal", `rsi has no output "signal", expected one of value`},
+        {"close.value", "close has no outputs to select"},
+        {"ichimoku().chikou", "ichimoku.chikou uses later bars"},
+        {"close[1.5]", "lookback must be an integer"},
+        {"highest(close, 0)", "highest length must be a positive integer"},
+        {"close[100000000000000000000]", "lookback must be at most 2147483647"},
+        {"change(close, 100000000000000000000)", "change length must be at most 2147483647"},
+        {"highest(close, 100000000000000000000)", "highest length must be at most 2147483647"},
+        {"sma(100000000000000000000)", "invalid sma parameter period: must be an integer"},
+        {"close > ", "unexpected end of expression"},
+        {"(close > 1", `expected ")"`},
+        {"close close", `unexpected "close" at position 6`},
+        {"close $ 1", `unexpected character '$' at position 6`},
+    }
+
+    for _, tt := range tests {
+        _, err := Parse(tt.source)
+        if err == nil {
+            t.Errorf("Expected error parsing %q", tt.source)
+            continue
+        }
+        if !strings.Contains(err.Error(), tt.err) {
+            t.Errorf("Parse(%q): expected error containing %q, got %q", tt.source, tt.err, err)
+        }
+    }
+}
+
+func TestArithmeticAndPrecedence(t *testing.T) {
+    data := testutil.RandomWalk("TEST", 5, 1)
+
+    for _, source := range []string{
+        "1 + 2 * 3 == 7",
+        "(1 + 2) * 3 == 9",
+        "-2 * -3 == 6",
+        "10 - 4 - 3 == 3",
+        "1 < 2 and not 2 < 1",
+        "1 > 2 or 3 >= 3",
+        "NOT (1 > 2) AND 1 != 2",
+        "hl2 == (high + low) / 2",
+    } {
+        for i, v := range mustEvaluate(t, source, data) {
+            if v != 1 {
+                t.Errorf("%q at bar %d: expected 1, got %v", source, i, v)
+            }
+        }
+    }
+
+    values := mustEvaluate(t, "close / 0", data)
+    if !math.IsNaN(values[0]) {
+        t.Errorf("Expected division by zero to be undefined, got %v", values[0])
+    }
+}
+
+func TestIndicatorsMatchBatch(t *testing.T) {
+    data := testutil.RandomWalk("TEST", 120, 2)
+
+    ema, _ := indicators.CalculateEMA(data, 9)
+    assertSeries(t, "ema", mustEvaluate(t, "ema(close, 9)", data), ema, 8)
+    assertSeries(t, "ema", mustEvaluate(t, "ema(9)", data), ema, 8)
+
+    rsi, _ := indicators.CalculateRSI(data, 14)
+    assertSeries(t, "rsi", mustEvaluate(t, "RSI(14)", data), rsi, 14)
+
+    macd, _ := indicators.CalculateMACD(data, 12, 26, 9)
+    assertSeries(t, "macd", mustEvaluate(t, "macd(12, 26, 9)", data), macd.MACD, 25)
+    assertSeries(t, "signal", mustEvaluate(t, "macd(12, 26, 9).signal", data), macd.Signal, 33)
+
+    bands, _ := indicators.CalculateBollingerBands(data, 20, 2)
+    assertSeries(t, "upper", mustEvaluate(t, "bollinger_bands(20, 2).upper", data), bands.Upper, 19)
+
+    adx, _ := indicators.CalculateADX(data, 14)
+    assertSeries(t, "plus_di", mustEvaluate(t, "adx(14).plus_di", data), adx.PlusDI, 14)
+
+    // Indicators without a warm-up are defined from the first bar, even
+    // where their value is zero
+    obv, _ := indicators.CalculateOBV(data)
+    assertSeries(t, "obv", mustEvaluate(t, "obv()", data), obv, 0)
+    drawdown, _ := indicators.CalculateDrawdown(data)
+    assertSeries(t, "drawdown", mustEvaluate(t, "drawdown()", data), drawdown.Drawdown, 0)
+
+    // Integer outputs are converted
+    supertrend, _ := indicators.CalculateSupertrend(data, 10, 3)
+    direction := mustEvaluate(t, "supertrend(10, 3).direction", data)
+    for i, d := range supertrend.Direction {
+        if d != 0 && direction[i] != float64(d) {
+            t.Fatalf("direction[%d]: expected %d, got %v", i, d, direction[i])
+        }
+    }
+}
+
+func TestSourceSeries(t *testing.T) {
+    data := testutil.RandomWalk("TEST", 80, 3)
+
+    // SMA of RSI starts from the first RSI value
+    rsi, _ := indicators.CalculateRSI(data, 14)
+    values := mustEvaluate(t, "sma(rsi(14), 5)", data)
+    expected := make([]float64, len(data))
+    for i := 18; i < len(data); i++ {
+        sum := 0.0
+        for _, v := range rsi[i-4 : i+1] {
+            sum += v
+        }
+        expected[i] = sum / 5
+    }
+    assertSeries(t, "sma(rsi)", values, expected, 18)
+
+    // hl2 as a source is the same as computing on the averaged prices
+    hl2 := mustEvaluate(t, "sma(hl2, 10)", data)
+    for i := 9; i < len(data); i++ {
+        sum := 0.0
+        for _, bar := range data[i-9 : i+1] {
+            sum += (bar.High + bar.Low) / 2
+        }
+        if math.Abs(hl2[i]-sum/10) > 1e-9 {
+            t.Fatalf("sma(hl2)[%d]: expected %v, got %v", i, sum/10, hl2[i])
+        }
+    }
+}
+
+func TestCrossoverSignals(t *testing.T) {
+    data := testutil.RandomWalk("TEST", 300, 4)
+
+    fast, _ := indicators.CalculateEMA(data, 9)
+    slow, _ := indicators.CalculateEMA(data, 21)
+    rsi, _ := indicators.CalculateRSI(data, 14)
+
+    e, err := Parse("ema(close,9) crosses above ema(close,21) and rsi(14) < 70")
+    if err != nil {
+        t.Fatalf("Failed to parse: %v", err)
+    }
+    signals, err := e.Signals(data)
+    if err != nil {
+        t.Fatalf("Failed to evaluate: %v", err)
+    }
+
+    crossings := 0
+    for i := range data {
+        expected := i > 20 && fast[i-1] <= slow[i-1] && fast[i] > slow[i] && rsi[i] < 70
+        if signals[i] != expected {
+            t.Fatalf("Bar %d: expected signal %v, got %v", i, expected, signals[i])
+        }
+        if expected {
+            crossings++
+        }
+    }
+    if crossings == 0 {
+        t.Fatal("Expected the test data to contain crossovers")
+    }
+
+    // Below and either direction
+    below := mustEvaluate(t, "ema(9) crosses below ema(21)", data)
+    either := mustEvaluate(t, "ema(9) crosses ema(21)", data)
+    for i := 21; i < len(data); i++ {
+        down := fast[i-1] >= slow[i-1] && fast[i] < slow[i]
+        up := fast[i-1] <= slow[i-1] && fast[i] > slow[i]
+        if Truthy(below[i]) != down || Truthy(either[i]) != (up || down) {
+            t.Fatalf("Bar %d: unexpected crossing values %v, %v", i, below[i], either[i])
+        }
+    }
+}
+
+func TestLookbackAndBuiltins(t *testing.T) {
+    data := testutil.RandomWalk("TEST", 30, 5)
+
+    previous := mustEvaluate(t, "close[1]", data)
+    change := mustEvaluate(t, "change(close, 3)", data)
+    highest := mustEvaluate(t, "highest(high, 5)", data)
+    lowest := mustEvaluate(t, "lowest(low[1], 5)", data)
+
+    if !math.IsNaN(previous[0]) || !math.IsNaN(change[2]) || !math.IsNaN(highest[3]) || !math.IsNaN(lowest[4]) {
+        t.Fatal("Expected undefined values before enough bars")
+    }
+    for i := 5; i < len(data); i++ {
+        if previous[i] != data[i-1].Close {
+            t.Errorf("close[1] at %d: expected %v, got %v", i, data[i-1].Close, previous[i])
+        }
+        if math.Abs(change[i]-(data[i].Close-data[i-3].Close)) > 1e-9 {
+            t.Errorf("change at %d: got %v", i, change[i])
+        }
+        high, low := 0.0, math.Inf(1)
+        for j := i - 4; j <= i; j++ {
+            high = math.Max(high, data[j].High)
+            low = math.Min(low, data[j-1].Low)
+        }
+        if highest[i] != high || lowest[i] != low {
+            t.Errorf("highest/lowest at %d: expected %v/%v, got %v/%v", i, high, low, highest[i], lowest[i])
+        }
+    }
+
+    for _, source := range []string{"close[50]", "change(close, 50)", "highest(close, 50)"} {
+        for i, v := range mustEvaluate(t, source, data) {
+            if !math.IsNaN(v) {
+                t.Fatalf("%s at %d: expected undefined with a window longer than the data, got %v", source, i, v)
+            }
+        }
+    }
+
+    abs := mustEvaluate(t, "abs(close - open) == max(close, open) - min(close, open)", data)
+    for i, v := range abs {
+        if v != 1 {
+            t.Errorf("abs at bar %d: expected 1, got %v", i, v)
+        }
+    }
+}
+
+func TestUndefinedValues(t *testing.T) {
+    data := testutil.RandomWalk("TEST", 30, 6)
+
+    // Comparisons during warm-up are undefined, but and/or are decided by a
+    // defined operand
+    compare := mustEvaluate(t, "rsi(14) < 70", data)
+    or := mustEvaluate(t, "rsi(14) < 70 or close > 0", data)
+    and := mustEvaluate(t, "rsi(14) < 70 and close < 0", data)
+    for i := 0; i < 14; i++ {
+        if !math.IsNaN(compare[i]) || or[i] != 1 || and[i] != 0 {
+            t.Fatalf("Bar %d: expected NaN, 1, 0, got %v, %v, %v", i, compare[i], or[i], and[i])
+        }
+    }
+    if Truthy(math.NaN()) || Truthy(0) || !Truthy(1) {
+        t.Error("Unexpected truthiness")
+    }
+
+    // Too little data for an indicator is an error
+    e, _ := Parse("sma(50) > close")
+    if _, err := e.Evaluate(data); err == nil {
+        t.Error("Expected error for insufficient data")
+    }
+}
+
+func TestUnmarshalConfig(t *testing.T) {
+    var config struct {
+        Entry *Expr `json:"entry"`
+    }
+    if err := json.Unmarshal([]byte(`{"entry": "rsi(14) < 30"}`), &config); err != nil {
+        t.Fatalf("Failed to unmarshal: %v", err)
+    }
+    if config.Entry.String() != "rsi(14) < 30" {
+        t.Errorf("Expected source to round-trip, got %q", config.Entry)
+    }
+    if err := json.Unmarshal([]byte(`{"entry": "rsi(14) <"}`), &config); err == nil {
+        t.Error("Expected invalid expression to fail unmarshalling")
+    }
+}
@@ -0,0 +1,70 @@
+package expr
+
+import (
+    "strings"
+
+    "github.com/algo-trading/market-data-service/pkg/indicators"
+)
+
+// usage describes an indicator's parameters for error messages
+func usage(def indicators.Definition) string {
+    if len(def.Params) == 0 {
+        return "no parameters"
+    }
+    names := make([]string, len(def.Params))
+    for i, p := range def.Params {
+        names[i] = p.Name
+        if !p.Required {
+            names[i] = "[" + p.Name + "]"
+        }
+    }
+    return "(" + strings.Join(names, ", ") + ")"
+}
+
+// hasOutput reports whether an indicator returns the named series
+func hasOutput(def indicators.Definition, name string) bool {
+    for _, output := range def.Outputs {
+        if output == name {
+            return true
+        }
+    }
+    return false
+}
+
+// lookAhead reports whether an output of def depends on later bars
+func lookAhead(def indicators.Definition, name string) bool {
+    for _, output := range def.LookAhead {
+        if output == name {
+            return true
+        }
+    }
+    return false
+}
+
+// builtin is a function over series rather than an indicator. lookback
+// builtins take a series and a window length.
+type builtin struct {
+    args     int
+    lookback bool
+}
+
+var builtins = map[string]builtin{
+    "abs":     {args: 1},
+    "min":     {args: 2},
+    "max":     {args: 2},
+    "highest": {args: 2, lookback: true},
+    "lowest":  {args: 2, lookback: true},
+    "change":  {args: 2, lookback: true},
+}
+
+// columns are the bar fields and price averages usable as series
+var columns = map[string]bool{
+    "open":   true,
+    "high":   true,
+    "low":    true,
+    "close":  true,
+    "volume": true,
+    "hl2":    true,
+    "hlc3":   true,
+    "ohlc4":  true,
+}
@@ -0,0 +1,82 @@
+package expr
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+    "unicode"
+)
+
+type tokenKind int
+
+const (
+    tokenEOF tokenKind = iota
+    tokenNumber
+    tokenIdent
+    tokenOperator
+)
+
+// token is a lexeme with its byte offset in the source
+type token struct {
+    kind  tokenKind
+    text  string
+    value float64
+    pos   int
+}
+
+func (t token) String() string {
+    if t.kind == tokenEOF {
+        return "end of expression"
+    }
+    return fmt.Sprintf("%q", t.text)
+}
+
+// operators are matched longest first
+var operators = []string{"<=", ">=", "==", "!=", "&&", "||", "<", ">", "+", "-", "*", "/", "!", "(", ")", "[", "]", ",", "."}
+
+// tokenize splits source into tokens. Identifiers are lower-cased so that
+// keywords and indicator names are case-insensitive.
+func tokenize(source string) ([]token, error) {
+    var tokens []token
+    for i := 0; i < len(source); {
+        c := rune(source[i])
+        switch {
+        case unicode.IsSpace(c):
+            i++
+
+        case unicode.IsDigit(c):
+            start := i
+            for i < len(source) && (unicode.IsDigit(rune(source[i])) || source[i] == '.') {
+                i++
+            }
+            value, err := strconv.ParseFloat(source[start:i], 64)
+            if err != nil {
+                return nil, fmt.Errorf("invalid number %q at position %d", source[start:i], start)
+            }
+            tokens = append(tokens, token{kind: tokenNumber, text: source[start:i], value: value, pos: start})
+
+        case unicode.IsLetter(c) || c == '_':
+            start := i
+            for i < len(source) && (unicode.IsLetter(rune(source[i])) || unicode.IsDigit(rune(source[i])) || source[i] == '_') {
+                i++
+            }
+            tokens = append(tokens, token{kind: tokenIdent, text: strings.ToLower(source[start:i]), pos: start})
+
+        default:
+            matched := ""
+            for _, op := range operators {
+                if strings.HasPrefix(source[i:], op) {
+                    matched = op
+                    break
+                }
+            }
+            if matched == "" {
+                return nil, fmt.Errorf("unexpected character %q at position %d", c, i)
+            }
+            tokens = append(tokens, token{kind: tokenOperator, text: matched, pos: i})
+            i += len(matched)
+        }
+    }
+
+    return append(tokens, token{kind: tokenEOF, pos: len(source)}), nil
+}
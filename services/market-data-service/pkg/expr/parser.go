@@ -0,0 +1,440 @@
+package expr
+
+import (
+    "fmt"
+    "math"
+    "strings"
+
+    "github.com/algo-trading/market-data-service/pkg/indicators"
+)
+
+// node is an expression evaluating to one value per bar
+type node interface {
+    eval(e *env) ([]float64, error)
+    String() string
+}
+
+type number struct {
+    value float64
+}
+
+type column struct {
+    name string
+}
+
+// indicator is a call of a registered indicator with literal parameters
+type indicator struct {
+    def      indicators.Definition
+    source   node
+    args     []float64
+    params   indicators.Params
+    output   string
+    selected bool
+}
+
+// call is a builtin over series; window is the literal length of lookback
+// builtins
+type call struct {
+    name   string
+    args   []node
+    window int
+}
+
+// lookback is a series shifted by a number of bars, written x[n]
+type lookback struct {
+    x    node
+    bars int
+}
+
+type unary struct {
+    op string
+    x  node
+}
+
+type binary struct {
+    op          string
+    left, right node
+}
+
+// cross is "left crosses above right", "crosses below" or plain "crosses"
+// for either direction
+type cross struct {
+    direction   string
+    left, right node
+}
+
+// keywords cannot be used as series names
+var keywords = map[string]bool{
+    "and":     true,
+    "or":      true,
+    "not":     true,
+    "crosses": true,
+    "above":   true,
+    "below":   true,
+}
+
+var comparisons = map[string]bool{"<": true, "<=": true, ">": true, ">=": true, "==": true, "!=": true}
+
+type parser struct {
+    tokens []token
+    pos    int
+}
+
+func (p *parser) peek() token {
+    return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+    t := p.tokens[p.pos]
+    if t.kind != tokenEOF {
+        p.pos++
+    }
+    return t
+}
+
+// accept consumes the next token if it is one of texts
+func (p *parser) accept(texts ...string) (token, bool) {
+    t := p.peek()
+    if t.kind != tokenOperator && t.kind != tokenIdent {
+        return t, false
+    }
+    for _, text := range texts {
+        if t.text == text {
+            return p.next(), true
+        }
+    }
+    return t, false
+}
+
+func (p *parser) expect(text string) error {
+    if _, ok := p.accept(text); !ok {
+        return p.unexpected(fmt.Sprintf("expected %q", text))
+    }
+    return nil
+}
+
+func (p *parser) unexpected(detail string) error {
+    t := p.peek()
+    if detail != "" {
+        return fmt.Errorf("unexpected %s at position %d: %s", t, t.pos, detail)
+    }
+    return fmt.Errorf("unexpected %s at position %d", t, t.pos)
+}
+
+// parse parses the whole token stream as one expression
+func (p *parser) parse() (node, error) {
+    root, err := p.parseOr()
+    if err != nil {
+        return nil, err
+    }
+    if p.peek().kind != tokenEOF {
+        return nil, p.unexpected("")
+    }
+    return root, nil
+}
+
+func (p *parser) parseOr() (node, error) {
+    left, err := p.parseAnd()
+    if err != nil {
+        return nil, err
+    }
+    for {
+        if _, ok := p.accept("or", "||"); !ok {
+            return left, nil
+        }
+        right, err := p.parseAnd()
+        if err != nil {
+            return nil, err
+        }
+        left = &binary{op: "or", left: left, right: right}
+    }
+}
+
+func (p *parser) parseAnd() (node, error) {
+    left, err := p.parseNot()
+    if err != nil {
+        return nil, err
+    }
+    for {
+        if _, ok := p.accept("and", "&&"); !ok {
+            return left, nil
+        }
+        right, err := p.parseNot()
+        if err != nil {
+            return nil, err
+        }
+        left = &binary{op: "and", left: left, right: right}
+    }
+}
+
+func (p *parser) parseNot() (node, error) {
+    if _, ok := p.accept("not", "!"); ok {
+        x, err := p.parseNot()
+        if err != nil {
+            return nil, err
+        }
+        return &unary{op: "not", x: x}, nil
+    }
+    return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+    left, err := p.parseAdditive()
+    if err != nil {
+        return nil, err
+    }
+    for {
+        t := p.peek()
+        switch {
+        case t.kind == tokenOperator && comparisons[t.text]:
+            p.next()
+            right, err := p.parseAdditive()
+            if err != nil {
+                return nil, err
+            }
+            left = &binary{op: t.text, left: left, right: right}
+
+        case t.kind == tokenIdent && t.text == "crosses":
+            p.next()
+            direction := ""
+            if d, ok := p.accept("above", "below"); ok {
+                direction = d.text
+            }
+            right, err := p.parseAdditive()
+            if err != nil {
+                return nil, err
+            }
+            left = &cross{direction: direction, left: left, right: right}
+
+        default:
+            return left, nil
+        }
+    }
+}
+
+func (p *parser) parseAdditive() (node, error) {
+    left, err := p.parseMultiplicative()
+    if err != nil {
+        return nil, err
+    }
+    for {
+        op, ok := p.accept("+", "-")
+        if !ok {
+            return left, nil
+        }
+        right, err := p.parseMultiplicative()
+        if err != nil {
+            return nil, err
+        }
+        left = &binary{op: op.text, left: left, right: right}
+    }
+}
+
+func (p *parser) parseMultiplicative() (node, error) {
+    left, err := p.parseUnary()
+    if err != nil {
+        return nil, err
+    }
+    for {
+        op, ok := p.accept("*", "/")
+        if !ok {
+            return left, nil
+        }
+        right, err := p.parseUnary()
+        if err != nil {
+            return nil, err
+        }
+        left = &binary{op: op.text, left: left, right: right}
+    }
+}
+
+func (p *parser) parseUnary() (node, error) {
+    if _, ok := p.accept("-"); ok {
+        x, err := p.parseUnary()
+        if err != nil {
+            return nil, err
+        }
+        // Negative literals stay literals so they can be parameters
+        if n, ok := x.(*number); ok {
+            return &number{value: -n.value}, nil
+        }
+        return &unary{op: "-", x: x}, nil
+    }
+    return p.parsePostfix()
+}
+
+func (p *parser) parsePostfix() (node, error) {
+    x, err := p.parsePrimary()
+    if err != nil {
+        return nil, err
+    }
+    for {
+        switch {
+        case p.peek().text == "[" && p.peek().kind == tokenOperator:
+            p.next()
+            bars, err := p.parseInteger("lookback", 0)
+            if err != nil {
+                return nil, err
+            }
+            if err := p.expect("]"); err != nil {
+                return nil, err
+            }
+            x = &lookback{x: x, bars: bars}
+
+        case p.peek().text == "." && p.peek().kind == tokenOperator:
+            p.next()
+            ind, ok := x.(*indicator)
+            if !ok || ind.selected {
+                return nil, fmt.Errorf("%s has no outputs to select", x)
+            }
+            name := p.next()
+            if name.kind != tokenIdent {
+                return nil, fmt.Errorf("expected output name after %s.", x)
+            }
+            if !hasOutput(ind.def, name.text) {
+                return nil, fmt.Errorf("%s has no output %q, expected one of %s",
+                    ind.def.Name, name.text, strings.Join(ind.def.Outputs, ", "))
+            }
+            if lookAhead(ind.def, name.text) {
+                return nil, fmt.Errorf("%s.%s uses later bars and cannot be used in expressions", ind.def.Name, name.text)
+            }
+            ind.output, ind.selected = name.text, true
+
+        default:
+            return x, nil
+        }
+    }
+}
+
+// parseInteger parses a non-negative integer literal of at least min
+func (p *parser) parseInteger(what string, min int) (int, error) {
+    t := p.next()
+    if t.kind != tokenNumber || t.value != math.Trunc(t.value) {
+        return 0, fmt.Errorf("%s must be an integer at position %d", what, t.pos)
+    }
+    if t.value < float64(min) {
+        return 0, fmt.Errorf("%s must be at least %d at position %d", what, min, t.pos)
+    }
+    if t.value > math.MaxInt32 {
+        return 0, fmt.Errorf("%s must be at most %d at position %d", what, math.MaxInt32, t.pos)
+    }
+    return int(t.value), nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+    t := p.peek()
+    switch {
+    case t.kind == tokenNumber:
+        p.next()
+        return &number{value: t.value}, nil
+
+    case t.kind == tokenIdent && !keywords[t.text]:
+        p.next()
+        if _, ok := p.accept("("); ok {
+            args, err := p.parseArgs()
+            if err != nil {
+                return nil, err
+            }
+            return newCall(t, args)
+        }
+        if !columns[t.text] {
+            return nil, fmt.Errorf("unknown series %q at position %d", t.text, t.pos)
+        }
+        return &column{name: t.text}, nil
+
+    case t.kind == tokenOperator && t.text == "(":
+        p.next()
+        x, err := p.parseOr()
+        if err != nil {
+            return nil, err
+        }
+        if err := p.expect(")"); err != nil {
+            return nil, err
+        }
+        return x, nil
+    }
+
+    return nil, p.unexpected("")
+}
+
+// parseArgs parses a call's arguments after the opening parenthesis
+func (p *parser) parseArgs() ([]node, error) {
+    var args []node
+    if _, ok := p.accept(")"); ok {
+        return args, nil
+    }
+    for {
+        arg, err := p.parseOr()
+        if err != nil {
+            return nil, err
+        }
+        args = append(args, arg)
+        if _, ok := p.accept(")"); ok {
+            return args, nil
+        }
+        if err := p.expect(","); err != nil {
+            return nil, err
+        }
+    }
+}
+
+// newCall resolves a call to a builtin or an indicator and checks its
+// arguments
+func newCall(name token, args []node) (node, error) {
+    if b, ok := builtins[name.text]; ok {
+        if len(args) != b.args {
+            return nil, fmt.Errorf("%s takes %d arguments, got %d at position %d", name.text, b.args, len(args), name.pos)
+        }
+        c := &call{name: name.text, args: args}
+        if b.lookback {
+            n, ok := args[1].(*number)
+            if !ok || n.value != math.Trunc(n.value) || n.value < 1 {
+                return nil, fmt.Errorf("%s length must be a positive integer at position %d", name.text, name.pos)
+            }
+            if n.value > math.MaxInt32 {
+                return nil, fmt.Errorf("%s length must be at most %d at position %d", name.text, math.MaxInt32, name.pos)
+            }
+            c.args, c.window = args[:1], int(n.value)
+        }
+        return c, nil
+    }
+
+    def, ok := indicators.Lookup(name.text)
+    if !ok {
+        return nil, fmt.Errorf("unknown function %q at position %d", name.text, name.pos)
+    }
+    if len(def.Outputs) == 0 {
+        return nil, fmt.Errorf("%s does not return a series at position %d", name.text, name.pos)
+    }
+
+    ind := &indicator{def: def, output: def.Outputs[0]}
+    // A leading argument that is not a literal is the source series
+    if def.Source && len(args) > 0 {
+        if _, literal := args[0].(*number); !literal {
+            ind.source, args = args[0], args[1:]
+        }
+    }
+    if len(args) > len(def.Params) {
+        return nil, fmt.Errorf("%s takes %s, got %d arguments at position %d", name.text, usage(def), len(args), name.pos)
+    }
+
+    // Arguments are the numeric parameters in order; the registry coerces
+    // and range-checks them and applies defaults
+    raw := make(map[string]interface{}, len(args))
+    for i, arg := range args {
+        param := def.Params[i]
+        n, ok := arg.(*number)
+        if !ok || param.Type == indicators.TypeString {
+            return nil, fmt.Errorf("%s %s must be a number at position %d", name.text, param.Name, name.pos)
+        }
+        raw[param.Name] = n.value
+        ind.args = append(ind.args, n.value)
+    }
+    params, err := def.Validate(raw)
+    if err != nil {
+        return nil, fmt.Errorf("%w at position %d", err, name.pos)
+    }
+    ind.params = params
+
+    return ind, nil
+}
@@ -0,0 +1,555 @@
+package indicators
+
+import (
+    "fmt"
+
+    "github.com/algo-trading/market-data-service/internal/models"
+)
+
+// valueOutput is the output of single-series indicators
+var valueOutput = []string{"value"}
+
+func periodParam() Param {
+    return IntParam("period", "lookback period in bars").WithMin(1)
+}
+
+// maTypeOptions lists the moving average names accepted by ParseMAType
+func maTypeOptions() []string {
+    var options []string
+    for maType := SMA; maType <= VWMA; maType++ {
+        options = append(options, maType.String())
+    }
+    return options
+}
+
+func maTypeParam(name, description string, defaultType MAType) Param {
+    return StringParam(name, description, maTypeOptions()...).WithDefault(defaultType.String())
+}
+
+// maTypeFirst returns the first valid index of a moving average selected by
+// a validated ma_type parameter
+func maTypeFirst(params Params, name string, period int) int {
+    maType, err := ParseMAType(params.String(name))
+    if err != nil {
+        return 0
+    }
+    return maFirst(maType, period)
+}
+
+// periodWarmUp declares the first defined value at period plus offset for
+// every output
+func periodWarmUp(offset int) func(Params, string) int {
+    return func(params Params, _ string) int {
+        return params.Int("period") + offset
+    }
+}
+
+// estimatorWarmUp returns the first valid index of a volatility estimator:
+// close-to-close returns need one bar more than the per-bar range estimators
+func estimatorWarmUp(estimator VolatilityEstimator, period int) int {
+    if estimator == Parkinson || estimator == GarmanKlass {
+        return period - 1
+    }
+    return period
+}
+
+// maDefinition registers a moving average type under its own name
+func maDefinition(maType MAType, description string) Definition {
+    return Definition{
+        Name:        maType.String(),
+        Description: description,
+        Params:      []Param{periodParam()},
+        Outputs:     valueOutput,
+        Source:      true,
+        WarmUp: func(params Params, _ string) int {
+            return maFirst(maType, params.Int("period"))
+        },
+        Calculate: func(data []models.OHLCV, params Params) (interface{}, error) {
+            return CalculateMA(data, params.Int("period"), maType)
+        },
+    }
+}
+
+// periodDefinition registers a single-series indicator taking a period whose
+// first value is defined at period plus offset
+func periodDefinition(name, description string, source bool, offset int, calculate func([]models.OHLCV, int) ([]float64, error)) Definition {
+    return Definition{
+        Name:        name,
+        Description: description,
+        Params:      []Param{periodParam()},
+        Outputs:     valueOutput,
+        Source:      source,
+        WarmUp:      periodWarmUp(offset),
+        Calculate: func(data []models.OHLCV, params Params) (interface{}, error) {
+            return calculate(data, params.Int("period"))
+        },
+    }
+}
+
+// statPeriodParam is the lookback of statistics that need two values
+func statPeriodParam() Param {
+    return periodParam().WithMin(2)
+}
+
+func periodsPerYearParam() Param {
+    return FloatParam("periods_per_year", "bars per year used to annualise").WithMin(1).WithDefault(float64(TradingDaysPerYear))
+}
+
+// volatilityDefinition registers a volatility estimator under its own name
+func volatilityDefinition(estimator VolatilityEstimator, description string) Definition {
+    return Definition{
+        Name:        estimator.String() + "_volatility",
+        Description: description,
+        Params:      []Param{statPeriodParam(), periodsPerYearParam()},
+        Outputs:     valueOutput,
+        Source:      estimator == CloseToClose,
+        WarmUp: func(params Params, _ string) int {
+            return estimatorWarmUp(estimator, params.Int("period"))
+        },
+        Calculate: func(data []models.OHLCV, params Params) (interface{}, error) {
+            return CalculateHistoricalVolatility(data, params.Int("period"), estimator, params.Float("periods_per_year"))
+        },
+    }
+}
+
+// volatilityEstimatorOptions lists the names accepted by
+// ParseVolatilityEstimator
+func volatilityEstimatorOptions() []string {
+    var options []string
+    for estimator := CloseToClose; estimator <= YangZhang; estimator++ {
+        options = append(options, estimator.String())
+    }
+    return options
+}
+
+// returnRatioDefinition registers a risk-adjusted return ratio
+func returnRatioDefinition(name, description string, calculate func([]models.OHLCV, int, float64, float64) ([]float64, error)) Definition {
+    return Definition{
+        Name:        name,
+        Description: description,
+        Params: []Param{
+            statPeriodParam(),
+            FloatParam("risk_free", "annualised risk-free rate").WithDefault(0.0),
+            periodsPerYearParam(),
+        },
+        Outputs: valueOutput,
+        Source:  true,
+        WarmUp:  periodWarmUp(0),
+        Calculate: func(data []models.OHLCV, params Params) (interface{}, error) {
+            return calculate(data, params.Int("period"), params.Float("risk_free"), params.Float("periods_per_year"))
+        },
+    }
+}
+
+// varDefinition registers a Value-at-Risk method
+func varDefinition(name, description string, calculate func([]models.OHLCV, int, float64) ([]float64, error)) Definition {
+    return Definition{
+        Name:        name,
+        Description: description,
+        Params: []Param{
+            statPeriodParam(),
+            FloatParam("confidence", "confidence level").WithMin(0.5).WithMax(0.999).WithDefault(0.95),
+        },
+        Outputs: valueOutput,
+        Source:  true,
+        WarmUp:  periodWarmUp(0),
+        Calculate: func(data []models.OHLCV, params Params) (interface{}, error) {
+            return calculate(data, params.Int("period"), params.Float("confidence"))
+        },
+    }
+}
+
+// builtinDefinitions lists the indicators of this package
+func builtinDefinitions() []Definition {
+    return []Definition{
+        maDefinition(SMA, "Simple Moving Average"),
+        maDefinition(EMA, "Exponential Moving Average"),
+        maDefinition(WMA, "Weighted Moving Average"),
+        maDefinition(DEMA, "Double Exponential Moving Average"),
+        maDefinition(TEMA, "Triple Exponential Moving Average"),
+        maDefinition(HMA, "Hull Moving Average"),
+        maDefinition(VWMA, "Volume Weighted Moving Average"),
+        {
+            Name:        "kama",
+            Description: "Kaufman Adaptive Moving Average",
+            Params: []Param{
+                periodParam(),
+                IntParam("fast_period", "fastest smoothing period").WithMin(1).WithDefault(KAMAFastPeriod),
+                IntParam("slow_period", "slowest smoothing period").WithMin(1).WithDefault(KAMASlowPeriod),
+            },
+            Outputs: valueOutput,
+            Source:  true,
+            WarmUp:  periodWarmUp(0),
+            Calculate: func(data []models.OHLCV, params Params) (interface{}, error) {
+                return CalculateKAMA(data, params.Int("period"), params.Int("fast_period"), params.Int("slow_period"))
+            },
+        },
+        {
+            Name:        "ma",
+            Description: "Moving average of the type selected by ma_type",
+            Params:      []Param{periodParam(), maTypeParam("ma_type", "moving average type", SMA)},
+            Outputs:     valueOutput,
+            Source:      true,
+            WarmUp: func(params Params, _ string) int {
+                return maTypeFirst(params, "ma_type", params.Int("period"))
+            },
+            Calculate: func(data []models.OHLCV, params Params) (interface{}, error) {
+                maType, err := ParseMAType(params.String("ma_type"))
+                if err != nil {
+                    return nil, err
+                }
+                return CalculateMA(data, params.Int("period"), maType)
+            },
+        },
+        periodDefinition("rsi", "Relative Strength Index", true, 0, CalculateRSI),
+        {
+            Name:        "macd",
+            Description: "Moving Average Convergence Divergence",
+            Params: []Param{
+                IntParam("fast_period", "fast EMA period").WithMin(1),
+                IntParam("slow_period", "slow EMA period").WithMin(1),
+                IntParam("signal_period", "signal line period").WithMin(1),
+                maTypeParam("signal_ma", "signal line moving average", EMA),
+            },
+            Outputs: []string{"macd", "signal", "histogram"},
+            Source:  true,
+            WarmUp: func(params Params, output string) int {
+                first := max(params.Int("fast_period"), params.Int("slow_period")) - 1
+                if output == "macd" {
+                    return first
+                }
+                return first + maTypeFirst(params, "signal_ma", params.Int("signal_period"))
+            },
+            Calculate: func(data []models.OHLCV, params Params) (interface{}, error) {
+                signalType, err := ParseMAType(params.String("signal_ma"))
+                if err != nil {
+                    return nil, err
+                }
+                return CalculateMACDWithMA(data, params.Int("fast_period"), params.Int("slow_period"), params.Int("signal_period"), signalType)
+            },
+        },
+        {
+            Name:        "bollinger_bands",
+            Description: "Bollinger Bands",
+            Params: []Param{
+                periodParam(),
+                FloatParam("deviation", "band width in standard deviations").WithMin(0),
+                maTypeParam("basis_ma", "middle band moving average", SMA),
+            },
+            Outputs: []string{"middle", "upper", "lower"},
+            Source:  true,
+            WarmUp: func(params Params, _ string) int {
+                return maTypeFirst(params, "basis_ma", params.Int("period"))
+            },
+            Calculate: func(data []models.OHLCV, params Params) (interface{}, error) {
+                basisType, err := ParseMAType(params.String("basis_ma"))
+                if err != nil {
+                    return nil, err
+                }
+                return CalculateBollingerBandsWithMA(data, params.Int("period"), params.Float("deviation"), basisType)
+            },
+        },
+        {
+            Name:        "stochastic",
+            Description: "Stochastic Oscillator",
+            Params: []Param{
+                IntParam("k_period", "%K lookback period").WithMin(1),
+                IntParam("d_period", "%D smoothing period").WithMin(1),
+                maTypeParam("d_ma", "%D moving average", SMA),
+            },
+            Outputs: []string{"k", "d"},
+            WarmUp: func(params Params, output string) int {
+                first := params.Int("k_period") - 1
+                if output == "k" {
+                    return first
+                }
+                return first + maTypeFirst(params, "d_ma", params.Int("d_period"))
+            },
+            Calculate: func(data []models.OHLCV, params Params) (interface{}, error) {
+                dType, err := ParseMAType(params.String("d_ma"))
+                if err != nil {
+                    return nil, err
+                }
+                return CalculateStochasticWithMA(data, params.Int("k_period"), params.Int("d_period"), dType)
+            },
+        },
+        periodDefinition("atr", "Average True Range", false, 0, CalculateATR),
+        {
+            Name:        "adx",
+            Description: "Average Directional Index with +DI and -DI",
+            Params:      []Param{periodParam()},
+            Outputs:     []string{"adx", "plus_di", "minus_di"},
+            WarmUp: func(params Params, output string) int {
+                if output == "adx" {
+                    return 2*params.Int("period") - 1
+                }
+                return params.Int("period")
+            },
+            Calculate: func(data []models.OHLCV, params Params) (interface{}, error) {
+                return CalculateADX(data, params.Int("period"))
+            },
+        },
+        {
+            Name:        "supertrend",
+            Description: "Supertrend",
+            Params: []Param{
+                periodParam(),
+                FloatParam("multiplier", "ATR multiplier").WithMin(0),
+            },
+            Outputs: []string{"value", "direction", "upper", "lower"},
+            WarmUp:  periodWarmUp(0),
+            Calculate: func(data []models.OHLCV, params Params) (interface{}, error) {
+                return CalculateSupertrend(data, params.Int("period"), params.Float("multiplier"))
+            },
+        },
+        {
+            Name:        "parabolic_sar",
+            Description: "Parabolic Stop and Reverse",
+            Params: []Param{
+                FloatParam("step", "acceleration factor step").WithMin(0.001).WithMax(1).WithDefault(0.02),
+                FloatParam("max_step", "maximum acceleration factor").WithMin(0.001).WithMax(1).WithDefault(0.2),
+            },
+            Outputs: []string{"sar", "trend"},
+            WarmUp: func(Params, string) int {
+                return 1
+            },
+            Calculate: func(data []models.OHLCV, params Params) (interface{}, error) {
+                return CalculateParabolicSAR(data, params.Float("step"), params.Float("max_step"))
+            },
+        },
+        {
+            Name:        "ichimoku",
+            Description: "Ichimoku Cloud",
+            Params: []Param{
+                IntParam("tenkan_period", "conversion line period").WithMin(1).WithDefault(9),
+                IntParam("kijun_period", "base line period").WithMin(1).WithDefault(26),
+                IntParam("senkou_period", "leading span B period").WithMin(1).WithDefault(52),
+            },
+            Outputs:   []string{"tenkan", "kijun", "senkou_a", "senkou_b", "chikou"},
+            LookAhead: []string{"chikou"},
+            WarmUp: func(params Params, output string) int {
+                tenkan, kijun, senkou := params.Int("tenkan_period"), params.Int("kijun_period"), params.Int("senkou_period")
+                switch output {
+                case "tenkan":
+                    return tenkan - 1
+                case "kijun":
+                    return kijun - 1
+                case "senkou_a":
+                    return max(tenkan, kijun) - 1 + kijun
+                case "senkou_b":
+                    return senkou - 1 + kijun
+                }
+                return 0
+            },
+            Calculate: func(data []models.OHLCV, params Params) (interface{}, error) {
+                return CalculateIchimoku(data, params.Int("tenkan_period"), params.Int("kijun_period"), params.Int("senkou_period"))
+            },
+        },
+        {
+            Name:        "aroon",
+            Description: "Aroon Up, Down and Oscillator",
+            Params:      []Param{periodParam()},
+            Outputs:     []string{"oscillator", "up", "down"},
+            WarmUp:      periodWarmUp(0),
+            Calculate: func(data []models.OHLCV, params Params) (interface{}, error) {
+                return CalculateAroon(data, params.Int("period"))
+            },
+        },
+        {
+            Name:        "keltner_channels",
+            Description: "Keltner Channels around an EMA",
+            Params: []Param{
+                IntParam("period", "EMA period").WithMin(1),
+                FloatParam("multiplier", "ATR multiplier").WithMin(0),
+                IntParam("atr_period", "ATR period, the EMA period by default").WithMin(1).Optional(),
+            },
+            Outputs: []string{"middle", "upper", "lower"},
+            WarmUp: func(params Params, _ string) int {
+                atrPeriod := params.Int("period")
+                if params.Has("atr_period") {
+                    atrPeriod = params.Int("atr_period")
+                }
+                return max(params.Int("period")-1, atrPeriod)
+            },
+            Calculate: func(data []models.OHLCV, params Params) (interface{}, error) {
+                atrPeriod := params.Int("period")
+                if params.Has("atr_period") {
+                    atrPeriod = params.Int("atr_period")
+                }
+                return CalculateKeltnerChannels(data, params.Int("period"), atrPeriod, params.Float("multiplier"))
+            },
+        },
+        {
+            Name:        "donchian_channels",
+            Description: "Donchian Channels of the highest high and lowest low",
+            Params:      []Param{periodParam()},
+            Outputs:     []string{"middle", "upper", "lower"},
+            WarmUp:      periodWarmUp(-1),
+            Calculate: func(data []models.OHLCV, params Params) (interface{}, error) {
+                return CalculateDonchianChannels(data, params.Int("period"))
+            },
+        },
+        {
+            Name:        "vwap",
+            Description: "Session VWAP with standard deviation bands",
+            Params: []Param{
+                FloatParam("deviation", "band width in standard deviations").WithMin(0).WithDefault(2.0),
+            },
+            Outputs: []string{"vwap", "std_dev", "upper", "lower"},
+            Calculate: func(data []models.OHLCV, params Params) (interface{}, error) {
+                return CalculateVWAP(data, params.Float("deviation"))
+            },
+        },
+        {
+            Name:        "obv",
+            Description: "On-Balance Volume",
+            Outputs:     valueOutput,
+            Calculate: func(data []models.OHLCV, params Params) (interface{}, error) {
+                return CalculateOBV(data)
+            },
+        },
+        periodDefinition("mfi", "Money Flow Index", false, 0, CalculateMFI),
+        {
+            Name:        "ad",
+            Description: "Accumulation/Distribution line",
+            Outputs:     valueOutput,
+            Calculate: func(data []models.OHLCV, params Params) (interface{}, error) {
+                return CalculateAD(data)
+            },
+        },
+        periodDefinition("cmf", "Chaikin Money Flow", false, -1, CalculateCMF),
+        {
+            Name:        "volume_profile",
+            Description: "Volume by price level with point of control and value area",
+            Params:      []Param{IntParam("bins", "number of price levels").WithMin(1)},
+            Calculate: func(data []models.OHLCV, params Params) (interface{}, error) {
+                return CalculateVolumeProfile(data, params.Int("bins"))
+            },
+        },
+        volatilityDefinition(CloseToClose, "Annualised close-to-close historical volatility"),
+        volatilityDefinition(Parkinson, "Annualised Parkinson high-low volatility"),
+        volatilityDefinition(GarmanKlass, "Annualised Garman-Klass OHLC volatility"),
+        volatilityDefinition(YangZhang, "Annualised Yang-Zhang volatility including overnight gaps"),
+        {
+            Name:        "historical_volatility",
+            Description: "Annualised historical volatility of the estimator selected by estimator",
+            Params: []Param{
+                statPeriodParam(),
+                StringParam("estimator", "volatility estimator", volatilityEstimatorOptions()...).WithDefault(CloseToClose.String()),
+                periodsPerYearParam(),
+            },
+            Outputs: valueOutput,
+            WarmUp: func(params Params, _ string) int {
+                estimator, err := ParseVolatilityEstimator(params.String("estimator"))
+                if err != nil {
+                    return 0
+                }
+                return estimatorWarmUp(estimator, params.Int("period"))
+            },
+            Calculate: func(data []models.OHLCV, params Params) (interface{}, error) {
+                estimator, err := ParseVolatilityEstimator(params.String("estimator"))
+                if err != nil {
+                    return nil, err
+                }
+                return CalculateHistoricalVolatility(data, params.Int("period"), estimator, params.Float("periods_per_year"))
+            },
+        },
+        returnRatioDefinition("sharpe", "Rolling annualised Sharpe ratio", CalculateSharpe),
+        returnRatioDefinition("sortino", "Rolling annualised Sortino ratio", CalculateSortino),
+        {
+            Name:        "drawdown",
+            Description: "Decline from the running peak close and the maximum drawdown so far",
+            Outputs:     []string{"drawdown", "max_drawdown"},
+            Source:      true,
+            Calculate: func(data []models.OHLCV, params Params) (interface{}, error) {
+                return CalculateDrawdown(data)
+            },
+        },
+        varDefinition("historical_var", "One-bar historical Value-at-Risk", CalculateHistoricalVaR),
+        varDefinition("parametric_var", "One-bar parametric (normal) Value-at-Risk", CalculateParametricVaR),
+        {
+            Name:        "zscore",
+            Description: "Standard deviations of the close from its mean",
+            Params:      []Param{statPeriodParam()},
+            Outputs:     valueOutput,
+            Source:      true,
+            WarmUp:      periodWarmUp(-1),
+            Calculate: func(data []models.OHLCV, params Params) (interface{}, error) {
+                return CalculateZScore(data, params.Int("period"))
+            },
+        },
+        {
+            Name:        "implied_volatility",
+            Description: "Implied volatility of an option on the last close",
+            Params:      optionParams(FloatParam("option_price", "option premium").WithMin(0)),
+            Calculate: func(data []models.OHLCV, params Params) (interface{}, error) {
+                contract, err := optionContract(data, params)
+                if err != nil {
+                    return nil, err
+                }
+                return ImpliedVolatility(contract, params.Float("option_price"))
+            },
+        },
+        {
+            Name:        "option_greeks",
+            Description: "Greeks of an option on the last close, at a volatility or the implied volatility of a price",
+            Params: optionParams(
+                FloatParam("option_price", "option premium").WithMin(0).Optional(),
+                FloatParam("volatility", "annualised volatility").WithMin(0).Optional(),
+            ),
+            Calculate: func(data []models.OHLCV, params Params) (interface{}, error) {
+                contract, err := optionContract(data, params)
+                if err != nil {
+                    return nil, err
+                }
+                if params.Has("volatility") {
+                    return contract.Greeks(params.Float("volatility")), nil
+                }
+                if !params.Has("option_price") {
+                    return nil, &ParamError{Indicator: "option_greeks", Param: "option_price", Reason: "is required without volatility"}
+                }
+                return AnalyzeOption(contract, params.Float("option_price"))
+            },
+        },
+    }
+}
+
+// optionParams declares the contract parameters of option indicators
+func optionParams(extra ...Param) []Param {
+    return append([]Param{
+        StringParam("option_type", "call or put", "call", "put", "c", "p", "ce", "pe"),
+        FloatParam("strike", "strike price").WithMin(0),
+        FloatParam("expiry", "time to expiry in years").WithMin(0),
+        FloatParam("rate", "annualised risk-free rate").WithDefault(0.0),
+        FloatParam("dividend_yield", "annualised dividend yield").WithDefault(0.0),
+        StringParam("model", "pricing model", "black_scholes", "black76").WithDefault("black_scholes"),
+    }, extra...)
+}
+
+// optionContract builds an option contract on the last close of data, e.g.
+// the underlying's latest bar
+func optionContract(data []models.OHLCV, params Params) (OptionContract, error) {
+    if len(data) == 0 {
+        return OptionContract{}, fmt.Errorf("insufficient data: need 1, got 0")
+    }
+
+    optionType, err := ParseOptionType(params.String("option_type"))
+    if err != nil {
+        return OptionContract{}, err
+    }
+
+    contract := OptionContract{
+        Type:       optionType,
+        Underlying: data[len(data)-1].Close,
+        Strike:     params.Float("strike"),
+        Expiry:     params.Float("expiry"),
+        Rate:       params.Float("rate"),
+        Dividend:   params.Float("dividend_yield"),
+        Model:      BlackScholes,
+    }
+    if params.String("model") == "black76" {
+        contract.Model = Black76
+    }
+
+    return contract, nil
+}
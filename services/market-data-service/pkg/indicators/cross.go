@@ -0,0 +1,223 @@
+package indicators
+
+import (
+    "fmt"
+    "math"
+
+    "github.com/algo-trading/market-data-service/internal/models"
+)
+
+// Align returns the bars of a and b that share a time, so cross-symbol
+// indicators compare the same bars. Both must be oldest first.
+func Align(a, b []models.OHLCV) ([]models.OHLCV, []models.OHLCV) {
+    var alignedA, alignedB []models.OHLCV
+    for i, j := 0, 0; i < len(a) && j < len(b); {
+        switch {
+        case a[i].Time.Before(b[j].Time):
+            i++
+        case b[j].Time.Before(a[i].Time):
+            j++
+        default:
+            alignedA = append(alignedA, a[i])
+            alignedB = append(alignedB, b[j])
+            i++
+            j++
+        }
+    }
+    return alignedA, alignedB
+}
+
+// checkAligned verifies a pair of series has the same bars and at least need
+// of them
+func checkAligned(a, b []models.OHLCV, need int) error {
+    if len(a) != len(b) {
+        return fmt.Errorf("series are not aligned: %d and %d bars", len(a), len(b))
+    }
+    for i := range a {
+        if !a[i].Time.Equal(b[i].Time) {
+            return fmt.Errorf("series are not aligned at bar %d: %s and %s", i, a[i].Time, b[i].Time)
+        }
+    }
+    if len(a) < need {
+        return fmt.Errorf("insufficient data: need %d, got %d", need, len(a))
+    }
+    return nil
+}
+
+// returns is the close-to-close return of each bar, zero for the first
+func returns(data []models.OHLCV) []float64 {
+    result := make([]float64, len(data))
+    for i := 1; i < len(data); i++ {
+        if data[i-1].Close != 0 {
+            result[i] = data[i].Close/data[i-1].Close - 1
+        }
+    }
+    return result
+}
+
+// windowMoments returns the means, variances and covariance of x and y over
+// the period values ending at end
+func windowMoments(x, y []float64, end, period int) (meanX, meanY, varX, varY, cov float64) {
+    for i := end - period + 1; i <= end; i++ {
+        meanX += x[i]
+        meanY += y[i]
+    }
+    meanX /= float64(period)
+    meanY /= float64(period)
+
+    for i := end - period + 1; i <= end; i++ {
+        dx, dy := x[i]-meanX, y[i]-meanY
+        varX += dx * dx
+        varY += dy * dy
+        cov += dx * dy
+    }
+    n := float64(period)
+    return meanX, meanY, varX / n, varY / n, cov / n
+}
+
+// CalculateCorrelation calculates the rolling Pearson correlation of the
+// returns of two aligned series over period bars. The first period values
+// are zero, as are windows in which either series is flat.
+func CalculateCorrelation(data, other []models.OHLCV, period int) ([]float64, error) {
+    if period < 2 {
+        return nil, fmt.Errorf("invalid period: %d", period)
+    }
+    if err := checkAligned(data, other, period+1); err != nil {
+        return nil, err
+    }
+
+    x, y := returns(data), returns(other)
+    result := make([]float64, len(data))
+    for i := period; i < len(data); i++ {
+        _, _, varX, varY, cov := windowMoments(x, y, i, period)
+        if varX > 0 && varY > 0 {
+            result[i] = cov / math.Sqrt(varX*varY)
+        }
+    }
+
+    return result, nil
+}
+
+// CalculateBeta calculates the rolling beta of data's returns against a
+// benchmark's over period bars. The first period values are zero, as are
+// windows in which the benchmark is flat.
+func CalculateBeta(data, benchmark []models.OHLCV, period int) ([]float64, error) {
+    if period < 2 {
+        return nil, fmt.Errorf("invalid period: %d", period)
+    }
+    if err := checkAligned(data, benchmark, period+1); err != nil {
+        return nil, err
+    }
+
+    x, y := returns(data), returns(benchmark)
+    result := make([]float64, len(data))
+    for i := period; i < len(data); i++ {
+        _, _, _, varY, cov := windowMoments(x, y, i, period)
+        if varY > 0 {
+            result[i] = cov / varY
+        }
+    }
+
+    return result, nil
+}
+
+// RelativeStrength represents the price of a symbol relative to a benchmark
+type RelativeStrength struct {
+    Ratio     []float64 // close / benchmark close, rebased to 100 at the first bar
+    Mansfield []float64 // percent distance of Ratio from its period SMA
+}
+
+// CalculateRelativeStrength compares a symbol with a benchmark such as an
+// index. Mansfield is positive while the symbol outperforms its recent
+// relative trend; its first period-1 values are zero.
+func CalculateRelativeStrength(data, benchmark []models.OHLCV, period int) (*RelativeStrength, error) {
+    if period <= 0 {
+        return nil, fmt.Errorf("invalid period: %d", period)
+    }
+    if err := checkAligned(data, benchmark, period); err != nil {
+        return nil, err
+    }
+    if data[0].Close == 0 || benchmark[0].Close == 0 {
+        return nil, fmt.Errorf("cannot rebase relative strength on a zero close")
+    }
+
+    base := data[0].Close / benchmark[0].Close
+    result := &RelativeStrength{
+        Ratio:     make([]float64, len(data)),
+        Mansfield: make([]float64, len(data)),
+    }
+    for i := range data {
+        if benchmark[i].Close != 0 {
+            result.Ratio[i] = 100 * data[i].Close / benchmark[i].Close / base
+        }
+    }
+
+    average, first := smaValues(result.Ratio, 0, period)
+    for i := first; i < len(data); i++ {
+        if average[i] != 0 {
+            result.Mansfield[i] = 100 * (result.Ratio[i]/average[i] - 1)
+        }
+    }
+
+    return result, nil
+}
+
+// PairSpread represents the spread of a pair of symbols
+type PairSpread struct {
+    Ratio      []float64 // a close / b close
+    HedgeRatio []float64 // slope of a regressed on b over the period
+    Spread     []float64 // a - HedgeRatio * b
+    ZScore     []float64 // Spread in standard deviations from its period mean
+}
+
+// CalculatePairSpread calculates the ratio and the hedged spread of a pair.
+// The hedge ratio is re-estimated on every bar from the last period closes
+// and the z-score uses the spread of that window under the same hedge ratio,
+// so it does not mix hedge ratios. Values before period-1 are zero, except
+// Ratio.
+func CalculatePairSpread(a, b []models.OHLCV, period int) (*PairSpread, error) {
+    if period < 2 {
+        return nil, fmt.Errorf("invalid period: %d", period)
+    }
+    if err := checkAligned(a, b, period); err != nil {
+        return nil, err
+    }
+
+    x, y := closes(b), closes(a)
+    result := &PairSpread{
+        Ratio:      make([]float64, len(a)),
+        HedgeRatio: make([]float64, len(a)),
+        Spread:     make([]float64, len(a)),
+        ZScore:     make([]float64, len(a)),
+    }
+    for i := range a {
+        if x[i] != 0 {
+            result.Ratio[i] = y[i] / x[i]
+        }
+    }
+
+    for i := period - 1; i < len(a); i++ {
+        meanX, meanY, varX, _, cov := windowMoments(x, y, i, period)
+        if varX == 0 {
+            continue
+        }
+        hedge := cov / varX
+
+        // The window's residuals have mean meanY - hedge*meanX
+        mean := meanY - hedge*meanX
+        variance := 0.0
+        for j := i - period + 1; j <= i; j++ {
+            d := y[j] - hedge*x[j] - mean
+            variance += d * d
+        }
+        stdDev := math.Sqrt(variance / float64(period))
+
+        result.HedgeRatio[i] = hedge
+        result.Spread[i] = y[i] - hedge*x[i]
+        if stdDev > 0 {
+            result.ZScore[i] = (result.Spread[i] - mean) / stdDev
+        }
+    }
+
+    return result, nil
+}
@@ -0,0 +1,157 @@
+package indicators
+
+import (
+    "math"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/algo-trading/market-data-service/internal/models"
+    "github.com/algo-trading/market-data-service/internal/testutil"
+)
+
+// scaleReturns builds a series whose return on every bar is factor times
+// data's
+func scaleReturns(data []models.OHLCV, factor float64) []models.OHLCV {
+    r := returns(data)
+    result := make([]models.OHLCV, len(data))
+    price := 500.0
+    for i, bar := range data {
+        price *= 1 + factor*r[i]
+        result[i] = models.OHLCV{Time: bar.Time, Symbol: "OTHER", Open: price, High: price, Low: price, Close: price}
+    }
+    return result
+}
+
+func TestAlign(t *testing.T) {
+    data := testutil.RandomWalk("TEST", 10, 1)
+    other := scaleReturns(data, 1)
+
+    // Each side misses bars the other has
+    a := append(append([]models.OHLCV{}, data[:3]...), data[5:]...)
+    b := append(append([]models.OHLCV{}, other[1:8]...), other[9])
+
+    alignedA, alignedB := Align(a, b)
+    expected := []int{1, 2, 5, 6, 7, 9}
+    if len(alignedA) != len(expected) || len(alignedB) != len(expected) {
+        t.Fatalf("Expected %d aligned bars, got %d and %d", len(expected), len(alignedA), len(alignedB))
+    }
+    for i, index := range expected {
+        if !alignedA[i].Time.Equal(data[index].Time) || !alignedB[i].Time.Equal(data[index].Time) {
+            t.Errorf("Bar %d: expected %s, got %s and %s", i, data[index].Time, alignedA[i].Time, alignedB[i].Time)
+        }
+    }
+}
+
+func TestCalculateCorrelationAndBeta(t *testing.T) {
+    data := testutil.RandomWalk("TEST", 100, 2)
+    period := 20
+
+    tests := []struct {
+        factor      float64
+        correlation float64
+        beta        float64
+    }{
+        {2, 1, 0.5},
+        {-1, -1, -1},
+    }
+
+    for _, tt := range tests {
+        other := scaleReturns(data, tt.factor)
+
+        correlation, err := CalculateCorrelation(data, other, period)
+        if err != nil {
+            t.Fatalf("Failed to calculate correlation: %v", err)
+        }
+        beta, err := CalculateBeta(data, other, period)
+        if err != nil {
+            t.Fatalf("Failed to calculate beta: %v", err)
+        }
+
+        if correlation[period-1] != 0 || beta[period-1] != 0 {
+            t.Errorf("Expected zero before %d bars of returns", period)
+        }
+        for i := period; i < len(data); i++ {
+            if math.Abs(correlation[i]-tt.correlation) > 1e-9 || math.Abs(beta[i]-tt.beta) > 1e-9 {
+                t.Fatalf("Factor %v, bar %d: expected correlation %v and beta %v, got %v and %v",
+                    tt.factor, i, tt.correlation, tt.beta, correlation[i], beta[i])
+            }
+        }
+    }
+
+    if _, err := CalculateBeta(data[1:], data[:len(data)-1], period); err == nil || !strings.Contains(err.Error(), "not aligned") {
+        t.Errorf("Expected an alignment error, got %v", err)
+    }
+    if _, err := CalculateCorrelation(data[:period], data[:period], period); err == nil {
+        t.Error("Expected an error with fewer than period+1 bars")
+    }
+}
+
+func TestCalculateRelativeStrength(t *testing.T) {
+    benchmark := testutil.RandomWalk("TEST", 30, 3)
+    data := make([]models.OHLCV, len(benchmark))
+    for i, bar := range benchmark {
+        data[i] = bar
+        data[i].Close = 2 * bar.Close
+    }
+    // The symbol gains 10% on the benchmark on the last bar
+    data[29].Close *= 1.1
+
+    rs, err := CalculateRelativeStrength(data, benchmark, 10)
+    if err != nil {
+        t.Fatalf("Failed to calculate relative strength: %v", err)
+    }
+
+    if math.Abs(rs.Ratio[0]-100) > 1e-9 || math.Abs(rs.Ratio[28]-100) > 1e-9 || math.Abs(rs.Ratio[29]-110) > 1e-9 {
+        t.Errorf("Unexpected ratios %v, %v, %v", rs.Ratio[0], rs.Ratio[28], rs.Ratio[29])
+    }
+    if math.Abs(rs.Mansfield[28]) > 1e-9 {
+        t.Errorf("Expected Mansfield 0 while in line with the benchmark, got %v", rs.Mansfield[28])
+    }
+    // 110 against an average of (9*100 + 110)/10
+    if expected := 100 * (110/101.0 - 1); math.Abs(rs.Mansfield[29]-expected) > 1e-9 {
+        t.Errorf("Expected Mansfield %v, got %v", expected, rs.Mansfield[29])
+    }
+}
+
+func TestCalculatePairSpread(t *testing.T) {
+    b := testutil.RandomWalk("TEST", 40, 4)
+    a := make([]models.OHLCV, len(b))
+    for i, bar := range b {
+        a[i] = bar
+        a[i].Close = 3*bar.Close + 5
+    }
+
+    spread, err := CalculatePairSpread(a, b, 20)
+    if err != nil {
+        t.Fatalf("Failed to calculate pair spread: %v", err)
+    }
+    for i := 19; i < len(a); i++ {
+        if math.Abs(spread.HedgeRatio[i]-3) > 1e-6 || math.Abs(spread.Spread[i]-5) > 1e-6 {
+            t.Fatalf("Bar %d: expected hedge ratio 3 and spread 5, got %v and %v", i, spread.HedgeRatio[i], spread.Spread[i])
+        }
+    }
+    if spread.HedgeRatio[18] != 0 || spread.Ratio[0] != a[0].Close/b[0].Close {
+        t.Errorf("Unexpected warm-up values %v, %v", spread.HedgeRatio[18], spread.Ratio[0])
+    }
+
+    // A jump away from the noisy relationship stands out in the z-score
+    for i := range a {
+        a[i].Close += math.Sin(float64(i))
+    }
+    a[39].Close += 50
+    spread, _ = CalculatePairSpread(a, b, 20)
+    if spread.ZScore[39] < 3 || math.Abs(spread.ZScore[38]) > 2 {
+        t.Errorf("Expected only the last bar to stand out, got z-scores %v and %v", spread.ZScore[38], spread.ZScore[39])
+    }
+}
+
+func TestCrossSymbolTimesMustMatch(t *testing.T) {
+    data := testutil.RandomWalk("TEST", 30, 5)
+    other := scaleReturns(data, 1)
+    other[10].Time = other[10].Time.Add(30 * time.Second)
+
+    if _, err := CalculatePairSpread(data, other, 10); err == nil || !strings.Contains(err.Error(), "bar 10") {
+        t.Errorf("Expected an alignment error at bar 10, got %v", err)
+    }
+}
@@ -11,9 +11,14 @@ import (
 type MAType int
 
 const (
-    SMA MAType = iota // Simple Moving Average
-    EMA               // Exponential Moving Average
-    WMA               // Weighted Moving Average
+    SMA  MAType = iota // Simple Moving Average
+    EMA                // Exponential Moving Average
+    WMA                // Weighted Moving Average
+    DEMA               // Double Exponential Moving Average
+    TEMA               // Triple Exponential Moving Average
+    HMA                // Hull Moving Average
+    KAMA               // Kaufman Adaptive Moving Average
+    VWMA               // Volume Weighted Moving Average
 )
 
 // CalculateSMA calculates Simple Moving Average
@@ -22,16 +27,7 @@ func CalculateSMA(data []models.OHLCV, period int) ([]float64, error) {
         return nil, fmt.Errorf("insufficient data: need %d, got %d", period, len(data))
     }
 
-    result := make([]float64, len(data))
-    
-    for i := period - 1; i < len(data); i++ {
-        sum := 0.0
-        for j := i - period + 1; j <= i; j++ {
-            sum += data[j].Close
-        }
-        result[i] = sum / float64(period)
-    }
-
+    result, _ := smaValues(closes(data), 0, period)
     return result, nil
 }
 
@@ -41,21 +37,8 @@ func CalculateEMA(data []models.OHLCV, period int) ([]float64, error) {
         return nil, fmt.Errorf("insufficient data: need %d, got %d", period, len(data))
     }
 
-    result := make([]float64, len(data))
-    multiplier := 2.0 / (float64(period) + 1.0)
-
-    // Calculate initial SMA as first EMA value
-    sum := 0.0
-    for i := 0; i < period; i++ {
-        sum += data[i].Close
-    }
-    result[period-1] = sum / float64(period)
-
-    // Calculate EMA for remaining values
-    for i := period; i < len(data); i++ {
-        result[i] = (data[i].Close * multiplier) + (result[i-1] * (1 - multiplier))
-    }
-
+    // Seeded with the SMA of the first period closes
+    result, _ := emaValues(closes(data), 0, period)
     return result, nil
 }
 
@@ -133,6 +116,12 @@ type MACD struct {
 
 // CalculateMACD calculates MACD indicator
 func CalculateMACD(data []models.OHLCV, fastPeriod, slowPeriod, signalPeriod int) (*MACD, error) {
+    return CalculateMACDWithMA(data, fastPeriod, slowPeriod, signalPeriod, EMA)
+}
+
+// CalculateMACDWithMA calculates MACD with the signal line smoothed by the
+// given moving average
+func CalculateMACDWithMA(data []models.OHLCV, fastPeriod, slowPeriod, signalPeriod int, signalType MAType) (*MACD, error) {
     if len(data) < slowPeriod {
         return nil, fmt.Errorf("insufficient data: need %d, got %d", slowPeriod, len(data))
     }
@@ -154,29 +143,18 @@ func CalculateMACD(data []models.OHLCV, fastPeriod, slowPeriod, signalPeriod int
         macdLine[i] = fastEMA[i] - slowEMA[i]
     }
 
-    // Create temporary OHLCV data for signal line calculation
-    tempData := make([]models.OHLCV, len(data)-slowPeriod+1)
-    for i := slowPeriod - 1; i < len(data); i++ {
-        tempData[i-slowPeriod+1] = models.OHLCV{
-            Close: macdLine[i],
-        }
-    }
-
-    // Calculate signal line (EMA of MACD)
-    signalEMA, err := CalculateEMA(tempData, signalPeriod)
+    // Calculate signal line (moving average of MACD)
+    signalLine, signalStart, err := smooth(macdLine, slowPeriod-1, signalPeriod, signalType)
     if err != nil {
-        return nil, fmt.Errorf("failed to calculate signal EMA: %w", err)
+        return nil, fmt.Errorf("failed to calculate signal line: %w", err)
     }
-
-    // Map signal values back to original data length
-    signalLine := make([]float64, len(data))
-    for i := 0; i < len(signalEMA); i++ {
-        signalLine[slowPeriod-1+i] = signalEMA[i]
+    if signalStart >= len(data) {
+        return nil, fmt.Errorf("failed to calculate signal line: insufficient data: need %d, got %d", signalStart+1, len(data))
     }
 
     // Calculate histogram
     histogram := make([]float64, len(data))
-    for i := slowPeriod + signalPeriod - 2; i < len(data); i++ {
+    for i := signalStart; i < len(data); i++ {
         histogram[i] = macdLine[i] - signalLine[i]
     }
 
@@ -196,36 +174,47 @@ type BollingerBands struct {
 
 // CalculateBollingerBands calculates Bollinger Bands
 func CalculateBollingerBands(data []models.OHLCV, period int, deviation float64) (*BollingerBands, error) {
+    return CalculateBollingerBandsWithMA(data, period, deviation, SMA)
+}
+
+// CalculateBollingerBandsWithMA calculates Bollinger Bands around a middle
+// band of the given moving average. The width is always the standard
+// deviation of the last period closes.
+func CalculateBollingerBandsWithMA(data []models.OHLCV, period int, deviation float64, basisType MAType) (*BollingerBands, error) {
     if len(data) < period {
         return nil, fmt.Errorf("insufficient data: need %d, got %d", period, len(data))
     }
 
-    // Calculate SMA (middle band)
-    sma, err := CalculateSMA(data, period)
+    // Calculate middle band
+    basis, first, err := movingAverage(data, period, basisType)
     if err != nil {
-        return nil, fmt.Errorf("failed to calculate SMA: %w", err)
+        return nil, fmt.Errorf("failed to calculate %s: %w", basisType, err)
+    }
+    mean := basis
+    if basisType != SMA {
+        mean, _ = smaValues(closes(data), 0, period)
     }
 
     upper := make([]float64, len(data))
     lower := make([]float64, len(data))
 
     // Calculate standard deviation and bands
-    for i := period - 1; i < len(data); i++ {
+    for i := first; i < len(data); i++ {
         // Calculate standard deviation
         sumSquares := 0.0
         for j := i - period + 1; j <= i; j++ {
-            diff := data[j].Close - sma[i]
+            diff := data[j].Close - mean[i]
             sumSquares += diff * diff
         }
         stdDev := math.Sqrt(sumSquares / float64(period))
 
-        upper[i] = sma[i] + (deviation * stdDev)
-        lower[i] = sma[i] - (deviation * stdDev)
+        upper[i] = basis[i] + (deviation * stdDev)
+        lower[i] = basis[i] - (deviation * stdDev)
     }
 
     return &BollingerBands{
         Upper:  upper,
-        Middle: sma,
+        Middle: basis,
         Lower:  lower,
     }, nil
 }
@@ -238,6 +227,12 @@ type Stochastic struct {
 
 // CalculateStochastic calculates Stochastic Oscillator
 func CalculateStochastic(data []models.OHLCV, kPeriod, dPeriod int) (*Stochastic, error) {
+    return CalculateStochasticWithMA(data, kPeriod, dPeriod, SMA)
+}
+
+// CalculateStochasticWithMA calculates Stochastic Oscillator with %D smoothed
+// by the given moving average
+func CalculateStochasticWithMA(data []models.OHLCV, kPeriod, dPeriod int, dType MAType) (*Stochastic, error) {
     if len(data) < kPeriod {
         return nil, fmt.Errorf("insufficient data: need %d, got %d", kPeriod, len(data))
     }
@@ -266,21 +261,13 @@ func CalculateStochastic(data []models.OHLCV, kPeriod, dPeriod int) (*Stochastic
         }
     }
 
-    // Calculate %D (SMA of %K)
-    tempData := make([]models.OHLCV, len(data))
-    for i := 0; i < len(data); i++ {
-        tempData[i] = models.OHLCV{Close: k[i]}
-    }
-
-    d, err := CalculateSMA(tempData[kPeriod-1:], dPeriod)
+    // Calculate %D (moving average of %K)
+    dLine, dStart, err := smooth(k, kPeriod-1, dPeriod, dType)
     if err != nil {
-        return nil, fmt.Errorf("failed to calculate %D: %w", err)
+        return nil, fmt.Errorf("failed to calculate %%D: %w", err)
     }
-
-    // Map %D back to original length
-    dLine := make([]float64, len(data))
-    for i := 0; i < len(d); i++ {
-        dLine[kPeriod-1+i] = d[i]
+    if dStart >= len(data) {
+        return nil, fmt.Errorf("failed to calculate %%D: insufficient data: need %d, got %d", dStart+1, len(data))
     }
 
     return &Stochastic{
@@ -322,69 +309,27 @@ func CalculateATR(data []models.OHLCV, period int) ([]float64, error) {
     return result, nil
 }
 
-// IndicatorCalculator provides a unified interface for calculating indicators
-type IndicatorCalculator struct{}
+// IndicatorCalculator computes indicators by name from a registry
+type IndicatorCalculator struct {
+    registry *Registry
+}
 
+// NewIndicatorCalculator creates a calculator over the default registry
 func NewIndicatorCalculator() *IndicatorCalculator {
-    return &IndicatorCalculator{}
+    return &IndicatorCalculator{registry: defaultRegistry}
 }
 
-func (ic *IndicatorCalculator) Calculate(data []models.OHLCV, indicatorName string, params map[string]interface{}) (interface{}, error) {
-    switch indicatorName {
-    case "sma":
-        period, ok := params["period"].(int)
-        if !ok {
-            return nil, fmt.Errorf("missing or invalid period parameter")
-        }
-        return CalculateSMA(data, period)
-
-    case "ema":
-        period, ok := params["period"].(int)
-        if !ok {
-            return nil, fmt.Errorf("missing or invalid period parameter")
-        }
-        return CalculateEMA(data, period)
-
-    case "rsi":
-        period, ok := params["period"].(int)
-        if !ok {
-            return nil, fmt.Errorf("missing or invalid period parameter")
-        }
-        return CalculateRSI(data, period)
-
-    case "macd":
-        fastPeriod, ok1 := params["fast_period"].(int)
-        slowPeriod, ok2 := params["slow_period"].(int)
-        signalPeriod, ok3 := params["signal_period"].(int)
-        if !ok1 || !ok2 || !ok3 {
-            return nil, fmt.Errorf("missing or invalid MACD parameters")
-        }
-        return CalculateMACD(data, fastPeriod, slowPeriod, signalPeriod)
-
-    case "bollinger_bands":
-        period, ok1 := params["period"].(int)
-        deviation, ok2 := params["deviation"].(float64)
-        if !ok1 || !ok2 {
-            return nil, fmt.Errorf("missing or invalid Bollinger Bands parameters")
-        }
-        return CalculateBollingerBands(data, period, deviation)
-
-    case "stochastic":
-        kPeriod, ok1 := params["k_period"].(int)
-        dPeriod, ok2 := params["d_period"].(int)
-        if !ok1 || !ok2 {
-            return nil, fmt.Errorf("missing or invalid Stochastic parameters")
-        }
-        return CalculateStochastic(data, kPeriod, dPeriod)
-
-    case "atr":
-        period, ok := params["period"].(int)
-        if !ok {
-            return nil, fmt.Errorf("missing or invalid period parameter")
-        }
-        return CalculateATR(data, period)
+// NewRegistryCalculator creates a calculator over a custom registry
+func NewRegistryCalculator(registry *Registry) *IndicatorCalculator {
+    return &IndicatorCalculator{registry: registry}
+}
 
-    default:
-        return nil, fmt.Errorf("unknown indicator: %s", indicatorName)
+// Calculate computes an indicator, coercing params to the declared types so
+// that values decoded from JSON are accepted
+func (ic *IndicatorCalculator) Calculate(data []models.OHLCV, indicatorName string, params map[string]interface{}) (interface{}, error) {
+    registry := ic.registry
+    if registry == nil {
+        registry = defaultRegistry
     }
+    return registry.Calculate(data, indicatorName, params)
 }
@@ -0,0 +1,232 @@
+package indicators
+
+import (
+    "fmt"
+    "math"
+)
+
+// The kernels compute indicators over float64 columns into caller-supplied
+// buffers, so screening thousands of symbols allocates nothing per symbol.
+// They agree with the Calculate functions within floating-point tolerance,
+// as rolling sums accumulate rounding differently: values before an
+// indicator's first one are zero, and dst must be at least as long as the
+// input.
+
+// resyncInterval is how often running window sums are recomputed from
+// scratch to keep rounding errors from accumulating over long series
+const resyncInterval = 1024
+
+func checkKernel(n, need, period int, dst ...[]float64) error {
+    if period <= 0 {
+        return fmt.Errorf("invalid period: %d", period)
+    }
+    if n < need {
+        return fmt.Errorf("insufficient data: need %d, got %d", need, n)
+    }
+    for _, d := range dst {
+        if len(d) < n {
+            return fmt.Errorf("buffer too short: need %d, got %d", n, len(d))
+        }
+    }
+    return nil
+}
+
+// windowSum sums the period values ending at end
+func windowSum(values []float64, end, period int) float64 {
+    sum := 0.0
+    for _, v := range values[end-period+1 : end+1] {
+        sum += v
+    }
+    return sum
+}
+
+// SMAInto writes the simple moving average of values into dst
+func SMAInto(dst, values []float64, period int) error {
+    n := len(values)
+    if err := checkKernel(n, period, period, dst); err != nil {
+        return err
+    }
+    dst = dst[:n]
+
+    clear(dst[:period-1])
+    scale := 1 / float64(period)
+    sum := windowSum(values, period-1, period)
+    dst[period-1] = sum * scale
+    for i := period; i < n; i++ {
+        if (i-period)%resyncInterval == resyncInterval-1 {
+            sum = windowSum(values, i, period)
+        } else {
+            sum += values[i] - values[i-period]
+        }
+        dst[i] = sum * scale
+    }
+    return nil
+}
+
+// EMAInto writes the exponential moving average of values, seeded with the
+// simple average of the first period values, into dst
+func EMAInto(dst, values []float64, period int) error {
+    n := len(values)
+    if err := checkKernel(n, period, period, dst); err != nil {
+        return err
+    }
+    emaInto(dst[:n], values, 0, period)
+    return nil
+}
+
+// emaInto is EMAInto over the values from start on. dst may be values.
+func emaInto(dst, values []float64, start, period int) {
+    first := start + period - 1
+    seed := windowSum(values, first, period) / float64(period)
+    clear(dst[:first])
+    dst[first] = seed
+
+    multiplier := 2 / (float64(period) + 1)
+    prev := seed
+    for i := first + 1; i < len(dst); i++ {
+        prev = values[i]*multiplier + prev*(1-multiplier)
+        dst[i] = prev
+    }
+}
+
+// RSIInto writes Wilder's Relative Strength Index of closes into dst
+func RSIInto(dst, closes []float64, period int) error {
+    n := len(closes)
+    if err := checkKernel(n, period+1, period, dst); err != nil {
+        return err
+    }
+    dst = dst[:n]
+
+    avgGain, avgLoss := 0.0, 0.0
+    for i := 1; i <= period; i++ {
+        if change := closes[i] - closes[i-1]; change > 0 {
+            avgGain += change
+        } else {
+            avgLoss -= change
+        }
+    }
+    avgGain /= float64(period)
+    avgLoss /= float64(period)
+
+    clear(dst[:period])
+    p := float64(period)
+    for i := period; i < n; i++ {
+        if i > period {
+            gain, loss := 0.0, 0.0
+            if change := closes[i] - closes[i-1]; change > 0 {
+                gain = change
+            } else {
+                loss = -change
+            }
+            avgGain = (avgGain*(p-1) + gain) / p
+            avgLoss = (avgLoss*(p-1) + loss) / p
+        }
+
+        if avgLoss == 0 {
+            dst[i] = 100
+        } else {
+            dst[i] = 100 - 100/(1+avgGain/avgLoss)
+        }
+    }
+    return nil
+}
+
+// MACDInto writes the MACD line, its EMA signal line and the histogram of
+// closes into the three buffers
+func MACDInto(macd, signal, histogram, closes []float64, fastPeriod, slowPeriod, signalPeriod int) error {
+    n := len(closes)
+    if fastPeriod <= 0 || signalPeriod <= 0 {
+        return fmt.Errorf("invalid period: %d", min(fastPeriod, signalPeriod))
+    }
+    if err := checkKernel(n, slowPeriod+signalPeriod-1, slowPeriod, macd, signal, histogram); err != nil {
+        return err
+    }
+    if fastPeriod > slowPeriod {
+        return fmt.Errorf("fast period %d exceeds slow period %d", fastPeriod, slowPeriod)
+    }
+    macd, signal, histogram = macd[:n], signal[:n], histogram[:n]
+
+    // The EMAs are staged in the signal and histogram buffers
+    emaInto(signal, closes, 0, fastPeriod)
+    emaInto(histogram, closes, 0, slowPeriod)
+    start := slowPeriod - 1
+    clear(macd[:start])
+    for i := start; i < n; i++ {
+        macd[i] = signal[i] - histogram[i]
+    }
+
+    emaInto(signal, macd, start, signalPeriod)
+    first := start + signalPeriod - 1
+    clear(histogram[:first])
+    for i := first; i < n; i++ {
+        histogram[i] = macd[i] - signal[i]
+    }
+    return nil
+}
+
+// BollingerBandsInto writes the SMA of closes and the bands deviation
+// population standard deviations around it into the three buffers
+func BollingerBandsInto(middle, upper, lower, closes []float64, period int, deviation float64) error {
+    n := len(closes)
+    if err := checkKernel(n, period, period, middle, upper, lower); err != nil {
+        return err
+    }
+    middle, upper, lower = middle[:n], upper[:n], lower[:n]
+
+    clear(middle[:period-1])
+    clear(upper[:period-1])
+    clear(lower[:period-1])
+
+    scale := 1 / float64(period)
+    var sum, squares float64
+    for i := period - 1; i < n; i++ {
+        if i == period-1 || (i-period)%resyncInterval == resyncInterval-1 {
+            sum, squares = 0, 0
+            for _, v := range closes[i-period+1 : i+1] {
+                sum += v
+                squares += v * v
+            }
+        } else {
+            in, out := closes[i], closes[i-period]
+            sum += in - out
+            squares += in*in - out*out
+        }
+
+        mean := sum * scale
+        stdDev := math.Sqrt(math.Max(squares*scale-mean*mean, 0))
+        middle[i] = mean
+        upper[i] = mean + deviation*stdDev
+        lower[i] = mean - deviation*stdDev
+    }
+    return nil
+}
+
+// ATRInto writes Wilder's Average True Range into dst
+func ATRInto(dst, high, low, closes []float64, period int) error {
+    n := len(closes)
+    if err := checkKernel(n, period+1, period, dst); err != nil {
+        return err
+    }
+    if len(high) < n || len(low) < n {
+        return fmt.Errorf("high, low and close columns differ in length")
+    }
+    dst = dst[:n]
+
+    trueRange := func(i int) float64 {
+        return math.Max(high[i]-low[i], math.Max(math.Abs(high[i]-closes[i-1]), math.Abs(low[i]-closes[i-1])))
+    }
+
+    sum := 0.0
+    for i := 1; i <= period; i++ {
+        sum += trueRange(i)
+    }
+    clear(dst[:period])
+    p := float64(period)
+    atr := sum / p
+    dst[period] = atr
+    for i := period + 1; i < n; i++ {
+        atr = (atr*(p-1) + trueRange(i)) / p
+        dst[i] = atr
+    }
+    return nil
+}
@@ -0,0 +1,192 @@
+package indicators
+
+import (
+    "math"
+    "testing"
+
+    "github.com/algo-trading/market-data-service/internal/testutil"
+)
+
+func assertClose(t *testing.T, name string, got, expected []float64) {
+    t.Helper()
+    for i := range expected {
+        if math.Abs(got[i]-expected[i]) > 1e-9*math.Max(1, math.Abs(expected[i])) {
+            t.Fatalf("%s[%d]: expected %v, got %v", name, i, expected[i], got[i])
+        }
+    }
+}
+
+func TestKernelsMatchBatch(t *testing.T) {
+    data := testutil.RandomWalk("TEST", 5000, 7)
+    s := ToSeries(data)
+    dst := make([]float64, s.Len())
+
+    sma, _ := CalculateSMA(data, 20)
+    if err := SMAInto(dst, s.Close, 20); err != nil {
+        t.Fatalf("Failed to calculate SMA: %v", err)
+    }
+    assertClose(t, "sma", dst, sma)
+
+    ema, _ := CalculateEMA(data, 20)
+    if err := EMAInto(dst, s.Close, 20); err != nil {
+        t.Fatalf("Failed to calculate EMA: %v", err)
+    }
+    assertClose(t, "ema", dst, ema)
+
+    rsi, _ := CalculateRSI(data, 14)
+    if err := RSIInto(dst, s.Close, 14); err != nil {
+        t.Fatalf("Failed to calculate RSI: %v", err)
+    }
+    assertClose(t, "rsi", dst, rsi)
+
+    atr, _ := CalculateATR(data, 14)
+    if err := ATRInto(dst, s.High, s.Low, s.Close, 14); err != nil {
+        t.Fatalf("Failed to calculate ATR: %v", err)
+    }
+    assertClose(t, "atr", dst, atr)
+
+    macd, _ := CalculateMACD(data, 12, 26, 9)
+    signal, histogram := make([]float64, s.Len()), make([]float64, s.Len())
+    if err := MACDInto(dst, signal, histogram, s.Close, 12, 26, 9); err != nil {
+        t.Fatalf("Failed to calculate MACD: %v", err)
+    }
+    assertClose(t, "macd", dst, macd.MACD)
+    assertClose(t, "macd signal", signal, macd.Signal)
+    assertClose(t, "macd histogram", histogram, macd.Histogram)
+
+    bands, _ := CalculateBollingerBands(data, 20, 2)
+    upper, lower := signal, histogram
+    if err := BollingerBandsInto(dst, upper, lower, s.Close, 20, 2); err != nil {
+        t.Fatalf("Failed to calculate Bollinger Bands: %v", err)
+    }
+    assertClose(t, "bollinger middle", dst, bands.Middle)
+    assertClose(t, "bollinger upper", upper, bands.Upper)
+    assertClose(t, "bollinger lower", lower, bands.Lower)
+}
+
+func TestKernelsDoNotAllocate(t *testing.T) {
+    s := ToSeries(testutil.RandomWalk("TEST", 2000, 8))
+    a, b, c := make([]float64, s.Len()), make([]float64, s.Len()), make([]float64, s.Len())
+
+    allocs := testing.AllocsPerRun(10, func() {
+        SMAInto(a, s.Close, 20)
+        EMAInto(a, s.Close, 20)
+        RSIInto(a, s.Close, 14)
+        ATRInto(a, s.High, s.Low, s.Close, 14)
+        MACDInto(a, b, c, s.Close, 12, 26, 9)
+        BollingerBandsInto(a, b, c, s.Close, 20, 2)
+    })
+    if allocs != 0 {
+        t.Errorf("Expected kernels not to allocate, got %v allocations", allocs)
+    }
+}
+
+func TestKernelErrors(t *testing.T) {
+    s := ToSeries(testutil.RandomWalk("TEST", 30, 9))
+
+    if err := SMAInto(make([]float64, 10), s.Close, 5); err == nil {
+        t.Error("Expected an error for a short buffer")
+    }
+    if err := RSIInto(make([]float64, 30), s.Close, 30); err == nil {
+        t.Error("Expected an error with fewer than period+1 closes")
+    }
+    if err := MACDInto(make([]float64, 30), make([]float64, 30), make([]float64, 30), s.Close, 12, 26, 9); err == nil {
+        t.Error("Expected an error without room for the signal line")
+    }
+}
+
+func TestSeriesReuse(t *testing.T) {
+    data := testutil.RandomWalk("TEST", 100, 10)
+    s := ToSeries(data)
+    if bar := s.Bar(99); !bar.Time.Equal(data[99].Time) || bar.High != data[99].High || bar.Close != data[99].Close || bar.Volume != data[99].Volume {
+        t.Errorf("Expected bar 99 to round-trip, got %+v", s.Bar(99))
+    }
+
+    allocs := testing.AllocsPerRun(10, func() {
+        s.Load(data[:50])
+    })
+    if allocs != 0 || s.Len() != 50 {
+        t.Errorf("Expected reloading to reuse the columns, got %v allocations and %d bars", allocs, s.Len())
+    }
+}
+
+// benchmarkBars is the length of the kernel benchmark series
+const benchmarkBars = 1_000_000
+
+func benchmarkKernel(b *testing.B, kernel func(s *Series, dst []float64)) {
+    s := ToSeries(testutil.RandomWalk("TEST", benchmarkBars, 11))
+    dst := make([]float64, s.Len())
+
+    b.ReportAllocs()
+    b.SetBytes(8 * benchmarkBars)
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        kernel(s, dst)
+    }
+    b.ReportMetric(float64(benchmarkBars)*float64(b.N)/b.Elapsed().Seconds(), "bars/s")
+}
+
+func BenchmarkSMAInto1M(b *testing.B) {
+    benchmarkKernel(b, func(s *Series, dst []float64) { SMAInto(dst, s.Close, 20) })
+}
+
+func BenchmarkEMAInto1M(b *testing.B) {
+    benchmarkKernel(b, func(s *Series, dst []float64) { EMAInto(dst, s.Close, 20) })
+}
+
+func BenchmarkRSIInto1M(b *testing.B) {
+    benchmarkKernel(b, func(s *Series, dst []float64) { RSIInto(dst, s.Close, 14) })
+}
+
+func BenchmarkATRInto1M(b *testing.B) {
+    benchmarkKernel(b, func(s *Series, dst []float64) { ATRInto(dst, s.High, s.Low, s.Close, 14) })
+}
+
+func BenchmarkMACDInto1M(b *testing.B) {
+    signal, histogram := make([]float64, benchmarkBars), make([]float64, benchmarkBars)
+    benchmarkKernel(b, func(s *Series, dst []float64) { MACDInto(dst, signal, histogram, s.Close, 12, 26, 9) })
+}
+
+func BenchmarkBollingerBandsInto1M(b *testing.B) {
+    upper, lower := make([]float64, benchmarkBars), make([]float64, benchmarkBars)
+    benchmarkKernel(b, func(s *Series, dst []float64) { BollingerBandsInto(dst, upper, lower, s.Close, 20, 2) })
+}
+
+// BenchmarkCalculateSMA1M is the struct-based baseline for BenchmarkSMAInto1M
+func BenchmarkCalculateSMA1M(b *testing.B) {
+    data := testutil.RandomWalk("TEST", benchmarkBars, 11)
+
+    b.ReportAllocs()
+    b.SetBytes(8 * benchmarkBars)
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        CalculateSMA(data, 20)
+    }
+    b.ReportMetric(float64(benchmarkBars)*float64(b.N)/b.Elapsed().Seconds(), "bars/s")
+}
+
+// BenchmarkScreen2000Symbols screens 2000 symbols of 500 daily bars reusing
+// one set of buffers, as a screener pass does
+func BenchmarkScreen2000Symbols(b *testing.B) {
+    const symbols, bars = 2000, 500
+    universe := make([]*Series, symbols)
+    for i := range universe {
+        universe[i] = ToSeries(testutil.RandomWalk("TEST", bars, int64(i)))
+    }
+    rsi, sma := make([]float64, bars), make([]float64, bars)
+
+    b.ReportAllocs()
+    b.ResetTimer()
+    matches := 0
+    for i := 0; i < b.N; i++ {
+        for _, s := range universe {
+            RSIInto(rsi, s.Close, 14)
+            SMAInto(sma, s.Close, 50)
+            if rsi[bars-1] < 30 && s.Close[bars-1] > sma[bars-1] {
+                matches++
+            }
+        }
+    }
+    b.ReportMetric(float64(symbols)*float64(b.N)/b.Elapsed().Seconds(), "symbols/s")
+    _ = matches
+}
@@ -0,0 +1,317 @@
+package indicators
+
+import (
+    "fmt"
+    "math"
+    "strings"
+
+    "github.com/algo-trading/market-data-service/internal/models"
+)
+
+// Default KAMA smoothing constants in bars
+const (
+    KAMAFastPeriod = 2
+    KAMASlowPeriod = 30
+)
+
+var maTypeNames = map[MAType]string{
+    SMA:  "sma",
+    EMA:  "ema",
+    WMA:  "wma",
+    DEMA: "dema",
+    TEMA: "tema",
+    HMA:  "hma",
+    KAMA: "kama",
+    VWMA: "vwma",
+}
+
+func (t MAType) String() string {
+    if name, ok := maTypeNames[t]; ok {
+        return name
+    }
+    return fmt.Sprintf("MAType(%d)", int(t))
+}
+
+// ParseMAType parses a moving average name such as "ema" or "HMA"
+func ParseMAType(name string) (MAType, error) {
+    name = strings.ToLower(name)
+    for maType, maName := range maTypeNames {
+        if maName == name {
+            return maType, nil
+        }
+    }
+    return 0, fmt.Errorf("unknown moving average type: %s", name)
+}
+
+// CalculateMA calculates a moving average of the close of the given type.
+// KAMA uses the default fast and slow periods.
+func CalculateMA(data []models.OHLCV, period int, maType MAType) ([]float64, error) {
+    result, _, err := movingAverage(data, period, maType)
+    return result, err
+}
+
+// movingAverage returns the moving average and its first valid index
+func movingAverage(data []models.OHLCV, period int, maType MAType) ([]float64, int, error) {
+    switch maType {
+    case VWMA:
+        result, err := CalculateVWMA(data, period)
+        return result, period - 1, err
+    case KAMA:
+        result, err := CalculateKAMA(data, period, KAMAFastPeriod, KAMASlowPeriod)
+        return result, period, err
+    }
+
+    result, first, err := smooth(closes(data), 0, period, maType)
+    if err != nil {
+        return nil, 0, err
+    }
+    if first >= len(data) {
+        return nil, 0, fmt.Errorf("insufficient data: need %d, got %d", first+1, len(data))
+    }
+    return result, first, nil
+}
+
+// maFirst returns the first valid index of a moving average of period bars,
+// as returned by movingAverage
+func maFirst(maType MAType, period int) int {
+    switch maType {
+    case DEMA:
+        return 2 * (period - 1)
+    case TEMA:
+        return 3 * (period - 1)
+    case HMA:
+        return period - 1 + max(int(math.Round(math.Sqrt(float64(period)))), 1) - 1
+    case KAMA:
+        return period
+    }
+    return period - 1
+}
+
+// CalculateWMA calculates Weighted Moving Average with linearly increasing
+// weights, the latest close weighing period times the oldest
+func CalculateWMA(data []models.OHLCV, period int) ([]float64, error) {
+    return CalculateMA(data, period, WMA)
+}
+
+// CalculateDEMA calculates Double Exponential Moving Average, 2*EMA - EMA(EMA)
+func CalculateDEMA(data []models.OHLCV, period int) ([]float64, error) {
+    return CalculateMA(data, period, DEMA)
+}
+
+// CalculateTEMA calculates Triple Exponential Moving Average,
+// 3*EMA - 3*EMA(EMA) + EMA(EMA(EMA))
+func CalculateTEMA(data []models.OHLCV, period int) ([]float64, error) {
+    return CalculateMA(data, period, TEMA)
+}
+
+// CalculateHMA calculates Hull Moving Average,
+// WMA(2*WMA(n/2) - WMA(n), sqrt(n))
+func CalculateHMA(data []models.OHLCV, period int) ([]float64, error) {
+    return CalculateMA(data, period, HMA)
+}
+
+// CalculateKAMA calculates Kaufman Adaptive Moving Average. The smoothing
+// constant moves between those of fastPeriod and slowPeriod EMAs with the
+// efficiency ratio of the last period closes.
+func CalculateKAMA(data []models.OHLCV, period, fastPeriod, slowPeriod int) ([]float64, error) {
+    if fastPeriod <= 0 || slowPeriod <= 0 {
+        return nil, fmt.Errorf("invalid KAMA periods: fast %d, slow %d", fastPeriod, slowPeriod)
+    }
+    if len(data) < period+1 {
+        return nil, fmt.Errorf("insufficient data: need %d, got %d", period+1, len(data))
+    }
+
+    result, _, err := kama(closes(data), 0, period, fastPeriod, slowPeriod)
+    return result, err
+}
+
+// CalculateVWMA calculates Volume Weighted Moving Average. Windows without
+// volume fall back to the simple average.
+func CalculateVWMA(data []models.OHLCV, period int) ([]float64, error) {
+    if period <= 0 {
+        return nil, fmt.Errorf("invalid period: %d", period)
+    }
+    if len(data) < period {
+        return nil, fmt.Errorf("insufficient data: need %d, got %d", period, len(data))
+    }
+
+    result := make([]float64, len(data))
+
+    for i := period - 1; i < len(data); i++ {
+        sum, weighted, volume := 0.0, 0.0, 0.0
+        for j := i - period + 1; j <= i; j++ {
+            sum += data[j].Close
+            weighted += data[j].Close * float64(data[j].Volume)
+            volume += float64(data[j].Volume)
+        }
+        if volume == 0 {
+            result[i] = sum / float64(period)
+        } else {
+            result[i] = weighted / volume
+        }
+    }
+
+    return result, nil
+}
+
+func closes(data []models.OHLCV) []float64 {
+    values := make([]float64, len(data))
+    for i := range data {
+        values[i] = data[i].Close
+    }
+    return values
+}
+
+// smooth applies a moving average to values whose first valid index is
+// start, as indicators do to their own output (MACD signal, %D). It returns
+// the result and its first valid index, which is len(values) or more when
+// there is not enough data.
+func smooth(values []float64, start, period int, maType MAType) ([]float64, int, error) {
+    if period <= 0 {
+        return nil, 0, fmt.Errorf("invalid period: %d", period)
+    }
+
+    switch maType {
+    case SMA:
+        result, first := smaValues(values, start, period)
+        return result, first, nil
+
+    case EMA:
+        result, first := emaValues(values, start, period)
+        return result, first, nil
+
+    case WMA:
+        result, first := wmaValues(values, start, period)
+        return result, first, nil
+
+    case DEMA:
+        ema1, first1 := emaValues(values, start, period)
+        ema2, first2 := emaValues(ema1, first1, period)
+        result := make([]float64, len(values))
+        for i := first2; i < len(values); i++ {
+            result[i] = 2*ema1[i] - ema2[i]
+        }
+        return result, first2, nil
+
+    case TEMA:
+        ema1, first1 := emaValues(values, start, period)
+        ema2, first2 := emaValues(ema1, first1, period)
+        ema3, first3 := emaValues(ema2, first2, period)
+        result := make([]float64, len(values))
+        for i := first3; i < len(values); i++ {
+            result[i] = 3*ema1[i] - 3*ema2[i] + ema3[i]
+        }
+        return result, first3, nil
+
+    case HMA:
+        half, first := wmaValues(values, start, max(period/2, 1))
+        full, fullFirst := wmaValues(values, start, period)
+        if fullFirst > first {
+            first = fullFirst
+        }
+        diff := make([]float64, len(values))
+        for i := first; i < len(values); i++ {
+            diff[i] = 2*half[i] - full[i]
+        }
+        result, first := wmaValues(diff, first, max(int(math.Round(math.Sqrt(float64(period)))), 1))
+        return result, first, nil
+
+    case KAMA:
+        return kama(values, start, period, KAMAFastPeriod, KAMASlowPeriod)
+
+    case VWMA:
+        return nil, 0, fmt.Errorf("%s requires volume and cannot smooth an indicator", maType)
+
+    default:
+        return nil, 0, fmt.Errorf("unknown moving average type: %d", int(maType))
+    }
+}
+
+func smaValues(values []float64, start, period int) ([]float64, int) {
+    result := make([]float64, len(values))
+    first := start + period - 1
+
+    for i := first; i < len(values); i++ {
+        sum := 0.0
+        for j := i - period + 1; j <= i; j++ {
+            sum += values[j]
+        }
+        result[i] = sum / float64(period)
+    }
+
+    return result, first
+}
+
+// emaValues seeds the EMA with the simple average of the first period values
+func emaValues(values []float64, start, period int) ([]float64, int) {
+    result := make([]float64, len(values))
+    first := start + period - 1
+    if first >= len(values) {
+        return result, first
+    }
+    multiplier := 2.0 / (float64(period) + 1.0)
+
+    sum := 0.0
+    for i := start; i <= first; i++ {
+        sum += values[i]
+    }
+    result[first] = sum / float64(period)
+
+    for i := first + 1; i < len(values); i++ {
+        result[i] = (values[i] * multiplier) + (result[i-1] * (1 - multiplier))
+    }
+
+    return result, first
+}
+
+func wmaValues(values []float64, start, period int) ([]float64, int) {
+    result := make([]float64, len(values))
+    first := start + period - 1
+    denominator := float64(period*(period+1)) / 2
+
+    for i := first; i < len(values); i++ {
+        sum := 0.0
+        for j := 0; j < period; j++ {
+            sum += values[i-period+1+j] * float64(j+1)
+        }
+        result[i] = sum / denominator
+    }
+
+    return result, first
+}
+
+// kama starts from the value before its first output, like TA-Lib
+func kama(values []float64, start, period, fastPeriod, slowPeriod int) ([]float64, int, error) {
+    if period <= 0 {
+        return nil, 0, fmt.Errorf("invalid period: %d", period)
+    }
+
+    result := make([]float64, len(values))
+    first := start + period
+    if first >= len(values) {
+        return result, first, nil
+    }
+
+    fastSC := 2.0 / (float64(fastPeriod) + 1)
+    slowSC := 2.0 / (float64(slowPeriod) + 1)
+    prev := values[first-1]
+
+    for i := first; i < len(values); i++ {
+        change := math.Abs(values[i] - values[i-period])
+        volatility := 0.0
+        for j := i - period + 1; j <= i; j++ {
+            volatility += math.Abs(values[j] - values[j-1])
+        }
+
+        efficiency := 0.0
+        if volatility != 0 {
+            efficiency = change / volatility
+        }
+        sc := efficiency*(fastSC-slowSC) + slowSC
+
+        prev += sc * sc * (values[i] - prev)
+        result[i] = prev
+    }
+
+    return result, first, nil
+}
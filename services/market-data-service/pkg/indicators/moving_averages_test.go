@@ -0,0 +1,179 @@
+package indicators
+
+import (
+    "math"
+    "testing"
+
+    "github.com/algo-trading/market-data-service/internal/testutil"
+)
+
+func TestMovingAverageValues(t *testing.T) {
+    data := createTestOHLCVData()
+
+    wma, err := CalculateWMA(data, 5)
+    if err != nil {
+        t.Fatalf("Failed to calculate WMA: %v", err)
+    }
+    expected := (102.0*1 + 106*2 + 108*3 + 110*4 + 113*5) / 15
+    if math.Abs(wma[4]-expected) > 1e-9 || wma[3] != 0 {
+        t.Errorf("Expected WMA[4] %f, got %f", expected, wma[4])
+    }
+
+    vwma, err := CalculateVWMA(data, 3)
+    if err != nil {
+        t.Fatalf("Failed to calculate VWMA: %v", err)
+    }
+    expected = (102.0*1000 + 106*1100 + 108*1200) / 3300
+    if math.Abs(vwma[2]-expected) > 1e-9 {
+        t.Errorf("Expected VWMA[2] %f, got %f", expected, vwma[2])
+    }
+
+    // On a straight line the efficiency ratio is 1, so KAMA moves by the
+    // square of the fast smoothing constant
+    trend := testutil.Trend("TEST", 10, 100, 2, 1000)
+    kama, err := CalculateKAMA(trend, 4, 2, 30)
+    if err != nil {
+        t.Fatalf("Failed to calculate KAMA: %v", err)
+    }
+    expected = trend[3].Close + 4.0/9*(trend[4].Close-trend[3].Close)
+    if math.Abs(kama[4]-expected) > 1e-9 || kama[3] != 0 {
+        t.Errorf("Expected KAMA[4] %f, got %f", expected, kama[4])
+    }
+
+    // HMA is the WMA of 2*WMA(n/2) - WMA(n) over sqrt(n) bars
+    hma, err := CalculateHMA(data, 4)
+    if err != nil {
+        t.Fatalf("Failed to calculate HMA: %v", err)
+    }
+    half, _ := CalculateWMA(data, 2)
+    full, _ := CalculateWMA(data, 4)
+    diff := func(i int) float64 { return 2*half[i] - full[i] }
+    expected = (diff(8)*1 + diff(9)*2) / 3
+    if math.Abs(hma[9]-expected) > 1e-9 || hma[3] != 0 || hma[4] == 0 {
+        t.Errorf("Expected HMA[9] %f, got %f", expected, hma[9])
+    }
+}
+
+func TestMovingAveragesOnConstantSeries(t *testing.T) {
+    data := testutil.Trend("TEST", 40, 100, 2, 1000)
+    for i := range data {
+        data[i].Close = 50
+    }
+
+    for maType := SMA; maType <= VWMA; maType++ {
+        ma, err := CalculateMA(data, 5, maType)
+        if err != nil {
+            t.Fatalf("Failed to calculate %s: %v", maType, err)
+        }
+        if math.Abs(ma[len(ma)-1]-50) > 1e-9 {
+            t.Errorf("Expected %s of a constant series to be 50, got %f", maType, ma[len(ma)-1])
+        }
+    }
+}
+
+func TestDoubleAndTripleEMALag(t *testing.T) {
+    // DEMA and TEMA remove the lag of an EMA on a linear trend
+    data := testutil.Trend("TEST", 60, 100, 2, 1000)
+    last := data[len(data)-1].Close
+
+    ema, _ := CalculateEMA(data, 10)
+    dema, err := CalculateDEMA(data, 10)
+    if err != nil {
+        t.Fatalf("Failed to calculate DEMA: %v", err)
+    }
+    tema, err := CalculateTEMA(data, 10)
+    if err != nil {
+        t.Fatalf("Failed to calculate TEMA: %v", err)
+    }
+
+    if math.Abs(dema[59]-last) > 1e-6 || math.Abs(tema[59]-last) > 1e-6 {
+        t.Errorf("Expected DEMA and TEMA to track %f, got %f and %f", last, dema[59], tema[59])
+    }
+    if last-ema[59] < 1 {
+        t.Errorf("Expected EMA to lag the trend, got %f", ema[59])
+    }
+    if dema[17] != 0 || dema[18] == 0 || tema[26] != 0 || tema[27] == 0 {
+        t.Errorf("Unexpected warm-up: DEMA %v, TEMA %v", dema[17:19], tema[26:28])
+    }
+
+    if _, err := CalculateTEMA(data[:27], 10); err == nil {
+        t.Errorf("Expected insufficient data error for TEMA")
+    }
+}
+
+func TestIndicatorsWithMAType(t *testing.T) {
+    data := testutil.Trend("TEST", 40, 100, 2, 1000)
+    for i := range data {
+        data[i].Close += 3 * math.Sin(float64(i))
+    }
+
+    macd, err := CalculateMACDWithMA(data, 5, 10, 4, SMA)
+    if err != nil {
+        t.Fatalf("Failed to calculate MACD: %v", err)
+    }
+    expected := (macd.MACD[36] + macd.MACD[37] + macd.MACD[38] + macd.MACD[39]) / 4
+    if math.Abs(macd.Signal[39]-expected) > 1e-9 || macd.Signal[11] != 0 || macd.Signal[12] == 0 {
+        t.Errorf("Expected SMA signal line %f, got %f", expected, macd.Signal[39])
+    }
+
+    ema, _ := CalculateMACD(data, 5, 10, 4)
+    viaType, _ := CalculateMACDWithMA(data, 5, 10, 4, EMA)
+    if ema.Signal[39] != viaType.Signal[39] {
+        t.Errorf("Expected CalculateMACD to use an EMA signal line")
+    }
+
+    bands, err := CalculateBollingerBandsWithMA(data, 10, 2, EMA)
+    if err != nil {
+        t.Fatalf("Failed to calculate Bollinger Bands: %v", err)
+    }
+    smaBands, _ := CalculateBollingerBands(data, 10, 2)
+    emaValues, _ := CalculateEMA(data, 10)
+    width := smaBands.Upper[39] - smaBands.Middle[39]
+    if bands.Middle[39] != emaValues[39] || math.Abs(bands.Upper[39]-bands.Middle[39]-width) > 1e-9 {
+        t.Errorf("Expected EMA basis with the standard deviation width")
+    }
+
+    stoch, err := CalculateStochasticWithMA(data, 5, 3, WMA)
+    if err != nil {
+        t.Fatalf("Failed to calculate Stochastic: %v", err)
+    }
+    expected = (stoch.K[37]*1 + stoch.K[38]*2 + stoch.K[39]*3) / 6
+    if math.Abs(stoch.D[39]-expected) > 1e-9 {
+        t.Errorf("Expected WMA %%D %f, got %f", expected, stoch.D[39])
+    }
+
+    if _, err := CalculateMACDWithMA(data, 5, 10, 4, VWMA); err == nil {
+        t.Errorf("Expected error smoothing MACD with VWMA")
+    }
+}
+
+func TestIndicatorCalculatorMovingAverages(t *testing.T) {
+    data := testutil.Trend("TEST", 40, 100, 2, 1000)
+    calc := NewIndicatorCalculator()
+
+    for _, name := range []string{"wma", "dema", "tema", "hma", "kama", "vwma"} {
+        if _, err := calc.Calculate(data, name, map[string]interface{}{"period": 5}); err != nil {
+            t.Errorf("Failed to calculate %s: %v", name, err)
+        }
+    }
+
+    result, err := calc.Calculate(data, "ma", map[string]interface{}{"period": 5, "ma_type": "HMA"})
+    if err != nil {
+        t.Fatalf("Failed to calculate ma: %v", err)
+    }
+    hma, _ := CalculateHMA(data, 5)
+    if result.([]float64)[39] != hma[39] {
+        t.Errorf("Expected ma_type to select HMA")
+    }
+
+    params := map[string]interface{}{"fast_period": 5, "slow_period": 10, "signal_period": 4, "signal_ma": "linear"}
+    if _, err := calc.Calculate(data, "macd", params); err == nil {
+        t.Errorf("Expected error for unknown signal_ma")
+    }
+
+    for maType := SMA; maType <= VWMA; maType++ {
+        if parsed, err := ParseMAType(maType.String()); err != nil || parsed != maType {
+            t.Errorf("Failed to round trip %s", maType)
+        }
+    }
+}
@@ -0,0 +1,440 @@
+package indicators
+
+import (
+    "errors"
+    "fmt"
+    "math"
+    "sort"
+    "strings"
+)
+
+// OptionType is a call or a put
+type OptionType int
+
+const (
+    Call OptionType = iota
+    Put
+)
+
+// ParseOptionType accepts call/put in the common spellings, including the
+// CE/PE suffixes of NSE trading symbols
+func ParseOptionType(s string) (OptionType, error) {
+    switch strings.ToLower(s) {
+    case "call", "c", "ce":
+        return Call, nil
+    case "put", "p", "pe":
+        return Put, nil
+    default:
+        return Call, fmt.Errorf("invalid option type: %s", s)
+    }
+}
+
+// PricingModel selects the option pricing formula
+type PricingModel int
+
+const (
+    BlackScholes PricingModel = iota // European options on spot with continuous dividend yield
+    Black76                          // European options on futures and forwards
+)
+
+// ErrNoImpliedVolatility is returned when the price lies outside the
+// no-arbitrage bounds, so no volatility reproduces it
+var ErrNoImpliedVolatility = errors.New("price outside no-arbitrage bounds")
+
+// Volatility search interval for the implied volatility solvers
+const (
+    minVolatility = 1e-6
+    maxVolatility = 5.0
+)
+
+// OptionContract holds the inputs of the pricing models. Underlying is the
+// spot price for Black-Scholes and the futures price for Black-76. Expiry is
+// the time to expiry in years, Rate and Dividend are continuously compounded.
+type OptionContract struct {
+    Type       OptionType
+    Model      PricingModel
+    Underlying float64
+    Strike     float64
+    Expiry     float64
+    Rate       float64
+    Dividend   float64
+}
+
+// Greeks are the option sensitivities. Theta is per calendar day, Vega and
+// Rho are per one percentage point change in volatility and rate.
+type Greeks struct {
+    Delta float64 `json:"delta"`
+    Gamma float64 `json:"gamma"`
+    Theta float64 `json:"theta"`
+    Vega  float64 `json:"vega"`
+    Rho   float64 `json:"rho"`
+}
+
+// yield is the carry of the underlying; a futures price behaves like a spot
+// price with a dividend yield equal to the rate
+func (c OptionContract) yield() float64 {
+    if c.Model == Black76 {
+        return c.Rate
+    }
+    return c.Dividend
+}
+
+func (c OptionContract) d1d2(sigma float64) (float64, float64) {
+    sqrtT := math.Sqrt(c.Expiry)
+    d1 := (math.Log(c.Underlying/c.Strike) + (c.Rate-c.yield()+sigma*sigma/2)*c.Expiry) / (sigma * sqrtT)
+    return d1, d1 - sigma*sqrtT
+}
+
+// Price returns the option value at volatility sigma
+func (c OptionContract) Price(sigma float64) float64 {
+    if c.Expiry <= 0 || sigma <= 0 {
+        return c.intrinsic()
+    }
+
+    d1, d2 := c.d1d2(sigma)
+    underlying := c.Underlying * math.Exp(-c.yield()*c.Expiry)
+    strike := c.Strike * math.Exp(-c.Rate*c.Expiry)
+    if c.Type == Call {
+        return underlying*normCDF(d1) - strike*normCDF(d2)
+    }
+    return strike*normCDF(-d2) - underlying*normCDF(-d1)
+}
+
+// Greeks returns the sensitivities at volatility sigma
+func (c OptionContract) Greeks(sigma float64) Greeks {
+    if c.Expiry <= 0 || sigma <= 0 {
+        var greeks Greeks
+        if intrinsic := c.intrinsic(); intrinsic > 0 {
+            greeks.Delta = 1
+            if c.Type == Put {
+                greeks.Delta = -1
+            }
+        }
+        return greeks
+    }
+
+    d1, d2 := c.d1d2(sigma)
+    sqrtT := math.Sqrt(c.Expiry)
+    carry := math.Exp(-c.yield() * c.Expiry)
+    discount := math.Exp(-c.Rate * c.Expiry)
+    density := normPDF(d1)
+
+    greeks := Greeks{
+        Gamma: carry * density / (c.Underlying * sigma * sqrtT),
+        Vega:  c.Underlying * carry * density * sqrtT / 100,
+    }
+
+    decay := -c.Underlying * carry * density * sigma / (2 * sqrtT)
+    if c.Type == Call {
+        greeks.Delta = carry * normCDF(d1)
+        greeks.Theta = decay - c.Rate*c.Strike*discount*normCDF(d2) + c.yield()*c.Underlying*carry*normCDF(d1)
+        greeks.Rho = c.Strike * c.Expiry * discount * normCDF(d2) / 100
+    } else {
+        greeks.Delta = -carry * normCDF(-d1)
+        greeks.Theta = decay + c.Rate*c.Strike*discount*normCDF(-d2) - c.yield()*c.Underlying*carry*normCDF(-d1)
+        greeks.Rho = -c.Strike * c.Expiry * discount * normCDF(-d2) / 100
+    }
+    greeks.Theta /= 365
+
+    // With the futures price held fixed only the discounting depends on the rate
+    if c.Model == Black76 {
+        greeks.Rho = -c.Expiry * c.Price(sigma) / 100
+    }
+
+    return greeks
+}
+
+func (c OptionContract) intrinsic() float64 {
+    if c.Type == Call {
+        return math.Max(c.Underlying-c.Strike, 0)
+    }
+    return math.Max(c.Strike-c.Underlying, 0)
+}
+
+// bounds returns the no-arbitrage price range of the option
+func (c OptionContract) bounds() (float64, float64) {
+    underlying := c.Underlying * math.Exp(-c.yield()*c.Expiry)
+    strike := c.Strike * math.Exp(-c.Rate*c.Expiry)
+    if c.Type == Call {
+        return math.Max(underlying-strike, 0), underlying
+    }
+    return math.Max(strike-underlying, 0), strike
+}
+
+func (c OptionContract) validate() error {
+    if c.Underlying <= 0 || c.Strike <= 0 {
+        return fmt.Errorf("underlying and strike must be positive")
+    }
+    if c.Expiry <= 0 {
+        return fmt.Errorf("option has expired")
+    }
+    return nil
+}
+
+// ImpliedVolatilityNewton solves for the volatility reproducing price with
+// Newton-Raphson iterations on vega, starting from guess
+func ImpliedVolatilityNewton(c OptionContract, price, guess float64) (float64, error) {
+    if err := c.validate(); err != nil {
+        return 0, err
+    }
+    if lower, upper := c.bounds(); price <= lower || price >= upper {
+        return 0, ErrNoImpliedVolatility
+    }
+
+    sigma := guess
+    if sigma <= 0 {
+        sigma = 0.2
+    }
+    for i := 0; i < 100; i++ {
+        diff := c.Price(sigma) - price
+        if math.Abs(diff) < 1e-8 {
+            return sigma, nil
+        }
+
+        vega := c.Greeks(sigma).Vega * 100
+        if vega < 1e-10 {
+            break
+        }
+        sigma -= diff / vega
+        if sigma < minVolatility || sigma > maxVolatility {
+            break
+        }
+    }
+
+    return 0, fmt.Errorf("newton implied volatility did not converge")
+}
+
+// ImpliedVolatilityBrent solves for the volatility reproducing price with
+// Brent's method, which converges once the root is bracketed unless the
+// iteration limit is hit first
+func ImpliedVolatilityBrent(c OptionContract, price float64) (float64, error) {
+    if err := c.validate(); err != nil {
+        return 0, err
+    }
+    if lower, upper := c.bounds(); price <= lower || price >= upper {
+        return 0, ErrNoImpliedVolatility
+    }
+
+    f := func(sigma float64) float64 { return c.Price(sigma) - price }
+    a, b := minVolatility, maxVolatility
+    fa, fb := f(a), f(b)
+    if fa*fb > 0 {
+        return 0, ErrNoImpliedVolatility
+    }
+    if math.Abs(fa) < math.Abs(fb) {
+        a, b, fa, fb = b, a, fb, fa
+    }
+
+    cc, fc := a, fa
+    d := b - a
+    bisected := true
+    for i := 0; i < 200; i++ {
+        if math.Abs(fb) < 1e-10 || math.Abs(b-a) < 1e-12 {
+            return b, nil
+        }
+
+        var s float64
+        if fa != fc && fb != fc {
+            // Inverse quadratic interpolation
+            s = a*fb*fc/((fa-fb)*(fa-fc)) + b*fa*fc/((fb-fa)*(fb-fc)) + cc*fa*fb/((fc-fa)*(fc-fb))
+        } else {
+            // Secant
+            s = b - fb*(b-a)/(fb-fa)
+        }
+
+        lo, hi := (3*a+b)/4, b
+        if lo > hi {
+            lo, hi = hi, lo
+        }
+        if s < lo || s > hi ||
+            (bisected && math.Abs(s-b) >= math.Abs(b-cc)/2) ||
+            (!bisected && math.Abs(s-b) >= math.Abs(cc-d)/2) {
+            s = (a + b) / 2
+            bisected = true
+        } else {
+            bisected = false
+        }
+
+        fs := f(s)
+        d, cc, fc = cc, b, fb
+        if fa*fs < 0 {
+            b, fb = s, fs
+        } else {
+            a, fa = s, fs
+        }
+        if math.Abs(fa) < math.Abs(fb) {
+            a, b, fa, fb = b, a, fb, fa
+        }
+    }
+
+    return 0, fmt.Errorf("brent implied volatility failed to converge")
+}
+
+// ImpliedVolatility tries Newton's method and falls back to Brent's method
+// for deep in or out of the money options where vega is tiny
+func ImpliedVolatility(c OptionContract, price float64) (float64, error) {
+    sigma, err := ImpliedVolatilityNewton(c, price, 0.2)
+    if err == nil {
+        return sigma, nil
+    }
+    if errors.Is(err, ErrNoImpliedVolatility) {
+        return 0, err
+    }
+    return ImpliedVolatilityBrent(c, price)
+}
+
+// OptionAnalytics is the implied volatility of an option's market price and
+// the Greeks at that volatility
+type OptionAnalytics struct {
+    Price float64 `json:"price"`
+    IV    float64 `json:"iv"`
+    Greeks
+}
+
+// AnalyzeOption computes the implied volatility and Greeks of a traded option,
+// e.g. from the last price of a live option tick
+func AnalyzeOption(c OptionContract, price float64) (*OptionAnalytics, error) {
+    sigma, err := ImpliedVolatility(c, price)
+    if err != nil {
+        return nil, err
+    }
+
+    return &OptionAnalytics{
+        Price:  price,
+        IV:     sigma,
+        Greeks: c.Greeks(sigma),
+    }, nil
+}
+
+// SurfaceQuote is an option price used to build a volatility surface.
+// Expiry is in years.
+type SurfaceQuote struct {
+    Type   OptionType
+    Strike float64
+    Expiry float64
+    Price  float64
+}
+
+// IVSurface is implied volatility on a strike by expiry grid. Points without
+// a quote are nil, rendered as null in JSON.
+type IVSurface struct {
+    Strikes  []float64    `json:"strikes"`
+    Expiries []float64    `json:"expiries"`
+    IV       [][]*float64 `json:"iv"` // IV[expiry][strike]
+}
+
+// BuildIVSurface solves the implied volatility of each quote against the
+// template contract's underlying, rate, dividend and model. Where both a call
+// and a put are quoted, the out of the money option is used as it is the
+// more liquid and less sensitive to the rate.
+func BuildIVSurface(template OptionContract, quotes []SurfaceQuote) (*IVSurface, error) {
+    type point struct{ strike, expiry float64 }
+    vols := make(map[point]float64)
+    strikeSet := make(map[float64]bool)
+    expirySet := make(map[float64]bool)
+
+    for _, q := range quotes {
+        c := template
+        c.Type = q.Type
+        c.Strike = q.Strike
+        c.Expiry = q.Expiry
+
+        otm := (q.Type == Call) == (q.Strike >= template.Underlying)
+        key := point{q.Strike, q.Expiry}
+        if _, exists := vols[key]; exists && !otm {
+            continue
+        }
+
+        sigma, err := ImpliedVolatility(c, q.Price)
+        if err != nil {
+            continue
+        }
+        vols[key] = sigma
+        strikeSet[q.Strike] = true
+        expirySet[q.Expiry] = true
+    }
+
+    if len(vols) == 0 {
+        return nil, fmt.Errorf("no quotes with a valid implied volatility")
+    }
+
+    surface := &IVSurface{
+        Strikes:  sortedKeys(strikeSet),
+        Expiries: sortedKeys(expirySet),
+    }
+    surface.IV = make([][]*float64, len(surface.Expiries))
+    for i, expiry := range surface.Expiries {
+        surface.IV[i] = make([]*float64, len(surface.Strikes))
+        for j, strike := range surface.Strikes {
+            if sigma, exists := vols[point{strike, expiry}]; exists {
+                surface.IV[i][j] = &sigma
+            }
+        }
+    }
+
+    return surface, nil
+}
+
+// At interpolates the surface linearly in strike and in total variance
+// across expiries. Points outside the grid are clamped to its edges.
+func (s *IVSurface) At(strike, expiry float64) float64 {
+    i, j, w := bracket(s.Expiries, expiry)
+    lower := s.smileAt(i, strike)
+    if w == 0 || i == j {
+        return lower
+    }
+    upper := s.smileAt(j, strike)
+
+    t1, t2 := s.Expiries[i], s.Expiries[j]
+    variance := (1-w)*lower*lower*t1 + w*upper*upper*t2
+    return math.Sqrt(variance / expiry)
+}
+
+// smileAt interpolates the smile of one expiry, skipping missing strikes
+func (s *IVSurface) smileAt(row int, strike float64) float64 {
+    var strikes, vols []float64
+    for j, sigma := range s.IV[row] {
+        if sigma != nil {
+            strikes = append(strikes, s.Strikes[j])
+            vols = append(vols, *sigma)
+        }
+    }
+    if len(strikes) == 0 {
+        return math.NaN()
+    }
+
+    i, j, w := bracket(strikes, strike)
+    return (1-w)*vols[i] + w*vols[j]
+}
+
+// bracket finds the neighbours of x in sorted values and the weight of the upper one
+func bracket(values []float64, x float64) (int, int, float64) {
+    n := len(values)
+    if x <= values[0] {
+        return 0, 0, 0
+    }
+    if x >= values[n-1] {
+        return n - 1, n - 1, 0
+    }
+
+    j := sort.SearchFloat64s(values, x)
+    i := j - 1
+    return i, j, (x - values[i]) / (values[j] - values[i])
+}
+
+func sortedKeys(set map[float64]bool) []float64 {
+    keys := make([]float64, 0, len(set))
+    for k := range set {
+        keys = append(keys, k)
+    }
+    sort.Float64s(keys)
+    return keys
+}
+
+func normCDF(x float64) float64 {
+    return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
+
+func normPDF(x float64) float64 {
+    return math.Exp(-x*x/2) / math.Sqrt(2*math.Pi)
+}
@@ -0,0 +1,226 @@
+package indicators
+
+import (
+    "encoding/json"
+    "errors"
+    "math"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/algo-trading/market-data-service/internal/models"
+)
+
+func TestBlackScholesPrice(t *testing.T) {
+    call := OptionContract{Type: Call, Underlying: 100, Strike: 100, Expiry: 1, Rate: 0.05}
+    put := call
+    put.Type = Put
+
+    if price := call.Price(0.2); math.Abs(price-10.4506) > 1e-4 {
+        t.Errorf("Expected call price 10.4506, got %f", price)
+    }
+    if price := put.Price(0.2); math.Abs(price-5.5735) > 1e-4 {
+        t.Errorf("Expected put price 5.5735, got %f", price)
+    }
+
+    // Put-call parity with a dividend yield
+    call.Dividend, put.Dividend = 0.02, 0.02
+    parity := call.Underlying*math.Exp(-0.02) - call.Strike*math.Exp(-0.05)
+    if diff := call.Price(0.3) - put.Price(0.3); math.Abs(diff-parity) > 1e-9 {
+        t.Errorf("Put-call parity violated: %f vs %f", diff, parity)
+    }
+}
+
+func TestBlack76Price(t *testing.T) {
+    call := OptionContract{Type: Call, Model: Black76, Underlying: 100, Strike: 100, Expiry: 1, Rate: 0.05}
+
+    expected := math.Exp(-0.05) * 100 * (normCDF(0.1) - normCDF(-0.1))
+    if price := call.Price(0.2); math.Abs(price-expected) > 1e-9 {
+        t.Errorf("Expected Black-76 price %f, got %f", expected, price)
+    }
+}
+
+func TestGreeksMatchFiniteDifferences(t *testing.T) {
+    const sigma = 0.25
+    const h = 1e-4
+
+    for _, model := range []PricingModel{BlackScholes, Black76} {
+        for _, optionType := range []OptionType{Call, Put} {
+            c := OptionContract{Type: optionType, Model: model, Underlying: 105, Strike: 100, Expiry: 0.5, Rate: 0.06, Dividend: 0.01}
+            greeks := c.Greeks(sigma)
+
+            bump := func(f func(c *OptionContract)) float64 {
+                up, down := c, c
+                f(&up)
+                return up.Price(sigma) - down.Price(sigma)
+            }
+
+            delta := bump(func(c *OptionContract) { c.Underlying += h }) / h
+            up, down := c, c
+            up.Underlying += h
+            down.Underlying -= h
+            gamma := (up.Price(sigma) - 2*c.Price(sigma) + down.Price(sigma)) / (h * h)
+            vega := (c.Price(sigma+h) - c.Price(sigma)) / h / 100
+            theta := bump(func(c *OptionContract) { c.Expiry -= h }) / h / 365
+            rho := bump(func(c *OptionContract) { c.Rate += h }) / h / 100
+
+            checks := []struct {
+                name          string
+                got, expected float64
+            }{
+                {"delta", greeks.Delta, delta},
+                {"gamma", greeks.Gamma, gamma},
+                {"vega", greeks.Vega, vega},
+                {"theta", greeks.Theta, theta},
+                {"rho", greeks.Rho, rho},
+            }
+            for _, check := range checks {
+                if math.Abs(check.got-check.expected) > 1e-3 {
+                    t.Errorf("Model %d type %d: expected %s %f, got %f", model, optionType, check.name, check.expected, check.got)
+                }
+            }
+        }
+    }
+}
+
+func TestImpliedVolatilitySolvers(t *testing.T) {
+    strikes := []float64{60, 90, 100, 110, 160}
+    for _, strike := range strikes {
+        for _, optionType := range []OptionType{Call, Put} {
+            c := OptionContract{Type: optionType, Underlying: 100, Strike: strike, Expiry: 0.25, Rate: 0.065}
+            price := c.Price(0.3)
+
+            sigma, err := ImpliedVolatility(c, price)
+            if err != nil {
+                t.Fatalf("Failed to solve IV for strike %v: %v", strike, err)
+            }
+            if math.Abs(sigma-0.3) > 1e-5 {
+                t.Errorf("Strike %v type %d: expected IV 0.3, got %f", strike, optionType, sigma)
+            }
+
+            sigma, err = ImpliedVolatilityBrent(c, price)
+            if err != nil || math.Abs(sigma-0.3) > 1e-5 {
+                t.Errorf("Brent strike %v type %d: expected IV 0.3, got %f (%v)", strike, optionType, sigma, err)
+            }
+        }
+    }
+
+    c := OptionContract{Type: Call, Underlying: 100, Strike: 100, Expiry: 0.25}
+    if _, err := ImpliedVolatility(c, 0); !errors.Is(err, ErrNoImpliedVolatility) {
+        t.Errorf("Expected no implied volatility for a zero price, got %v", err)
+    }
+    if _, err := ImpliedVolatility(c, 150); !errors.Is(err, ErrNoImpliedVolatility) {
+        t.Errorf("Expected no implied volatility above the underlying, got %v", err)
+    }
+}
+
+func TestBuildIVSurface(t *testing.T) {
+    template := OptionContract{Underlying: 100, Rate: 0.05}
+    smile := func(strike, expiry float64) float64 {
+        m := math.Log(strike / 100)
+        return 0.2 + m*m + 0.05*expiry
+    }
+
+    var quotes []SurfaceQuote
+    for _, expiry := range []float64{0.1, 0.5} {
+        for _, strike := range []float64{90, 100, 110} {
+            for _, optionType := range []OptionType{Call, Put} {
+                c := template
+                c.Type, c.Strike, c.Expiry = optionType, strike, expiry
+                quotes = append(quotes, SurfaceQuote{Type: optionType, Strike: strike, Expiry: expiry, Price: c.Price(smile(strike, expiry))})
+            }
+        }
+    }
+
+    surface, err := BuildIVSurface(template, quotes)
+    if err != nil {
+        t.Fatalf("Failed to build IV surface: %v", err)
+    }
+    if len(surface.Expiries) != 2 || len(surface.Strikes) != 3 {
+        t.Fatalf("Unexpected surface grid: %v x %v", surface.Expiries, surface.Strikes)
+    }
+
+    for i, expiry := range surface.Expiries {
+        for j, strike := range surface.Strikes {
+            if surface.IV[i][j] == nil {
+                t.Fatalf("Expected an IV at %v/%v", strike, expiry)
+            }
+            if math.Abs(*surface.IV[i][j]-smile(strike, expiry)) > 1e-5 {
+                t.Errorf("IV at %v/%v: expected %f, got %f", strike, expiry, smile(strike, expiry), *surface.IV[i][j])
+            }
+            if math.Abs(surface.At(strike, expiry)-*surface.IV[i][j]) > 1e-9 {
+                t.Errorf("Interpolation does not reproduce grid point %v/%v", strike, expiry)
+            }
+        }
+    }
+
+    mid := surface.At(95, 0.3)
+    if mid < smile(100, 0.1) || mid > smile(90, 0.5) {
+        t.Errorf("Interpolated IV %f outside neighbouring grid values", mid)
+    }
+}
+
+func TestIVSurfaceMissingPoints(t *testing.T) {
+    template := OptionContract{Underlying: 100, Rate: 0.05}
+    var quotes []SurfaceQuote
+    for _, q := range []struct{ strike, expiry float64 }{{90, 0.1}, {110, 0.1}, {110, 0.5}} {
+        c := template
+        c.Type, c.Strike, c.Expiry = Call, q.strike, q.expiry
+        quotes = append(quotes, SurfaceQuote{Type: Call, Strike: q.strike, Expiry: q.expiry, Price: c.Price(0.2)})
+    }
+
+    surface, err := BuildIVSurface(template, quotes)
+    if err != nil {
+        t.Fatalf("Failed to build IV surface: %v", err)
+    }
+    if surface.IV[1][0] != nil {
+        t.Errorf("Expected no IV at 90/0.5, got %f", *surface.IV[1][0])
+    }
+
+    data, err := json.Marshal(surface)
+    if err != nil {
+        t.Fatalf("Failed to marshal a surface with missing points: %v", err)
+    }
+    if !strings.Contains(string(data), `[null,`) {
+        t.Errorf("Expected the missing point as null, got %s", data)
+    }
+    if sigma := surface.At(100, 0.5); math.Abs(sigma-0.2) > 1e-5 {
+        t.Errorf("Expected interpolation to skip the missing point, got %f", sigma)
+    }
+}
+
+func TestIndicatorCalculatorOptions(t *testing.T) {
+    calc := NewIndicatorCalculator()
+    data := []models.OHLCV{{Time: time.Now(), Symbol: "NIFTY", Close: 100}}
+    price := OptionContract{Type: Call, Underlying: 100, Strike: 105, Expiry: 0.1, Rate: 0.065}.Price(0.18)
+
+    params := map[string]interface{}{
+        "option_type":  "CE",
+        "strike":       105.0,
+        "expiry":       0.1,
+        "rate":         0.065,
+        "option_price": price,
+    }
+
+    iv, err := calc.Calculate(data, "implied_volatility", params)
+    if err != nil {
+        t.Fatalf("Failed to calculate implied volatility: %v", err)
+    }
+    if math.Abs(iv.(float64)-0.18) > 1e-5 {
+        t.Errorf("Expected IV 0.18, got %v", iv)
+    }
+
+    result, err := calc.Calculate(data, "option_greeks", params)
+    if err != nil {
+        t.Fatalf("Failed to calculate Greeks: %v", err)
+    }
+    analytics := result.(*OptionAnalytics)
+    if analytics.Delta <= 0 || analytics.Delta >= 0.5 {
+        t.Errorf("Expected OTM call delta between 0 and 0.5, got %f", analytics.Delta)
+    }
+
+    params["model"] = "binomial"
+    if _, err := calc.Calculate(data, "option_greeks", params); err == nil {
+        t.Errorf("Expected error for unknown pricing model")
+    }
+}
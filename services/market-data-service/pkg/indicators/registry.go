@@ -0,0 +1,431 @@
+package indicators
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "math"
+    "reflect"
+    "regexp"
+    "sort"
+    "strconv"
+    "strings"
+    "sync"
+    "unicode"
+
+    "github.com/algo-trading/market-data-service/internal/models"
+)
+
+// ErrUnknownIndicator is returned for names that are not registered
+var ErrUnknownIndicator = errors.New("unknown indicator")
+
+// ParamType is the type of an indicator parameter
+type ParamType string
+
+const (
+    TypeInt    ParamType = "int"
+    TypeFloat  ParamType = "float"
+    TypeString ParamType = "string"
+)
+
+// Param describes an indicator parameter. Optional parameters without a
+// default are left out of Params when not given.
+type Param struct {
+    Name        string      `json:"name"`
+    Type        ParamType   `json:"type"`
+    Required    bool        `json:"required"`
+    Default     interface{} `json:"default,omitempty"`
+    Min         *float64    `json:"min,omitempty"`
+    Max         *float64    `json:"max,omitempty"`
+    Options     []string    `json:"options,omitempty"`
+    Description string      `json:"description,omitempty"`
+}
+
+// IntParam declares a required integer parameter
+func IntParam(name, description string) Param {
+    return Param{Name: name, Type: TypeInt, Required: true, Description: description}
+}
+
+// FloatParam declares a required float parameter
+func FloatParam(name, description string) Param {
+    return Param{Name: name, Type: TypeFloat, Required: true, Description: description}
+}
+
+// StringParam declares a required string parameter, limited to options when
+// given. Options are matched case-insensitively.
+func StringParam(name, description string, options ...string) Param {
+    return Param{Name: name, Type: TypeString, Required: true, Options: options, Description: description}
+}
+
+// Optional makes a parameter optional without a default
+func (p Param) Optional() Param {
+    p.Required = false
+    return p
+}
+
+// WithDefault makes a parameter optional with a default value
+func (p Param) WithDefault(value interface{}) Param {
+    p.Required = false
+    p.Default = value
+    return p
+}
+
+// WithMin sets the inclusive lower bound of a numeric parameter
+func (p Param) WithMin(min float64) Param {
+    p.Min = &min
+    return p
+}
+
+// WithMax sets the inclusive upper bound of a numeric parameter
+func (p Param) WithMax(max float64) Param {
+    p.Max = &max
+    return p
+}
+
+// ParamError reports an invalid parameter of an indicator
+type ParamError struct {
+    Indicator string
+    Param     string
+    Reason    string
+}
+
+func (e *ParamError) Error() string {
+    return fmt.Sprintf("invalid %s parameter %s: %s", e.Indicator, e.Param, e.Reason)
+}
+
+// Params are validated parameter values: int for TypeInt, float64 for
+// TypeFloat and string for TypeString
+type Params map[string]interface{}
+
+// Has reports whether a parameter was given or has a default
+func (p Params) Has(name string) bool {
+    _, exists := p[name]
+    return exists
+}
+
+func (p Params) Int(name string) int {
+    value, _ := p[name].(int)
+    return value
+}
+
+func (p Params) Float(name string) float64 {
+    value, _ := p[name].(float64)
+    return value
+}
+
+func (p Params) String(name string) string {
+    value, _ := p[name].(string)
+    return value
+}
+
+// CalculateFunc computes an indicator from validated parameters
+type CalculateFunc func(data []models.OHLCV, params Params) (interface{}, error)
+
+// Definition describes a registered indicator
+type Definition struct {
+    Name        string  `json:"name"`
+    Description string  `json:"description"`
+    Params      []Param `json:"params"`
+    // Outputs names the series an indicator returns, the default first. A
+    // single output is returned as []float64, several as a pointer to a
+    // struct with a slice field per output, e.g. plus_di for PlusDI. Empty
+    // for results that are not series.
+    Outputs []string `json:"outputs,omitempty"`
+    // Source indicators only use the close, so they can be applied to any
+    // series by substituting it for the prices
+    Source bool `json:"source"`
+    // LookAhead names outputs whose value at a bar depends on later bars,
+    // such as the Ichimoku lagging span, which signals must not use
+    LookAhead []string `json:"look_ahead,omitempty"`
+    // WarmUp returns the index of the first defined value of an output for
+    // the given parameters; earlier values are placeholders. Nil means every
+    // value is defined.
+    WarmUp    func(params Params, output string) int `json:"-"`
+    Calculate CalculateFunc                          `json:"-"`
+}
+
+// FirstValid returns the index of the first defined value of the output
+func (d Definition) FirstValid(params Params, output string) int {
+    if d.WarmUp == nil {
+        return 0
+    }
+    return d.WarmUp(params, output)
+}
+
+// Validate coerces raw parameters, such as numbers decoded from JSON, to the
+// declared types, applies defaults and checks ranges and options
+func (d Definition) Validate(raw map[string]interface{}) (Params, error) {
+    known := make(map[string]bool, len(d.Params))
+    for _, p := range d.Params {
+        known[p.Name] = true
+    }
+    var unknown []string
+    for name := range raw {
+        if !known[name] {
+            unknown = append(unknown, name)
+        }
+    }
+    if len(unknown) > 0 {
+        sort.Strings(unknown)
+        return nil, &ParamError{Indicator: d.Name, Param: unknown[0], Reason: "unknown parameter"}
+    }
+
+    params := make(Params, len(d.Params))
+    for _, p := range d.Params {
+        value, exists := raw[p.Name]
+        if !exists || value == nil {
+            if p.Required {
+                return nil, &ParamError{Indicator: d.Name, Param: p.Name, Reason: "is required"}
+            }
+            if p.Default == nil {
+                continue
+            }
+            value = p.Default
+        }
+
+        coerced, err := p.coerce(value)
+        if err != nil {
+            return nil, &ParamError{Indicator: d.Name, Param: p.Name, Reason: err.Error()}
+        }
+        params[p.Name] = coerced
+    }
+
+    return params, nil
+}
+
+// coerce converts a value to the parameter's type and checks its bounds
+func (p Param) coerce(value interface{}) (interface{}, error) {
+    if p.Type == TypeString {
+        var s string
+        switch v := value.(type) {
+        case string:
+            s = v
+        case fmt.Stringer:
+            s = v.String()
+        default:
+            return nil, fmt.Errorf("must be a string, got %T", value)
+        }
+        if len(p.Options) == 0 {
+            return s, nil
+        }
+        for _, option := range p.Options {
+            if strings.EqualFold(s, option) {
+                return option, nil
+            }
+        }
+        return nil, fmt.Errorf("must be one of %s, got %q", strings.Join(p.Options, ", "), s)
+    }
+
+    f, err := toFloat(value)
+    if err != nil {
+        return nil, err
+    }
+    if p.Min != nil && f < *p.Min {
+        return nil, fmt.Errorf("must be at least %v, got %v", *p.Min, f)
+    }
+    if p.Max != nil && f > *p.Max {
+        return nil, fmt.Errorf("must be at most %v, got %v", *p.Max, f)
+    }
+
+    if p.Type == TypeInt {
+        if f != math.Trunc(f) || math.Abs(f) > math.MaxInt32 {
+            return nil, fmt.Errorf("must be an integer, got %v", f)
+        }
+        return int(f), nil
+    }
+    return f, nil
+}
+
+// toFloat accepts Go numbers, json.Number and numeric strings
+func toFloat(value interface{}) (float64, error) {
+    var f float64
+    switch v := value.(type) {
+    case float64:
+        f = v
+    case float32:
+        f = float64(v)
+    case json.Number:
+        parsed, err := v.Float64()
+        if err != nil {
+            return 0, fmt.Errorf("must be a number, got %q", v)
+        }
+        f = parsed
+    case string:
+        parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+        if err != nil {
+            return 0, fmt.Errorf("must be a number, got %q", v)
+        }
+        f = parsed
+    default:
+        rv := reflect.ValueOf(value)
+        switch rv.Kind() {
+        case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+            f = float64(rv.Int())
+        case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+            f = float64(rv.Uint())
+        default:
+            return 0, fmt.Errorf("must be a number, got %T", value)
+        }
+    }
+
+    if math.IsNaN(f) || math.IsInf(f, 0) {
+        return 0, fmt.Errorf("must be a finite number")
+    }
+    return f, nil
+}
+
+// Series returns the named output of a result returned by the indicator's
+// Calculate, converting integer outputs such as trend directions to float64
+func (d Definition) Series(result interface{}, output string) ([]float64, error) {
+    if values, ok := result.([]float64); ok && len(d.Outputs) == 1 && d.Outputs[0] == output {
+        return values, nil
+    }
+
+    value := reflect.Indirect(reflect.ValueOf(result))
+    if value.Kind() == reflect.Struct {
+        for i := 0; i < value.NumField(); i++ {
+            if snakeCase(value.Type().Field(i).Name) != output {
+                continue
+            }
+            switch field := value.Field(i).Interface().(type) {
+            case []float64:
+                return field, nil
+            case []int:
+                values := make([]float64, len(field))
+                for j, v := range field {
+                    values[j] = float64(v)
+                }
+                return values, nil
+            }
+        }
+    }
+
+    return nil, fmt.Errorf("%s result %T has no series output %s", d.Name, result, output)
+}
+
+// snakeCase converts a Go field name such as PlusDI or SenkouA to plus_di or
+// senkou_a
+func snakeCase(name string) string {
+    runes := []rune(name)
+    var b strings.Builder
+    for i, r := range runes {
+        if unicode.IsUpper(r) {
+            if i > 0 && (unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+                b.WriteByte('_')
+            }
+            r = unicode.ToLower(r)
+        }
+        b.WriteRune(r)
+    }
+    return b.String()
+}
+
+var indicatorName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// Registry holds indicator definitions by name
+type Registry struct {
+    mu          sync.RWMutex
+    definitions map[string]Definition
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+    return &Registry{definitions: make(map[string]Definition)}
+}
+
+// Register adds an indicator, checking its schema and defaults
+func (r *Registry) Register(def Definition) error {
+    if !indicatorName.MatchString(def.Name) {
+        return fmt.Errorf("invalid indicator name %q: must be lower-case letters, digits and underscores", def.Name)
+    }
+    if def.Calculate == nil {
+        return fmt.Errorf("indicator %s has no Calculate function", def.Name)
+    }
+
+    seen := make(map[string]bool, len(def.Params))
+    for _, p := range def.Params {
+        if p.Name == "" || seen[p.Name] {
+            return fmt.Errorf("indicator %s has an empty or duplicate parameter name %q", def.Name, p.Name)
+        }
+        seen[p.Name] = true
+        switch p.Type {
+        case TypeInt, TypeFloat, TypeString:
+        default:
+            return fmt.Errorf("indicator %s parameter %s has unknown type %q", def.Name, p.Name, p.Type)
+        }
+        if p.Default != nil {
+            if _, err := p.coerce(p.Default); err != nil {
+                return fmt.Errorf("indicator %s parameter %s has an invalid default: %w", def.Name, p.Name, err)
+            }
+        }
+    }
+
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    if _, exists := r.definitions[def.Name]; exists {
+        return fmt.Errorf("indicator %s is already registered", def.Name)
+    }
+    r.definitions[def.Name] = def
+    return nil
+}
+
+// Lookup returns the definition of a registered indicator
+func (r *Registry) Lookup(name string) (Definition, bool) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    def, exists := r.definitions[name]
+    return def, exists
+}
+
+// Definitions lists the registered indicators by name
+func (r *Registry) Definitions() []Definition {
+    r.mu.RLock()
+    defs := make([]Definition, 0, len(r.definitions))
+    for _, def := range r.definitions {
+        defs = append(defs, def)
+    }
+    r.mu.RUnlock()
+
+    sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+    return defs
+}
+
+// Calculate validates raw parameters and computes the named indicator
+func (r *Registry) Calculate(data []models.OHLCV, name string, raw map[string]interface{}) (interface{}, error) {
+    def, exists := r.Lookup(name)
+    if !exists {
+        return nil, fmt.Errorf("%w: %s", ErrUnknownIndicator, name)
+    }
+    params, err := def.Validate(raw)
+    if err != nil {
+        return nil, err
+    }
+    return def.Calculate(data, params)
+}
+
+// defaultRegistry holds the built-in indicators and those added with Register
+var defaultRegistry = NewRegistry()
+
+func init() {
+    for _, def := range builtinDefinitions() {
+        if err := defaultRegistry.Register(def); err != nil {
+            panic(err)
+        }
+    }
+}
+
+// Register adds an indicator to the default registry, making it available to
+// IndicatorCalculator and expressions
+func Register(def Definition) error {
+    return defaultRegistry.Register(def)
+}
+
+// Lookup returns a definition from the default registry
+func Lookup(name string) (Definition, bool) {
+    return defaultRegistry.Lookup(name)
+}
+
+// Definitions lists the indicators of the default registry
+func Definitions() []Definition {
+    return defaultRegistry.Definitions()
+}
@@ -0,0 +1,227 @@
+package indicators
+
+import (
+    "bytes"
+    "encoding/json"
+    "errors"
+    "strings"
+    "testing"
+
+    "github.com/algo-trading/market-data-service/internal/models"
+    "github.com/algo-trading/market-data-service/internal/testutil"
+)
+
+func TestCalculateWithJSONParams(t *testing.T) {
+    data := testutil.RandomWalk("TEST", 100, 1)
+    calc := NewIndicatorCalculator()
+
+    var params map[string]interface{}
+    if err := json.Unmarshal([]byte(`{"period": 14}`), &params); err != nil {
+        t.Fatal(err)
+    }
+    result, err := calc.Calculate(data, "rsi", params)
+    if err != nil {
+        t.Fatalf("Failed to calculate RSI with JSON params: %v", err)
+    }
+    expected, _ := CalculateRSI(data, 14)
+    if result.([]float64)[99] != expected[99] {
+        t.Errorf("Expected RSI %v, got %v", expected[99], result.([]float64)[99])
+    }
+
+    // json.Number and numeric strings are coerced as well
+    decoder := json.NewDecoder(bytes.NewReader([]byte(`{"fast_period": 12, "slow_period": "26", "signal_period": 9, "signal_ma": "SMA"}`)))
+    decoder.UseNumber()
+    params = nil
+    if err := decoder.Decode(&params); err != nil {
+        t.Fatal(err)
+    }
+    result, err = calc.Calculate(data, "macd", params)
+    if err != nil {
+        t.Fatalf("Failed to calculate MACD with JSON params: %v", err)
+    }
+    macd, _ := CalculateMACDWithMA(data, 12, 26, 9, SMA)
+    if result.(*MACD).Signal[99] != macd.Signal[99] {
+        t.Errorf("Expected signal_ma to select SMA")
+    }
+}
+
+func TestValidateParams(t *testing.T) {
+    bands, _ := Lookup("bollinger_bands")
+
+    params, err := bands.Validate(map[string]interface{}{"period": 20.0, "deviation": 2, "basis_ma": EMA})
+    if err != nil {
+        t.Fatalf("Failed to validate: %v", err)
+    }
+    if params.Int("period") != 20 || params.Float("deviation") != 2 || params.String("basis_ma") != "ema" {
+        t.Errorf("Unexpected coerced params %v", params)
+    }
+
+    // Defaults are applied
+    params, err = bands.Validate(map[string]interface{}{"period": 20, "deviation": 2.5})
+    if err != nil || params.String("basis_ma") != "sma" {
+        t.Errorf("Expected default basis_ma sma, got %v (%v)", params, err)
+    }
+
+    tests := []struct {
+        params map[string]interface{}
+        field  string
+        reason string
+    }{
+        {map[string]interface{}{"deviation": 2.0}, "period", "is required"},
+        {map[string]interface{}{"period": 20.5, "deviation": 2.0}, "period", "must be an integer"},
+        {map[string]interface{}{"period": 0, "deviation": 2.0}, "period", "must be at least 1"},
+        {map[string]interface{}{"period": "twenty", "deviation": 2.0}, "period", "must be a number"},
+        {map[string]interface{}{"period": 20, "deviation": true}, "deviation", "must be a number"},
+        {map[string]interface{}{"period": 20, "deviation": 2.0, "basis_ma": "linear"}, "basis_ma", "must be one of sma, ema"},
+        {map[string]interface{}{"period": 20, "deviation": 2.0, "stddev": 2.0}, "stddev", "unknown parameter"},
+    }
+    for _, tt := range tests {
+        _, err := bands.Validate(tt.params)
+        var paramErr *ParamError
+        if !errors.As(err, &paramErr) {
+            t.Errorf("Expected ParamError for %v, got %v", tt.params, err)
+            continue
+        }
+        if paramErr.Param != tt.field || !strings.Contains(paramErr.Reason, tt.reason) {
+            t.Errorf("Expected %s: %s, got %v", tt.field, tt.reason, err)
+        }
+    }
+
+    if _, err := NewIndicatorCalculator().Calculate(nil, "unknown", nil); !errors.Is(err, ErrUnknownIndicator) {
+        t.Errorf("Expected ErrUnknownIndicator, got %v", err)
+    }
+}
+
+func TestRegisterIndicator(t *testing.T) {
+    registry := NewRegistry()
+
+    midpoint := Definition{
+        Name:        "midpoint",
+        Description: "Average of the high and low",
+        Params:      []Param{FloatParam("offset", "added to the midpoint").WithDefault(0.0)},
+        Outputs:     []string{"value"},
+        Calculate: func(data []models.OHLCV, params Params) (interface{}, error) {
+            values := make([]float64, len(data))
+            for i, bar := range data {
+                values[i] = (bar.High+bar.Low)/2 + params.Float("offset")
+            }
+            return values, nil
+        },
+    }
+    if err := registry.Register(midpoint); err != nil {
+        t.Fatalf("Failed to register: %v", err)
+    }
+    if err := registry.Register(midpoint); err == nil {
+        t.Error("Expected error registering a duplicate")
+    }
+
+    data := []models.OHLCV{{High: 110, Low: 100}}
+    result, err := NewRegistryCalculator(registry).Calculate(data, "midpoint", map[string]interface{}{"offset": 1})
+    if err != nil {
+        t.Fatalf("Failed to calculate: %v", err)
+    }
+    if result.([]float64)[0] != 106 {
+        t.Errorf("Expected 106, got %v", result)
+    }
+
+    invalid := []Definition{
+        {Name: "Mid Point", Calculate: midpoint.Calculate},
+        {Name: "nocalc"},
+        {Name: "dup", Params: []Param{IntParam("n", ""), IntParam("n", "")}, Calculate: midpoint.Calculate},
+        {Name: "baddefault", Params: []Param{IntParam("n", "").WithMin(1).WithDefault(0)}, Calculate: midpoint.Calculate},
+        {Name: "badtype", Params: []Param{{Name: "n", Type: "bool"}}, Calculate: midpoint.Calculate},
+    }
+    for _, def := range invalid {
+        if err := registry.Register(def); err == nil {
+            t.Errorf("Expected error registering %q", def.Name)
+        }
+    }
+}
+
+func TestBuiltinOutputs(t *testing.T) {
+    data := testutil.RandomWalk("TEST", 300, 2)
+
+    for _, def := range Definitions() {
+        if len(def.Outputs) == 0 {
+            continue
+        }
+
+        params := make(map[string]interface{})
+        for _, p := range def.Params {
+            if !p.Required {
+                continue
+            }
+            switch p.Type {
+            case TypeInt:
+                params[p.Name] = 10
+            case TypeFloat:
+                params[p.Name] = 2.0
+            }
+        }
+
+        result, err := def.Calculate(data, mustValidate(t, def, params))
+        if err != nil {
+            t.Errorf("Failed to calculate %s: %v", def.Name, err)
+            continue
+        }
+        for _, output := range def.Outputs {
+            series, err := def.Series(result, output)
+            if err != nil {
+                t.Errorf("Failed to read %s.%s: %v", def.Name, output, err)
+                continue
+            }
+            if len(series) < len(data) {
+                t.Errorf("Expected %s.%s to cover %d bars, got %d", def.Name, output, len(data), len(series))
+            }
+        }
+    }
+}
+
+func TestBuiltinWarmUp(t *testing.T) {
+    data := testutil.RandomWalk("TEST", 300, 2)
+
+    for _, def := range Definitions() {
+        if len(def.Outputs) == 0 || def.WarmUp == nil {
+            continue
+        }
+
+        // Distinct periods catch warm-ups using the wrong parameter
+        params := make(map[string]interface{})
+        period := 5
+        for _, p := range def.Params {
+            switch {
+            case p.Type == TypeInt:
+                params[p.Name] = period
+                period += 3
+            case p.Type == TypeFloat && p.Required:
+                params[p.Name] = 2.0
+            }
+        }
+        validated := mustValidate(t, def, params)
+
+        result, err := def.Calculate(data, validated)
+        if err != nil {
+            t.Errorf("Failed to calculate %s: %v", def.Name, err)
+            continue
+        }
+        for _, output := range def.Outputs {
+            series, _ := def.Series(result, output)
+            first := def.FirstValid(validated, output)
+            if first > 0 && series[first-1] != 0 {
+                t.Errorf("%s.%s: expected a placeholder before bar %d, got %v", def.Name, output, first, series[first-1])
+            }
+            if series[first] == 0 {
+                t.Errorf("%s.%s: expected a value at bar %d", def.Name, output, first)
+            }
+        }
+    }
+}
+
+func mustValidate(t *testing.T, def Definition, raw map[string]interface{}) Params {
+    t.Helper()
+    params, err := def.Validate(raw)
+    if err != nil {
+        t.Fatalf("Failed to validate %s params: %v", def.Name, err)
+    }
+    return params
+}
@@ -0,0 +1,108 @@
+package indicators
+
+import (
+    "fmt"
+    "math"
+    "time"
+
+    "github.com/algo-trading/market-data-service/internal/models"
+)
+
+// BarClock places bars of a timeframe in trading sessions, as
+// calendar.Calendar does
+type BarClock interface {
+    BarStart(t time.Time, timeframe string) (time.Time, error)
+    BarEnd(start time.Time, timeframe string) (time.Time, error)
+}
+
+// Resampled is a series aggregated to a higher timeframe
+type Resampled struct {
+    Bars []models.OHLCV
+    // Closed is the index of the source bar at whose close each higher bar
+    // completed, or -1 for a trailing bar still in progress
+    Closed []int
+
+    sources int
+}
+
+// Resample aggregates bars of timeframe from, oldest first, into bars of the
+// higher timeframe to. A higher bar is complete once a source bar closes at
+// or after its end, so a trailing partial bar is returned but left open.
+func Resample(data []models.OHLCV, from, to string, clock BarClock) (*Resampled, error) {
+    result := &Resampled{sources: len(data)}
+    var end time.Time
+    for i, bar := range data {
+        start, err := clock.BarStart(bar.Time, to)
+        if err != nil {
+            return nil, fmt.Errorf("failed to place %s bar at %s in %s: %w", from, bar.Time, to, err)
+        }
+
+        n := len(result.Bars)
+        if n == 0 || !start.Equal(result.Bars[n-1].Time) {
+            if n > 0 && start.Before(result.Bars[n-1].Time) {
+                return nil, fmt.Errorf("bars are not in time order at %s", bar.Time)
+            }
+            // A bar missing its last source bars is known to be complete
+            // once a later one starts
+            if n > 0 && result.Closed[n-1] < 0 {
+                result.Closed[n-1] = i
+            }
+            if end, err = clock.BarEnd(start, to); err != nil {
+                return nil, fmt.Errorf("failed to place %s bar at %s: %w", to, start, err)
+            }
+            result.Bars = append(result.Bars, models.OHLCV{
+                Time:      start,
+                Symbol:    bar.Symbol,
+                Open:      bar.Open,
+                High:      bar.High,
+                Low:       bar.Low,
+                Close:     bar.Close,
+                Timeframe: to,
+            })
+            result.Closed = append(result.Closed, -1)
+            n++
+        }
+
+        higher := &result.Bars[n-1]
+        higher.High = math.Max(higher.High, bar.High)
+        higher.Low = math.Min(higher.Low, bar.Low)
+        higher.Close = bar.Close
+        higher.Volume += bar.Volume
+
+        barStart, err := clock.BarStart(bar.Time, from)
+        if err != nil {
+            return nil, fmt.Errorf("failed to place %s bar at %s: %w", from, bar.Time, err)
+        }
+        barEnd, err := clock.BarEnd(barStart, from)
+        if err != nil {
+            return nil, fmt.Errorf("failed to place %s bar at %s: %w", from, bar.Time, err)
+        }
+        if !barEnd.Before(end) {
+            result.Closed[n-1] = i
+        }
+    }
+
+    return result, nil
+}
+
+// Align maps values computed on the resampled bars back onto the source bars
+// without look-ahead: each source bar gets the value of the latest higher bar
+// that had completed by its close, and NaN before the first one completes.
+func (r *Resampled) Align(values []float64) ([]float64, error) {
+    if len(values) != len(r.Bars) {
+        return nil, fmt.Errorf("expected %d values, got %d", len(r.Bars), len(values))
+    }
+
+    result := make([]float64, r.sources)
+    current := math.NaN()
+    k := 0
+    for i := range result {
+        for k < len(r.Closed) && r.Closed[k] >= 0 && r.Closed[k] <= i {
+            current = values[k]
+            k++
+        }
+        result[i] = current
+    }
+
+    return result, nil
+}
@@ -0,0 +1,98 @@
+package indicators
+
+import (
+    "math"
+    "testing"
+    "time"
+
+    "github.com/algo-trading/market-data-service/internal/calendar"
+    "github.com/algo-trading/market-data-service/internal/models"
+)
+
+// createSessionBars returns 15 minute bars from the 09:15 IST open of a
+// trading day
+func createSessionBars(n int) []models.OHLCV {
+    open := time.Date(2026, time.October, 16, 9, 15, 0, 0, calendar.IST)
+    data := make([]models.OHLCV, n)
+    for i := range data {
+        price := 100 + float64(i)
+        data[i] = models.OHLCV{
+            Time:      open.Add(time.Duration(i) * 15 * time.Minute),
+            Symbol:    "TCS",
+            Open:      price,
+            High:      price + 2,
+            Low:       price - 1,
+            Close:     price + 1,
+            Volume:    10,
+            Timeframe: "15m",
+        }
+    }
+    return data
+}
+
+func TestResample(t *testing.T) {
+    cal, err := calendar.NewCalendar(calendar.NSE)
+    if err != nil {
+        t.Fatalf("Failed to create calendar: %v", err)
+    }
+
+    // 09:15-10:15 is complete, 10:15-11:15 has two of its four bars
+    data := createSessionBars(6)
+    resampled, err := Resample(data, "15m", "1h", cal)
+    if err != nil {
+        t.Fatalf("Failed to resample: %v", err)
+    }
+
+    if len(resampled.Bars) != 2 {
+        t.Fatalf("Expected 2 hourly bars, got %d", len(resampled.Bars))
+    }
+    first := resampled.Bars[0]
+    if !first.Time.Equal(data[0].Time) || first.Open != 100 || first.High != 105 || first.Low != 99 ||
+        first.Close != 104 || first.Volume != 40 || first.Timeframe != "1h" {
+        t.Errorf("Unexpected hourly bar %+v", first)
+    }
+    if resampled.Closed[0] != 3 || resampled.Closed[1] != -1 {
+        t.Errorf("Expected bars to close at 3 and still be open, got %v", resampled.Closed)
+    }
+
+    aligned, err := resampled.Align([]float64{1, 2})
+    if err != nil {
+        t.Fatalf("Failed to align: %v", err)
+    }
+    // The open hour is never visible to the bars inside it
+    for i, expected := range []float64{math.NaN(), math.NaN(), math.NaN(), 1, 1, 1} {
+        if aligned[i] != expected && !(math.IsNaN(expected) && math.IsNaN(aligned[i])) {
+            t.Errorf("Bar %d: expected %v, got %v", i, expected, aligned[i])
+        }
+    }
+
+    if _, err := resampled.Align([]float64{1}); err == nil {
+        t.Error("Expected an error for a value count mismatch")
+    }
+}
+
+func TestResampleMissingBars(t *testing.T) {
+    cal, _ := calendar.NewCalendar(calendar.NSE)
+
+    // Without the 10:00 bar the first hour completes when 10:15 arrives
+    data := createSessionBars(8)
+    data = append(data[:3], data[4:]...)
+    resampled, err := Resample(data, "15m", "1h", cal)
+    if err != nil {
+        t.Fatalf("Failed to resample: %v", err)
+    }
+    if resampled.Closed[0] != 3 || resampled.Closed[1] != 6 {
+        t.Errorf("Expected bars to close at 3 and 6, got %v", resampled.Closed)
+    }
+
+    aligned, _ := resampled.Align([]float64{1, 2})
+    if !math.IsNaN(aligned[2]) || aligned[3] != 1 || aligned[5] != 1 || aligned[6] != 2 {
+        t.Errorf("Unexpected aligned values %v", aligned)
+    }
+
+    // Bars outside the session cannot be placed
+    data[0].Time = data[0].Time.Add(-time.Hour)
+    if _, err := Resample(data, "15m", "1h", cal); err == nil {
+        t.Error("Expected an error for a bar before the open")
+    }
+}
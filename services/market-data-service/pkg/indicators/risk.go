@@ -0,0 +1,338 @@
+package indicators
+
+import (
+    "fmt"
+    "math"
+    "sort"
+    "strings"
+
+    "github.com/algo-trading/market-data-service/internal/models"
+)
+
+// TradingDaysPerYear annualises statistics of daily bars. Intraday callers
+// pass the number of their bars in a year instead, e.g. 252*375 for 1m bars
+// of a 6h15m session.
+const TradingDaysPerYear = 252
+
+// VolatilityEstimator selects how historical volatility is estimated
+type VolatilityEstimator int
+
+const (
+    CloseToClose VolatilityEstimator = iota // Standard deviation of log returns
+    Parkinson                               // High-low range
+    GarmanKlass                             // Open, high, low and close
+    YangZhang                               // Overnight gaps, open-close and Rogers-Satchell
+)
+
+var volatilityEstimatorNames = map[VolatilityEstimator]string{
+    CloseToClose: "close_to_close",
+    Parkinson:    "parkinson",
+    GarmanKlass:  "garman_klass",
+    YangZhang:    "yang_zhang",
+}
+
+func (e VolatilityEstimator) String() string {
+    if name, exists := volatilityEstimatorNames[e]; exists {
+        return name
+    }
+    return fmt.Sprintf("VolatilityEstimator(%d)", int(e))
+}
+
+// ParseVolatilityEstimator returns the estimator with the given name, e.g.
+// "parkinson"
+func ParseVolatilityEstimator(name string) (VolatilityEstimator, error) {
+    for estimator, estimatorName := range volatilityEstimatorNames {
+        if strings.EqualFold(name, estimatorName) {
+            return estimator, nil
+        }
+    }
+    return 0, fmt.Errorf("unknown volatility estimator: %s", name)
+}
+
+// logReturns is the log close-to-close return of each bar, zero for the first
+func logReturns(data []models.OHLCV) []float64 {
+    result := make([]float64, len(data))
+    for i := 1; i < len(data); i++ {
+        if data[i-1].Close > 0 && data[i].Close > 0 {
+            result[i] = math.Log(data[i].Close / data[i-1].Close)
+        }
+    }
+    return result
+}
+
+// windowMean returns the mean and sample variance of the period values
+// ending at end
+func windowMean(values []float64, end, period int) (float64, float64) {
+    mean := 0.0
+    for i := end - period + 1; i <= end; i++ {
+        mean += values[i]
+    }
+    mean /= float64(period)
+
+    variance := 0.0
+    for i := end - period + 1; i <= end; i++ {
+        d := values[i] - mean
+        variance += d * d
+    }
+    if period > 1 {
+        variance /= float64(period - 1)
+    }
+    return mean, variance
+}
+
+// logRatio is ln(a/b), zero unless both are positive
+func logRatio(a, b float64) float64 {
+    if a <= 0 || b <= 0 {
+        return 0
+    }
+    return math.Log(a / b)
+}
+
+// CalculateHistoricalVolatility calculates the annualised volatility of the
+// last period bars. Close-to-close and Yang-Zhang use the previous close, so
+// their first period values are zero; the range estimators start at
+// period-1.
+func CalculateHistoricalVolatility(data []models.OHLCV, period int, estimator VolatilityEstimator, periodsPerYear float64) ([]float64, error) {
+    if period < 2 {
+        return nil, fmt.Errorf("invalid period: %d", period)
+    }
+    if periodsPerYear <= 0 {
+        return nil, fmt.Errorf("invalid periods per year: %v", periodsPerYear)
+    }
+
+    need := period
+    if estimator == CloseToClose || estimator == YangZhang {
+        need = period + 1
+    }
+    if len(data) < need {
+        return nil, fmt.Errorf("insufficient data: need %d, got %d", need, len(data))
+    }
+
+    result := make([]float64, len(data))
+    switch estimator {
+    case CloseToClose:
+        r := logReturns(data)
+        for i := period; i < len(data); i++ {
+            _, variance := windowMean(r, i, period)
+            result[i] = math.Sqrt(variance * periodsPerYear)
+        }
+
+    case Parkinson, GarmanKlass:
+        terms := make([]float64, len(data))
+        for i, bar := range data {
+            hl := logRatio(bar.High, bar.Low)
+            if estimator == Parkinson {
+                terms[i] = hl * hl / (4 * math.Ln2)
+            } else {
+                co := logRatio(bar.Close, bar.Open)
+                terms[i] = 0.5*hl*hl - (2*math.Ln2-1)*co*co
+            }
+        }
+        for i := period - 1; i < len(data); i++ {
+            mean, _ := windowMean(terms, i, period)
+            result[i] = math.Sqrt(math.Max(mean, 0) * periodsPerYear)
+        }
+
+    case YangZhang:
+        overnight := make([]float64, len(data))
+        openClose := make([]float64, len(data))
+        rogersSatchell := make([]float64, len(data))
+        for i := 1; i < len(data); i++ {
+            bar := data[i]
+            overnight[i] = logRatio(bar.Open, data[i-1].Close)
+            openClose[i] = logRatio(bar.Close, bar.Open)
+            rogersSatchell[i] = logRatio(bar.High, bar.Close)*logRatio(bar.High, bar.Open) +
+                logRatio(bar.Low, bar.Close)*logRatio(bar.Low, bar.Open)
+        }
+
+        n := float64(period)
+        k := 0.34 / (1.34 + (n+1)/(n-1))
+        for i := period; i < len(data); i++ {
+            _, overnightVariance := windowMean(overnight, i, period)
+            _, openCloseVariance := windowMean(openClose, i, period)
+            rsVariance, _ := windowMean(rogersSatchell, i, period)
+            variance := overnightVariance + k*openCloseVariance + (1-k)*rsVariance
+            result[i] = math.Sqrt(math.Max(variance, 0) * periodsPerYear)
+        }
+
+    default:
+        return nil, fmt.Errorf("unknown volatility estimator: %d", int(estimator))
+    }
+
+    return result, nil
+}
+
+// CalculateSharpe calculates the annualised Sharpe ratio of the returns of
+// the last period bars over an annual risk-free rate. The first period values
+// are zero, as are windows without variation.
+func CalculateSharpe(data []models.OHLCV, period int, riskFree, periodsPerYear float64) ([]float64, error) {
+    return excessReturnRatio(data, period, riskFree, periodsPerYear, func(excess []float64, end int) float64 {
+        _, variance := windowMean(excess, end, period)
+        return math.Sqrt(variance)
+    })
+}
+
+// CalculateSortino calculates the annualised Sortino ratio, which only
+// penalises returns below the risk-free rate. The first period values are
+// zero, as are windows without a loss.
+func CalculateSortino(data []models.OHLCV, period int, riskFree, periodsPerYear float64) ([]float64, error) {
+    return excessReturnRatio(data, period, riskFree, periodsPerYear, func(excess []float64, end int) float64 {
+        downside := 0.0
+        for i := end - period + 1; i <= end; i++ {
+            if excess[i] < 0 {
+                downside += excess[i] * excess[i]
+            }
+        }
+        return math.Sqrt(downside / float64(period))
+    })
+}
+
+// excessReturnRatio divides the mean excess return of each window by the
+// deviation risk returns for it and annualises the result
+func excessReturnRatio(data []models.OHLCV, period int, riskFree, periodsPerYear float64, risk func(excess []float64, end int) float64) ([]float64, error) {
+    if period < 2 {
+        return nil, fmt.Errorf("invalid period: %d", period)
+    }
+    if periodsPerYear <= 0 {
+        return nil, fmt.Errorf("invalid periods per year: %v", periodsPerYear)
+    }
+    if len(data) < period+1 {
+        return nil, fmt.Errorf("insufficient data: need %d, got %d", period+1, len(data))
+    }
+
+    excess := returns(data)
+    for i := 1; i < len(excess); i++ {
+        excess[i] -= riskFree / periodsPerYear
+    }
+
+    result := make([]float64, len(data))
+    for i := period; i < len(data); i++ {
+        mean, _ := windowMean(excess, i, period)
+        if deviation := risk(excess, i); deviation > 0 {
+            result[i] = mean / deviation * math.Sqrt(periodsPerYear)
+        }
+    }
+
+    return result, nil
+}
+
+// Drawdown represents the decline of the close from its running peak, as
+// fractions of the peak
+type Drawdown struct {
+    Drawdown    []float64
+    MaxDrawdown []float64 // Largest drawdown up to each bar
+}
+
+// CalculateDrawdown calculates drawdowns from the first bar on
+func CalculateDrawdown(data []models.OHLCV) (*Drawdown, error) {
+    if len(data) == 0 {
+        return nil, fmt.Errorf("insufficient data: need 1, got 0")
+    }
+
+    result := &Drawdown{
+        Drawdown:    make([]float64, len(data)),
+        MaxDrawdown: make([]float64, len(data)),
+    }
+    peak, maxDrawdown := data[0].Close, 0.0
+    for i, bar := range data {
+        peak = math.Max(peak, bar.Close)
+        if peak > 0 {
+            result.Drawdown[i] = 1 - bar.Close/peak
+        }
+        maxDrawdown = math.Max(maxDrawdown, result.Drawdown[i])
+        result.MaxDrawdown[i] = maxDrawdown
+    }
+
+    return result, nil
+}
+
+func checkConfidence(confidence float64) error {
+    if confidence <= 0 || confidence >= 1 {
+        return fmt.Errorf("invalid confidence: %v, expected between 0 and 1", confidence)
+    }
+    return nil
+}
+
+// CalculateHistoricalVaR calculates the one-bar Value-at-Risk at confidence,
+// e.g. 0.95, from the returns of the last period bars: the loss, as a
+// positive fraction, that returns fell below 1-confidence of the time. The
+// first period values are zero.
+func CalculateHistoricalVaR(data []models.OHLCV, period int, confidence float64) ([]float64, error) {
+    if period < 2 {
+        return nil, fmt.Errorf("invalid period: %d", period)
+    }
+    if err := checkConfidence(confidence); err != nil {
+        return nil, err
+    }
+    if len(data) < period+1 {
+        return nil, fmt.Errorf("insufficient data: need %d, got %d", period+1, len(data))
+    }
+
+    r := returns(data)
+    result := make([]float64, len(data))
+    window := make([]float64, period)
+    for i := period; i < len(data); i++ {
+        copy(window, r[i-period+1:i+1])
+        sort.Float64s(window)
+
+        // Linear interpolation between the closest ranks
+        rank := (1 - confidence) * float64(period-1)
+        lower := int(rank)
+        quantile := window[lower]
+        if lower+1 < period {
+            quantile += (rank - float64(lower)) * (window[lower+1] - window[lower])
+        }
+        result[i] = -quantile
+    }
+
+    return result, nil
+}
+
+// CalculateParametricVaR calculates the one-bar Value-at-Risk at confidence
+// assuming normally distributed returns with the mean and standard deviation
+// of the last period bars. The first period values are zero.
+func CalculateParametricVaR(data []models.OHLCV, period int, confidence float64) ([]float64, error) {
+    if period < 2 {
+        return nil, fmt.Errorf("invalid period: %d", period)
+    }
+    if err := checkConfidence(confidence); err != nil {
+        return nil, err
+    }
+    if len(data) < period+1 {
+        return nil, fmt.Errorf("insufficient data: need %d, got %d", period+1, len(data))
+    }
+
+    z := math.Sqrt2 * math.Erfinv(2*confidence-1)
+    r := returns(data)
+    result := make([]float64, len(data))
+    for i := period; i < len(data); i++ {
+        mean, variance := windowMean(r, i, period)
+        result[i] = z*math.Sqrt(variance) - mean
+    }
+
+    return result, nil
+}
+
+// CalculateZScore calculates how many standard deviations the close is from
+// its mean over the last period bars. The first period-1 values are zero, as
+// are windows of a flat close.
+func CalculateZScore(data []models.OHLCV, period int) ([]float64, error) {
+    if period < 2 {
+        return nil, fmt.Errorf("invalid period: %d", period)
+    }
+    if len(data) < period {
+        return nil, fmt.Errorf("insufficient data: need %d, got %d", period, len(data))
+    }
+
+    values := closes(data)
+    result := make([]float64, len(data))
+    for i := period - 1; i < len(data); i++ {
+        mean, variance := windowMean(values, i, period)
+        // Population deviation, as Bollinger Bands use
+        if stdDev := math.Sqrt(variance * float64(period-1) / float64(period)); stdDev > 0 {
+            result[i] = (values[i] - mean) / stdDev
+        }
+    }
+
+    return result, nil
+}
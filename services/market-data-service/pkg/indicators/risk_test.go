@@ -0,0 +1,187 @@
+package indicators
+
+import (
+    "math"
+    "testing"
+    "time"
+
+    "github.com/algo-trading/market-data-service/internal/models"
+    "github.com/algo-trading/market-data-service/internal/testutil"
+)
+
+// barsFromReturns builds daily bars whose closes follow the given returns
+// from 100
+func barsFromReturns(r ...float64) []models.OHLCV {
+    start := time.Date(2025, time.October, 14, 3, 45, 0, 0, time.UTC)
+    data := make([]models.OHLCV, len(r)+1)
+    price := 100.0
+    for i := range data {
+        if i > 0 {
+            price *= 1 + r[i-1]
+        }
+        data[i] = models.OHLCV{Time: start.AddDate(0, 0, i), Open: price, High: price, Low: price, Close: price}
+    }
+    return data
+}
+
+func TestHistoricalVolatility(t *testing.T) {
+    // Closes alternate between 100 and 110
+    var r []float64
+    for i := 0; i < 20; i++ {
+        if i%2 == 0 {
+            r = append(r, 0.1)
+        } else {
+            r = append(r, -1/11.0)
+        }
+    }
+    data := barsFromReturns(r...)
+
+    volatility, err := CalculateHistoricalVolatility(data, 10, CloseToClose, TradingDaysPerYear)
+    if err != nil {
+        t.Fatalf("Failed to calculate volatility: %v", err)
+    }
+    lr := math.Log(1.1)
+    expected := math.Sqrt(10 * lr * lr / 9 * TradingDaysPerYear)
+    if volatility[9] != 0 || math.Abs(volatility[10]-expected) > 1e-9 || math.Abs(volatility[20]-expected) > 1e-9 {
+        t.Errorf("Expected close-to-close volatility %v from bar 10, got %v, %v", expected, volatility[9], volatility[10])
+    }
+
+    // Bars 1% either side of an unchanged open and close
+    for i := range data {
+        data[i].Open, data[i].Close = 100, 100
+        data[i].High, data[i].Low = 100*math.Exp(0.01), 100*math.Exp(-0.01)
+    }
+    tests := []struct {
+        estimator VolatilityEstimator
+        first     int
+        variance  float64
+    }{
+        {Parkinson, 9, 0.0004 / (4 * math.Ln2)},
+        {GarmanKlass, 9, 0.5 * 0.0004},
+        {YangZhang, 10, (1 - 0.34/(1.34+11.0/9)) * 0.0002},
+    }
+    for _, tt := range tests {
+        volatility, err := CalculateHistoricalVolatility(data, 10, tt.estimator, TradingDaysPerYear)
+        if err != nil {
+            t.Fatalf("Failed to calculate %s volatility: %v", tt.estimator, err)
+        }
+        expected := math.Sqrt(tt.variance * TradingDaysPerYear)
+        if volatility[tt.first-1] != 0 || math.Abs(volatility[tt.first]-expected) > 1e-9 {
+            t.Errorf("Expected %s volatility %v from bar %d, got %v", tt.estimator, expected, tt.first, volatility[tt.first])
+        }
+    }
+
+    if _, err := ParseVolatilityEstimator("Garman_Klass"); err != nil {
+        t.Errorf("Failed to parse estimator: %v", err)
+    }
+    if _, err := CalculateHistoricalVolatility(data[:10], 10, YangZhang, TradingDaysPerYear); err == nil {
+        t.Error("Expected an error with fewer than period+1 bars")
+    }
+}
+
+func TestSharpeAndSortino(t *testing.T) {
+    var r []float64
+    for i := 0; i < 10; i++ {
+        if i%2 == 0 {
+            r = append(r, 0.02)
+        } else {
+            r = append(r, -0.01)
+        }
+    }
+    data := barsFromReturns(r...)
+
+    sharpe, err := CalculateSharpe(data, 10, 0, TradingDaysPerYear)
+    if err != nil {
+        t.Fatalf("Failed to calculate Sharpe: %v", err)
+    }
+    // Mean 0.5%, sample deviation of ±1.5%
+    expected := 0.005 / math.Sqrt(10*0.015*0.015/9) * math.Sqrt(TradingDaysPerYear)
+    if math.Abs(sharpe[10]-expected) > 1e-9 {
+        t.Errorf("Expected Sharpe %v, got %v", expected, sharpe[10])
+    }
+
+    sortino, err := CalculateSortino(data, 10, 0, TradingDaysPerYear)
+    if err != nil {
+        t.Fatalf("Failed to calculate Sortino: %v", err)
+    }
+    expected = 0.005 / math.Sqrt(5*0.0001/10) * math.Sqrt(TradingDaysPerYear)
+    if math.Abs(sortino[10]-expected) > 1e-9 {
+        t.Errorf("Expected Sortino %v, got %v", expected, sortino[10])
+    }
+
+    // A risk-free rate above every return leaves only losses
+    sharpe, _ = CalculateSharpe(data, 10, 0.05*TradingDaysPerYear, TradingDaysPerYear)
+    if sharpe[10] >= 0 {
+        t.Errorf("Expected a negative Sharpe, got %v", sharpe[10])
+    }
+    gains := barsFromReturns(0.01, 0.02, 0.01, 0.02)
+    if sortino, _ := CalculateSortino(gains, 3, 0, TradingDaysPerYear); sortino[3] != 0 {
+        t.Errorf("Expected Sortino 0 without losses, got %v", sortino[3])
+    }
+}
+
+func TestCalculateDrawdown(t *testing.T) {
+    data := barsFromReturns(0.2, -0.25, 130/90.0-1, -0.1)
+
+    drawdown, err := CalculateDrawdown(data)
+    if err != nil {
+        t.Fatalf("Failed to calculate drawdown: %v", err)
+    }
+    expected := []float64{0, 0, 0.25, 0, 0.1}
+    maxExpected := []float64{0, 0, 0.25, 0.25, 0.25}
+    for i := range expected {
+        if math.Abs(drawdown.Drawdown[i]-expected[i]) > 1e-9 || math.Abs(drawdown.MaxDrawdown[i]-maxExpected[i]) > 1e-9 {
+            t.Errorf("Bar %d: expected drawdown %v and max %v, got %v and %v",
+                i, expected[i], maxExpected[i], drawdown.Drawdown[i], drawdown.MaxDrawdown[i])
+        }
+    }
+}
+
+func TestValueAtRisk(t *testing.T) {
+    // Returns of -10%, -9%, ..., 9%
+    var r []float64
+    for i := 0; i < 20; i++ {
+        r = append(r, float64(i-10)/100)
+    }
+    data := barsFromReturns(r...)
+
+    historical, err := CalculateHistoricalVaR(data, 20, 0.95)
+    if err != nil {
+        t.Fatalf("Failed to calculate historical VaR: %v", err)
+    }
+    // The 5% quantile lies 0.95 of the way from -10% to -9%
+    if expected := 0.1 - 0.95*0.01; historical[19] != 0 || math.Abs(historical[20]-expected) > 1e-9 {
+        t.Errorf("Expected historical VaR %v, got %v", expected, historical[20])
+    }
+
+    parametric, err := CalculateParametricVaR(data, 20, 0.95)
+    if err != nil {
+        t.Fatalf("Failed to calculate parametric VaR: %v", err)
+    }
+    mean, variance := -0.005, 0.0
+    for _, v := range r {
+        variance += (v - mean) * (v - mean)
+    }
+    expected := 1.6448536269514722*math.Sqrt(variance/19) - mean
+    if math.Abs(parametric[20]-expected) > 1e-9 {
+        t.Errorf("Expected parametric VaR %v, got %v", expected, parametric[20])
+    }
+
+    if _, err := CalculateHistoricalVaR(data, 20, 1); err == nil {
+        t.Error("Expected an error for confidence 1")
+    }
+}
+
+func TestCalculateZScore(t *testing.T) {
+    data := testutil.Trend("TEST", 10, 100, 2, 1000)
+
+    zscore, err := CalculateZScore(data, 10)
+    if err != nil {
+        t.Fatalf("Failed to calculate z-score: %v", err)
+    }
+    // Evenly spaced closes: the last is 4.5 steps above a mean with
+    // variance 8.25 steps squared
+    if expected := 4.5 / math.Sqrt(8.25); zscore[8] != 0 || math.Abs(zscore[9]-expected) > 1e-9 {
+        t.Errorf("Expected z-score %v, got %v", expected, zscore[9])
+    }
+}
@@ -0,0 +1,82 @@
+package indicators
+
+import (
+    "time"
+
+    "github.com/algo-trading/market-data-service/internal/models"
+)
+
+// Series is a price series stored as one column per field, oldest first, for
+// the kernels. Loading many symbols into the same Series reuses its columns.
+type Series struct {
+    Time   []time.Time
+    Open   []float64
+    High   []float64
+    Low    []float64
+    Close  []float64
+    Volume []float64
+}
+
+// NewSeries creates an empty series with room for capacity bars
+func NewSeries(capacity int) *Series {
+    return &Series{
+        Time:   make([]time.Time, 0, capacity),
+        Open:   make([]float64, 0, capacity),
+        High:   make([]float64, 0, capacity),
+        Low:    make([]float64, 0, capacity),
+        Close:  make([]float64, 0, capacity),
+        Volume: make([]float64, 0, capacity),
+    }
+}
+
+// ToSeries converts bars to a series
+func ToSeries(data []models.OHLCV) *Series {
+    s := NewSeries(len(data))
+    s.Load(data)
+    return s
+}
+
+// Len returns the number of bars
+func (s *Series) Len() int {
+    return len(s.Close)
+}
+
+// Append adds a bar to the end of the series
+func (s *Series) Append(bar models.OHLCV) {
+    s.Time = append(s.Time, bar.Time)
+    s.Open = append(s.Open, bar.Open)
+    s.High = append(s.High, bar.High)
+    s.Low = append(s.Low, bar.Low)
+    s.Close = append(s.Close, bar.Close)
+    s.Volume = append(s.Volume, float64(bar.Volume))
+}
+
+// Reset empties the series, keeping its capacity
+func (s *Series) Reset() {
+    s.Time = s.Time[:0]
+    s.Open = s.Open[:0]
+    s.High = s.High[:0]
+    s.Low = s.Low[:0]
+    s.Close = s.Close[:0]
+    s.Volume = s.Volume[:0]
+}
+
+// Load replaces the series with bars, only allocating when they do not fit
+func (s *Series) Load(data []models.OHLCV) {
+    s.Reset()
+    for _, bar := range data {
+        s.Append(bar)
+    }
+}
+
+// Bar returns the bar at index i
+func (s *Series) Bar(i int) models.OHLCV {
+    return models.OHLCV{
+        Time:   s.Time[i],
+        Open:   s.Open[i],
+        High:   s.High[i],
+        Low:    s.Low[i],
+        Close:  s.Close[i],
+        Volume: int64(s.Volume[i]),
+    }
+}
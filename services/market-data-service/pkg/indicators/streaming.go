@@ -0,0 +1,402 @@
+package indicators
+
+import (
+    "fmt"
+    "math"
+
+    "github.com/algo-trading/market-data-service/internal/models"
+)
+
+// Streaming is an indicator updated one bar at a time. Update returns the
+// value for the bar, which is exactly the last value of the batch function
+// over all bars seen so far and zero until the indicator has warmed up.
+type Streaming[T any] interface {
+    Update(bar models.OHLCV) T
+    Ready() bool
+}
+
+// Warmup feeds history to a streaming indicator and returns its latest value
+func Warmup[T any](indicator Streaming[T], history []models.OHLCV) T {
+    var value T
+    for _, bar := range history {
+        value = indicator.Update(bar)
+    }
+    return value
+}
+
+func checkPeriods(periods ...int) error {
+    for _, period := range periods {
+        if period <= 0 {
+            return fmt.Errorf("invalid period: %d", period)
+        }
+    }
+    return nil
+}
+
+// window holds the last values of a series in arrival order
+type window struct {
+    values []float64
+    next   int
+    count  int
+}
+
+func newWindow(size int) *window {
+    return &window{values: make([]float64, size)}
+}
+
+func (w *window) push(value float64) {
+    w.values[w.next] = value
+    w.next = (w.next + 1) % len(w.values)
+    if w.count < len(w.values) {
+        w.count++
+    }
+}
+
+func (w *window) full() bool {
+    return w.count == len(w.values)
+}
+
+// each visits the values from oldest to newest, the order the batch
+// functions sum them in
+func (w *window) each(f func(value float64)) {
+    start := (w.next - w.count + len(w.values)) % len(w.values)
+    for i := 0; i < w.count; i++ {
+        f(w.values[(start+i)%len(w.values)])
+    }
+}
+
+func (w *window) sum() float64 {
+    sum := 0.0
+    w.each(func(value float64) { sum += value })
+    return sum
+}
+
+// smaState is a simple moving average over a stream of values
+type smaState struct {
+    window *window
+}
+
+func (s *smaState) update(value float64) (float64, bool) {
+    s.window.push(value)
+    if !s.window.full() {
+        return 0, false
+    }
+    return s.window.sum() / float64(len(s.window.values)), true
+}
+
+// emaState is an exponential moving average seeded with the simple average
+// of its first period values
+type emaState struct {
+    period     int
+    multiplier float64
+    sum        float64
+    count      int
+    value      float64
+}
+
+func newEMAState(period int) *emaState {
+    return &emaState{period: period, multiplier: 2.0 / (float64(period) + 1.0)}
+}
+
+func (e *emaState) update(value float64) (float64, bool) {
+    e.count++
+    switch {
+    case e.count < e.period:
+        e.sum += value
+        return 0, false
+    case e.count == e.period:
+        e.sum += value
+        e.value = e.sum / float64(e.period)
+    default:
+        e.value = (value * e.multiplier) + (e.value * (1 - e.multiplier))
+    }
+    return e.value, true
+}
+
+// StreamingSMA is the streaming version of CalculateSMA
+type StreamingSMA struct {
+    state smaState
+    ready bool
+}
+
+func NewStreamingSMA(period int) (*StreamingSMA, error) {
+    if err := checkPeriods(period); err != nil {
+        return nil, err
+    }
+    return &StreamingSMA{state: smaState{window: newWindow(period)}}, nil
+}
+
+func (s *StreamingSMA) Update(bar models.OHLCV) float64 {
+    value, ready := s.state.update(bar.Close)
+    s.ready = ready
+    return value
+}
+
+func (s *StreamingSMA) Ready() bool {
+    return s.ready
+}
+
+// StreamingEMA is the streaming version of CalculateEMA
+type StreamingEMA struct {
+    state *emaState
+    ready bool
+}
+
+func NewStreamingEMA(period int) (*StreamingEMA, error) {
+    if err := checkPeriods(period); err != nil {
+        return nil, err
+    }
+    return &StreamingEMA{state: newEMAState(period)}, nil
+}
+
+func (s *StreamingEMA) Update(bar models.OHLCV) float64 {
+    value, ready := s.state.update(bar.Close)
+    s.ready = ready
+    return value
+}
+
+func (s *StreamingEMA) Ready() bool {
+    return s.ready
+}
+
+// StreamingRSI is the streaming version of CalculateRSI with Wilder smoothing
+type StreamingRSI struct {
+    period    int
+    count     int
+    prevClose float64
+    avgGain   float64
+    avgLoss   float64
+}
+
+func NewStreamingRSI(period int) (*StreamingRSI, error) {
+    if err := checkPeriods(period); err != nil {
+        return nil, err
+    }
+    return &StreamingRSI{period: period}, nil
+}
+
+func (s *StreamingRSI) Update(bar models.OHLCV) float64 {
+    s.count++
+    if s.count == 1 {
+        s.prevClose = bar.Close
+        return 0
+    }
+
+    change := bar.Close - s.prevClose
+    s.prevClose = bar.Close
+    gain, loss := 0.0, 0.0
+    if change > 0 {
+        gain = change
+    } else {
+        loss = -change
+    }
+
+    switch {
+    case s.count <= s.period:
+        s.avgGain += gain
+        s.avgLoss += loss
+        return 0
+    case s.count == s.period+1:
+        s.avgGain = (s.avgGain + gain) / float64(s.period)
+        s.avgLoss = (s.avgLoss + loss) / float64(s.period)
+    default:
+        s.avgGain = ((s.avgGain * float64(s.period-1)) + gain) / float64(s.period)
+        s.avgLoss = ((s.avgLoss * float64(s.period-1)) + loss) / float64(s.period)
+    }
+
+    if s.avgLoss == 0 {
+        return 100
+    }
+    rs := s.avgGain / s.avgLoss
+    return 100 - (100 / (1 + rs))
+}
+
+func (s *StreamingRSI) Ready() bool {
+    return s.count > s.period
+}
+
+// MACDValue is one bar of MACD
+type MACDValue struct {
+    MACD      float64 `json:"macd"`
+    Signal    float64 `json:"signal"`
+    Histogram float64 `json:"histogram"`
+}
+
+// StreamingMACD is the streaming version of CalculateMACD
+type StreamingMACD struct {
+    fast   *emaState
+    slow   *emaState
+    signal *emaState
+    ready  bool
+}
+
+func NewStreamingMACD(fastPeriod, slowPeriod, signalPeriod int) (*StreamingMACD, error) {
+    if err := checkPeriods(fastPeriod, slowPeriod, signalPeriod); err != nil {
+        return nil, err
+    }
+    return &StreamingMACD{
+        fast:   newEMAState(fastPeriod),
+        slow:   newEMAState(slowPeriod),
+        signal: newEMAState(signalPeriod),
+    }, nil
+}
+
+func (s *StreamingMACD) Update(bar models.OHLCV) MACDValue {
+    fast, _ := s.fast.update(bar.Close)
+    slow, ok := s.slow.update(bar.Close)
+    if !ok {
+        return MACDValue{}
+    }
+
+    value := MACDValue{MACD: fast - slow}
+    signal, ready := s.signal.update(value.MACD)
+    s.ready = ready
+    if ready {
+        value.Signal = signal
+        value.Histogram = value.MACD - signal
+    }
+    return value
+}
+
+func (s *StreamingMACD) Ready() bool {
+    return s.ready
+}
+
+// BandsValue is one bar of Bollinger Bands
+type BandsValue struct {
+    Upper  float64 `json:"upper"`
+    Middle float64 `json:"middle"`
+    Lower  float64 `json:"lower"`
+}
+
+// StreamingBollingerBands is the streaming version of CalculateBollingerBands
+type StreamingBollingerBands struct {
+    state     smaState
+    deviation float64
+    ready     bool
+}
+
+func NewStreamingBollingerBands(period int, deviation float64) (*StreamingBollingerBands, error) {
+    if err := checkPeriods(period); err != nil {
+        return nil, err
+    }
+    return &StreamingBollingerBands{state: smaState{window: newWindow(period)}, deviation: deviation}, nil
+}
+
+func (s *StreamingBollingerBands) Update(bar models.OHLCV) BandsValue {
+    sma, ready := s.state.update(bar.Close)
+    s.ready = ready
+    if !ready {
+        return BandsValue{}
+    }
+
+    sumSquares := 0.0
+    s.state.window.each(func(value float64) {
+        diff := value - sma
+        sumSquares += diff * diff
+    })
+    stdDev := math.Sqrt(sumSquares / float64(len(s.state.window.values)))
+
+    return BandsValue{
+        Upper:  sma + (s.deviation * stdDev),
+        Middle: sma,
+        Lower:  sma - (s.deviation * stdDev),
+    }
+}
+
+func (s *StreamingBollingerBands) Ready() bool {
+    return s.ready
+}
+
+// StochasticValue is one bar of the Stochastic Oscillator
+type StochasticValue struct {
+    K float64 `json:"k"`
+    D float64 `json:"d"`
+}
+
+// StreamingStochastic is the streaming version of CalculateStochastic
+type StreamingStochastic struct {
+    highs *window
+    lows  *window
+    d     smaState
+    ready bool
+}
+
+func NewStreamingStochastic(kPeriod, dPeriod int) (*StreamingStochastic, error) {
+    if err := checkPeriods(kPeriod, dPeriod); err != nil {
+        return nil, err
+    }
+    return &StreamingStochastic{
+        highs: newWindow(kPeriod),
+        lows:  newWindow(kPeriod),
+        d:     smaState{window: newWindow(dPeriod)},
+    }, nil
+}
+
+func (s *StreamingStochastic) Update(bar models.OHLCV) StochasticValue {
+    s.highs.push(bar.High)
+    s.lows.push(bar.Low)
+    if !s.highs.full() {
+        return StochasticValue{}
+    }
+
+    highest, lowest := math.Inf(-1), math.Inf(1)
+    s.highs.each(func(value float64) { highest = math.Max(highest, value) })
+    s.lows.each(func(value float64) { lowest = math.Min(lowest, value) })
+
+    value := StochasticValue{K: 50} // Avoid division by zero
+    if highest != lowest {
+        value.K = ((bar.Close - lowest) / (highest - lowest)) * 100
+    }
+
+    value.D, s.ready = s.d.update(value.K)
+    return value
+}
+
+func (s *StreamingStochastic) Ready() bool {
+    return s.ready
+}
+
+// StreamingATR is the streaming version of CalculateATR with Wilder smoothing
+type StreamingATR struct {
+    period    int
+    count     int
+    prevClose float64
+    value     float64
+}
+
+func NewStreamingATR(period int) (*StreamingATR, error) {
+    if err := checkPeriods(period); err != nil {
+        return nil, err
+    }
+    return &StreamingATR{period: period}, nil
+}
+
+func (s *StreamingATR) Update(bar models.OHLCV) float64 {
+    s.count++
+    prevClose := s.prevClose
+    s.prevClose = bar.Close
+    if s.count == 1 {
+        return 0
+    }
+
+    tr1 := bar.High - bar.Low
+    tr2 := math.Abs(bar.High - prevClose)
+    tr3 := math.Abs(bar.Low - prevClose)
+    trueRange := math.Max(tr1, math.Max(tr2, tr3))
+
+    switch {
+    case s.count <= s.period:
+        s.value += trueRange
+        return 0
+    case s.count == s.period+1:
+        s.value = (s.value + trueRange) / float64(s.period)
+    default:
+        s.value = ((s.value * float64(s.period-1)) + trueRange) / float64(s.period)
+    }
+    return s.value
+}
+
+func (s *StreamingATR) Ready() bool {
+    return s.count > s.period
+}
@@ -0,0 +1,125 @@
+package indicators
+
+import (
+    "testing"
+
+    "github.com/algo-trading/market-data-service/internal/testutil"
+)
+
+// assertSeries checks that each streamed value equals the batch value
+func assertSeries(t *testing.T, name string, batch []float64, streamed []float64) {
+    t.Helper()
+    for i := range batch {
+        if streamed[i] != batch[i] {
+            t.Fatalf("%s[%d]: streaming %v, batch %v", name, i, streamed[i], batch[i])
+        }
+    }
+}
+
+func TestStreamingMatchesBatch(t *testing.T) {
+    data := testutil.RandomWalk("TEST", 500, 42)
+    n := len(data)
+
+    t.Run("SMA", func(t *testing.T) {
+        batch, _ := CalculateSMA(data, 20)
+        stream, _ := NewStreamingSMA(20)
+        streamed := make([]float64, n)
+        for i, bar := range data {
+            streamed[i] = stream.Update(bar)
+            if stream.Ready() != (i >= 19) {
+                t.Fatalf("Unexpected readiness at %d", i)
+            }
+        }
+        assertSeries(t, "SMA", batch, streamed)
+    })
+
+    t.Run("EMA", func(t *testing.T) {
+        batch, _ := CalculateEMA(data, 20)
+        stream, _ := NewStreamingEMA(20)
+        streamed := make([]float64, n)
+        for i, bar := range data {
+            streamed[i] = stream.Update(bar)
+        }
+        assertSeries(t, "EMA", batch, streamed)
+    })
+
+    t.Run("RSI", func(t *testing.T) {
+        batch, _ := CalculateRSI(data, 14)
+        stream, _ := NewStreamingRSI(14)
+        streamed := make([]float64, n)
+        for i, bar := range data {
+            streamed[i] = stream.Update(bar)
+            if stream.Ready() != (i >= 14) {
+                t.Fatalf("Unexpected readiness at %d", i)
+            }
+        }
+        assertSeries(t, "RSI", batch, streamed)
+    })
+
+    t.Run("MACD", func(t *testing.T) {
+        batch, _ := CalculateMACD(data, 12, 26, 9)
+        stream, _ := NewStreamingMACD(12, 26, 9)
+        macd, signal, histogram := make([]float64, n), make([]float64, n), make([]float64, n)
+        for i, bar := range data {
+            value := stream.Update(bar)
+            macd[i], signal[i], histogram[i] = value.MACD, value.Signal, value.Histogram
+        }
+        assertSeries(t, "MACD", batch.MACD, macd)
+        assertSeries(t, "Signal", batch.Signal, signal)
+        assertSeries(t, "Histogram", batch.Histogram, histogram)
+    })
+
+    t.Run("BollingerBands", func(t *testing.T) {
+        batch, _ := CalculateBollingerBands(data, 20, 2)
+        stream, _ := NewStreamingBollingerBands(20, 2)
+        upper, middle, lower := make([]float64, n), make([]float64, n), make([]float64, n)
+        for i, bar := range data {
+            value := stream.Update(bar)
+            upper[i], middle[i], lower[i] = value.Upper, value.Middle, value.Lower
+        }
+        assertSeries(t, "Upper", batch.Upper, upper)
+        assertSeries(t, "Middle", batch.Middle, middle)
+        assertSeries(t, "Lower", batch.Lower, lower)
+    })
+
+    t.Run("Stochastic", func(t *testing.T) {
+        batch, _ := CalculateStochastic(data, 14, 3)
+        stream, _ := NewStreamingStochastic(14, 3)
+        k, d := make([]float64, n), make([]float64, n)
+        for i, bar := range data {
+            value := stream.Update(bar)
+            k[i], d[i] = value.K, value.D
+        }
+        assertSeries(t, "K", batch.K, k)
+        assertSeries(t, "D", batch.D, d)
+    })
+
+    t.Run("ATR", func(t *testing.T) {
+        batch, _ := CalculateATR(data, 14)
+        stream, _ := NewStreamingATR(14)
+        streamed := make([]float64, n)
+        for i, bar := range data {
+            streamed[i] = stream.Update(bar)
+        }
+        assertSeries(t, "ATR", batch, streamed)
+    })
+}
+
+func TestStreamingWarmup(t *testing.T) {
+    data := testutil.RandomWalk("TEST", 300, 7)
+    batch, _ := CalculateRSI(data, 14)
+
+    stream, _ := NewStreamingRSI(14)
+    if value := Warmup[float64](stream, data[:200]); value != batch[199] {
+        t.Errorf("Expected warm-up to return the last batch value %v, got %v", batch[199], value)
+    }
+    for i := 200; i < len(data); i++ {
+        if value := stream.Update(data[i]); value != batch[i] {
+            t.Fatalf("RSI[%d] after warm-up: streaming %v, batch %v", i, value, batch[i])
+        }
+    }
+
+    if _, err := NewStreamingMACD(12, 0, 9); err == nil {
+        t.Errorf("Expected error for a zero period")
+    }
+}
@@ -0,0 +1,391 @@
+package indicators
+
+import (
+    "fmt"
+    "math"
+
+    "github.com/algo-trading/market-data-service/internal/models"
+)
+
+// Trend directions of Supertrend and Parabolic SAR
+const (
+    TrendDown = -1
+    TrendUp   = 1
+)
+
+// ADX represents Average Directional Index with its directional indicators
+type ADX struct {
+    ADX     []float64
+    PlusDI  []float64
+    MinusDI []float64
+}
+
+// CalculateADX calculates ADX, +DI and -DI with Wilder smoothing. The DIs
+// start at index period and ADX, the smoothed DX, at 2*period-1.
+func CalculateADX(data []models.OHLCV, period int) (*ADX, error) {
+    if period <= 0 {
+        return nil, fmt.Errorf("invalid period: %d", period)
+    }
+    if len(data) < 2*period {
+        return nil, fmt.Errorf("insufficient data: need %d, got %d", 2*period, len(data))
+    }
+
+    result := &ADX{
+        ADX:     make([]float64, len(data)),
+        PlusDI:  make([]float64, len(data)),
+        MinusDI: make([]float64, len(data)),
+    }
+    dx := make([]float64, len(data))
+
+    var trueRange, plusDM, minusDM float64
+    for i := 1; i < len(data); i++ {
+        upMove := data[i].High - data[i-1].High
+        downMove := data[i-1].Low - data[i].Low
+        plus, minus := 0.0, 0.0
+        if upMove > downMove && upMove > 0 {
+            plus = upMove
+        }
+        if downMove > upMove && downMove > 0 {
+            minus = downMove
+        }
+        tr := math.Max(data[i].High-data[i].Low,
+            math.Max(math.Abs(data[i].High-data[i-1].Close), math.Abs(data[i].Low-data[i-1].Close)))
+
+        // Wilder sums over the first period, then smooths
+        if i <= period {
+            trueRange += tr
+            plusDM += plus
+            minusDM += minus
+            if i < period {
+                continue
+            }
+        } else {
+            trueRange = trueRange - trueRange/float64(period) + tr
+            plusDM = plusDM - plusDM/float64(period) + plus
+            minusDM = minusDM - minusDM/float64(period) + minus
+        }
+
+        if trueRange > 0 {
+            result.PlusDI[i] = 100 * plusDM / trueRange
+            result.MinusDI[i] = 100 * minusDM / trueRange
+        }
+        if sum := result.PlusDI[i] + result.MinusDI[i]; sum > 0 {
+            dx[i] = 100 * math.Abs(result.PlusDI[i]-result.MinusDI[i]) / sum
+        }
+    }
+
+    // ADX starts as the average of the first period DX values
+    first := 2*period - 1
+    sum := 0.0
+    for i := period; i <= first; i++ {
+        sum += dx[i]
+    }
+    result.ADX[first] = sum / float64(period)
+
+    for i := first + 1; i < len(data); i++ {
+        result.ADX[i] = ((result.ADX[i-1] * float64(period-1)) + dx[i]) / float64(period)
+    }
+
+    return result, nil
+}
+
+// Supertrend represents the Supertrend indicator. Value is the lower band in
+// an uptrend and the upper band in a downtrend.
+type Supertrend struct {
+    Value     []float64
+    Direction []int
+    Upper     []float64
+    Lower     []float64
+}
+
+// CalculateSupertrend calculates Supertrend with bands multiplier ATRs away
+// from the bar's midpoint. Bands only tighten while the trend holds, and the
+// trend flips when the close crosses the opposite band.
+func CalculateSupertrend(data []models.OHLCV, period int, multiplier float64) (*Supertrend, error) {
+    atr, err := CalculateATR(data, period)
+    if err != nil {
+        return nil, fmt.Errorf("failed to calculate ATR: %w", err)
+    }
+
+    result := &Supertrend{
+        Value:     make([]float64, len(data)),
+        Direction: make([]int, len(data)),
+        Upper:     make([]float64, len(data)),
+        Lower:     make([]float64, len(data)),
+    }
+
+    for i := period; i < len(data); i++ {
+        mid := (data[i].High + data[i].Low) / 2
+        upper := mid + multiplier*atr[i]
+        lower := mid - multiplier*atr[i]
+
+        if i == period {
+            result.Upper[i], result.Lower[i] = upper, lower
+            result.Direction[i] = TrendDown
+            if data[i].Close > mid {
+                result.Direction[i] = TrendUp
+            }
+        } else {
+            prevClose := data[i-1].Close
+            if upper < result.Upper[i-1] || prevClose > result.Upper[i-1] {
+                result.Upper[i] = upper
+            } else {
+                result.Upper[i] = result.Upper[i-1]
+            }
+            if lower > result.Lower[i-1] || prevClose < result.Lower[i-1] {
+                result.Lower[i] = lower
+            } else {
+                result.Lower[i] = result.Lower[i-1]
+            }
+
+            result.Direction[i] = result.Direction[i-1]
+            if result.Direction[i] == TrendDown && data[i].Close > result.Upper[i] {
+                result.Direction[i] = TrendUp
+            } else if result.Direction[i] == TrendUp && data[i].Close < result.Lower[i] {
+                result.Direction[i] = TrendDown
+            }
+        }
+
+        if result.Direction[i] == TrendUp {
+            result.Value[i] = result.Lower[i]
+        } else {
+            result.Value[i] = result.Upper[i]
+        }
+    }
+
+    return result, nil
+}
+
+// ParabolicSAR represents Wilder's Parabolic Stop and Reverse
+type ParabolicSAR struct {
+    SAR   []float64
+    Trend []int
+}
+
+// CalculateParabolicSAR calculates Parabolic SAR with the acceleration factor
+// starting at step and growing by step per new extreme up to maxStep. The
+// first trend follows the direction of the second close.
+func CalculateParabolicSAR(data []models.OHLCV, step, maxStep float64) (*ParabolicSAR, error) {
+    if step <= 0 || maxStep < step {
+        return nil, fmt.Errorf("invalid acceleration: step %v, max %v", step, maxStep)
+    }
+    if len(data) < 2 {
+        return nil, fmt.Errorf("insufficient data: need 2, got %d", len(data))
+    }
+
+    result := &ParabolicSAR{
+        SAR:   make([]float64, len(data)),
+        Trend: make([]int, len(data)),
+    }
+
+    trend := TrendUp
+    sar, extreme := data[0].Low, data[0].High
+    if data[1].Close < data[0].Close {
+        trend = TrendDown
+        sar, extreme = data[0].High, data[0].Low
+    }
+    factor := step
+
+    for i := 1; i < len(data); i++ {
+        sar += factor * (extreme - sar)
+
+        if trend == TrendUp {
+            // The SAR may not rise above the last two lows
+            sar = math.Min(sar, data[i-1].Low)
+            if i > 1 {
+                sar = math.Min(sar, data[i-2].Low)
+            }
+            if data[i].Low < sar {
+                trend, sar, extreme, factor = TrendDown, extreme, data[i].Low, step
+            } else if data[i].High > extreme {
+                extreme = data[i].High
+                factor = math.Min(factor+step, maxStep)
+            }
+        } else {
+            sar = math.Max(sar, data[i-1].High)
+            if i > 1 {
+                sar = math.Max(sar, data[i-2].High)
+            }
+            if data[i].High > sar {
+                trend, sar, extreme, factor = TrendUp, extreme, data[i].High, step
+            } else if data[i].Low < extreme {
+                extreme = data[i].Low
+                factor = math.Min(factor+step, maxStep)
+            }
+        }
+
+        result.SAR[i] = sar
+        result.Trend[i] = trend
+    }
+
+    return result, nil
+}
+
+// Ichimoku represents the Ichimoku Cloud. The spans are plotted kijun
+// periods ahead, so SenkouA and SenkouB extend that many bars past the data,
+// and the lagging span is plotted kijun periods back.
+type Ichimoku struct {
+    Tenkan  []float64
+    Kijun   []float64
+    SenkouA []float64
+    SenkouB []float64
+    Chikou  []float64
+}
+
+// midpoint returns the average of the highest high and lowest low of the
+// period bars ending at end
+func midpoint(data []models.OHLCV, end, period int) float64 {
+    highest, lowest := data[end].High, data[end].Low
+    for j := end - period + 1; j < end; j++ {
+        highest = math.Max(highest, data[j].High)
+        lowest = math.Min(lowest, data[j].Low)
+    }
+    return (highest + lowest) / 2
+}
+
+// CalculateIchimoku calculates the Ichimoku Cloud, typically with periods 9,
+// 26 and 52
+func CalculateIchimoku(data []models.OHLCV, tenkanPeriod, kijunPeriod, senkouPeriod int) (*Ichimoku, error) {
+    if tenkanPeriod <= 0 || kijunPeriod <= 0 || senkouPeriod <= 0 {
+        return nil, fmt.Errorf("invalid Ichimoku periods: %d, %d, %d", tenkanPeriod, kijunPeriod, senkouPeriod)
+    }
+    if len(data) < kijunPeriod {
+        return nil, fmt.Errorf("insufficient data: need %d, got %d", kijunPeriod, len(data))
+    }
+
+    displacement := kijunPeriod
+    result := &Ichimoku{
+        Tenkan:  make([]float64, len(data)),
+        Kijun:   make([]float64, len(data)),
+        SenkouA: make([]float64, len(data)+displacement),
+        SenkouB: make([]float64, len(data)+displacement),
+        Chikou:  make([]float64, len(data)),
+    }
+
+    for i := range data {
+        if i >= tenkanPeriod-1 {
+            result.Tenkan[i] = midpoint(data, i, tenkanPeriod)
+        }
+        if i >= kijunPeriod-1 {
+            result.Kijun[i] = midpoint(data, i, kijunPeriod)
+        }
+        if i >= tenkanPeriod-1 && i >= kijunPeriod-1 {
+            result.SenkouA[i+displacement] = (result.Tenkan[i] + result.Kijun[i]) / 2
+        }
+        if i >= senkouPeriod-1 {
+            result.SenkouB[i+displacement] = midpoint(data, i, senkouPeriod)
+        }
+        if i >= displacement {
+            result.Chikou[i-displacement] = data[i].Close
+        }
+    }
+
+    return result, nil
+}
+
+// Aroon represents the Aroon indicator
+type Aroon struct {
+    Up         []float64
+    Down       []float64
+    Oscillator []float64
+}
+
+// CalculateAroon calculates Aroon Up and Down from the number of bars since
+// the highest high and lowest low of the last period+1 bars
+func CalculateAroon(data []models.OHLCV, period int) (*Aroon, error) {
+    if period <= 0 {
+        return nil, fmt.Errorf("invalid period: %d", period)
+    }
+    if len(data) < period+1 {
+        return nil, fmt.Errorf("insufficient data: need %d, got %d", period+1, len(data))
+    }
+
+    result := &Aroon{
+        Up:         make([]float64, len(data)),
+        Down:       make([]float64, len(data)),
+        Oscillator: make([]float64, len(data)),
+    }
+
+    for i := period; i < len(data); i++ {
+        highest, lowest := i-period, i-period
+        for j := i - period + 1; j <= i; j++ {
+            // Ties go to the most recent bar
+            if data[j].High >= data[highest].High {
+                highest = j
+            }
+            if data[j].Low <= data[lowest].Low {
+                lowest = j
+            }
+        }
+
+        result.Up[i] = 100 * float64(period-(i-highest)) / float64(period)
+        result.Down[i] = 100 * float64(period-(i-lowest)) / float64(period)
+        result.Oscillator[i] = result.Up[i] - result.Down[i]
+    }
+
+    return result, nil
+}
+
+// Channel represents a price channel such as Keltner or Donchian
+type Channel struct {
+    Upper  []float64
+    Middle []float64
+    Lower  []float64
+}
+
+// CalculateKeltnerChannels calculates Keltner Channels, an EMA of the close
+// with bands multiplier ATRs away
+func CalculateKeltnerChannels(data []models.OHLCV, emaPeriod, atrPeriod int, multiplier float64) (*Channel, error) {
+    ema, err := CalculateEMA(data, emaPeriod)
+    if err != nil {
+        return nil, fmt.Errorf("failed to calculate EMA: %w", err)
+    }
+    atr, err := CalculateATR(data, atrPeriod)
+    if err != nil {
+        return nil, fmt.Errorf("failed to calculate ATR: %w", err)
+    }
+
+    result := &Channel{
+        Upper:  make([]float64, len(data)),
+        Middle: make([]float64, len(data)),
+        Lower:  make([]float64, len(data)),
+    }
+
+    for i := max(emaPeriod-1, atrPeriod); i < len(data); i++ {
+        result.Middle[i] = ema[i]
+        result.Upper[i] = ema[i] + multiplier*atr[i]
+        result.Lower[i] = ema[i] - multiplier*atr[i]
+    }
+
+    return result, nil
+}
+
+// CalculateDonchianChannels calculates Donchian Channels, the highest high and
+// lowest low of the last period bars
+func CalculateDonchianChannels(data []models.OHLCV, period int) (*Channel, error) {
+    if period <= 0 {
+        return nil, fmt.Errorf("invalid period: %d", period)
+    }
+    if len(data) < period {
+        return nil, fmt.Errorf("insufficient data: need %d, got %d", period, len(data))
+    }
+
+    result := &Channel{
+        Upper:  make([]float64, len(data)),
+        Middle: make([]float64, len(data)),
+        Lower:  make([]float64, len(data)),
+    }
+
+    for i := period - 1; i < len(data); i++ {
+        highest, lowest := data[i].High, data[i].Low
+        for j := i - period + 1; j < i; j++ {
+            highest = math.Max(highest, data[j].High)
+            lowest = math.Min(lowest, data[j].Low)
+        }
+        result.Upper[i] = highest
+        result.Lower[i] = lowest
+        result.Middle[i] = (highest + lowest) / 2
+    }
+
+    return result, nil
+}
@@ -0,0 +1,179 @@
+package indicators
+
+import (
+    "math"
+    "testing"
+
+    "github.com/algo-trading/market-data-service/internal/models"
+    "github.com/algo-trading/market-data-service/internal/testutil"
+)
+
+// createReversalData rises for n bars then falls for n bars
+func createReversalData(n int) []models.OHLCV {
+    data := testutil.Trend("TEST", 2*n, 100, 2, 1000)
+    for i := n; i < 2*n; i++ {
+        price := data[n-1].Close - 2*float64(i-n+1)
+        data[i].Open, data[i].High, data[i].Low, data[i].Close = price+1, price+2, price-1, price
+    }
+    return data
+}
+
+func TestCalculateADX(t *testing.T) {
+    data := testutil.Trend("TEST", 60, 100, 2, 1000)
+
+    adx, err := CalculateADX(data, 14)
+    if err != nil {
+        t.Fatalf("Failed to calculate ADX: %v", err)
+    }
+    if adx.ADX[26] != 0 || adx.ADX[27] == 0 || adx.PlusDI[13] != 0 || adx.PlusDI[14] == 0 {
+        t.Errorf("Unexpected warm-up: ADX %v, +DI %v", adx.ADX[26:28], adx.PlusDI[13:15])
+    }
+
+    // Every bar makes a higher high and higher low, so all movement is up
+    if adx.MinusDI[59] != 0 || math.Abs(adx.ADX[59]-100) > 1e-9 {
+        t.Errorf("Expected -DI 0 and ADX 100 in a steady uptrend, got %f and %f", adx.MinusDI[59], adx.ADX[59])
+    }
+    // +DM of 2 against a true range of 3 (high 1 above close, low 2 below)
+    if math.Abs(adx.PlusDI[59]-100*2.0/3) > 1e-9 {
+        t.Errorf("Expected +DI 66.67, got %f", adx.PlusDI[59])
+    }
+
+    if _, err := CalculateADX(data[:27], 14); err == nil {
+        t.Errorf("Expected insufficient data error")
+    }
+}
+
+func TestCalculateSupertrend(t *testing.T) {
+    data := createReversalData(30)
+
+    st, err := CalculateSupertrend(data, 10, 3)
+    if err != nil {
+        t.Fatalf("Failed to calculate Supertrend: %v", err)
+    }
+    if st.Direction[9] != 0 || st.Direction[29] != TrendUp || st.Direction[59] != TrendDown {
+        t.Errorf("Expected an uptrend then a downtrend, got %v", st.Direction)
+    }
+    if st.Value[29] != st.Lower[29] || st.Value[59] != st.Upper[59] {
+        t.Errorf("Expected the value to follow the band opposite the trend")
+    }
+
+    // The lower band never falls while the uptrend holds
+    for i := 11; i < 30; i++ {
+        if st.Lower[i] < st.Lower[i-1] {
+            t.Errorf("Lower band fell at %d during an uptrend", i)
+        }
+    }
+}
+
+func TestCalculateParabolicSAR(t *testing.T) {
+    data := createReversalData(20)
+
+    psar, err := CalculateParabolicSAR(data, 0.02, 0.2)
+    if err != nil {
+        t.Fatalf("Failed to calculate Parabolic SAR: %v", err)
+    }
+
+    reversed := false
+    for i := 1; i < len(data); i++ {
+        if psar.Trend[i] == TrendUp && psar.SAR[i] > data[i].Low {
+            t.Errorf("SAR[%d] %f above the low in an uptrend", i, psar.SAR[i])
+        }
+        if psar.Trend[i] == TrendDown {
+            reversed = true
+            if psar.SAR[i] < data[i].High {
+                t.Errorf("SAR[%d] %f below the high in a downtrend", i, psar.SAR[i])
+            }
+        }
+    }
+    if psar.Trend[19] != TrendUp || !reversed || psar.Trend[39] != TrendDown {
+        t.Errorf("Expected SAR to reverse with the trend, got %v", psar.Trend)
+    }
+
+    if _, err := CalculateParabolicSAR(data, 0.2, 0.02); err == nil {
+        t.Errorf("Expected error when the step exceeds the maximum")
+    }
+}
+
+func TestCalculateIchimoku(t *testing.T) {
+    data := testutil.Trend("TEST", 60, 100, 2, 1000)
+
+    ichimoku, err := CalculateIchimoku(data, 9, 26, 52)
+    if err != nil {
+        t.Fatalf("Failed to calculate Ichimoku: %v", err)
+    }
+    if len(ichimoku.SenkouA) != 86 || len(ichimoku.Tenkan) != 60 {
+        t.Fatalf("Expected spans to extend 26 bars ahead")
+    }
+
+    // Highs and lows rise by 2 per bar
+    tenkan := (data[59].High + data[51].Low) / 2
+    kijun := (data[59].High + data[34].Low) / 2
+    senkouB := (data[59].High + data[8].Low) / 2
+    if ichimoku.Tenkan[59] != tenkan || ichimoku.Kijun[59] != kijun {
+        t.Errorf("Expected Tenkan %f and Kijun %f, got %f and %f", tenkan, kijun, ichimoku.Tenkan[59], ichimoku.Kijun[59])
+    }
+    if ichimoku.SenkouA[85] != (tenkan+kijun)/2 || ichimoku.SenkouB[85] != senkouB || ichimoku.SenkouB[76] != 0 {
+        t.Errorf("Unexpected leading spans: %f, %f", ichimoku.SenkouA[85], ichimoku.SenkouB[85])
+    }
+    if ichimoku.Chikou[0] != data[26].Close || ichimoku.Chikou[34] != 0 {
+        t.Errorf("Expected the lagging span to plot closes 26 bars back")
+    }
+}
+
+func TestCalculateAroon(t *testing.T) {
+    data := createReversalData(20)
+
+    aroon, err := CalculateAroon(data, 10)
+    if err != nil {
+        t.Fatalf("Failed to calculate Aroon: %v", err)
+    }
+    if aroon.Up[19] != 100 || aroon.Down[19] != 0 || aroon.Oscillator[19] != 100 {
+        t.Errorf("Expected Aroon Up 100 at the top, got %f/%f", aroon.Up[19], aroon.Down[19])
+    }
+    // Five bars after the top, and ten bars into the fall when the low is new
+    if aroon.Up[24] != 50 || aroon.Up[29] != 0 || aroon.Down[29] != 100 {
+        t.Errorf("Expected Aroon Up 50 then 0 and Down 100, got %f, %f and %f", aroon.Up[24], aroon.Up[29], aroon.Down[29])
+    }
+}
+
+func TestCalculateChannels(t *testing.T) {
+    data := createTestOHLCVData()
+
+    donchian, err := CalculateDonchianChannels(data, 3)
+    if err != nil {
+        t.Fatalf("Failed to calculate Donchian Channels: %v", err)
+    }
+    if donchian.Upper[4] != 115 || donchian.Lower[4] != 104 || donchian.Middle[4] != 109.5 || donchian.Upper[1] != 0 {
+        t.Errorf("Unexpected Donchian Channels at 4: %f/%f/%f", donchian.Upper[4], donchian.Middle[4], donchian.Lower[4])
+    }
+
+    keltner, err := CalculateKeltnerChannels(data, 5, 3, 2)
+    if err != nil {
+        t.Fatalf("Failed to calculate Keltner Channels: %v", err)
+    }
+    ema, _ := CalculateEMA(data, 5)
+    atr, _ := CalculateATR(data, 3)
+    if keltner.Middle[9] != ema[9] || math.Abs(keltner.Upper[9]-(ema[9]+2*atr[9])) > 1e-9 || keltner.Upper[3] != 0 {
+        t.Errorf("Unexpected Keltner Channels")
+    }
+}
+
+func TestIndicatorCalculatorTrend(t *testing.T) {
+    data := testutil.Trend("TEST", 80, 100, 2, 1000)
+    calc := NewIndicatorCalculator()
+
+    tests := map[string]map[string]interface{}{
+        "adx":               {"period": 14},
+        "supertrend":        {"period": 10, "multiplier": 3.0},
+        "parabolic_sar":     {},
+        "ichimoku":          {},
+        "aroon":             {"period": 25},
+        "keltner_channels":  {"period": 20, "multiplier": 2.0},
+        "donchian_channels": {"period": 20},
+    }
+    for name, params := range tests {
+        if _, err := calc.Calculate(data, name, params); err != nil {
+            t.Errorf("Failed to calculate %s: %v", name, err)
+        }
+    }
+}
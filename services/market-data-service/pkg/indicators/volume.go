@@ -0,0 +1,288 @@
+package indicators
+
+import (
+    "fmt"
+    "math"
+
+    "github.com/algo-trading/market-data-service/internal/models"
+)
+
+// ValueAreaShare is the share of volume around the point of control that
+// makes up the value area of a volume profile
+const ValueAreaShare = 0.70
+
+func typicalPrice(bar models.OHLCV) float64 {
+    return (bar.High + bar.Low + bar.Close) / 3
+}
+
+// moneyFlowMultiplier is where the close lies in the bar's range, from -1 at
+// the low to 1 at the high
+func moneyFlowMultiplier(bar models.OHLCV) float64 {
+    if bar.High == bar.Low {
+        return 0
+    }
+    return ((bar.Close - bar.Low) - (bar.High - bar.Close)) / (bar.High - bar.Low)
+}
+
+// VWAP represents session-anchored Volume Weighted Average Price with
+// volume weighted standard deviation bands
+type VWAP struct {
+    VWAP   []float64
+    StdDev []float64
+    Upper  []float64
+    Lower  []float64
+}
+
+// CalculateVWAP calculates VWAP of the typical price, restarting at the first
+// bar of each calendar day in the bars' time zone. NSE and BSE sessions fall
+// on a single day both in IST and UTC.
+func CalculateVWAP(data []models.OHLCV, deviation float64) (*VWAP, error) {
+    if len(data) == 0 {
+        return nil, fmt.Errorf("insufficient data: need 1, got 0")
+    }
+
+    result := &VWAP{
+        VWAP:   make([]float64, len(data)),
+        StdDev: make([]float64, len(data)),
+        Upper:  make([]float64, len(data)),
+        Lower:  make([]float64, len(data)),
+    }
+
+    var volume, priceVolume, squareVolume float64
+    for i, bar := range data {
+        y1, m1, d1 := bar.Time.Date()
+        if i > 0 {
+            if y0, m0, d0 := data[i-1].Time.Date(); y0 != y1 || m0 != m1 || d0 != d1 {
+                volume, priceVolume, squareVolume = 0, 0, 0
+            }
+        }
+
+        price := typicalPrice(bar)
+        volume += float64(bar.Volume)
+        priceVolume += price * float64(bar.Volume)
+        squareVolume += price * price * float64(bar.Volume)
+
+        // Without volume so far the session VWAP is the typical price
+        vwap, stdDev := price, 0.0
+        if volume > 0 {
+            vwap = priceVolume / volume
+            stdDev = math.Sqrt(math.Max(squareVolume/volume-vwap*vwap, 0))
+        }
+
+        result.VWAP[i] = vwap
+        result.StdDev[i] = stdDev
+        result.Upper[i] = vwap + deviation*stdDev
+        result.Lower[i] = vwap - deviation*stdDev
+    }
+
+    return result, nil
+}
+
+// CalculateOBV calculates On-Balance Volume, adding the volume of up closes
+// and subtracting that of down closes
+func CalculateOBV(data []models.OHLCV) ([]float64, error) {
+    if len(data) == 0 {
+        return nil, fmt.Errorf("insufficient data: need 1, got 0")
+    }
+
+    result := make([]float64, len(data))
+
+    for i := 1; i < len(data); i++ {
+        result[i] = result[i-1]
+        switch {
+        case data[i].Close > data[i-1].Close:
+            result[i] += float64(data[i].Volume)
+        case data[i].Close < data[i-1].Close:
+            result[i] -= float64(data[i].Volume)
+        }
+    }
+
+    return result, nil
+}
+
+// CalculateMFI calculates Money Flow Index, a volume weighted RSI of the
+// typical price
+func CalculateMFI(data []models.OHLCV, period int) ([]float64, error) {
+    if period <= 0 {
+        return nil, fmt.Errorf("invalid period: %d", period)
+    }
+    if len(data) < period+1 {
+        return nil, fmt.Errorf("insufficient data: need %d, got %d", period+1, len(data))
+    }
+
+    result := make([]float64, len(data))
+    positive := make([]float64, len(data))
+    negative := make([]float64, len(data))
+
+    // Classify raw money flow by the change in typical price
+    for i := 1; i < len(data); i++ {
+        price := typicalPrice(data[i])
+        previous := typicalPrice(data[i-1])
+        flow := price * float64(data[i].Volume)
+        if price > previous {
+            positive[i] = flow
+        } else if price < previous {
+            negative[i] = flow
+        }
+    }
+
+    for i := period; i < len(data); i++ {
+        positiveFlow, negativeFlow := 0.0, 0.0
+        for j := i - period + 1; j <= i; j++ {
+            positiveFlow += positive[j]
+            negativeFlow += negative[j]
+        }
+
+        switch {
+        case negativeFlow == 0 && positiveFlow == 0:
+            result[i] = 50
+        case negativeFlow == 0:
+            result[i] = 100
+        default:
+            result[i] = 100 - (100 / (1 + positiveFlow/negativeFlow))
+        }
+    }
+
+    return result, nil
+}
+
+// CalculateAD calculates the Accumulation/Distribution line, the running total
+// of volume weighted by where each bar closed in its range
+func CalculateAD(data []models.OHLCV) ([]float64, error) {
+    if len(data) == 0 {
+        return nil, fmt.Errorf("insufficient data: need 1, got 0")
+    }
+
+    result := make([]float64, len(data))
+    total := 0.0
+
+    for i, bar := range data {
+        total += moneyFlowMultiplier(bar) * float64(bar.Volume)
+        result[i] = total
+    }
+
+    return result, nil
+}
+
+// CalculateCMF calculates Chaikin Money Flow, the money flow volume of the
+// last period bars relative to their volume
+func CalculateCMF(data []models.OHLCV, period int) ([]float64, error) {
+    if period <= 0 {
+        return nil, fmt.Errorf("invalid period: %d", period)
+    }
+    if len(data) < period {
+        return nil, fmt.Errorf("insufficient data: need %d, got %d", period, len(data))
+    }
+
+    result := make([]float64, len(data))
+
+    for i := period - 1; i < len(data); i++ {
+        flow, volume := 0.0, 0.0
+        for j := i - period + 1; j <= i; j++ {
+            flow += moneyFlowMultiplier(data[j]) * float64(data[j].Volume)
+            volume += float64(data[j].Volume)
+        }
+        if volume > 0 {
+            result[i] = flow / volume
+        }
+    }
+
+    return result, nil
+}
+
+// VolumeLevel is one price bin of a volume profile
+type VolumeLevel struct {
+    Low    float64 `json:"low"`
+    High   float64 `json:"high"`
+    Volume float64 `json:"volume"`
+}
+
+// VolumeProfile is the traded volume by price over a range of bars
+type VolumeProfile struct {
+    Levels         []VolumeLevel `json:"levels"`
+    TotalVolume    float64       `json:"total_volume"`
+    PointOfControl float64       `json:"point_of_control"`
+    ValueAreaLow   float64       `json:"value_area_low"`
+    ValueAreaHigh  float64       `json:"value_area_high"`
+}
+
+// CalculateVolumeProfile distributes each bar's volume evenly over its
+// high-low range into bins equal price bins. The point of control is the
+// middle of the bin with the most volume and the value area the bins around
+// it holding ValueAreaShare of the volume.
+func CalculateVolumeProfile(data []models.OHLCV, bins int) (*VolumeProfile, error) {
+    if bins <= 0 {
+        return nil, fmt.Errorf("invalid number of bins: %d", bins)
+    }
+    if len(data) == 0 {
+        return nil, fmt.Errorf("insufficient data: need 1, got 0")
+    }
+
+    low, high := data[0].Low, data[0].High
+    for _, bar := range data[1:] {
+        low = math.Min(low, bar.Low)
+        high = math.Max(high, bar.High)
+    }
+
+    profile := &VolumeProfile{Levels: make([]VolumeLevel, bins)}
+    size := (high - low) / float64(bins)
+    for i := range profile.Levels {
+        profile.Levels[i].Low = low + float64(i)*size
+        profile.Levels[i].High = low + float64(i+1)*size
+    }
+    profile.Levels[bins-1].High = high
+
+    binOf := func(price float64) int {
+        if size == 0 {
+            return 0
+        }
+        return min(int((price-low)/size), bins-1)
+    }
+
+    for _, bar := range data {
+        volume := float64(bar.Volume)
+        profile.TotalVolume += volume
+
+        first, last := binOf(bar.Low), binOf(bar.High)
+        if first == last || bar.High == bar.Low {
+            profile.Levels[binOf(bar.Close)].Volume += volume
+            continue
+        }
+        for i := first; i <= last; i++ {
+            overlap := math.Min(bar.High, profile.Levels[i].High) - math.Max(bar.Low, profile.Levels[i].Low)
+            profile.Levels[i].Volume += volume * math.Max(overlap, 0) / (bar.High - bar.Low)
+        }
+    }
+
+    poc := 0
+    for i, level := range profile.Levels {
+        if level.Volume > profile.Levels[poc].Volume {
+            poc = i
+        }
+    }
+    profile.PointOfControl = (profile.Levels[poc].Low + profile.Levels[poc].High) / 2
+
+    // Grow the value area towards the neighbouring bin with more volume
+    lowBin, highBin := poc, poc
+    area := profile.Levels[poc].Volume
+    for area < ValueAreaShare*profile.TotalVolume && (lowBin > 0 || highBin < bins-1) {
+        below, above := -1.0, -1.0
+        if lowBin > 0 {
+            below = profile.Levels[lowBin-1].Volume
+        }
+        if highBin < bins-1 {
+            above = profile.Levels[highBin+1].Volume
+        }
+        if above >= below {
+            highBin++
+            area += above
+        } else {
+            lowBin--
+            area += below
+        }
+    }
+    profile.ValueAreaLow = profile.Levels[lowBin].Low
+    profile.ValueAreaHigh = profile.Levels[highBin].High
+
+    return profile, nil
+}
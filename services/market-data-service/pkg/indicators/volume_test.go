@@ -0,0 +1,154 @@
+package indicators
+
+import (
+    "math"
+    "testing"
+    "time"
+
+    "github.com/algo-trading/market-data-service/internal/models"
+    "github.com/algo-trading/market-data-service/internal/testutil"
+)
+
+func TestCalculateVWAP(t *testing.T) {
+    day := time.Date(2025, time.October, 14, 3, 45, 0, 0, time.UTC)
+    data := []models.OHLCV{
+        {Time: day, High: 102, Low: 98, Close: 100, Volume: 100},
+        {Time: day.Add(time.Minute), High: 112, Low: 108, Close: 110, Volume: 300},
+        {Time: day.AddDate(0, 0, 1), High: 52, Low: 48, Close: 50, Volume: 10},
+    }
+
+    vwap, err := CalculateVWAP(data, 2)
+    if err != nil {
+        t.Fatalf("Failed to calculate VWAP: %v", err)
+    }
+
+    // Typical prices 100 and 110 weighted 1:3
+    if math.Abs(vwap.VWAP[1]-107.5) > 1e-9 {
+        t.Errorf("Expected VWAP 107.5, got %f", vwap.VWAP[1])
+    }
+    stdDev := math.Sqrt(0.25*7.5*7.5 + 0.75*2.5*2.5)
+    if math.Abs(vwap.StdDev[1]-stdDev) > 1e-9 || math.Abs(vwap.Upper[1]-(107.5+2*stdDev)) > 1e-9 {
+        t.Errorf("Expected standard deviation %f, got %f", stdDev, vwap.StdDev[1])
+    }
+
+    // A new session restarts the average
+    if vwap.VWAP[2] != 50 || vwap.StdDev[2] != 0 {
+        t.Errorf("Expected VWAP to reset on a new day, got %f", vwap.VWAP[2])
+    }
+}
+
+func TestCalculateOBVAndAD(t *testing.T) {
+    data := []models.OHLCV{
+        {High: 11, Low: 9, Close: 10, Volume: 100},
+        {High: 12, Low: 10, Close: 12, Volume: 200},
+        {High: 12, Low: 10, Close: 11, Volume: 300},
+        {High: 12, Low: 10, Close: 11, Volume: 400},
+    }
+
+    obv, err := CalculateOBV(data)
+    if err != nil {
+        t.Fatalf("Failed to calculate OBV: %v", err)
+    }
+    for i, expected := range []float64{0, 200, -100, -100} {
+        if obv[i] != expected {
+            t.Errorf("Expected OBV[%d] %f, got %f", i, expected, obv[i])
+        }
+    }
+
+    ad, err := CalculateAD(data)
+    if err != nil {
+        t.Fatalf("Failed to calculate A/D: %v", err)
+    }
+    // Multipliers 0, 1, 0 and 0
+    for i, expected := range []float64{0, 200, 200, 200} {
+        if ad[i] != expected {
+            t.Errorf("Expected A/D[%d] %f, got %f", i, expected, ad[i])
+        }
+    }
+
+    cmf, err := CalculateCMF(data, 2)
+    if err != nil {
+        t.Fatalf("Failed to calculate CMF: %v", err)
+    }
+    if math.Abs(cmf[1]-200.0/300) > 1e-9 || cmf[0] != 0 || cmf[3] != 0 {
+        t.Errorf("Unexpected CMF: %v", cmf)
+    }
+}
+
+func TestCalculateMFI(t *testing.T) {
+    data := createTestOHLCVData()
+
+    mfi, err := CalculateMFI(data, 5)
+    if err != nil {
+        t.Fatalf("Failed to calculate MFI: %v", err)
+    }
+    // Typical price rises on every bar of the test data
+    for i := 5; i < len(data); i++ {
+        if mfi[i] != 100 {
+            t.Errorf("Expected MFI[%d] 100 in an uptrend, got %f", i, mfi[i])
+        }
+    }
+
+    mixed := testutil.RandomWalk("TEST", 200, 3)
+    mfi, _ = CalculateMFI(mixed, 14)
+    for i := 14; i < len(mixed); i++ {
+        if mfi[i] < 0 || mfi[i] > 100 {
+            t.Errorf("MFI[%d] = %f is out of range [0, 100]", i, mfi[i])
+        }
+    }
+
+    if _, err := CalculateMFI(data[:5], 5); err == nil {
+        t.Errorf("Expected insufficient data error")
+    }
+}
+
+func TestCalculateVolumeProfile(t *testing.T) {
+    data := []models.OHLCV{
+        {High: 104, Low: 100, Close: 102, Volume: 400},
+        {High: 103, Low: 102, Close: 103, Volume: 700},
+        {High: 101, Low: 101, Close: 101, Volume: 50},
+    }
+
+    profile, err := CalculateVolumeProfile(data, 4)
+    if err != nil {
+        t.Fatalf("Failed to calculate volume profile: %v", err)
+    }
+
+    expected := []float64{100, 150, 800, 100}
+    for i, level := range profile.Levels {
+        if math.Abs(level.Volume-expected[i]) > 1e-9 {
+            t.Errorf("Expected %f volume in %v-%v, got %f", expected[i], level.Low, level.High, level.Volume)
+        }
+    }
+    if profile.TotalVolume != 1150 || profile.PointOfControl != 102.5 {
+        t.Errorf("Unexpected total %f or point of control %f", profile.TotalVolume, profile.PointOfControl)
+    }
+    // 800 is short of 70% of 1150, so the value area grows into the busier
+    // neighbouring 101-102 bin
+    if profile.ValueAreaLow != 101 || profile.ValueAreaHigh != 103 {
+        t.Errorf("Expected value area 101-103, got %f-%f", profile.ValueAreaLow, profile.ValueAreaHigh)
+    }
+}
+
+func TestIndicatorCalculatorVolume(t *testing.T) {
+    data := createTestOHLCVData()
+    calc := NewIndicatorCalculator()
+
+    tests := map[string]map[string]interface{}{
+        "vwap":           {},
+        "obv":            {},
+        "mfi":            {"period": 5},
+        "ad":             {},
+        "cmf":            {"period": 5},
+        "volume_profile": {"bins": 10},
+    }
+    for name, params := range tests {
+        if _, err := calc.Calculate(data, name, params); err != nil {
+            t.Errorf("Failed to calculate %s: %v", name, err)
+        }
+    }
+
+    if _, err := calc.Calculate(data, "volume_profile", map[string]interface{}{}); err == nil {
+        t.Errorf("Expected error for missing bins")
+    }
+}
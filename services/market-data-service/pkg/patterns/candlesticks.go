@@ -0,0 +1,142 @@
+package patterns
+
+import (
+    "math"
+
+    "github.com/algo-trading/market-data-service/internal/models"
+)
+
+// Proportions of a candle's range used to classify its shape
+const (
+    dojiBody = 0.1 // body of a doji
+    longBody = 0.5 // body of a long candle
+    starBody = 0.3 // body of a star relative to the first candle's body
+)
+
+type candle struct {
+    models.OHLCV
+}
+
+func (c candle) body() float64     { return math.Abs(c.Close - c.Open) }
+func (c candle) span() float64     { return c.High - c.Low }
+func (c candle) top() float64      { return math.Max(c.Open, c.Close) }
+func (c candle) bottom() float64   { return math.Min(c.Open, c.Close) }
+func (c candle) upper() float64    { return c.High - c.top() }
+func (c candle) lower() float64    { return c.bottom() - c.Low }
+func (c candle) bullish() bool     { return c.Close > c.Open }
+func (c candle) bearish() bool     { return c.Close < c.Open }
+func (c candle) midpoint() float64 { return (c.Open + c.Close) / 2 }
+func (c candle) long() bool        { return c.span() > 0 && c.body() >= longBody*c.span() }
+
+// trend returns the direction of the closes over the bars before end, or 0
+// when there are not enough bars
+func trend(data []models.OHLCV, end, bars int) int {
+    start := end - bars
+    if start < 0 || bars <= 0 {
+        return 0
+    }
+    switch {
+    case data[end].Close > data[start].Close:
+        return 1
+    case data[end].Close < data[start].Close:
+        return -1
+    default:
+        return 0
+    }
+}
+
+// Candlesticks returns the candlestick patterns completed at each bar.
+// Reversal patterns are only reported after a trend in the opposite
+// direction.
+func Candlesticks(data []models.OHLCV, opts Options) []Event {
+    var events []Event
+    for i := range data {
+        events = append(events, candlesticksAt(data, i, opts)...)
+    }
+    return events
+}
+
+// candlesticksAt returns the patterns completed by bar i
+func candlesticksAt(data []models.OHLCV, i int, opts Options) []Event {
+    var events []Event
+    c := candle{data[i]}
+
+    if c.span() > 0 && c.body() <= dojiBody*c.span() {
+        events = append(events, newEvent(data, Doji, Neutral, i, 1, 1-c.body()/(dojiBody*c.span())))
+    }
+
+    if i >= 1 {
+        before := trend(data, i-1, opts.TrendBars)
+
+        // Long lower shadow after a decline, with the body near the high
+        if before < 0 && c.body() > 0 && c.lower() >= 2*c.body() && c.upper() <= c.body() {
+            events = append(events, newEvent(data, Hammer, Bullish, i, 1, c.lower()/c.span()))
+        }
+
+        p := candle{data[i-1]}
+        if p.body() > 0 && c.body() > p.body() && c.top() >= p.top() && c.bottom() <= p.bottom() {
+            confidence := 1 - p.body()/c.body()
+            if before < 0 && p.bearish() && c.bullish() {
+                events = append(events, newEvent(data, BullishEngulfing, Bullish, i, 2, confidence))
+            } else if before > 0 && p.bullish() && c.bearish() {
+                events = append(events, newEvent(data, BearishEngulfing, Bearish, i, 2, confidence))
+            }
+        }
+
+        if p.long() && c.body() < longBody*p.body() && c.top() <= p.top() && c.bottom() >= p.bottom() {
+            confidence := 1 - c.body()/(longBody*p.body())
+            if before < 0 && p.bearish() {
+                events = append(events, newEvent(data, BullishHarami, Bullish, i, 2, confidence))
+            } else if before > 0 && p.bullish() {
+                events = append(events, newEvent(data, BearishHarami, Bearish, i, 2, confidence))
+            }
+        }
+    }
+
+    if i >= 2 {
+        before := trend(data, i-2, opts.TrendBars)
+        first, star := candle{data[i-2]}, candle{data[i-1]}
+
+        // A long candle, a small star beyond its close, then a candle closing
+        // deep into the first one's body
+        if first.long() && star.body() <= starBody*first.body() {
+            if before < 0 && first.bearish() && c.bullish() && star.top() <= first.Close && c.Close > first.midpoint() {
+                events = append(events, newEvent(data, MorningStar, Bullish, i, 3, (c.Close-first.midpoint())/(first.Open-first.midpoint())))
+            } else if before > 0 && first.bullish() && c.bearish() && star.bottom() >= first.Close && c.Close < first.midpoint() {
+                events = append(events, newEvent(data, EveningStar, Bearish, i, 3, (first.midpoint()-c.Close)/(first.midpoint()-first.Open)))
+            }
+        }
+
+        if soldiers, confidence := threeCandles(data[i-2:i+1], true); soldiers {
+            events = append(events, newEvent(data, ThreeWhiteSoldiers, Bullish, i, 3, confidence))
+        } else if crows, confidence := threeCandles(data[i-2:i+1], false); crows {
+            events = append(events, newEvent(data, ThreeBlackCrows, Bearish, i, 3, confidence))
+        }
+    }
+
+    return events
+}
+
+// threeCandles checks for three long candles in the same direction, each
+// opening within the previous body and closing beyond the previous close.
+// The confidence is the average share of the range taken by the bodies.
+func threeCandles(bars []models.OHLCV, up bool) (bool, float64) {
+    total := 0.0
+    for i, bar := range bars {
+        c := candle{bar}
+        if !c.long() || (up && !c.bullish()) || (!up && !c.bearish()) {
+            return false, 0
+        }
+        if i > 0 {
+            p := candle{bars[i-1]}
+            if c.Open < p.bottom() || c.Open > p.top() {
+                return false, 0
+            }
+            if (up && c.Close <= p.Close) || (!up && c.Close >= p.Close) {
+                return false, 0
+            }
+        }
+        total += c.body() / c.span()
+    }
+    return true, total / float64(len(bars))
+}
@@ -0,0 +1,72 @@
+package patterns
+
+import (
+    "sync"
+
+    "github.com/algo-trading/market-data-service/internal/models"
+)
+
+// DefaultHistory is the number of bars per series a Detector keeps
+const DefaultHistory = 200
+
+// Detector finds patterns as bars complete, keeping recent bars per symbol
+// and timeframe
+type Detector struct {
+    opts    Options
+    history int
+    series  map[string][]models.OHLCV
+    mu      sync.Mutex
+}
+
+func NewDetector(opts Options, history int) *Detector {
+    if history <= 0 {
+        history = DefaultHistory
+    }
+    return &Detector{
+        opts:    opts,
+        history: history,
+        series:  make(map[string][]models.OHLCV),
+    }
+}
+
+// Update adds a completed bar and returns the patterns it completes: the
+// candlestick patterns ending on it, the swing it confirms and level breaks.
+// Indices refer to the detector's window of bars, so use Time to place them.
+// Bars not after the last one seen, e.g. replays, are ignored.
+func (d *Detector) Update(bar models.OHLCV) []Event {
+    d.mu.Lock()
+    defer d.mu.Unlock()
+
+    key := bar.Symbol + "|" + bar.Timeframe
+    bars := d.series[key]
+    if n := len(bars); n > 0 && !bars[n-1].Time.Before(bar.Time) {
+        return nil
+    }
+
+    bars = append(bars, bar)
+    if len(bars) > d.history {
+        bars = append(bars[:0:0], bars[len(bars)-d.history:]...)
+    }
+    d.series[key] = bars
+
+    return completedAt(bars, len(bars)-1, d.opts)
+}
+
+// Recent returns the patterns completed on the last n bars of data, as a
+// Detector fed data bar by bar would have reported them
+func Recent(data []models.OHLCV, opts Options, n int) []Event {
+    var events []Event
+    for last := max(len(data)-n, 0); last < len(data); last++ {
+        events = append(events, completedAt(data[:last+1], last, opts)...)
+    }
+    return events
+}
+
+// completedAt returns the patterns completed by bar last, the final bar of
+// data
+func completedAt(data []models.OHLCV, last int, opts Options) []Event {
+    events := candlesticksAt(data, last, opts)
+    events = append(events, swingsAt(data, last-opts.SwingStrength, opts.SwingStrength)...)
+    events = append(events, breakoutsAt(data, last, opts)...)
+    return events
+}
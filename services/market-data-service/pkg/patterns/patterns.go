@@ -0,0 +1,118 @@
+package patterns
+
+import (
+    "fmt"
+    "math"
+    "sort"
+    "strings"
+    "time"
+
+    "github.com/algo-trading/market-data-service/internal/models"
+)
+
+// Pattern identifies a candlestick pattern or chart structure
+type Pattern string
+
+const (
+    Doji               Pattern = "doji"
+    Hammer             Pattern = "hammer"
+    BullishEngulfing   Pattern = "bullish_engulfing"
+    BearishEngulfing   Pattern = "bearish_engulfing"
+    MorningStar        Pattern = "morning_star"
+    EveningStar        Pattern = "evening_star"
+    BullishHarami      Pattern = "bullish_harami"
+    BearishHarami      Pattern = "bearish_harami"
+    ThreeWhiteSoldiers Pattern = "three_white_soldiers"
+    ThreeBlackCrows    Pattern = "three_black_crows"
+    SwingHigh          Pattern = "swing_high"
+    SwingLow           Pattern = "swing_low"
+    ResistanceBreakout Pattern = "resistance_breakout"
+    SupportBreakdown   Pattern = "support_breakdown"
+)
+
+// All lists every pattern the package detects
+var All = []Pattern{
+    Doji, Hammer, BullishEngulfing, BearishEngulfing, MorningStar, EveningStar, BullishHarami,
+    BearishHarami, ThreeWhiteSoldiers, ThreeBlackCrows, SwingHigh, SwingLow, ResistanceBreakout,
+    SupportBreakdown,
+}
+
+// ParsePattern returns the pattern with the given name, e.g. "hammer"
+func ParsePattern(name string) (Pattern, error) {
+    for _, pattern := range All {
+        if strings.EqualFold(name, string(pattern)) {
+            return pattern, nil
+        }
+    }
+    return "", fmt.Errorf("unknown pattern: %s", name)
+}
+
+// Bias is the direction a pattern suggests
+type Bias string
+
+const (
+    Bullish Bias = "bullish"
+    Bearish Bias = "bearish"
+    Neutral Bias = "neutral"
+)
+
+// Event is a pattern found in a series of bars. Index is the bar completing
+// the pattern, or the swing bar itself for swings, and Bars the number of
+// bars the pattern spans. Confidence ranges from 0 to 1 with how clearly the
+// bars match the pattern.
+type Event struct {
+    Pattern    Pattern   `json:"pattern"`
+    Bias       Bias      `json:"bias"`
+    Symbol     string    `json:"symbol"`
+    Timeframe  string    `json:"timeframe"`
+    Time       time.Time `json:"time"`
+    Index      int       `json:"index"`
+    Bars       int       `json:"bars"`
+    Price      float64   `json:"price,omitempty"`
+    Confidence float64   `json:"confidence"`
+}
+
+// Options tune pattern detection
+type Options struct {
+    TrendBars      int     // bars used to judge the trend before a reversal pattern
+    SwingStrength  int     // bars on each side a swing high or low must exceed
+    LevelTolerance float64 // relative distance within which swings form one level
+    MinTouches     int     // swings needed to form a support or resistance level
+}
+
+func DefaultOptions() Options {
+    return Options{
+        TrendBars:      5,
+        SwingStrength:  3,
+        LevelTolerance: 0.005,
+        MinTouches:     2,
+    }
+}
+
+// Scan returns the candlestick patterns and swings in data ordered by index
+func Scan(data []models.OHLCV, opts Options) []Event {
+    events := Candlesticks(data, opts)
+    events = append(events, Swings(data, opts.SwingStrength)...)
+    sort.SliceStable(events, func(i, j int) bool { return events[i].Index < events[j].Index })
+    return events
+}
+
+func newEvent(data []models.OHLCV, pattern Pattern, bias Bias, index, bars int, confidence float64) Event {
+    return Event{
+        Pattern:    pattern,
+        Bias:       bias,
+        Symbol:     data[index].Symbol,
+        Timeframe:  data[index].Timeframe,
+        Time:       data[index].Time,
+        Index:      index,
+        Bars:       bars,
+        Confidence: clamp(confidence),
+    }
+}
+
+func clamp(value float64) float64 {
+    if math.IsNaN(value) {
+        return 0
+    }
+    return math.Max(0, math.Min(1, value))
+}
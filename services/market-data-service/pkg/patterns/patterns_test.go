@@ -0,0 +1,221 @@
+package patterns
+
+import (
+    "math"
+    "testing"
+    "time"
+
+    "github.com/algo-trading/market-data-service/internal/models"
+    "github.com/algo-trading/market-data-service/internal/testutil"
+)
+
+func bar(open, high, low, close float64) models.OHLCV {
+    return models.OHLCV{Symbol: "TEST", Timeframe: "5m", Open: open, High: high, Low: low, Close: close, Volume: 1000}
+}
+
+// trendBars returns n bars moving by step per bar from start
+func trendBars(start, step float64, n int) []models.OHLCV {
+    bars := make([]models.OHLCV, n)
+    for i := range bars {
+        open := start + step*float64(i)
+        close := open + step*0.8
+        bars[i] = bar(open, math.Max(open, close)+0.5, math.Min(open, close)-0.5, close)
+    }
+    return bars
+}
+
+func withTimes(bars []models.OHLCV) []models.OHLCV {
+    for i := range bars {
+        bars[i].Time = testutil.Start.Add(time.Duration(i) * 5 * time.Minute)
+    }
+    return bars
+}
+
+func find(events []Event, pattern Pattern, index int) *Event {
+    for i := range events {
+        if events[i].Pattern == pattern && events[i].Index == index {
+            return &events[i]
+        }
+    }
+    return nil
+}
+
+func TestCandlestickPatterns(t *testing.T) {
+    opts := DefaultOptions()
+    down := trendBars(120, -2, 6)
+    up := trendBars(80, 2, 6)
+
+    tests := []struct {
+        name    string
+        bars    []models.OHLCV
+        pattern Pattern
+        bias    Bias
+    }{
+        {"doji", []models.OHLCV{bar(100, 105, 95, 100.2)}, Doji, Neutral},
+        {"hammer", append(down, bar(106, 107.3, 100, 107.2)), Hammer, Bullish},
+        {"bullish engulfing", append(down, bar(108, 108.5, 105, 106), bar(105.5, 110, 105, 109)), BullishEngulfing, Bullish},
+        {"bearish engulfing", append(up, bar(92, 95, 91.5, 94), bar(94.5, 95, 90, 91)), BearishEngulfing, Bearish},
+        {"bullish harami", append(down, bar(110, 110.5, 101.5, 102), bar(104, 106, 103.5, 105)), BullishHarami, Bullish},
+        {"bearish harami", append(up, bar(90, 98.5, 89.5, 98), bar(95, 96, 93.5, 94)), BearishHarami, Bearish},
+        {"morning star", append(down, bar(110, 110.5, 101.5, 102), bar(101, 101.8, 99.5, 101.2), bar(102, 109.5, 101.5, 109)), MorningStar, Bullish},
+        {"evening star", append(up, bar(90, 98.5, 89.5, 98), bar(99, 100.5, 98.2, 99.3), bar(98, 98.5, 90.5, 91)), EveningStar, Bearish},
+        {"three white soldiers", []models.OHLCV{bar(100, 104.2, 99.8, 104), bar(102, 106.3, 101.8, 106), bar(104, 108.2, 103.9, 108)}, ThreeWhiteSoldiers, Bullish},
+        {"three black crows", []models.OHLCV{bar(108, 108.2, 103.8, 104), bar(106, 106.1, 101.7, 102), bar(104, 104.2, 99.9, 100)}, ThreeBlackCrows, Bearish},
+    }
+
+    for _, tt := range tests {
+        bars := withTimes(append([]models.OHLCV(nil), tt.bars...))
+        last := len(bars) - 1
+        event := find(Candlesticks(bars, opts), tt.pattern, last)
+        if event == nil {
+            t.Errorf("%s: pattern not detected in %v", tt.name, Candlesticks(bars, opts))
+            continue
+        }
+        if event.Bias != tt.bias || event.Confidence <= 0 || event.Confidence > 1 || !event.Time.Equal(bars[last].Time) {
+            t.Errorf("%s: unexpected event %+v", tt.name, *event)
+        }
+    }
+
+    // Reversal patterns need the opposite trend first
+    bars := append(up, bar(106, 107.3, 100, 107.2))
+    if find(Candlesticks(bars, opts), Hammer, len(bars)-1) != nil {
+        t.Errorf("Expected no hammer after an uptrend")
+    }
+}
+
+func TestSwingsAndLevels(t *testing.T) {
+    // Oscillate between about 100 and 110 three times, then break out
+    var bars []models.OHLCV
+    for cycle := 0; cycle < 3; cycle++ {
+        bars = append(bars, trendBars(100, 2, 5)...)
+        bars = append(bars, trendBars(110, -2, 5)...)
+    }
+    bars = withTimes(bars)
+
+    swings := Swings(bars, 3)
+    highs, lows := 0, 0
+    for _, swing := range swings {
+        switch swing.Pattern {
+        case SwingHigh:
+            highs++
+            if swing.Price != bars[swing.Index].High {
+                t.Errorf("Expected swing high price to be the bar's high")
+            }
+        case SwingLow:
+            lows++
+        }
+    }
+    if highs != 3 || lows != 2 {
+        t.Fatalf("Expected 3 swing highs and 2 swing lows, got %d and %d: %+v", highs, lows, swings)
+    }
+
+    levels := Levels(bars, DefaultOptions())
+    if len(levels) != 2 || levels[0].Kind != Support || levels[1].Kind != Resistance || levels[1].Touches != 3 {
+        t.Fatalf("Expected one support and one resistance level, got %+v", levels)
+    }
+
+    // A close above resistance is a breakout
+    breakout := append(bars, bar(105, 115, 104, 114))
+    events := breakoutsAt(breakout, len(breakout)-1, DefaultOptions())
+    if len(events) != 1 || events[0].Pattern != ResistanceBreakout || events[0].Price != levels[1].Price {
+        t.Errorf("Expected a resistance breakout, got %+v", events)
+    }
+}
+
+func TestPivots(t *testing.T) {
+    levels := Pivots(bar(95, 110, 90, 100))
+    if levels.Pivot != 100 || levels.R1 != 110 || levels.S1 != 90 || levels.R2 != 120 || levels.S2 != 80 || levels.R3 != 130 || levels.S3 != 70 {
+        t.Errorf("Unexpected pivots: %+v", levels)
+    }
+}
+
+func TestDetectorMatchesScan(t *testing.T) {
+    var bars []models.OHLCV
+    for cycle := 0; cycle < 4; cycle++ {
+        bars = append(bars, trendBars(100, 2, 6)...)
+        bars = append(bars, bar(112, 112.5, 108, 109), bar(108.5, 113, 104, 105))
+        bars = append(bars, trendBars(104, -2, 6)...)
+    }
+    bars = withTimes(bars)
+    opts := DefaultOptions()
+
+    detector := NewDetector(opts, 0)
+    var streamed []Event
+    for _, b := range bars {
+        streamed = append(streamed, detector.Update(b)...)
+    }
+
+    // Apart from breakouts, streaming finds exactly what a full scan does
+    scanned := Scan(bars, opts)
+    count := 0
+    for _, event := range streamed {
+        if event.Pattern == ResistanceBreakout || event.Pattern == SupportBreakdown {
+            continue
+        }
+        count++
+        if find(scanned, event.Pattern, event.Index) == nil {
+            t.Errorf("Streamed %s at %d not found by Scan", event.Pattern, event.Index)
+        }
+    }
+    if count != len(scanned) || count == 0 {
+        t.Errorf("Expected %d streamed events, got %d", len(scanned), count)
+    }
+
+    // Replayed and out of order bars are ignored
+    if events := detector.Update(bars[len(bars)-1]); events != nil {
+        t.Errorf("Expected a replayed bar to be ignored, got %+v", events)
+    }
+    if events := detector.Update(bars[len(bars)-3]); events != nil {
+        t.Errorf("Expected an older bar to be ignored, got %+v", events)
+    }
+    last := bars[len(bars)-1]
+    last.Time = last.Time.Add(5 * time.Minute)
+    detector.Update(last)
+    if len(detector.series[last.Symbol+"|"+last.Timeframe]) != len(bars)+1 {
+        t.Errorf("Expected a new bar to be added after ignored ones")
+    }
+
+    // Series are kept per symbol and timeframe
+    other := bars[0]
+    other.Symbol = "OTHER"
+    if events := detector.Update(other); len(events) != 0 && events[0].Symbol != "OTHER" {
+        t.Errorf("Expected events for the other symbol only")
+    }
+}
+
+func TestRecentMatchesDetector(t *testing.T) {
+    var bars []models.OHLCV
+    for cycle := 0; cycle < 3; cycle++ {
+        bars = append(bars, trendBars(100, 2, 6)...)
+        bars = append(bars, bar(112, 112.5, 108, 109), bar(108.5, 113, 104, 105))
+        bars = append(bars, trendBars(104, -2, 6)...)
+    }
+    bars = withTimes(bars)
+    opts := DefaultOptions()
+
+    detector := NewDetector(opts, 0)
+    var streamed []Event
+    for i, b := range bars {
+        events := detector.Update(b)
+        if i >= len(bars)-10 {
+            streamed = append(streamed, events...)
+        }
+    }
+
+    recent := Recent(bars, opts, 10)
+    if len(recent) != len(streamed) || len(recent) == 0 {
+        t.Fatalf("Expected %d recent events, got %d", len(streamed), len(recent))
+    }
+    for i := range recent {
+        if recent[i].Pattern != streamed[i].Pattern || !recent[i].Time.Equal(streamed[i].Time) {
+            t.Errorf("Event %d: expected %s at %s, got %s at %s", i, streamed[i].Pattern, streamed[i].Time, recent[i].Pattern, recent[i].Time)
+        }
+    }
+
+    if pattern, err := ParsePattern("Bullish_Engulfing"); err != nil || pattern != BullishEngulfing {
+        t.Errorf("Expected bullish_engulfing, got %s (%v)", pattern, err)
+    }
+    if _, err := ParsePattern("cup_and_handle"); err == nil {
+        t.Error("Expected an error for an unknown pattern")
+    }
+}
@@ -0,0 +1,180 @@
+package patterns
+
+import (
+    "math"
+    "sort"
+
+    "github.com/algo-trading/market-data-service/internal/models"
+)
+
+// PivotLevels are classic floor trader pivots computed from a completed
+// period, typically the previous day, and used as levels for the next one
+type PivotLevels struct {
+    Pivot float64 `json:"pivot"`
+    R1    float64 `json:"r1"`
+    R2    float64 `json:"r2"`
+    R3    float64 `json:"r3"`
+    S1    float64 `json:"s1"`
+    S2    float64 `json:"s2"`
+    S3    float64 `json:"s3"`
+}
+
+func Pivots(bar models.OHLCV) PivotLevels {
+    pivot := (bar.High + bar.Low + bar.Close) / 3
+    span := bar.High - bar.Low
+
+    return PivotLevels{
+        Pivot: pivot,
+        R1:    2*pivot - bar.Low,
+        R2:    pivot + span,
+        R3:    bar.High + 2*(pivot-bar.Low),
+        S1:    2*pivot - bar.High,
+        S2:    pivot - span,
+        S3:    bar.Low - 2*(bar.High-pivot),
+    }
+}
+
+// Swings returns the swing highs and lows: bars whose high (low) is above
+// (below) that of strength bars on either side. A swing is therefore only
+// known strength bars after it. The confidence is the swing's prominence
+// over its neighbours relative to their average range.
+func Swings(data []models.OHLCV, strength int) []Event {
+    var events []Event
+    for i := strength; i+strength < len(data); i++ {
+        events = append(events, swingsAt(data, i, strength)...)
+    }
+    return events
+}
+
+func swingsAt(data []models.OHLCV, i, strength int) []Event {
+    if strength <= 0 || i < strength || i+strength >= len(data) {
+        return nil
+    }
+
+    highest, lowest := math.Inf(-1), math.Inf(1)
+    span := 0.0
+    for j := i - strength; j <= i+strength; j++ {
+        span += data[j].High - data[j].Low
+        if j == i {
+            continue
+        }
+        highest = math.Max(highest, data[j].High)
+        lowest = math.Min(lowest, data[j].Low)
+    }
+    span /= float64(2*strength + 1)
+
+    var events []Event
+    if data[i].High > highest {
+        event := newEvent(data, SwingHigh, Bearish, i, 2*strength+1, prominence(data[i].High-highest, span))
+        event.Price = data[i].High
+        events = append(events, event)
+    }
+    if data[i].Low < lowest {
+        event := newEvent(data, SwingLow, Bullish, i, 2*strength+1, prominence(lowest-data[i].Low, span))
+        event.Price = data[i].Low
+        events = append(events, event)
+    }
+    return events
+}
+
+func prominence(distance, span float64) float64 {
+    if span == 0 {
+        return 1
+    }
+    return distance / span
+}
+
+// LevelKind tells whether a level is below or above the last close
+type LevelKind string
+
+const (
+    Support    LevelKind = "support"
+    Resistance LevelKind = "resistance"
+)
+
+// Level is a support or resistance price formed by swings at a similar price
+type Level struct {
+    Kind       LevelKind `json:"kind"`
+    Price      float64   `json:"price"`
+    Touches    int       `json:"touches"`
+    FirstIndex int       `json:"first_index"`
+    LastIndex  int       `json:"last_index"`
+    Confidence float64   `json:"confidence"`
+}
+
+// Levels clusters swing highs and lows lying within opts.LevelTolerance of
+// each other into support and resistance levels, ordered by price. Levels
+// need opts.MinTouches swings; confidence grows with the touches.
+func Levels(data []models.OHLCV, opts Options) []Level {
+    if len(data) == 0 {
+        return nil
+    }
+
+    swings := Swings(data, opts.SwingStrength)
+    sort.Slice(swings, func(i, j int) bool { return swings[i].Price < swings[j].Price })
+
+    var levels []Level
+    var cluster []Event
+    flush := func() {
+        if len(cluster) >= max(opts.MinTouches, 1) {
+            levels = append(levels, newLevel(cluster, data[len(data)-1].Close))
+        }
+        cluster = nil
+    }
+
+    for _, swing := range swings {
+        if len(cluster) > 0 {
+            base := cluster[0].Price
+            if swing.Price-base > opts.LevelTolerance*base {
+                flush()
+            }
+        }
+        cluster = append(cluster, swing)
+    }
+    flush()
+
+    return levels
+}
+
+func newLevel(cluster []Event, close float64) Level {
+    level := Level{Touches: len(cluster), FirstIndex: cluster[0].Index, LastIndex: cluster[0].Index}
+
+    sum := 0.0
+    for _, swing := range cluster {
+        sum += swing.Price
+        level.FirstIndex = min(level.FirstIndex, swing.Index)
+        level.LastIndex = max(level.LastIndex, swing.Index)
+    }
+    level.Price = sum / float64(len(cluster))
+
+    level.Kind = Support
+    if level.Price > close {
+        level.Kind = Resistance
+    }
+    level.Confidence = clamp(float64(level.Touches) / 4)
+
+    return level
+}
+
+// breakoutsAt returns closes at bar i crossing a level formed before it
+func breakoutsAt(data []models.OHLCV, i int, opts Options) []Event {
+    if i < 1 {
+        return nil
+    }
+
+    var events []Event
+    // Only swings confirmed by bar i-1 count towards the levels
+    for _, level := range Levels(data[:i], opts) {
+        previous, current := data[i-1].Close, data[i].Close
+        if previous <= level.Price && current > level.Price {
+            event := newEvent(data, ResistanceBreakout, Bullish, i, 1, level.Confidence)
+            event.Price = level.Price
+            events = append(events, event)
+        } else if previous >= level.Price && current < level.Price {
+            event := newEvent(data, SupportBreakdown, Bearish, i, 1, level.Confidence)
+            event.Price = level.Price
+            events = append(events, event)
+        }
+    }
+    return events
+}